@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	repository "github.com/RizkiMufrizal/gofiber-clean-architecture/repository/impl"
+	service "github.com/RizkiMufrizal/gofiber-clean-architecture/service/impl"
+)
+
+// Re-encrypts every payment gateway secret with the active master key.
+// Add the new key to SECRET_MASTER_KEYS, point SECRET_ACTIVE_KEY_VERSION at it,
+// run this command, then remove the retired key once it has completed.
+func main() {
+	configPath := ""
+	if len(os.Args) > 1 {
+		configPath = os.Args[1]
+	}
+	logger.NewLogger()
+
+	config := configuration.New(configPath + ".env")
+	database := configuration.NewDatabase(config)
+	redis := configuration.NewRedis(config)
+
+	secretCipher, err := common.NewSecretCipher(config)
+	exception.PanicLogging(err)
+
+	paymentConfigRepository := repository.NewPaymentConfigRepository(database, redis)
+	paymentConfigAuditRepository := repository.NewPaymentConfigAuditRepository(database)
+	paymentConfigService := service.NewPaymentConfigService(paymentConfigRepository, paymentConfigAuditRepository, secretCipher)
+
+	logger.Logger.Info("Rotating payment secrets to key version " + secretCipher.ActiveVersion())
+	rotated, err := paymentConfigService.RotateSecrets(context.Background(), model.AuditActor{Username: "system"})
+	if err != nil {
+		logger.Logger.Error("Rotation stopped after " + strconv.Itoa(rotated) + " configurations: " + err.Error())
+		os.Exit(1)
+	}
+	logger.Logger.Info("Rotated " + strconv.Itoa(rotated) + " payment configurations")
+}
@@ -3,4 +3,157 @@ package common
 const CUSTOMER_ROLE = "customer"
 const ADMIN_ROLE = "admin"
 const REFINERY_ADMIN_ROLE = "refinery_admin"
+const REFINERY_DISPATCHER_ROLE = "refinery_dispatcher"
+const REFINERY_FINANCE_ROLE = "refinery_finance"
 const TRUCK_DRIVER_ROLE = "truck_driver"
+
+// RefineryStaffRoles can be given to users that belong to a refinery. The
+// refinery_admin role is the refinery owner.
+var RefineryStaffRoles = []string{REFINERY_ADMIN_ROLE, REFINERY_DISPATCHER_ROLE, REFINERY_FINANCE_ROLE}
+
+const ADDRESS_LABEL_HOME = "home"
+const ADDRESS_LABEL_OFFICE = "office"
+const ADDRESS_LABEL_SITE = "site"
+
+var AddressLabels = []string{ADDRESS_LABEL_HOME, ADDRESS_LABEL_OFFICE, ADDRESS_LABEL_SITE}
+
+const OTP_PURPOSE_REGISTRATION = "registration"
+const OTP_PURPOSE_RESET_PASSWORD = "reset-password"
+const OTP_PURPOSE_EMAIL_VERIFY = "email-verify"
+const OTP_PURPOSE_DELIVERY = "delivery"
+
+const SECURITY_EVENT_LOGIN_SUCCEEDED = "login_succeeded"
+const SECURITY_EVENT_LOGIN_FAILED = "login_failed"
+const SECURITY_EVENT_LOGIN_BLOCKED = "login_blocked"
+const SECURITY_EVENT_ACCOUNT_LOCKED = "account_locked"
+const SECURITY_EVENT_IP_LOCKED = "ip_locked"
+const SECURITY_EVENT_LOCKOUT_CLEARED = "lockout_cleared"
+
+const SECURITY_EVENT_TWO_FACTOR_ENABLED = "two_factor_enabled"
+const SECURITY_EVENT_TWO_FACTOR_DISABLED = "two_factor_disabled"
+const SECURITY_EVENT_TWO_FACTOR_FAILED = "two_factor_failed"
+const SECURITY_EVENT_RECOVERY_CODE_USED = "recovery_code_used"
+
+const SECURITY_EVENT_ACCOUNT_DELETED = "account_deleted"
+
+const DATA_EXPORT_STATUS_PENDING = "pending"
+const DATA_EXPORT_STATUS_PROCESSING = "processing"
+const DATA_EXPORT_STATUS_COMPLETED = "completed"
+const DATA_EXPORT_STATUS_FAILED = "failed"
+const DATA_EXPORT_STATUS_EXPIRED = "expired"
+
+const FILE_CATEGORY_PROFILE_PHOTO = "profile_photo"
+const FILE_CATEGORY_DRIVER_DOCUMENT = "driver_document"
+const FILE_CATEGORY_DELIVERY_PHOTO = "delivery_photo"
+
+// DriverDocumentTypes are the documents a truck driver can have on file
+var DriverDocumentTypes = []string{"licence", "identity", "insurance", "roadworthiness"}
+
+// DATA_EXPORT_TOPIC is the broker topic the export jobs are queued on
+const DATA_EXPORT_TOPIC = "data.export"
+
+// TwoFactorRequiredRoles must complete TOTP enrolment before they can sign in
+var TwoFactorRequiredRoles = []string{ADMIN_ROLE}
+
+const TEMPLATE_VERSION_STATUS_DRAFT = "draft"
+const TEMPLATE_VERSION_STATUS_PUBLISHED = "published"
+const TEMPLATE_VERSION_STATUS_ARCHIVED = "archived"
+
+const TEMPLATE_AUDIT_CREATE = "create"
+const TEMPLATE_AUDIT_UPDATE = "update"
+const TEMPLATE_AUDIT_DELETE = "delete"
+const TEMPLATE_AUDIT_CREATE_DRAFT = "create_draft"
+const TEMPLATE_AUDIT_UPDATE_DRAFT = "update_draft"
+const TEMPLATE_AUDIT_PUBLISH = "publish"
+const TEMPLATE_AUDIT_ROLLBACK = "rollback"
+const TEMPLATE_AUDIT_TEST_SEND = "test_send"
+
+const SMS_PROVIDER_AFRICAS_TALKING = "africastalking"
+const SMS_PROVIDER_TWILIO = "twilio"
+const SMS_PROVIDER_HUBTEL = "hubtel"
+const SMS_PROVIDER_FAKE = "fake"
+
+// A notification is queued when it is handed to the broker, sent once a
+// provider accepted it and delivered or failed from the delivery report.
+// Email has no delivery reports and stays sent.
+const NOTIFICATION_STATUS_QUEUED = "queued"
+const NOTIFICATION_STATUS_SENT = "sent"
+const NOTIFICATION_STATUS_DELIVERED = "delivered"
+const NOTIFICATION_STATUS_FAILED = "failed"
+
+// A broker is reconnecting from the moment its connection drops until the
+// connection, the consumers and any buffered publishes are restored
+const BROKER_STATE_CONNECTED = "connected"
+const BROKER_STATE_RECONNECTING = "reconnecting"
+const BROKER_STATE_CLOSED = "closed"
+
+// BROKER_PUBLISH_MODE_FAIL rejects publishes during an outage,
+// BROKER_PUBLISH_MODE_BUFFER holds them in memory until the broker is back
+const BROKER_PUBLISH_MODE_FAIL = "fail"
+const BROKER_PUBLISH_MODE_BUFFER = "buffer"
+
+// Domain events published through the outbox, the payload of each version is
+// documented in events/schemas
+const EVENT_ORDER_CREATED = "order.created"
+const EVENT_ORDER_APPROVED = "order.approved"
+const EVENT_ORDER_REJECTED = "order.rejected"
+const EVENT_ORDER_ASSIGNED = "order.assigned"
+const EVENT_ORDER_READY_FOR_DELIVERY = "order.ready_for_delivery"
+const EVENT_ORDER_DELIVERED = "order.delivered"
+const EVENT_PAYMENT_SUCCEEDED = "payment.succeeded"
+const EVENT_PAYMENT_FAILED = "payment.failed"
+const EVENT_REFUND_COMPLETED = "refund.completed"
+
+// Users choose per category which channels they are messaged on. Security
+// messages ignore the choice and quiet hours.
+const NOTIFICATION_CATEGORY_ORDER_UPDATES = "order_updates"
+const NOTIFICATION_CATEGORY_MARKETING = "marketing"
+const NOTIFICATION_CATEGORY_SECURITY = "security"
+
+var NotificationCategories = []string{NOTIFICATION_CATEGORY_ORDER_UPDATES, NOTIFICATION_CATEGORY_MARKETING, NOTIFICATION_CATEGORY_SECURITY}
+
+const NOTIFICATION_CHANNEL_SMS = "sms"
+const NOTIFICATION_CHANNEL_EMAIL = "email"
+const NOTIFICATION_CHANNEL_PUSH = "push"
+const NOTIFICATION_CHANNEL_IN_APP = "in_app"
+
+var NotificationChannels = []string{NOTIFICATION_CHANNEL_SMS, NOTIFICATION_CHANNEL_EMAIL, NOTIFICATION_CHANNEL_PUSH, NOTIFICATION_CHANNEL_IN_APP}
+
+// DefaultNotificationChannels are the channels of each category a user gets
+// until they change their preferences
+var DefaultNotificationChannels = map[string][]string{
+	NOTIFICATION_CATEGORY_ORDER_UPDATES: {NOTIFICATION_CHANNEL_PUSH, NOTIFICATION_CHANNEL_IN_APP},
+	NOTIFICATION_CATEGORY_MARKETING:     {NOTIFICATION_CHANNEL_EMAIL, NOTIFICATION_CHANNEL_PUSH, NOTIFICATION_CHANNEL_IN_APP},
+	NOTIFICATION_CATEGORY_SECURITY:      NotificationChannels,
+}
+
+// What the dispatcher did with a message on each channel
+const DISPATCH_SENT = "sent"
+const DISPATCH_FAILED = "failed"
+const DISPATCH_OPTED_OUT = "opted_out"
+const DISPATCH_QUIET_HOURS = "quiet_hours"
+const DISPATCH_UNAVAILABLE = "unavailable"
+
+// A campaign is scheduled once it has a send time, sending while its batches
+// are being delivered and completed when all of them were. Cancelling stops
+// the batches that were not delivered yet.
+const CAMPAIGN_STATUS_DRAFT = "draft"
+const CAMPAIGN_STATUS_SCHEDULED = "scheduled"
+const CAMPAIGN_STATUS_SENDING = "sending"
+const CAMPAIGN_STATUS_COMPLETED = "completed"
+const CAMPAIGN_STATUS_CANCELLED = "cancelled"
+
+// CAMPAIGN_DELIVERY_TOPIC is the broker topic campaign batches are queued on
+const CAMPAIGN_DELIVERY_TOPIC = "campaign.deliver"
+
+// NOTIFICATION_DISPATCH_TOPIC is the broker topic the notifications recorded
+// in the outbox are relayed on
+const NOTIFICATION_DISPATCH_TOPIC = "notification.dispatch"
+
+// Platforms a device can be registered for
+const DEVICE_PLATFORM_ANDROID = "android"
+const DEVICE_PLATFORM_IOS = "ios"
+const DEVICE_PLATFORM_WEB = "web"
+const DEVICE_PLATFORM_UNKNOWN = "unknown"
+
+var DevicePlatforms = []string{DEVICE_PLATFORM_ANDROID, DEVICE_PLATFORM_IOS, DEVICE_PLATFORM_WEB}
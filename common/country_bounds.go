@@ -0,0 +1,27 @@
+package common
+
+// CountryBounds is a bounding box used to catch coordinates that clearly do
+// not belong to the country an address claims to be in
+type CountryBounds struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+}
+
+// SupportedCountryBounds covers the countries addresses can be saved for,
+// keyed by ISO 3166-1 alpha-2 code
+var SupportedCountryBounds = map[string]CountryBounds{
+	"GH": {MinLatitude: 4.5, MaxLatitude: 11.2, MinLongitude: -3.3, MaxLongitude: 1.3},
+	"NG": {MinLatitude: 4.2, MaxLatitude: 13.9, MinLongitude: 2.6, MaxLongitude: 14.7},
+	"TG": {MinLatitude: 6.1, MaxLatitude: 11.2, MinLongitude: -0.2, MaxLongitude: 1.9},
+	"BJ": {MinLatitude: 6.2, MaxLatitude: 12.5, MinLongitude: 0.7, MaxLongitude: 3.9},
+	"CI": {MinLatitude: 4.3, MaxLatitude: 10.8, MinLongitude: -8.6, MaxLongitude: -2.4},
+	"BF": {MinLatitude: 9.4, MaxLatitude: 15.1, MinLongitude: -5.6, MaxLongitude: 2.5},
+}
+
+// Contains reports whether the coordinates fall inside the bounding box
+func (b CountryBounds) Contains(latitude, longitude float64) bool {
+	return latitude >= b.MinLatitude && latitude <= b.MaxLatitude &&
+		longitude >= b.MinLongitude && longitude <= b.MaxLongitude
+}
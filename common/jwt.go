@@ -9,15 +9,20 @@ import (
 	"time"
 )
 
-func GenerateToken(username string, roles []map[string]interface{}, user entity.User, config configuration.Config) string {
+// GenerateToken signs an access token for the user. tokenId is stored as the
+// jti claim so the token can be revoked before it expires.
+func GenerateToken(username string, roles []map[string]interface{}, user entity.User, tokenId string, config configuration.Config) (string, time.Time) {
 	jwtSecret := config.Get("JWT_SECRET_KEY")
 	jwtExpired, err := strconv.Atoi(config.Get("JWT_EXPIRE_MINUTES_COUNT"))
 	exception.PanicLogging(err)
 
+	expiresAt := time.Now().Add(time.Minute * time.Duration(jwtExpired))
 	claims := jwt.MapClaims{
+		"jti":          tokenId,
 		"username":     username,
 		"roles":        roles,
-		"exp":          time.Now().Add(time.Minute * time.Duration(jwtExpired)).Unix(),
+		"role":         user.UserRole,
+		"exp":          expiresAt.Unix(),
 		"userId":       user.ID,
 		"emailAddress": user.Email,
 		"phoneNumber":  user.PhoneNumber,
@@ -28,5 +33,5 @@ func GenerateToken(username string, roles []map[string]interface{}, user entity.
 	tokenSigned, err := token.SignedString([]byte(jwtSecret))
 	exception.PanicLogging(err)
 
-	return tokenSigned
+	return tokenSigned, expiresAt
 }
@@ -0,0 +1,42 @@
+package common
+
+import "strings"
+
+const LANGUAGE_ENGLISH = "en"
+const LANGUAGE_FRENCH = "fr"
+const LANGUAGE_HAUSA = "ha"
+const LANGUAGE_TWI = "tw"
+
+// SupportedLanguages are the languages messages can be written in
+var SupportedLanguages = []string{LANGUAGE_ENGLISH, LANGUAGE_FRENCH, LANGUAGE_HAUSA, LANGUAGE_TWI}
+
+// countryLanguages is the default for users that have not picked a language,
+// keyed by ISO 3166-1 alpha-2 code. Other countries default to English.
+var countryLanguages = map[string]string{
+	"TG": LANGUAGE_FRENCH,
+	"BJ": LANGUAGE_FRENCH,
+	"CI": LANGUAGE_FRENCH,
+	"BF": LANGUAGE_FRENCH,
+}
+
+func IsSupportedLanguage(language string) bool {
+	for _, supported := range SupportedLanguages {
+		if supported == language {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveLanguage returns the language to message a user in, their own
+// choice when they made one and otherwise the default for their country
+func ResolveLanguage(language string, countryCode string) string {
+	language = strings.ToLower(language)
+	if IsSupportedLanguage(language) {
+		return language
+	}
+	if countryLanguage, ok := countryLanguages[strings.ToUpper(countryCode)]; ok {
+		return countryLanguage
+	}
+	return LANGUAGE_ENGLISH
+}
@@ -0,0 +1,108 @@
+package common
+
+const ORDER_CREATE_PERMISSION = "order:create"
+const ORDER_READ_PERMISSION = "order:read"
+const ORDER_APPROVE_PERMISSION = "order:approve"
+const ORDER_DISPATCH_PERMISSION = "order:dispatch"
+const ORDER_DELIVER_PERMISSION = "order:deliver"
+const ORDER_RATE_PERMISSION = "order:rate"
+const TRANSACTION_READ_PERMISSION = "transaction:read"
+const TRANSACTION_READ_ALL_PERMISSION = "transaction:read_all"
+const DASHBOARD_REFINERY_PERMISSION = "dashboard:refinery"
+const DASHBOARD_ADMIN_PERMISSION = "dashboard:admin"
+const REFINERY_READ_PERMISSION = "refinery:read"
+const REFINERY_WRITE_PERMISSION = "refinery:write"
+const REFINERY_MANAGE_PERMISSION = "refinery:manage"
+const REFINERY_PRICING_PERMISSION = "refinery:pricing"
+const REFINERY_STAFF_PERMISSION = "refinery:staff"
+const REFINERY_ORDER_READ_PERMISSION = "refinery_order:read"
+const TRUCK_READ_PERMISSION = "truck:read"
+const TRUCK_WRITE_PERMISSION = "truck:write"
+const SETTINGS_READ_PERMISSION = "settings:read"
+const SETTINGS_WRITE_PERMISSION = "settings:write"
+const PAYMENT_CONFIG_READ_PERMISSION = "payment_config:read"
+const PAYMENT_CONFIG_WRITE_PERMISSION = "payment_config:write"
+const PAYMENT_METHOD_READ_PERMISSION = "payment_method:read"
+const USER_READ_PERMISSION = "user:read"
+const USER_WRITE_PERMISSION = "user:write"
+const PROFILE_READ_PERMISSION = "profile:read"
+const PROFILE_WRITE_PERMISSION = "profile:write"
+const LOCATION_READ_PERMISSION = "location:read"
+const LOCATION_WRITE_PERMISSION = "location:write"
+const MESSAGE_READ_PERMISSION = "message:read"
+const MESSAGE_SEND_PERMISSION = "message:send"
+const MESSAGE_TEMPLATE_WRITE_PERMISSION = "message_template:write"
+const NOTIFICATION_SEND_PERMISSION = "notification:send"
+const ROLES_READ_PERMISSION = "roles:read"
+const ROLES_WRITE_PERMISSION = "roles:write"
+const SECURITY_READ_PERMISSION = "security:read"
+const SECURITY_WRITE_PERMISSION = "security:write"
+const QUEUE_MANAGE_PERMISSION = "queue:manage"
+const CAMPAIGN_MANAGE_PERMISSION = "campaign:manage"
+
+// Roles lists every role a user can be assigned
+var Roles = []string{ADMIN_ROLE, CUSTOMER_ROLE, REFINERY_ADMIN_ROLE, REFINERY_DISPATCHER_ROLE, REFINERY_FINANCE_ROLE, TRUCK_DRIVER_ROLE}
+
+// Permissions lists every permission that can be granted to a role
+var Permissions = []string{
+	ORDER_CREATE_PERMISSION, ORDER_READ_PERMISSION, ORDER_APPROVE_PERMISSION,
+	ORDER_DISPATCH_PERMISSION, ORDER_DELIVER_PERMISSION, ORDER_RATE_PERMISSION,
+	TRANSACTION_READ_PERMISSION, TRANSACTION_READ_ALL_PERMISSION,
+	DASHBOARD_REFINERY_PERMISSION, DASHBOARD_ADMIN_PERMISSION,
+	REFINERY_READ_PERMISSION, REFINERY_WRITE_PERMISSION, REFINERY_MANAGE_PERMISSION,
+	REFINERY_PRICING_PERMISSION, REFINERY_STAFF_PERMISSION, REFINERY_ORDER_READ_PERMISSION,
+	TRUCK_READ_PERMISSION, TRUCK_WRITE_PERMISSION,
+	SETTINGS_READ_PERMISSION, SETTINGS_WRITE_PERMISSION,
+	PAYMENT_CONFIG_READ_PERMISSION, PAYMENT_CONFIG_WRITE_PERMISSION, PAYMENT_METHOD_READ_PERMISSION,
+	USER_READ_PERMISSION, USER_WRITE_PERMISSION, PROFILE_READ_PERMISSION, PROFILE_WRITE_PERMISSION,
+	LOCATION_READ_PERMISSION, LOCATION_WRITE_PERMISSION,
+	MESSAGE_READ_PERMISSION, MESSAGE_SEND_PERMISSION, MESSAGE_TEMPLATE_WRITE_PERMISSION, NOTIFICATION_SEND_PERMISSION,
+	ROLES_READ_PERMISSION, ROLES_WRITE_PERMISSION,
+	SECURITY_READ_PERMISSION, SECURITY_WRITE_PERMISSION,
+	QUEUE_MANAGE_PERMISSION, CAMPAIGN_MANAGE_PERMISSION,
+}
+
+// selfServicePermissions are granted to every role
+var selfServicePermissions = []string{
+	PROFILE_READ_PERMISSION, PROFILE_WRITE_PERMISSION, ORDER_READ_PERMISSION,
+	REFINERY_READ_PERMISSION, SETTINGS_READ_PERMISSION, LOCATION_READ_PERMISSION,
+}
+
+// DefaultRolePermissions is the mapping seeded for roles that have no
+// permissions stored yet. Admins edit the stored mapping afterwards.
+var DefaultRolePermissions = map[string][]string{
+	ADMIN_ROLE: Permissions,
+	CUSTOMER_ROLE: append([]string{
+		ORDER_CREATE_PERMISSION, ORDER_RATE_PERMISSION, PAYMENT_METHOD_READ_PERMISSION,
+	}, selfServicePermissions...),
+	REFINERY_ADMIN_ROLE: append([]string{
+		REFINERY_ORDER_READ_PERMISSION, ORDER_APPROVE_PERMISSION, ORDER_DISPATCH_PERMISSION,
+		TRANSACTION_READ_PERMISSION, DASHBOARD_REFINERY_PERMISSION,
+		REFINERY_WRITE_PERMISSION, REFINERY_PRICING_PERMISSION, REFINERY_STAFF_PERMISSION,
+		TRUCK_READ_PERMISSION, TRUCK_WRITE_PERMISSION,
+	}, selfServicePermissions...),
+	REFINERY_DISPATCHER_ROLE: append([]string{
+		REFINERY_ORDER_READ_PERMISSION, ORDER_APPROVE_PERMISSION, ORDER_DISPATCH_PERMISSION,
+		DASHBOARD_REFINERY_PERMISSION, TRUCK_READ_PERMISSION,
+	}, selfServicePermissions...),
+	REFINERY_FINANCE_ROLE: append([]string{
+		REFINERY_ORDER_READ_PERMISSION, TRANSACTION_READ_PERMISSION,
+		DASHBOARD_REFINERY_PERMISSION, REFINERY_PRICING_PERMISSION,
+	}, selfServicePermissions...),
+	TRUCK_DRIVER_ROLE: append([]string{
+		ORDER_DELIVER_PERMISSION,
+	}, selfServicePermissions...),
+}
+
+// IntroducedPermissions were added after roles were first seeded. Each one is
+// granted once to the roles that have it by default, later edits made by
+// admins are left alone.
+var IntroducedPermissions = []string{
+	SECURITY_READ_PERMISSION, SECURITY_WRITE_PERMISSION,
+	REFINERY_PRICING_PERMISSION, REFINERY_STAFF_PERMISSION, REFINERY_ORDER_READ_PERMISSION,
+	MESSAGE_TEMPLATE_WRITE_PERMISSION, QUEUE_MANAGE_PERMISSION, CAMPAIGN_MANAGE_PERMISSION,
+}
+
+// LockedAdminPermissions cannot be removed from the admin role so that the
+// mapping can always be edited again
+var LockedAdminPermissions = []string{ROLES_READ_PERMISSION, ROLES_WRITE_PERMISSION}
@@ -0,0 +1,165 @@
+package common
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+)
+
+// EncryptedSecret is the envelope stored for a secret value. Ciphertext is
+// encrypted with a random data key, and the data key itself is wrapped with
+// the master key identified by KeyVersion.
+type EncryptedSecret struct {
+	Ciphertext       string
+	EncryptedDataKey string
+	KeyVersion       string
+}
+
+// SecretCipher performs envelope encryption using the master keys loaded from
+// configuration. New secrets are always sealed with the active key version;
+// older versions are kept so that existing rows can still be opened and rotated.
+type SecretCipher struct {
+	masterKeys    map[string][]byte
+	activeVersion string
+}
+
+// NewSecretCipher loads the master keys from SECRET_MASTER_KEYS, a comma
+// separated list of version:base64key pairs, and the version to encrypt new
+// secrets with from SECRET_ACTIVE_KEY_VERSION. Every key must be 32 bytes.
+func NewSecretCipher(config configuration.Config) (*SecretCipher, error) {
+	rawKeys := config.Get("SECRET_MASTER_KEYS")
+	if rawKeys == "" {
+		return nil, errors.New("SECRET_MASTER_KEYS is not configured")
+	}
+
+	masterKeys := make(map[string][]byte)
+	for _, pair := range strings.Split(rawKeys, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid master key entry %q, expected version:base64key", pair)
+		}
+		key, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("master key %s is not valid base64: %w", parts[0], err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("master key %s must be 32 bytes, got %d", parts[0], len(key))
+		}
+		masterKeys[parts[0]] = key
+	}
+
+	activeVersion := config.Get("SECRET_ACTIVE_KEY_VERSION")
+	if _, ok := masterKeys[activeVersion]; !ok {
+		return nil, fmt.Errorf("active master key version %q is not present in SECRET_MASTER_KEYS", activeVersion)
+	}
+
+	return &SecretCipher{masterKeys: masterKeys, activeVersion: activeVersion}, nil
+}
+
+// ActiveVersion returns the master key version used for new secrets
+func (s *SecretCipher) ActiveVersion() string {
+	return s.activeVersion
+}
+
+// Encrypt seals plaintext with a fresh data key wrapped by the active master key
+func (s *SecretCipher) Encrypt(plaintext string) (EncryptedSecret, error) {
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return EncryptedSecret{}, err
+	}
+
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return EncryptedSecret{}, err
+	}
+	wrappedKey, err := seal(s.masterKeys[s.activeVersion], dataKey)
+	if err != nil {
+		return EncryptedSecret{}, err
+	}
+
+	return EncryptedSecret{
+		Ciphertext:       ciphertext,
+		EncryptedDataKey: wrappedKey,
+		KeyVersion:       s.activeVersion,
+	}, nil
+}
+
+// Decrypt opens a secret sealed by Encrypt. Secrets without a key version were
+// written before encryption was introduced and are returned unchanged.
+func (s *SecretCipher) Decrypt(secret EncryptedSecret) (string, error) {
+	if secret.KeyVersion == "" {
+		return secret.Ciphertext, nil
+	}
+
+	masterKey, ok := s.masterKeys[secret.KeyVersion]
+	if !ok {
+		return "", fmt.Errorf("master key version %q is not configured", secret.KeyVersion)
+	}
+
+	dataKey, err := open(masterKey, secret.EncryptedDataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	plaintext, err := open(dataKey, secret.Ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRotation reports whether a secret is not sealed with the active key
+func (s *SecretCipher) NeedsRotation(secret EncryptedSecret) bool {
+	return secret.KeyVersion != s.activeVersion
+}
+
+// MaskSecret hides all but the key prefix and the last four characters
+func MaskSecret(secret string) string {
+	if len(secret) <= 12 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:8] + strings.Repeat("*", len(secret)-12) + secret[len(secret)-4:]
+}
+
+func seal(key []byte, plaintext []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func open(key []byte, encoded string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
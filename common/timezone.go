@@ -0,0 +1,42 @@
+package common
+
+import (
+	"strings"
+	"time"
+	// Servers without a zoneinfo database still resolve user timezones
+	_ "time/tzdata"
+)
+
+// countryTimezones is the default for users that have not picked a
+// timezone, keyed by ISO 3166-1 alpha-2 code. Other countries default to UTC.
+var countryTimezones = map[string]string{
+	"GH": "Africa/Accra",
+	"NG": "Africa/Lagos",
+	"TG": "Africa/Lome",
+	"BJ": "Africa/Porto-Novo",
+	"CI": "Africa/Abidjan",
+	"BF": "Africa/Ouagadougou",
+}
+
+func IsValidTimezone(timezone string) bool {
+	if timezone == "" {
+		return false
+	}
+	_, err := time.LoadLocation(timezone)
+	return err == nil
+}
+
+// ResolveTimezone returns the location to apply a user's quiet hours in,
+// their own choice when they made one and otherwise the default for their
+// country
+func ResolveTimezone(timezone string, countryCode string) *time.Location {
+	if location, err := time.LoadLocation(timezone); timezone != "" && err == nil {
+		return location
+	}
+	if countryTimezone, ok := countryTimezones[strings.ToUpper(countryCode)]; ok {
+		if location, err := time.LoadLocation(countryTimezone); err == nil {
+			return location
+		}
+	}
+	return time.UTC
+}
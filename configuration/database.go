@@ -52,8 +52,9 @@ func NewDatabase(config Config) *gorm.DB {
 		&entity.User{}, &entity.UserRole{}, &entity.MessageTemplate{},
 		&entity.LocalGovernmentArea{}, &entity.Address{}, &entity.Truck{},
 		&entity.OneTimePassword{}, &entity.Refinery{}, &entity.PaymentMethod{},
-		&entity.Order{}, &entity.Notification{}, &entity.PaymentConfiguration{},
-		&entity.Setting{},
+		&entity.Order{}, &entity.Notification{}, &entity.PaymentConfiguration{}, &entity.PaymentConfigurationAudit{},
+		&entity.Setting{}, &entity.RefreshToken{}, &entity.RolePermission{}, &entity.SecurityEvent{},
+		&entity.UserTwoFactor{}, &entity.TwoFactorRecoveryCode{}, &entity.Invitation{}, &entity.PermissionGrant{}, &entity.DataExport{}, &entity.StoredFile{}, &entity.MessageTemplateVersion{}, &entity.MessageTemplateAudit{}, &entity.OutboxEvent{}, &entity.NotificationPreference{}, &entity.NotificationSetting{}, &entity.Campaign{}, &entity.CampaignDelivery{}, &entity.CampaignBatch{}, &entity.Device{},
 	)
 	//autoMigrate
 	//err = db.AutoMigrate(&entity.Product{})
@@ -8,5 +8,7 @@ import (
 func NewFiberConfiguration() fiber.Config {
 	return fiber.Config{
 		ErrorHandler: exception.ErrorHandler,
+		// Room for uploads, the per file limit is FILE_MAX_UPLOAD_MB
+		BodyLimit: 10 * 1024 * 1024,
 	}
 }
@@ -7,6 +7,14 @@ import (
 
 // NewRabbitMQ creates a new RabbitMQ connection
 func NewRabbitMQ(config Config) *amqp.Connection {
+	conn, err := amqp.Dial(RabbitMQURL(config))
+	exception.PanicLogging(err)
+
+	return conn
+}
+
+// RabbitMQURL is RABBITMQ_URL, or the URL built from the RABBITMQ_* parts
+func RabbitMQURL(config Config) string {
 	connectionString := config.Get("RABBITMQ_URL")
 	if connectionString == "" {
 		// Fallback to constructing URL from parts
@@ -24,11 +32,7 @@ func NewRabbitMQ(config Config) *amqp.Connection {
 		// Construct the connection URL
 		connectionString = "amqp://" + user + ":" + password + "@" + host + ":" + port + "/" + vhost
 	}
-
-	conn, err := amqp.Dial(connectionString)
-	exception.PanicLogging(err)
-
-	return conn
+	return connectionString
 }
 
 // CreateChannel creates a new channel from a RabbitMQ connection
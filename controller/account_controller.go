@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AccountController struct {
+	service.AccountService
+	service.AuthorizationService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewAccountController(accountService service.AccountService, authorizationService service.AuthorizationService) *AccountController {
+	return &AccountController{
+		AccountService:       accountService,
+		AuthorizationService: authorizationService,
+		responseBuilder:      utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *AccountController) Route(app *fiber.App) {
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_WRITE_PERMISSION)
+
+	app.Get("/v1/api/account/exports", canRead, controller.ListExports)
+	app.Post("/v1/api/account/exports", canRead, controller.RequestExport)
+	app.Get("/v1/api/account/exports/:id", canRead, controller.GetExport)
+	app.Get("/v1/api/account/exports/:id/download", canRead, controller.DownloadExport)
+	app.Delete("/v1/api/account", canWrite, controller.DeleteAccount)
+}
+
+func (controller *AccountController) RequestExport(c *fiber.Ctx) error {
+	export, err := controller.AccountService.RequestExport(c.Context(), currentUserId(c))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(controller.responseBuilder.Success(export, "Data export requested, it will be available to download shortly"))
+}
+
+func (controller *AccountController) ListExports(c *fiber.Ctx) error {
+	exports, err := controller.AccountService.ListExports(c.Context(), currentUserId(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(exports, "Data exports retrieved successfully"))
+}
+
+func (controller *AccountController) GetExport(c *fiber.Ctx) error {
+	id, err := exportId(c)
+	if err != nil {
+		return err
+	}
+	export, err := controller.AccountService.GetExport(c.Context(), currentUserId(c), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(export, "Data export retrieved successfully"))
+}
+
+func (controller *AccountController) DownloadExport(c *fiber.Ctx) error {
+	id, err := exportId(c)
+	if err != nil {
+		return err
+	}
+	path, fileName, err := controller.AccountService.ExportFile(c.Context(), currentUserId(c), id)
+	if err != nil {
+		return err
+	}
+	return c.Download(path, fileName)
+}
+
+// DeleteAccount pseudonymises the caller's account, their sessions are
+// revoked so the token used for this request stops working as well
+func (controller *AccountController) DeleteAccount(c *fiber.Ctx) error {
+	var request model.DeleteAccountModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	if request.Password == "" {
+		return exception.BadRequestError{Message: "password is required"}
+	}
+
+	if err := controller.AccountService.DeleteAccount(c.Context(), currentUserId(c), request, sessionClient(c)); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Account deleted successfully"))
+}
+
+func exportId(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, exception.BadRequestError{Message: "Invalid export id"}
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AddressController struct {
+	service.AddressService
+	service.AuthorizationService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewAddressController(addressService service.AddressService, authorizationService service.AuthorizationService) *AddressController {
+	return &AddressController{
+		AddressService:       addressService,
+		AuthorizationService: authorizationService,
+		responseBuilder:      utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *AddressController) Route(app *fiber.App) {
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_WRITE_PERMISSION)
+
+	app.Get("/v1/api/addresses", canRead, controller.List)
+	app.Post("/v1/api/addresses", canWrite, controller.Create)
+	app.Get("/v1/api/addresses/:id", canRead, controller.Get)
+	app.Put("/v1/api/addresses/:id", canWrite, controller.Update)
+	app.Delete("/v1/api/addresses/:id", canWrite, controller.Delete)
+	app.Post("/v1/api/addresses/:id/main", canWrite, controller.SetMain)
+
+	// Older routes kept for app versions that predate the address book
+	app.Post("/v1/api/save-address", canWrite, controller.SaveAddress)
+	app.Get("/v1/api/get-addresses", canRead, controller.List)
+}
+
+func (controller *AddressController) List(c *fiber.Ctx) error {
+	addresses, err := controller.AddressService.List(c.Context(), currentUserId(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(addresses, "Addresses retrieved successfully"))
+}
+
+func (controller *AddressController) Get(c *fiber.Ctx) error {
+	id, err := addressId(c)
+	if err != nil {
+		return err
+	}
+	address, err := controller.AddressService.Get(c.Context(), currentUserId(c), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(address, "Address retrieved successfully"))
+}
+
+func (controller *AddressController) Create(c *fiber.Ctx) error {
+	var request model.AddressRequestModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	address, err := controller.AddressService.Create(c.Context(), currentUserId(c), request)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(address, "Address saved successfully"))
+}
+
+func (controller *AddressController) Update(c *fiber.Ctx) error {
+	id, err := addressId(c)
+	if err != nil {
+		return err
+	}
+	var request model.AddressRequestModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	address, err := controller.AddressService.Update(c.Context(), currentUserId(c), id, request)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(address, "Address updated successfully"))
+}
+
+func (controller *AddressController) Delete(c *fiber.Ctx) error {
+	id, err := addressId(c)
+	if err != nil {
+		return err
+	}
+	if err := controller.AddressService.Delete(c.Context(), currentUserId(c), id); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Address deleted successfully"))
+}
+
+func (controller *AddressController) SetMain(c *fiber.Ctx) error {
+	id, err := addressId(c)
+	if err != nil {
+		return err
+	}
+	if err := controller.AddressService.SetMain(c.Context(), currentUserId(c), id); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Main address updated successfully"))
+}
+
+// SaveAddress accepts the snake_case body of the older route, which defaulted
+// the country to Ghana
+func (controller *AddressController) SaveAddress(c *fiber.Ctx) error {
+	var request model.SaveAddressModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	if request.CountryCode == "" {
+		request.CountryCode = "GH"
+	}
+
+	address, err := controller.AddressService.Create(c.Context(), currentUserId(c), model.AddressRequestModel{
+		Description: request.Description,
+		PlaceId:     request.PlaceId,
+		Longitude:   request.Longitude,
+		Latitude:    request.Latitude,
+		CountryCode: request.CountryCode,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(address, "Address saved successfully"))
+}
+
+func addressId(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, exception.BadRequestError{Message: "Invalid address id"}
+	}
+	return uint(id), nil
+}
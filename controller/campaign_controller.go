@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CampaignController struct {
+	service.CampaignService
+	service.AuthorizationService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewCampaignController(campaignService service.CampaignService, authorizationService service.AuthorizationService) *CampaignController {
+	return &CampaignController{
+		CampaignService:      campaignService,
+		AuthorizationService: authorizationService,
+		responseBuilder:      utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *CampaignController) Route(app *fiber.App) {
+	canManage := middleware.RequirePermission(controller.AuthorizationService, common.CAMPAIGN_MANAGE_PERMISSION)
+	canWriteProfile := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_WRITE_PERMISSION)
+
+	app.Get("/v1/api/campaigns", canManage, controller.List)
+	app.Post("/v1/api/campaigns", canManage, controller.Create)
+	app.Post("/v1/api/campaigns/audience", canManage, controller.PreviewAudience)
+	app.Get("/v1/api/campaigns/:id", canManage, controller.Get)
+	app.Put("/v1/api/campaigns/:id", canManage, controller.Update)
+	app.Post("/v1/api/campaigns/:id/schedule", canManage, controller.Schedule)
+	app.Post("/v1/api/campaigns/:id/cancel", canManage, controller.Cancel)
+	app.Get("/v1/api/campaigns/:id/stats", canManage, controller.Stats)
+	// Recipients report that they opened a campaign
+	app.Post("/v1/api/campaigns/:id/open", canWriteProfile, controller.MarkOpened)
+}
+
+func (controller *CampaignController) List(c *fiber.Ctx) error {
+	pagination := utils.ExtractPaginationParams(c)
+	campaigns, totalCount, err := controller.CampaignService.List(c.Context(), c.Query("status"), pagination.Page, pagination.Limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Pagination(campaigns, pagination.Page, pagination.Limit, totalCount))
+}
+
+func (controller *CampaignController) Create(c *fiber.Ctx) error {
+	var request model.CampaignRequestModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	campaign, err := controller.CampaignService.Create(c.Context(), request, auditActor(c))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(controller.responseBuilder.Success(campaign, "Campaign created successfully"))
+}
+
+func (controller *CampaignController) PreviewAudience(c *fiber.Ctx) error {
+	var segment model.CampaignSegmentModel
+	if err := c.BodyParser(&segment); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	audience, err := controller.CampaignService.PreviewAudience(c.Context(), segment)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(audience, "Campaign audience counted successfully"))
+}
+
+func (controller *CampaignController) Get(c *fiber.Ctx) error {
+	id, err := pathId(c, "id", "campaign")
+	if err != nil {
+		return err
+	}
+	campaign, err := controller.CampaignService.Get(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(campaign, "Campaign retrieved successfully"))
+}
+
+func (controller *CampaignController) Update(c *fiber.Ctx) error {
+	id, err := pathId(c, "id", "campaign")
+	if err != nil {
+		return err
+	}
+	var request model.CampaignRequestModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	campaign, err := controller.CampaignService.Update(c.Context(), id, request)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(campaign, "Campaign updated successfully"))
+}
+
+func (controller *CampaignController) Schedule(c *fiber.Ctx) error {
+	id, err := pathId(c, "id", "campaign")
+	if err != nil {
+		return err
+	}
+	// Without a body the campaign is sent right away
+	var request model.CampaignScheduleModel
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return exception.BadRequestError{Message: "Invalid request body"}
+		}
+	}
+	campaign, err := controller.CampaignService.Schedule(c.Context(), id, request)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(campaign, "Campaign scheduled successfully"))
+}
+
+func (controller *CampaignController) Cancel(c *fiber.Ctx) error {
+	id, err := pathId(c, "id", "campaign")
+	if err != nil {
+		return err
+	}
+	campaign, err := controller.CampaignService.Cancel(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(campaign, "Campaign cancelled successfully"))
+}
+
+func (controller *CampaignController) Stats(c *fiber.Ctx) error {
+	id, err := pathId(c, "id", "campaign")
+	if err != nil {
+		return err
+	}
+	stats, err := controller.CampaignService.Stats(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(stats, "Campaign stats retrieved successfully"))
+}
+
+func (controller *CampaignController) MarkOpened(c *fiber.Ctx) error {
+	id, err := pathId(c, "id", "campaign")
+	if err != nil {
+		return err
+	}
+	if err := controller.CampaignService.MarkOpened(c.Context(), id, currentUserId(c)); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Campaign marked as opened"))
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DeadLetterController struct {
+	service.DeadLetterService
+	service.AuthorizationService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewDeadLetterController(deadLetterService service.DeadLetterService, authorizationService service.AuthorizationService) *DeadLetterController {
+	return &DeadLetterController{
+		DeadLetterService:    deadLetterService,
+		AuthorizationService: authorizationService,
+		responseBuilder:      utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *DeadLetterController) Route(app *fiber.App) {
+	canManage := middleware.RequirePermission(controller.AuthorizationService, common.QUEUE_MANAGE_PERMISSION)
+
+	app.Get("/v1/api/dead-letters", canManage, controller.ListQueues)
+	app.Get("/v1/api/dead-letters/:topic", canManage, controller.ListDeadLetters)
+	app.Post("/v1/api/dead-letters/:topic/replay", canManage, controller.Replay)
+	app.Delete("/v1/api/dead-letters/:topic", canManage, controller.Purge)
+}
+
+func (controller *DeadLetterController) ListQueues(c *fiber.Ctx) error {
+	queues, err := controller.DeadLetterService.ListDeadLetterQueues(c.Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(queues, "Dead letter queues retrieved successfully"))
+}
+
+// ListDeadLetters peeks at the oldest dead letters of a topic, they stay on
+// the queue. limit defaults to 50 and is capped at 500.
+func (controller *DeadLetterController) ListDeadLetters(c *fiber.Ctx) error {
+	deadLetters, err := controller.DeadLetterService.ListDeadLetters(c.Context(), c.Params("topic"), c.QueryInt("limit"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(deadLetters, "Dead letters retrieved successfully"))
+}
+
+func (controller *DeadLetterController) Replay(c *fiber.Ctx) error {
+	request, err := deadLetterAction(c)
+	if err != nil {
+		return err
+	}
+	replayed, err := controller.DeadLetterService.ReplayDeadLetters(c.Context(), c.Params("topic"), request.MessageIds)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(fiber.Map{"replayed": replayed}, "Dead letters replayed successfully"))
+}
+
+func (controller *DeadLetterController) Purge(c *fiber.Ctx) error {
+	request, err := deadLetterAction(c)
+	if err != nil {
+		return err
+	}
+	purged, err := controller.DeadLetterService.PurgeDeadLetters(c.Context(), c.Params("topic"), request.MessageIds)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(fiber.Map{"purged": purged}, "Dead letters purged successfully"))
+}
+
+// deadLetterAction reads the optional body, no body selects every message
+func deadLetterAction(c *fiber.Ctx) (model.DeadLetterActionModel, error) {
+	var request model.DeadLetterActionModel
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return request, exception.BadRequestError{Message: "Invalid request body"}
+		}
+	}
+	return request, nil
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DeliveryReportController struct {
+	service.MessageService
+	service.AuthorizationService
+	configuration.Config
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewDeliveryReportController(messageService service.MessageService, authorizationService service.AuthorizationService, config configuration.Config) *DeliveryReportController {
+	return &DeliveryReportController{
+		MessageService:       messageService,
+		AuthorizationService: authorizationService,
+		Config:               config,
+		responseBuilder:      utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *DeliveryReportController) Route(app *fiber.App) {
+	// Public, the provider authenticates with the token in the callback URL
+	app.Post("/v1/api/sms/delivery-reports/africastalking", controller.AfricasTalkingReport)
+	app.Get("/v1/api/messages/delivery-stats", middleware.RequirePermission(controller.AuthorizationService, common.MESSAGE_READ_PERMISSION), controller.DeliveryStats)
+}
+
+// AfricasTalkingReport receives the delivery reports configured in the
+// Africa's Talking dashboard as .../africastalking?token=<SMS_CALLBACK_TOKEN>
+func (controller *DeliveryReportController) AfricasTalkingReport(c *fiber.Ctx) error {
+	token := controller.Config.Get("SMS_CALLBACK_TOKEN")
+	if token == "" || subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(token)) != 1 {
+		return exception.UnauthorizedError{Message: "Invalid callback token"}
+	}
+
+	var report model.SMSDeliveryReportModel
+	if err := c.BodyParser(&report); err != nil || report.Id == "" {
+		return exception.BadRequestError{Message: "Invalid delivery report"}
+	}
+	if err := controller.MessageService.HandleSMSDeliveryReport(c.Context(), common.SMS_PROVIDER_AFRICAS_TALKING, report); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// DeliveryStats takes from and to as YYYY-MM-DD, both inclusive, and
+// defaults to the last 7 days. type is sms or email.
+func (controller *DeliveryReportController) DeliveryStats(c *fiber.Ctx) error {
+	today := time.Now().Truncate(24 * time.Hour)
+	from, err := queryDate(c, "from", today.AddDate(0, 0, -6))
+	if err != nil {
+		return err
+	}
+	to, err := queryDate(c, "to", today)
+	if err != nil {
+		return err
+	}
+	if to.Before(from) {
+		return exception.BadRequestError{Message: "to must not be before from"}
+	}
+	messageType := c.Query("type")
+	if messageType != "" && messageType != string(entity.SMS) && messageType != string(entity.EMAIL) {
+		return exception.BadRequestError{Message: "type must be sms or email"}
+	}
+
+	stats, err := controller.MessageService.DeliveryStats(c.Context(), messageType, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(stats, "Delivery statistics retrieved successfully"))
+}
+
+func queryDate(c *fiber.Ctx, name string, fallback time.Time) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, exception.BadRequestError{Message: name + " must be a date in YYYY-MM-DD format"}
+	}
+	return date, nil
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type DeviceController struct {
+	service.DeviceService
+	service.AuthorizationService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewDeviceController(deviceService service.DeviceService, authorizationService service.AuthorizationService) *DeviceController {
+	return &DeviceController{
+		DeviceService:        deviceService,
+		AuthorizationService: authorizationService,
+		responseBuilder:      utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *DeviceController) Route(app *fiber.App) {
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_WRITE_PERMISSION)
+
+	app.Get("/v1/api/devices", canRead, controller.List)
+	app.Post("/v1/api/devices", canWrite, controller.Register)
+	app.Delete("/v1/api/devices/:id", canWrite, controller.Remove)
+}
+
+func (controller *DeviceController) List(c *fiber.Ctx) error {
+	devices, err := controller.DeviceService.List(c.Context(), currentUserId(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(devices, "Devices retrieved successfully"))
+}
+
+func (controller *DeviceController) Register(c *fiber.Ctx) error {
+	var request model.RegisterDeviceModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	device, err := controller.DeviceService.Register(c.Context(), currentUserId(c), request)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(device, "Device registered successfully"))
+}
+
+func (controller *DeviceController) Remove(c *fiber.Ctx) error {
+	id, err := pathId(c, "id", "device")
+	if err != nil {
+		return err
+	}
+	if err := controller.DeviceService.Remove(c.Context(), currentUserId(c), id); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Device removed successfully"))
+}
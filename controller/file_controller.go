@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type FileController struct {
+	service.FileService
+	service.AuthorizationService
+	service.AccessPolicyService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewFileController(fileService service.FileService, authorizationService service.AuthorizationService, accessPolicyService service.AccessPolicyService) *FileController {
+	return &FileController{
+		FileService:          fileService,
+		AuthorizationService: authorizationService,
+		AccessPolicyService:  accessPolicyService,
+		responseBuilder:      utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *FileController) Route(app *fiber.App) {
+	// Outside /v1/api, the signature in the URL authorises the request
+	app.Get("/files/*", controller.Serve)
+
+	app.Post("/v1/api/profile/photo", controller.requirePermission(common.PROFILE_WRITE_PERMISSION), controller.UploadProfilePhoto)
+	app.Get("/v1/api/trucks/:id/documents", controller.requirePermission(common.TRUCK_READ_PERMISSION), controller.ListDriverDocuments)
+	app.Post("/v1/api/trucks/:id/documents", controller.requirePermission(common.TRUCK_WRITE_PERMISSION), controller.UploadDriverDocument)
+	app.Delete("/v1/api/trucks/:id/documents/:documentId", controller.requirePermission(common.TRUCK_WRITE_PERMISSION), controller.DeleteDriverDocument)
+	app.Get("/v1/api/order/:id/delivery-photos", controller.requirePermission(common.ORDER_READ_PERMISSION), controller.ListDeliveryPhotos)
+	app.Post("/v1/api/order/:id/delivery-photos", controller.requirePermission(common.ORDER_DELIVER_PERMISSION), controller.UploadDeliveryPhoto)
+}
+
+func (controller *FileController) requirePermission(permission string) fiber.Handler {
+	return middleware.RequirePermission(controller.AuthorizationService, permission)
+}
+
+func (controller *FileController) Serve(c *fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return exception.NotFoundError{Message: "File not found"}
+	}
+
+	reader, contentType, err := controller.FileService.OpenSigned(c.Context(), key, c.Query("expires"), c.Query("signature"))
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return c.SendStream(reader)
+}
+
+func (controller *FileController) UploadProfilePhoto(c *fiber.Ctx) error {
+	upload, err := formFile(c)
+	if err != nil {
+		return err
+	}
+	file, err := controller.FileService.UploadProfilePhoto(c.Context(), currentUserId(c), upload)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(file, "Profile photo updated successfully"))
+}
+
+func (controller *FileController) ListDriverDocuments(c *fiber.Ctx) error {
+	truckId, err := pathId(c, "id", "truck")
+	if err != nil {
+		return err
+	}
+	claims, _ := middleware.GetClaims(c)
+	if err := controller.AccessPolicyService.CanManageTruck(c.Context(), claims, truckId); err != nil {
+		return err
+	}
+	documents, err := controller.FileService.ListDriverDocuments(c.Context(), truckId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(documents, "Driver documents retrieved successfully"))
+}
+
+func (controller *FileController) UploadDriverDocument(c *fiber.Ctx) error {
+	truckId, err := pathId(c, "id", "truck")
+	if err != nil {
+		return err
+	}
+	claims, _ := middleware.GetClaims(c)
+	if err := controller.AccessPolicyService.CanManageTruck(c.Context(), claims, truckId); err != nil {
+		return err
+	}
+	upload, err := formFile(c)
+	if err != nil {
+		return err
+	}
+	document, err := controller.FileService.UploadDriverDocument(c.Context(), truckId, c.FormValue("documentType"), upload, currentUserId(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(document, "Driver document uploaded successfully"))
+}
+
+func (controller *FileController) DeleteDriverDocument(c *fiber.Ctx) error {
+	truckId, err := pathId(c, "id", "truck")
+	if err != nil {
+		return err
+	}
+	claims, _ := middleware.GetClaims(c)
+	if err := controller.AccessPolicyService.CanManageTruck(c.Context(), claims, truckId); err != nil {
+		return err
+	}
+	documentId, err := pathId(c, "documentId", "document")
+	if err != nil {
+		return err
+	}
+	if err := controller.FileService.DeleteDriverDocument(c.Context(), truckId, documentId); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Driver document deleted successfully"))
+}
+
+func (controller *FileController) ListDeliveryPhotos(c *fiber.Ctx) error {
+	orderId, err := pathId(c, "id", "order")
+	if err != nil {
+		return err
+	}
+	claims, _ := middleware.GetClaims(c)
+	if err := controller.AccessPolicyService.CanReadOrder(c.Context(), claims, orderId); err != nil {
+		return err
+	}
+
+	photos, err := controller.FileService.ListDeliveryPhotos(c.Context(), orderId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(photos, "Delivery photos retrieved successfully"))
+}
+
+// UploadDeliveryPhoto is used by the driver as proof of delivery
+func (controller *FileController) UploadDeliveryPhoto(c *fiber.Ctx) error {
+	orderId, err := pathId(c, "id", "order")
+	if err != nil {
+		return err
+	}
+	claims, _ := middleware.GetClaims(c)
+	if err := controller.AccessPolicyService.CanDeliverOrder(c.Context(), claims, orderId); err != nil {
+		return err
+	}
+
+	upload, err := formFile(c)
+	if err != nil {
+		return err
+	}
+	photo, err := controller.FileService.UploadDeliveryPhoto(c.Context(), orderId, upload, currentUserId(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(photo, "Delivery photo uploaded successfully"))
+}
+
+// formFile reads the "file" field of a multipart form, the size and content
+// type are validated by the FileService
+func formFile(c *fiber.Ctx) (model.FileUploadModel, error) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return model.FileUploadModel{}, exception.BadRequestError{Message: "file is required"}
+	}
+	file, err := header.Open()
+	if err != nil {
+		return model.FileUploadModel{}, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return model.FileUploadModel{}, err
+	}
+	return model.FileUploadModel{FileName: header.Filename, Data: data}, nil
+}
+
+func pathId(c *fiber.Ctx, param string, name string) (uint, error) {
+	id, err := strconv.ParseUint(c.Params(param), 10, 64)
+	if err != nil {
+		return 0, exception.BadRequestError{Message: "Invalid " + name + " id"}
+	}
+	return uint(id), nil
+}
@@ -1,23 +1,28 @@
 package controller
 
 import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 	"github.com/gofiber/fiber/v2"
 )
 
 type GoogleMapsController struct {
-	mapsService service.GoogleMapsService
+	mapsService          service.GoogleMapsService
+	authorizationService service.AuthorizationService
 }
 
-func NewGoogleMapsController(mapsService service.GoogleMapsService) *GoogleMapsController {
-	return &GoogleMapsController{mapsService: mapsService}
+func NewGoogleMapsController(mapsService service.GoogleMapsService, authorizationService service.AuthorizationService) *GoogleMapsController {
+	return &GoogleMapsController{mapsService: mapsService, authorizationService: authorizationService}
 }
 
 func (g *GoogleMapsController) RegisterRoutes(router fiber.Router) {
-	router.Get("/v1/api/googlemaps/suggest", g.PlaceSuggestion)
-	router.Get("/v1/api/googlemaps/reverse-geocode", g.ReverseGeocode)
-	router.Get("/v1/api/googlemaps/place-detail", g.PlaceDetail)
+	canRead := middleware.RequirePermission(g.authorizationService, common.LOCATION_READ_PERMISSION)
+
+	router.Get("/v1/api/googlemaps/suggest", canRead, g.PlaceSuggestion)
+	router.Get("/v1/api/googlemaps/reverse-geocode", canRead, g.ReverseGeocode)
+	router.Get("/v1/api/googlemaps/place-detail", canRead, g.PlaceDetail)
 }
 
 func (g *GoogleMapsController) PlaceSuggestion(c *fiber.Ctx) error {
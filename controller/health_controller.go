@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthController struct {
+	service.MessageBrokerService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewHealthController(messageBrokerService service.MessageBrokerService) *HealthController {
+	return &HealthController{
+		MessageBrokerService: messageBrokerService,
+		responseBuilder:      utils.NewResponseBuilder(),
+	}
+}
+
+// Route registers the health check outside /v1/api so that it needs no token
+func (controller *HealthController) Route(app *fiber.App) {
+	app.Get("/health", controller.Health)
+}
+
+// Health answers 503 while the message broker is not connected so that load
+// balancers and orchestrators can take the instance out of rotation
+func (controller *HealthController) Health(c *fiber.Ctx) error {
+	broker := controller.MessageBrokerService.Health()
+	data := fiber.Map{"broker": broker}
+	if broker.State != common.BROKER_STATE_CONNECTED {
+		response := controller.responseBuilder.Error(fiber.StatusServiceUnavailable, "Message broker is "+broker.State)
+		response.Data = data
+		return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+	}
+	return c.JSON(controller.responseBuilder.Success(data, "Healthy"))
+}
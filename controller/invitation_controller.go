@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type InvitationController struct {
+	service.InvitationService
+	service.AuthorizationService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewInvitationController(invitationService service.InvitationService, authorizationService service.AuthorizationService) *InvitationController {
+	return &InvitationController{
+		InvitationService:    invitationService,
+		AuthorizationService: authorizationService,
+		responseBuilder:      utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *InvitationController) Route(app *fiber.App) {
+	// Public, the invitation token authenticates the request
+	app.Post("/v1/api/invitations/activate", controller.Activate)
+
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.USER_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.USER_WRITE_PERMISSION)
+
+	app.Get("/v1/api/invitations", canRead, controller.ListPending)
+	app.Post("/v1/api/invitations/:id/resend", canWrite, controller.Resend)
+	app.Delete("/v1/api/invitations/:id", canWrite, controller.Revoke)
+}
+
+func (controller *InvitationController) Activate(c *fiber.Ctx) error {
+	var request model.ActivateInvitationModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	if request.Token == "" {
+		return exception.BadRequestError{Message: "token is required"}
+	}
+
+	if err := controller.InvitationService.Activate(c.Context(), request); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Account activated successfully"))
+}
+
+func (controller *InvitationController) ListPending(c *fiber.Ctx) error {
+	pagination := utils.ExtractPaginationParams(c)
+	invitations, totalCount, err := controller.InvitationService.ListPending(c.Context(), pagination.Page, pagination.Limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Pagination(invitations, pagination.Page, pagination.Limit, totalCount))
+}
+
+func (controller *InvitationController) Resend(c *fiber.Ctx) error {
+	id, err := invitationId(c)
+	if err != nil {
+		return err
+	}
+	if err := controller.InvitationService.Resend(c.Context(), id); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Invitation resent successfully"))
+}
+
+func (controller *InvitationController) Revoke(c *fiber.Ctx) error {
+	id, err := invitationId(c)
+	if err != nil {
+		return err
+	}
+	if err := controller.InvitationService.Revoke(c.Context(), id); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Invitation revoked successfully"))
+}
+
+func invitationId(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, exception.BadRequestError{Message: "Invalid invitation id"}
+	}
+	return uint(id), nil
+}
@@ -1,7 +1,9 @@
 package controller
 
 import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 	"github.com/gofiber/fiber/v2"
@@ -9,16 +11,20 @@ import (
 
 type LocalGovernmentAreaController struct {
 	service.LocalGovernmentService
+	service.AuthorizationService
 }
 
-func NewLocalGovernmentAreaController(service *service.LocalGovernmentService) LocalGovernmentAreaController {
-	return LocalGovernmentAreaController{LocalGovernmentService: *service}
+func NewLocalGovernmentAreaController(service *service.LocalGovernmentService, authorizationService *service.AuthorizationService) LocalGovernmentAreaController {
+	return LocalGovernmentAreaController{LocalGovernmentService: *service, AuthorizationService: *authorizationService}
 }
 
 func (l LocalGovernmentAreaController) Route(app fiber.Router) {
-	app.Get("/v1/api/local-government-areas", l.FindAll)
-	app.Put("/v1/api/local-government-areas/:id/toggle-active", l.ToggleLocalGovernmentActive)
-	app.Get("/v1/api/get-place-suggestion/:id", l.GetPlaceSuggestion)
+	canRead := middleware.RequirePermission(l.AuthorizationService, common.LOCATION_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(l.AuthorizationService, common.LOCATION_WRITE_PERMISSION)
+
+	app.Get("/v1/api/local-government-areas", canRead, l.FindAll)
+	app.Put("/v1/api/local-government-areas/:id/toggle-active", canWrite, l.ToggleLocalGovernmentActive)
+	app.Get("/v1/api/get-place-suggestion/:id", canRead, l.GetPlaceSuggestion)
 }
 
 func (l LocalGovernmentAreaController) FindAll(c *fiber.Ctx) error {
@@ -1,25 +1,30 @@
 package controller
 
 import (
-	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 	"github.com/gofiber/fiber/v2"
-	"strconv"
 )
 
 type MessageController struct {
 	service.MessageService
+	service.UserService
+	service.AuthorizationService
 }
 
 func (c MessageController) Route(app *fiber.App) {
+	// These routes live outside /v1/api so claims are extracted here
+	authenticated := middleware.ExtractClaims(c.UserService)
+
 	app.Get("/", c.WelcomeToAquaWizz)
-	app.Get("/message-templates", c.FindAllMessageTemplates)
-	app.Get("/send-sms", c.SendSms)
+	app.Get("/message-templates", authenticated, middleware.RequirePermission(c.AuthorizationService, common.MESSAGE_READ_PERMISSION), c.FindAllMessageTemplates)
+	app.Get("/send-sms", authenticated, middleware.RequirePermission(c.AuthorizationService, common.MESSAGE_SEND_PERMISSION), c.SendSms)
 }
 
-func NewMessageController(messageService *service.MessageService) *MessageController {
-	return &MessageController{MessageService: *messageService}
+func NewMessageController(messageService *service.MessageService, userService *service.UserService, authorizationService *service.AuthorizationService) *MessageController {
+	return &MessageController{MessageService: *messageService, UserService: *userService, AuthorizationService: *authorizationService}
 }
 
 func (c MessageController) FindAllMessageTemplates(ctx *fiber.Ctx) error {
@@ -27,40 +32,6 @@ func (c MessageController) FindAllMessageTemplates(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(messageTemplates)
 }
 
-func (c MessageController) FindMessageTemplateById(ctx *fiber.Ctx) error {
-	idStr := ctx.Params("id")
-	id, err := strconv.Atoi(idStr)
-	exception.PanicLogging(err)
-	messageTemplate := c.MessageService.FindMessageTemplateById(ctx.Context(), id)
-	return ctx.Status(fiber.StatusOK).JSON(messageTemplate)
-}
-
-func (c MessageController) CreateMessageTemplate(ctx *fiber.Ctx) error {
-	var messageTemlate model.MessageTemplateModel
-	err := ctx.BodyParser(&messageTemlate)
-	exception.PanicLogging(err)
-	c.MessageService.CreateMessageTemplate(ctx.Context(), messageTemlate)
-	return ctx.Status(fiber.StatusCreated).JSON(model.GeneralResponse{
-		Code:    200,
-		Message: "Success",
-		Data:    nil,
-		Success: true,
-	})
-}
-
-func (c MessageController) UpdateMessageTemplate(ctx *fiber.Ctx) error {
-	var messageTemlate model.MessageTemplateModel
-	err := ctx.BodyParser(&messageTemlate)
-	exception.PanicLogging(err)
-	c.MessageService.UpdateMessageTemplate(ctx.Context(), messageTemlate)
-	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
-		Code:    200,
-		Message: "Success",
-		Data:    nil,
-		Success: true,
-	})
-}
-
 func (c MessageController) WelcomeToAquaWizz(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
 		Code:    200,
@@ -0,0 +1,233 @@
+package controller
+
+import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type MessageTemplateController struct {
+	service.MessageTemplateService
+	service.AuthorizationService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewMessageTemplateController(messageTemplateService service.MessageTemplateService, authorizationService service.AuthorizationService) *MessageTemplateController {
+	return &MessageTemplateController{
+		MessageTemplateService: messageTemplateService,
+		AuthorizationService:   authorizationService,
+		responseBuilder:        utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *MessageTemplateController) Route(app *fiber.App) {
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.MESSAGE_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.MESSAGE_TEMPLATE_WRITE_PERMISSION)
+
+	app.Get("/v1/api/message-templates", canRead, controller.ListTemplates)
+	app.Post("/v1/api/message-templates", canWrite, controller.CreateTemplate)
+	app.Get("/v1/api/message-templates/:id", canRead, controller.GetTemplate)
+	app.Put("/v1/api/message-templates/:id", canWrite, controller.UpdateTemplate)
+	app.Delete("/v1/api/message-templates/:id", canWrite, controller.DeleteTemplate)
+	app.Post("/v1/api/message-templates/:id/test", canWrite, controller.SendTest)
+	app.Get("/v1/api/message-templates/:id/audits", canRead, controller.ListAudits)
+	app.Get("/v1/api/message-templates/:id/versions", canRead, controller.ListVersions)
+	app.Post("/v1/api/message-templates/:id/versions", canWrite, controller.CreateDraft)
+	app.Put("/v1/api/message-templates/:id/versions/:versionId", canWrite, controller.UpdateDraft)
+	app.Post("/v1/api/message-templates/:id/versions/:versionId/publish", canWrite, controller.Publish)
+	app.Post("/v1/api/message-templates/:id/versions/:versionId/preview", canRead, controller.Preview)
+	app.Post("/v1/api/message-templates/:id/rollback", canWrite, controller.Rollback)
+}
+
+func (controller *MessageTemplateController) ListTemplates(c *fiber.Ctx) error {
+	templates, err := controller.MessageTemplateService.ListTemplates(c.Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(templates, "Message templates retrieved successfully"))
+}
+
+func (controller *MessageTemplateController) GetTemplate(c *fiber.Ctx) error {
+	templateId, err := pathId(c, "id", "template")
+	if err != nil {
+		return err
+	}
+	template, err := controller.MessageTemplateService.GetTemplate(c.Context(), templateId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(template, "Message template retrieved successfully"))
+}
+
+func (controller *MessageTemplateController) CreateTemplate(c *fiber.Ctx) error {
+	var request model.MessageTemplateModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	template, err := controller.MessageTemplateService.CreateTemplate(c.Context(), request, auditActor(c))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(controller.responseBuilder.Success(template, "Message template created successfully"))
+}
+
+func (controller *MessageTemplateController) UpdateTemplate(c *fiber.Ctx) error {
+	templateId, err := pathId(c, "id", "template")
+	if err != nil {
+		return err
+	}
+	var request model.MessageTemplateModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	template, err := controller.MessageTemplateService.UpdateTemplate(c.Context(), templateId, request, auditActor(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(template, "Message template updated successfully"))
+}
+
+func (controller *MessageTemplateController) DeleteTemplate(c *fiber.Ctx) error {
+	templateId, err := pathId(c, "id", "template")
+	if err != nil {
+		return err
+	}
+	if err := controller.MessageTemplateService.DeleteTemplate(c.Context(), templateId, auditActor(c)); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Message template deleted successfully"))
+}
+
+func (controller *MessageTemplateController) SendTest(c *fiber.Ctx) error {
+	templateId, err := pathId(c, "id", "template")
+	if err != nil {
+		return err
+	}
+	var request model.MessageTemplateTestModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	rendered, err := controller.MessageTemplateService.SendTest(c.Context(), templateId, request, auditActor(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(rendered, "Test message sent successfully"))
+}
+
+func (controller *MessageTemplateController) ListAudits(c *fiber.Ctx) error {
+	templateId, err := pathId(c, "id", "template")
+	if err != nil {
+		return err
+	}
+	audits, err := controller.MessageTemplateService.ListAudits(c.Context(), templateId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(audits, "Message template audit trail retrieved successfully"))
+}
+
+func (controller *MessageTemplateController) ListVersions(c *fiber.Ctx) error {
+	templateId, err := pathId(c, "id", "template")
+	if err != nil {
+		return err
+	}
+	versions, err := controller.MessageTemplateService.ListVersions(c.Context(), templateId, c.Query("language"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(versions, "Template versions retrieved successfully"))
+}
+
+func (controller *MessageTemplateController) CreateDraft(c *fiber.Ctx) error {
+	templateId, err := pathId(c, "id", "template")
+	if err != nil {
+		return err
+	}
+	var request model.MessageTemplateDraftModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	version, err := controller.MessageTemplateService.CreateDraft(c.Context(), templateId, request, auditActor(c))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(controller.responseBuilder.Success(version, "Template draft created successfully"))
+}
+
+func (controller *MessageTemplateController) UpdateDraft(c *fiber.Ctx) error {
+	templateId, versionId, err := templateVersionIds(c)
+	if err != nil {
+		return err
+	}
+	var request model.MessageTemplateDraftModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	version, err := controller.MessageTemplateService.UpdateDraft(c.Context(), templateId, versionId, request, auditActor(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(version, "Template draft updated successfully"))
+}
+
+func (controller *MessageTemplateController) Publish(c *fiber.Ctx) error {
+	templateId, versionId, err := templateVersionIds(c)
+	if err != nil {
+		return err
+	}
+	version, err := controller.MessageTemplateService.Publish(c.Context(), templateId, versionId, auditActor(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(version, "Template version published successfully"))
+}
+
+func (controller *MessageTemplateController) Rollback(c *fiber.Ctx) error {
+	templateId, err := pathId(c, "id", "template")
+	if err != nil {
+		return err
+	}
+	var request model.MessageTemplateRollbackModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	version, err := controller.MessageTemplateService.Rollback(c.Context(), templateId, request.Language, auditActor(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(version, "Template rolled back successfully"))
+}
+
+func (controller *MessageTemplateController) Preview(c *fiber.Ctx) error {
+	templateId, versionId, err := templateVersionIds(c)
+	if err != nil {
+		return err
+	}
+	var request model.MessageTemplatePreviewModel
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			return exception.BadRequestError{Message: "Invalid request body"}
+		}
+	}
+	rendered, err := controller.MessageTemplateService.Preview(c.Context(), templateId, versionId, request.Data)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(rendered, "Template preview rendered successfully"))
+}
+
+func templateVersionIds(c *fiber.Ctx) (uint, uint, error) {
+	templateId, err := pathId(c, "id", "template")
+	if err != nil {
+		return 0, 0, err
+	}
+	versionId, err := pathId(c, "versionId", "version")
+	if err != nil {
+		return 0, 0, err
+	}
+	return templateId, versionId, nil
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationInboxController struct {
+	service.NotificationInboxService
+	service.AuthorizationService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewNotificationInboxController(notificationInboxService service.NotificationInboxService, authorizationService service.AuthorizationService) *NotificationInboxController {
+	return &NotificationInboxController{
+		NotificationInboxService: notificationInboxService,
+		AuthorizationService:     authorizationService,
+		responseBuilder:          utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *NotificationInboxController) Route(app *fiber.App) {
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_WRITE_PERMISSION)
+
+	app.Get("/v1/api/notifications", canRead, controller.List)
+	app.Get("/v1/api/notifications/unread-count", canRead, controller.UnreadCount)
+	app.Post("/v1/api/notifications/read-all", canWrite, controller.MarkAllRead)
+	app.Post("/v1/api/notifications/:id/read", canWrite, controller.MarkRead)
+}
+
+func (controller *NotificationInboxController) List(c *fiber.Ctx) error {
+	pagination := utils.ExtractPaginationParams(c)
+	notifications, totalCount, err := controller.NotificationInboxService.List(c.Context(), currentUserId(c), pagination.Page, pagination.Limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Pagination(notifications, pagination.Page, pagination.Limit, totalCount))
+}
+
+func (controller *NotificationInboxController) UnreadCount(c *fiber.Ctx) error {
+	count, err := controller.NotificationInboxService.UnreadCount(c.Context(), currentUserId(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(count, "Unread notification count retrieved successfully"))
+}
+
+func (controller *NotificationInboxController) MarkRead(c *fiber.Ctx) error {
+	id, err := pathId(c, "id", "notification")
+	if err != nil {
+		return err
+	}
+	if err := controller.NotificationInboxService.MarkRead(c.Context(), currentUserId(c), id); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Notification marked as read"))
+}
+
+func (controller *NotificationInboxController) MarkAllRead(c *fiber.Ctx) error {
+	count, err := controller.NotificationInboxService.MarkAllRead(c.Context(), currentUserId(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(count, "All notifications marked as read"))
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationPreferenceController struct {
+	service.NotificationPreferenceService
+	service.AuthorizationService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewNotificationPreferenceController(notificationPreferenceService service.NotificationPreferenceService, authorizationService service.AuthorizationService) *NotificationPreferenceController {
+	return &NotificationPreferenceController{
+		NotificationPreferenceService: notificationPreferenceService,
+		AuthorizationService:          authorizationService,
+		responseBuilder:               utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *NotificationPreferenceController) Route(app *fiber.App) {
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_WRITE_PERMISSION)
+
+	app.Get("/v1/api/notification-preferences", canRead, controller.Get)
+	app.Put("/v1/api/notification-preferences", canWrite, controller.Update)
+}
+
+func (controller *NotificationPreferenceController) Get(c *fiber.Ctx) error {
+	preferences, err := controller.NotificationPreferenceService.Get(c.Context(), currentUserId(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(preferences, "Notification preferences retrieved successfully"))
+}
+
+func (controller *NotificationPreferenceController) Update(c *fiber.Ctx) error {
+	var request model.UpdateNotificationPreferencesModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	preferences, err := controller.NotificationPreferenceService.Update(c.Context(), currentUserId(c), request)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(preferences, "Notification preferences updated successfully"))
+}
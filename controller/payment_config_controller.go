@@ -1,6 +1,8 @@
 package controller
 
 import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 	"github.com/gofiber/fiber/v2"
@@ -8,18 +10,23 @@ import (
 
 type PaymentConfigController struct {
 	service.PaymentConfigService
+	service.AuthorizationService
 }
 
-func NewPaymentConfigController(service service.PaymentConfigService) *PaymentConfigController {
-	return &PaymentConfigController{PaymentConfigService: service}
+func NewPaymentConfigController(service service.PaymentConfigService, authorizationService service.AuthorizationService) *PaymentConfigController {
+	return &PaymentConfigController{PaymentConfigService: service, AuthorizationService: authorizationService}
 }
 
 func (controller *PaymentConfigController) Route(app *fiber.App) {
-	app.Get("/v1/api/payment-configs", controller.List)
-	app.Get("/v1/v1/api/payment-configs/:countryCode", controller.Get)
-	app.Post("/v1/api/payment-configs", controller.Create)
-	app.Put("/v1/api/payment-configs/:countryCode", controller.Update)
-	app.Delete("/v1/api/payment-configs/:countryCode", controller.Delete)
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.PAYMENT_CONFIG_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.PAYMENT_CONFIG_WRITE_PERMISSION)
+
+	app.Get("/v1/api/payment-configs", canRead, controller.List)
+	app.Get("/v1/api/payment-configs/:countryCode", canRead, controller.Get)
+	app.Get("/v1/api/payment-configs/:countryCode/audit", canRead, controller.AuditTrail)
+	app.Post("/v1/api/payment-configs", canWrite, controller.Create)
+	app.Put("/v1/api/payment-configs/:countryCode", canWrite, controller.Update)
+	app.Delete("/v1/api/payment-configs/:countryCode", canWrite, controller.Delete)
 }
 
 func (controller *PaymentConfigController) List(c *fiber.Ctx) error {
@@ -67,7 +74,7 @@ func (controller *PaymentConfigController) Create(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := controller.PaymentConfigService.CreatePaymentConfig(c.Context(), &config); err != nil {
+	if err := controller.PaymentConfigService.CreatePaymentConfig(c.Context(), &config, auditActor(c)); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(model.GeneralResponse{
 			Code:    500,
 			Message: "Error creating payment configuration",
@@ -94,7 +101,7 @@ func (controller *PaymentConfigController) Update(c *fiber.Ctx) error {
 	}
 
 	config.CountryCode = countryCode
-	if err := controller.PaymentConfigService.UpdatePaymentConfig(c.Context(), &config); err != nil {
+	if err := controller.PaymentConfigService.UpdatePaymentConfig(c.Context(), &config, auditActor(c)); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(model.GeneralResponse{
 			Code:    500,
 			Message: "Error updating payment configuration",
@@ -111,7 +118,7 @@ func (controller *PaymentConfigController) Update(c *fiber.Ctx) error {
 
 func (controller *PaymentConfigController) Delete(c *fiber.Ctx) error {
 	countryCode := c.Params("countryCode")
-	if err := controller.PaymentConfigService.DeletePaymentConfig(c.Context(), countryCode); err != nil {
+	if err := controller.PaymentConfigService.DeletePaymentConfig(c.Context(), countryCode, auditActor(c)); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(model.GeneralResponse{
 			Code:    500,
 			Message: "Error deleting payment configuration",
@@ -125,3 +132,35 @@ func (controller *PaymentConfigController) Delete(c *fiber.Ctx) error {
 		Data:    nil,
 	})
 }
+
+func (controller *PaymentConfigController) AuditTrail(c *fiber.Ctx) error {
+	countryCode := c.Params("countryCode")
+	audits, err := controller.PaymentConfigService.ListAuditTrail(c.Context(), countryCode)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(model.GeneralResponse{
+			Code:    500,
+			Message: "Error fetching payment configuration audit trail",
+			Data:    err.Error(),
+		})
+	}
+
+	return c.JSON(model.GeneralResponse{
+		Code:    200,
+		Message: "Success",
+		Data:    audits,
+	})
+}
+
+// auditActor builds the audit actor from the claims of the authenticated user
+func auditActor(c *fiber.Ctx) model.AuditActor {
+	var actor model.AuditActor
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return actor
+	}
+	if userId, ok := claims["userId"].(float64); ok {
+		actor.UserId = uint(userId)
+	}
+	actor.Username, _ = claims["username"].(string)
+	return actor
+}
@@ -1,8 +1,10 @@
 package controller
 
 import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 	"github.com/gofiber/fiber/v2"
@@ -11,11 +13,12 @@ import (
 type PaymentController struct {
 	service.PaymentService
 	service.UserService
+	service.AuthorizationService
 	configuration.Config
 }
 
 func (c PaymentController) Route(app *fiber.App) {
-	app.Get("/v1/api/get-payment-options", c.GetPaymentMethods)
+	app.Get("/v1/api/get-payment-options", middleware.RequirePermission(c.AuthorizationService, common.PAYMENT_METHOD_READ_PERMISSION), c.GetPaymentMethods)
 
 }
 
@@ -32,10 +35,11 @@ func (c PaymentController) GetPaymentMethods(ctx *fiber.Ctx) error {
 	})
 }
 
-func NewPaymentController(paymentService *service.PaymentService, userService *service.UserService, config configuration.Config) *PaymentController {
+func NewPaymentController(paymentService *service.PaymentService, userService *service.UserService, authorizationService *service.AuthorizationService, config configuration.Config) *PaymentController {
 	return &PaymentController{
-		PaymentService: *paymentService,
-		UserService:    *userService,
-		Config:         config,
+		PaymentService:       *paymentService,
+		UserService:          *userService,
+		AuthorizationService: *authorizationService,
+		Config:               config,
 	}
 }
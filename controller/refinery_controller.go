@@ -1,8 +1,12 @@
 package controller
 
 import (
+	"strconv"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 	"github.com/gofiber/fiber/v2"
@@ -11,22 +15,40 @@ import (
 type RefineryController struct {
 	service.RefineryService
 	service.UserService
+	service.AuthorizationService
+	service.AccessPolicyService
+	service.RefineryStaffService
 	configuration.Config
 }
 
 func (controller RefineryController) Route(app *fiber.App) {
-	app.Post("/v1/api/refinery/get", controller.GetRefinery)
-	app.Post("/v1/api/refinery/create", controller.CreateRefinery)
-	app.Post("/v1/api/refinery/update/:id", controller.UpdateRefinery)
-	app.Get("/v1/api/refinery/list", controller.ListRefineries)
-	app.Post("/v1/api/refinery/toggle-status", controller.ToggleRefineryStatus)
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.REFINERY_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.REFINERY_WRITE_PERMISSION)
+	canManage := middleware.RequirePermission(controller.AuthorizationService, common.REFINERY_MANAGE_PERMISSION)
+	canPrice := middleware.RequirePermission(controller.AuthorizationService, common.REFINERY_PRICING_PERMISSION)
+	canManageStaff := middleware.RequirePermission(controller.AuthorizationService, common.REFINERY_STAFF_PERMISSION)
+
+	app.Post("/v1/api/refinery/get", canRead, controller.GetRefinery)
+	app.Post("/v1/api/refinery/create", canManage, controller.CreateRefinery)
+	app.Post("/v1/api/refinery/update/:id", canWrite, controller.UpdateRefinery)
+	app.Get("/v1/api/refinery/list", canRead, controller.ListRefineries)
+	app.Post("/v1/api/refinery/toggle-status", canManage, controller.ToggleRefineryStatus)
+	app.Put("/v1/api/refinery/pricing", canPrice, controller.UpdatePricing)
+
+	app.Get("/v1/api/refinery/staff", canManageStaff, controller.ListStaff)
+	app.Post("/v1/api/refinery/staff", canManageStaff, controller.InviteStaff)
+	app.Put("/v1/api/refinery/staff/:id/role", canManageStaff, controller.UpdateStaffRole)
+	app.Delete("/v1/api/refinery/staff/:id", canManageStaff, controller.RemoveStaff)
 
 }
 
-func NewRefineryController(refineryService *service.RefineryService, config configuration.Config) *RefineryController {
+func NewRefineryController(refineryService *service.RefineryService, authorizationService *service.AuthorizationService, accessPolicyService *service.AccessPolicyService, refineryStaffService *service.RefineryStaffService, config configuration.Config) *RefineryController {
 	return &RefineryController{
-		Config:          config,
-		RefineryService: *refineryService,
+		Config:               config,
+		RefineryService:      *refineryService,
+		AuthorizationService: *authorizationService,
+		AccessPolicyService:  *accessPolicyService,
+		RefineryStaffService: *refineryStaffService,
 	}
 }
 
@@ -34,6 +56,11 @@ func (controller RefineryController) GetRefinery(ctx *fiber.Ctx) error {
 	var getRefineryModel model.GetRefineryModel
 	err := ctx.BodyParser(&getRefineryModel)
 	exception.PanicLogging(err)
+
+	claims, _ := middleware.GetClaims(ctx)
+	if err := controller.AccessPolicyService.CanUseAddress(ctx.Context(), claims, getRefineryModel.AddressId); err != nil {
+		return err
+	}
 	refinery, err := controller.RefineryService.GetRefinery(ctx.Context(), getRefineryModel)
 
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
@@ -75,8 +102,20 @@ func (controller RefineryController) UpdateRefinery(ctx *fiber.Ctx) error {
 	exception.PanicLogging(err)
 
 	id := ctx.Params("id")
+	refineryId, err := strconv.Atoi(id)
+	if err != nil {
+		return exception.BadRequestError{Message: "Invalid refinery id"}
+	}
 
-	refinery, err := controller.RefineryService.UpdateRefinery(ctx.Context(), updateRefineryModel, id)
+	// Refinery staff may only update their own refinery
+	claims, _ := middleware.GetClaims(ctx)
+	if !controller.AuthorizationService.CanAccessRefinery(ctx.Context(), claims, uint(refineryId)) {
+		return exception.ForbiddenError{Message: "Access denied"}
+	}
+
+	// Prices are left untouched unless the caller may change pricing
+	includePricing := controller.AuthorizationService.HasPermission(ctx.Context(), claims, common.REFINERY_PRICING_PERMISSION)
+	refinery, err := controller.RefineryService.UpdateRefinery(ctx.Context(), updateRefineryModel, id, includePricing)
 	exception.PanicLogging(err)
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
 		Code:    fiber.StatusOK,
@@ -98,3 +137,96 @@ func (controller RefineryController) ToggleRefineryStatus(ctx *fiber.Ctx) error
 		Data:    refinery,
 	})
 }
+
+// UpdatePricing sets the prices of the caller's own refinery
+func (controller RefineryController) UpdatePricing(ctx *fiber.Ctx) error {
+	var request model.RefineryPricingModel
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	refineryId := currentRefineryId(ctx)
+	if refineryId == 0 {
+		return exception.ForbiddenError{Message: "Your account is not linked to a refinery"}
+	}
+
+	refinery, err := controller.RefineryService.UpdatePricing(ctx.Context(), refineryId, request)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+		Code:    fiber.StatusOK,
+		Message: "Successful",
+		Data:    refinery,
+		Success: true,
+	})
+}
+
+func (controller RefineryController) ListStaff(ctx *fiber.Ctx) error {
+	staff, err := controller.RefineryStaffService.ListStaff(ctx.Context(), currentRefineryId(ctx))
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+		Code:    fiber.StatusOK,
+		Message: "Successful",
+		Data:    staff,
+		Success: true,
+	})
+}
+
+func (controller RefineryController) InviteStaff(ctx *fiber.Ctx) error {
+	var request model.InviteRefineryStaffModel
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+
+	staff, err := controller.RefineryStaffService.InviteStaff(ctx.Context(), currentRefineryId(ctx), currentUserId(ctx), request)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusCreated).JSON(model.GeneralResponse{
+		Code:    fiber.StatusCreated,
+		Message: "Staff member invited",
+		Data:    staff,
+		Success: true,
+	})
+}
+
+func (controller RefineryController) UpdateStaffRole(ctx *fiber.Ctx) error {
+	userId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return exception.BadRequestError{Message: "Invalid staff id"}
+	}
+	var request model.UpdateRefineryStaffRoleModel
+	if err := ctx.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+
+	staff, err := controller.RefineryStaffService.UpdateStaffRole(ctx.Context(), currentRefineryId(ctx), currentUserId(ctx), uint(userId), request.Role)
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+		Code:    fiber.StatusOK,
+		Message: "Successful",
+		Data:    staff,
+		Success: true,
+	})
+}
+
+func (controller RefineryController) RemoveStaff(ctx *fiber.Ctx) error {
+	userId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return exception.BadRequestError{Message: "Invalid staff id"}
+	}
+
+	err = controller.RefineryStaffService.RemoveStaff(ctx.Context(), currentRefineryId(ctx), currentUserId(ctx), uint(userId))
+	if err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+		Code:    fiber.StatusOK,
+		Message: "Staff member removed",
+		Success: true,
+	})
+}
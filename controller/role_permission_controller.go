@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+type RolePermissionController struct {
+	service.AuthorizationService
+}
+
+func NewRolePermissionController(authorizationService service.AuthorizationService) *RolePermissionController {
+	return &RolePermissionController{AuthorizationService: authorizationService}
+}
+
+func (controller *RolePermissionController) Route(app *fiber.App) {
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.ROLES_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.ROLES_WRITE_PERMISSION)
+
+	app.Get("/v1/api/permissions", canRead, controller.ListPermissions)
+	app.Get("/v1/api/roles/permissions", canRead, controller.ListRolePermissions)
+	app.Put("/v1/api/roles/:role/permissions", canWrite, controller.UpdateRolePermissions)
+}
+
+func (controller *RolePermissionController) ListPermissions(c *fiber.Ctx) error {
+	return c.JSON(model.GeneralResponse{
+		Code:    200,
+		Message: "Success",
+		Data:    controller.AuthorizationService.ListPermissions(),
+		Success: true,
+	})
+}
+
+func (controller *RolePermissionController) ListRolePermissions(c *fiber.Ctx) error {
+	rolePermissions, err := controller.AuthorizationService.ListRolePermissions(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(model.GeneralResponse{
+			Code:    500,
+			Message: "Error fetching role permissions",
+			Data:    err.Error(),
+		})
+	}
+
+	return c.JSON(model.GeneralResponse{
+		Code:    200,
+		Message: "Success",
+		Data:    rolePermissions,
+		Success: true,
+	})
+}
+
+func (controller *RolePermissionController) UpdateRolePermissions(c *fiber.Ctx) error {
+	var request model.UpdateRolePermissionsModel
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(model.GeneralResponse{
+			Code:    400,
+			Message: "Invalid request body",
+			Data:    err.Error(),
+		})
+	}
+
+	rolePermission, err := controller.AuthorizationService.UpdateRolePermissions(c.Context(), c.Params("role"), request.Permissions)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(model.GeneralResponse{
+		Code:    200,
+		Message: "Role permissions updated successfully",
+		Data:    rolePermission,
+		Success: true,
+	})
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type SecurityController struct {
+	service.SecurityService
+	service.AuthorizationService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewSecurityController(securityService service.SecurityService, authorizationService service.AuthorizationService) *SecurityController {
+	return &SecurityController{
+		SecurityService:      securityService,
+		AuthorizationService: authorizationService,
+		responseBuilder:      utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *SecurityController) Route(app *fiber.App) {
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.SECURITY_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.SECURITY_WRITE_PERMISSION)
+
+	app.Get("/v1/api/login-lockouts", canRead, controller.ListLockouts)
+	app.Delete("/v1/api/login-lockouts", canWrite, controller.ClearLockout)
+	app.Get("/v1/api/security-events", canRead, controller.ListEvents)
+}
+
+func (controller *SecurityController) ListLockouts(c *fiber.Ctx) error {
+	lockouts, err := controller.SecurityService.ListLockouts(c.Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(lockouts, "Login lockouts retrieved successfully"))
+}
+
+// ClearLockout lifts a lockout given as ?type=username|ip&value=...
+func (controller *SecurityController) ClearLockout(c *fiber.Ctx) error {
+	err := controller.SecurityService.ClearLockout(c.Context(), c.Query("type"), c.Query("value"), auditActor(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Login lockout cleared successfully"))
+}
+
+func (controller *SecurityController) ListEvents(c *fiber.Ctx) error {
+	pagination := utils.ExtractPaginationParams(c)
+	filter := model.SecurityEventFilterModel{
+		Username:  c.Query("username"),
+		EventType: c.Query("event_type"),
+		IpAddress: c.Query("ip_address"),
+	}
+
+	events, totalCount, err := controller.SecurityService.ListEvents(c.Context(), filter, pagination.Page, pagination.Limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Pagination(events, pagination.Page, pagination.Limit, totalCount))
+}
@@ -1,24 +1,30 @@
 package controller
 
 import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 	"github.com/gofiber/fiber/v2"
 )
 
 type SettingController struct {
-	SettingService service.SettingService
+	SettingService       service.SettingService
+	AuthorizationService service.AuthorizationService
 }
 
-func NewSettingController(service service.SettingService) *SettingController {
-	return &SettingController{SettingService: service}
+func NewSettingController(service service.SettingService, authorizationService service.AuthorizationService) *SettingController {
+	return &SettingController{SettingService: service, AuthorizationService: authorizationService}
 }
 
 func (controller *SettingController) Route(app *fiber.App) {
-	app.Get("/v1/api/settings", controller.List)
-	app.Get("/v1/api/settings/:key", controller.FindByKey)
-	app.Post("/v1/api/settings", controller.Create)
-	app.Put("/v1/api/settings/:key", controller.Update)
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.SETTINGS_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.SETTINGS_WRITE_PERMISSION)
+
+	app.Get("/v1/api/settings", canRead, controller.List)
+	app.Get("/v1/api/settings/:key", canRead, controller.FindByKey)
+	app.Post("/v1/api/settings", canWrite, controller.Create)
+	app.Put("/v1/api/settings/:key", canWrite, controller.Update)
 }
 
 func (controller *SettingController) List(c *fiber.Ctx) error {
@@ -1,8 +1,10 @@
 package controller
 
 import (
+	"context"
 	"strconv"
 
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
@@ -16,6 +18,7 @@ type TransactionController struct {
 	service.TransactionService
 	service.UserService
 	service.AuthorizationService
+	service.AccessPolicyService
 	configuration.Config
 	responseBuilder *utils.ResponseBuilder
 }
@@ -24,45 +27,57 @@ func NewTransactionController(
 	transactionService *service.TransactionService,
 	userService *service.UserService,
 	authService *service.AuthorizationService,
+	accessPolicyService *service.AccessPolicyService,
 	config configuration.Config,
 ) *TransactionController {
 	return &TransactionController{
 		TransactionService:   *transactionService,
 		UserService:          *userService,
 		AuthorizationService: *authService,
+		AccessPolicyService:  *accessPolicyService,
 		Config:               config,
 		responseBuilder:      utils.NewResponseBuilder(),
 	}
 }
 
 func (c TransactionController) Route(app *fiber.App) {
-	// Apply the JWT claims middleware to all authenticated routes
 	api := app.Group("/v1/api")
 
-	// Public routes (no auth required)
-	api.Get("/payment-status/:id", c.PaymentStatus)
-
-	// Protected routes (require authentication)
-	protected := api.Group("/", middleware.ExtractClaims(c.UserService), middleware.RequireClaims())
-	protected.Post("/initialize-card-transaction", c.InitiateMobileMoneyPayment)
-	protected.Post("/payment-mobile-money", c.InitiateMobileMoneyPayment)
-	protected.Post("/recurring-payment", c.ProcessRecurringPayment)
-	protected.Get("/refinery-dashboard-data", c.GetRefineryDashboardData)
-	protected.Get("/admin-dashboard-data", c.GetAdminDashboardData)
-	protected.Get("/pending-orders", c.GetRefineryOrders)
-	protected.Post("/approve-or-reject-order", c.ApproveOrRejectOrder)
-	protected.Get("/get-driver-pending-orders", c.GetDriverPendingOrder)
-	protected.Get("/get-customer-pending-orders", c.GetCustomerPendingOrder)
-	protected.Get("/get-driver-completed-orders", c.GetDriverCompletedOrder)
-	protected.Get("/get-customer-orders", c.GetCustomerOrders)
-	protected.Get("/list", c.GetTransactions)
-	protected.Get("/transactions-by-country", c.GetTransactionsByCountryCode)
-	protected.Get("/mark-order-ready-for-delivery/:id", c.MarkOrderReadyForDelivery)
-	protected.Get("/close-order/:id", c.CloseOrder)
-	protected.Get("/order/:id", c.FindById)
-	protected.Post("/submit-rating", c.SubmitRating)
+	// Claims are extracted for every /v1/api route in main
+	api.Get("/payment-status/:id", c.requirePermission(common.ORDER_READ_PERMISSION), c.PaymentStatus)
+	api.Post("/initialize-card-transaction", c.requirePermission(common.ORDER_CREATE_PERMISSION), c.InitiateMobileMoneyPayment)
+	api.Post("/payment-mobile-money", c.requirePermission(common.ORDER_CREATE_PERMISSION), c.InitiateMobileMoneyPayment)
+	api.Post("/recurring-payment", c.requirePermission(common.ORDER_CREATE_PERMISSION), c.ProcessRecurringPayment)
+	api.Get("/refinery-dashboard-data", c.requirePermission(common.DASHBOARD_REFINERY_PERMISSION), c.GetRefineryDashboardData)
+	api.Get("/admin-dashboard-data", c.requirePermission(common.DASHBOARD_ADMIN_PERMISSION), c.GetAdminDashboardData)
+	api.Get("/pending-orders", c.requirePermission(common.REFINERY_ORDER_READ_PERMISSION), c.GetRefineryOrders)
+	api.Post("/approve-or-reject-order", c.requirePermission(common.ORDER_APPROVE_PERMISSION), c.ApproveOrRejectOrder)
+	api.Get("/get-driver-pending-orders", c.requirePermission(common.ORDER_DELIVER_PERMISSION), c.GetDriverPendingOrder)
+	api.Get("/get-customer-pending-orders", c.requirePermission(common.ORDER_READ_PERMISSION), c.GetCustomerPendingOrder)
+	api.Get("/get-driver-completed-orders", c.requirePermission(common.ORDER_DELIVER_PERMISSION), c.GetDriverCompletedOrder)
+	api.Get("/get-customer-orders", c.requirePermission(common.ORDER_READ_PERMISSION), c.GetCustomerOrders)
+	api.Get("/list", c.requirePermission(common.TRANSACTION_READ_ALL_PERMISSION), c.GetTransactions)
+	api.Get("/transactions-by-country", c.requirePermission(common.TRANSACTION_READ_PERMISSION), c.GetTransactionsByCountryCode)
+	api.Get("/mark-order-ready-for-delivery/:id", c.requirePermission(common.ORDER_DISPATCH_PERMISSION), c.MarkOrderReadyForDelivery)
+	api.Get("/close-order/:id", c.requirePermission(common.ORDER_DELIVER_PERMISSION), c.CloseOrder)
+	api.Get("/order/:id", c.requirePermission(common.ORDER_READ_PERMISSION), c.FindById)
+	api.Post("/submit-rating", c.requirePermission(common.ORDER_RATE_PERMISSION), c.SubmitRating)
 	// Get rating for a specific order
-	protected.Get("/order/:id/rating", c.GetOrderRating)
+	api.Get("/order/:id/rating", c.requirePermission(common.ORDER_READ_PERMISSION), c.GetOrderRating)
+}
+
+func (c TransactionController) requirePermission(permission string) fiber.Handler {
+	return middleware.RequirePermission(c.AuthorizationService, permission)
+}
+
+// authorizeOrder runs an access policy check for an order id taken from the path
+func (c TransactionController) authorizeOrder(ctx *fiber.Ctx, id string, check func(context.Context, map[string]interface{}, uint) error) error {
+	orderId, err := strconv.Atoi(id)
+	if err != nil {
+		return exception.BadRequestError{Message: "Invalid order id"}
+	}
+	claims, _ := middleware.GetClaims(ctx)
+	return check(ctx.Context(), claims, uint(orderId))
 }
 
 func (c TransactionController) InitiateMobileMoneyPayment(ctx *fiber.Ctx) error {
@@ -82,6 +97,12 @@ func (c TransactionController) InitiateMobileMoneyPayment(ctx *fiber.Ctx) error
 	mobileMoneyRequestModel.UserId = uint(claims["userId"].(float64))
 	mobileMoneyRequestModel.EmailAddress = claims["emailAddress"].(string)
 
+	if mobileMoneyRequestModel.AddressId != 0 {
+		if err := c.AccessPolicyService.CanUseAddress(ctx.Context(), claims, mobileMoneyRequestModel.AddressId); err != nil {
+			return err
+		}
+	}
+
 	response := c.TransactionService.InitiateMobileMoneyTransaction(ctx.Context(), mobileMoneyRequestModel)
 
 	return ctx.Status(fiber.StatusOK).JSON(c.responseBuilder.Success(response, "Mobile money payment initiated successfully"))
@@ -89,6 +110,12 @@ func (c TransactionController) InitiateMobileMoneyPayment(ctx *fiber.Ctx) error
 
 func (c TransactionController) PaymentStatus(ctx *fiber.Ctx) error {
 	id := ctx.Params("id")
+	// Verifying a payment writes its status and creates the order, only
+	// those who may see the transaction may trigger it
+	claims, _ := middleware.GetClaims(ctx)
+	if err := c.AccessPolicyService.CanReadTransaction(ctx.Context(), claims, id); err != nil {
+		return err
+	}
 	paymentStatus := c.TransactionService.PaymentStatus(ctx.Context(), id)
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
 		Code:    200,
@@ -154,6 +181,16 @@ func (c TransactionController) ApproveOrRejectOrder(ctx *fiber.Ctx) error {
 	err := ctx.BodyParser(&approveOrRejectOrderModel)
 	exception.PanicLogging(err)
 
+	claims, _ := middleware.GetClaims(ctx)
+	if err := c.AccessPolicyService.CanManageOrder(ctx.Context(), claims, approveOrRejectOrderModel.OrderId); err != nil {
+		return err
+	}
+	if approveOrRejectOrderModel.Action == "approve" && approveOrRejectOrderModel.TruckId != 0 {
+		if err := c.AccessPolicyService.CanAssignTruck(ctx.Context(), claims, approveOrRejectOrderModel.OrderId, approveOrRejectOrderModel.TruckId); err != nil {
+			return err
+		}
+	}
+
 	approveOrderResponse, err := c.TransactionService.ApproveOrRejectOrder(ctx.Context(), approveOrRejectOrderModel)
 	exception.PanicLogging(err)
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
@@ -260,8 +297,16 @@ func (c TransactionController) GetCustomerOrders(ctx *fiber.Ctx) error {
 }
 
 func (c TransactionController) FindById(ctx *fiber.Ctx) error {
-	id := ctx.Params("id")
-	parsedId, err := strconv.Atoi(id)
+	parsedId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(c.responseBuilder.Error(fiber.StatusBadRequest, "Invalid order id"))
+	}
+
+	claims, _ := middleware.GetClaims(ctx)
+	if err := c.AccessPolicyService.CanReadOrder(ctx.Context(), claims, uint(parsedId)); err != nil {
+		return err
+	}
+
 	order, err := c.TransactionService.FindById(ctx.Context(), uint(parsedId))
 	exception.PanicLogging(err)
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
@@ -279,6 +324,11 @@ func (c TransactionController) GetOrderRating(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(c.responseBuilder.Error(fiber.StatusBadRequest, "Invalid order id"))
 	}
 
+	claims, _ := middleware.GetClaims(ctx)
+	if err := c.AccessPolicyService.CanReadOrder(ctx.Context(), claims, uint(parsedId)); err != nil {
+		return err
+	}
+
 	rating, err := c.TransactionService.GetOrderRating(ctx.Context(), uint(parsedId))
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(c.responseBuilder.Error(fiber.StatusInternalServerError, err.Error()))
@@ -311,6 +361,9 @@ func (c TransactionController) ProcessRecurringPayment(ctx *fiber.Ctx) error {
 
 func (c TransactionController) MarkOrderReadyForDelivery(ctx *fiber.Ctx) error {
 	id := ctx.Params("id")
+	if err := c.authorizeOrder(ctx, id, c.AccessPolicyService.CanManageOrder); err != nil {
+		return err
+	}
 	err := c.TransactionService.MarkOrderReadyForDelivery(id)
 	exception.PanicLogging(err)
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
@@ -323,6 +376,9 @@ func (c TransactionController) MarkOrderReadyForDelivery(ctx *fiber.Ctx) error {
 
 func (c TransactionController) CloseOrder(ctx *fiber.Ctx) error {
 	id := ctx.Params("id")
+	if err := c.authorizeOrder(ctx, id, c.AccessPolicyService.CanDeliverOrder); err != nil {
+		return err
+	}
 	err := c.TransactionService.CloseOrder(id)
 	exception.PanicLogging(err)
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
@@ -353,6 +409,10 @@ func (c TransactionController) SubmitRating(ctx *fiber.Ctx) error {
 
 	ratingModel.UserId = uint(userId)
 
+	if err := c.AccessPolicyService.CanRateOrder(ctx.Context(), claims, ratingModel.OrderId); err != nil {
+		return err
+	}
+
 	err = c.TransactionService.SubmitRating(ctx.Context(), ratingModel)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(c.responseBuilder.Error(fiber.StatusInternalServerError, err.Error()))
@@ -366,37 +426,22 @@ func (c TransactionController) GetTransactionsByCountryCode(ctx *fiber.Ctx) erro
 	// Extract pagination parameters using our utility
 	pagination := utils.ExtractPaginationParams(ctx)
 
-	// Get country code from claims
-	countryCode := ""
-	claims, ok := middleware.GetClaims(ctx)
-	if ok && claims["countryCode"] != nil {
-		countryCode, _ = claims["countryCode"].(string)
-	}
+	// Refinery staff only see their refinery's transactions, others their own
+	claims, _ := middleware.GetClaims(ctx)
+	scope := c.AccessPolicyService.TransactionScope(ctx.Context(), claims)
 
 	// Allow override via query parameter for admin users
 	queryCountryCode := ctx.Query("country_code", "")
-	if queryCountryCode != "" && c.AuthorizationService.IsAdmin(claims) {
-		countryCode = queryCountryCode
+	if queryCountryCode != "" && c.AuthorizationService.HasPermission(ctx.Context(), claims, common.TRANSACTION_READ_ALL_PERMISSION) {
+		scope.CountryCode = queryCountryCode
 	}
 
-	// Get transactions with country code filter and pagination
-	transactions, totalCount := c.TransactionService.GetTransactionsByCountryCode(
-		ctx.Context(), countryCode, pagination.Page, pagination.Limit)
+	// Get transactions with scope filter and pagination
+	transactions, totalCount := c.TransactionService.GetTransactionsByScope(
+		ctx.Context(), scope, pagination.Page, pagination.Limit)
 
 	// Use response builder for consistent response format
 	return ctx.Status(fiber.StatusOK).JSON(
 		c.responseBuilder.Pagination(transactions, pagination.Page, pagination.Limit, totalCount),
 	)
 }
-
-// isAdminUser checks if the current user has admin privileges
-func (c TransactionController) isAdminUser(ctx *fiber.Ctx) bool {
-	// Get claims from context (set by middleware)
-	claims, ok := middleware.GetClaims(ctx)
-	if !ok {
-		return false
-	}
-
-	// Use AuthorizationService to check if the user is an admin
-	return c.AuthorizationService.IsAdmin(claims)
-}
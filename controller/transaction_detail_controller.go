@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
@@ -10,15 +11,17 @@ import (
 
 type TransactionDetailController struct {
 	service.TransactionDetailService
+	service.AuthorizationService
+	service.AccessPolicyService
 	configuration.Config
 }
 
-func NewTransactionDetailController(transactionDetailService *service.TransactionDetailService, config configuration.Config) *TransactionDetailController {
-	return &TransactionDetailController{TransactionDetailService: *transactionDetailService, Config: config}
+func NewTransactionDetailController(transactionDetailService *service.TransactionDetailService, authorizationService *service.AuthorizationService, accessPolicyService *service.AccessPolicyService, config configuration.Config) *TransactionDetailController {
+	return &TransactionDetailController{TransactionDetailService: *transactionDetailService, AuthorizationService: *authorizationService, AccessPolicyService: *accessPolicyService, Config: config}
 }
 
 func (controller TransactionDetailController) Route(app *fiber.App) {
-	app.Get("/v1/api/transaction-detail/:id", middleware.AuthenticateJWT("ROLE_USER", controller.Config), controller.FindById)
+	app.Get("/v1/api/transaction-detail/:id", middleware.RequirePermission(controller.AuthorizationService, common.TRANSACTION_READ_PERMISSION), controller.FindById)
 }
 
 // FindById func gets one exists transaction detail.
@@ -33,6 +36,10 @@ func (controller TransactionDetailController) Route(app *fiber.App) {
 // @Router /v1/api/transaction-detail/{id} [get]
 func (controller TransactionDetailController) FindById(c *fiber.Ctx) error {
 	id := c.Params("id")
+	claims, _ := middleware.GetClaims(c)
+	if err := controller.AccessPolicyService.CanReadTransactionDetail(c.Context(), claims, id); err != nil {
+		return err
+	}
 
 	result := controller.TransactionDetailService.FindById(c.Context(), id)
 	return c.Status(fiber.StatusOK).JSON(model.GeneralResponse{
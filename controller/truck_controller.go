@@ -1,8 +1,10 @@
 package controller
 
 import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 	"github.com/gofiber/fiber/v2"
@@ -10,23 +12,29 @@ import (
 
 type TruckController struct {
 	service.TruckService
+	service.AuthorizationService
+	service.AccessPolicyService
 	configuration.Config
 }
 
-func NewTruckController(s *service.TruckService, c configuration.Config) TruckController {
-	return TruckController{TruckService: *s, Config: c}
+func NewTruckController(s *service.TruckService, a *service.AuthorizationService, p *service.AccessPolicyService, c configuration.Config) TruckController {
+	return TruckController{TruckService: *s, AuthorizationService: *a, AccessPolicyService: *p, Config: c}
 }
 
 func (controller TruckController) Route(app *fiber.App) {
-	app.Get("/v1/api/trucks", controller.ListAllTrucks)
-	app.Get("/v1/api/trucks/country/:country_code", controller.ListTrucksByCountryCode)
-	app.Post("/v1/api/truck", controller.CreateTruck)
-	app.Put("/v1/api/truck", controller.UpdateTruck)
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.TRUCK_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.TRUCK_WRITE_PERMISSION)
+
+	app.Get("/v1/api/trucks", canRead, controller.ListAllTrucks)
+	app.Get("/v1/api/trucks/country/:country_code", canRead, controller.ListTrucksByCountryCode)
+	app.Post("/v1/api/truck", canWrite, controller.CreateTruck)
+	app.Put("/v1/api/truck", canWrite, controller.UpdateTruck)
 
 }
 
 func (controller TruckController) ListAllTrucks(c *fiber.Ctx) error {
-	trucks, err := controller.TruckService.ListAllTrucks(c.Context())
+	// Refinery staff only see the trucks of their own refinery
+	trucks, err := controller.TruckService.ListAllTrucks(c.Context(), currentRefineryId(c))
 	exception.PanicLogging(err)
 	return c.Status(fiber.StatusOK).JSON(trucks)
 }
@@ -34,8 +42,12 @@ func (controller TruckController) CreateTruck(c *fiber.Ctx) error {
 	var request model.TruckModel
 	err := c.BodyParser(&request)
 	exception.PanicLogging(err)
+	// Refinery staff always register trucks for their own refinery
+	if refineryId := currentRefineryId(c); refineryId != 0 {
+		request.RefineryId = refineryId
+	}
 
-	truck, err := controller.TruckService.CreateTruck(request)
+	truck, err := controller.TruckService.CreateTruck(c.Context(), request)
 	exception.PanicLogging(err)
 
 	return c.Status(fiber.StatusOK).JSON(truck)
@@ -46,6 +58,17 @@ func (controller TruckController) UpdateTruck(c *fiber.Ctx) error {
 	err := c.BodyParser(&request)
 	exception.PanicLogging(err)
 
+	claims, _ := middleware.GetClaims(c)
+	if err := controller.AccessPolicyService.CanManageTruck(c.Context(), claims, request.Id); err != nil {
+		return err
+	}
+	// Refinery staff may not move the truck to another refinery or hand it to
+	// a driver of their choosing
+	if currentRefineryId(c) != 0 {
+		request.RefineryId = 0
+		request.UserId = 0
+	}
+
 	truck, err := controller.TruckService.UpdateTruck(request)
 	exception.PanicLogging(err)
 
@@ -62,7 +85,7 @@ func (controller TruckController) ListTrucksByCountryCode(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "country_code required"})
 	}
 
-	trucks, err := controller.TruckService.ListTrucksByCountryCode(c.Context(), countryCode)
+	trucks, err := controller.TruckService.ListTrucksByCountryCode(c.Context(), countryCode, currentRefineryId(c))
 	exception.PanicLogging(err)
 	return c.Status(fiber.StatusOK).JSON(trucks)
 }
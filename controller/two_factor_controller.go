@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+type TwoFactorController struct {
+	service.TwoFactorService
+	service.TokenService
+	service.AuthorizationService
+	responseBuilder *utils.ResponseBuilder
+}
+
+func NewTwoFactorController(twoFactorService service.TwoFactorService, tokenService service.TokenService, authorizationService service.AuthorizationService) *TwoFactorController {
+	return &TwoFactorController{
+		TwoFactorService:     twoFactorService,
+		TokenService:         tokenService,
+		AuthorizationService: authorizationService,
+		responseBuilder:      utils.NewResponseBuilder(),
+	}
+}
+
+func (controller *TwoFactorController) Route(app *fiber.App) {
+	// Second login step, public because the caller only holds a challenge token
+	app.Post("/v1/api/authentication/two-factor", controller.CompleteLogin)
+	app.Post("/v1/api/authentication/two-factor/enrol", controller.StartLoginEnrolment)
+
+	canRead := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_READ_PERMISSION)
+	canWrite := middleware.RequirePermission(controller.AuthorizationService, common.PROFILE_WRITE_PERMISSION)
+
+	app.Get("/v1/api/two-factor", canRead, controller.Status)
+	app.Post("/v1/api/two-factor/enrol", canWrite, controller.StartEnrolment)
+	app.Post("/v1/api/two-factor/confirm", canWrite, controller.ConfirmEnrolment)
+	app.Post("/v1/api/two-factor/disable", canWrite, controller.Disable)
+	app.Post("/v1/api/two-factor/recovery-codes", canWrite, controller.RegenerateRecoveryCodes)
+}
+
+// CompleteLogin verifies the TOTP or recovery code for a login challenge and
+// issues the session tokens. Recovery codes are included when this request
+// also completed a mandatory enrolment.
+func (controller *TwoFactorController) CompleteLogin(c *fiber.Ctx) error {
+	var request model.TwoFactorLoginModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	if request.ChallengeToken == "" || request.Code == "" {
+		return exception.BadRequestError{Message: "challengeToken and code are required"}
+	}
+
+	user, recoveryCodes, err := controller.TwoFactorService.CompleteLogin(c.Context(), request, sessionClient(c))
+	if err != nil {
+		return err
+	}
+	tokens, err := controller.TokenService.IssueTokens(c.Context(), user, sessionClient(c))
+	if err != nil {
+		return err
+	}
+
+	response := loginResponse(tokens, user)
+	if len(recoveryCodes) > 0 {
+		response["recoveryCodes"] = recoveryCodes
+	}
+	return c.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+		Code:    200,
+		Message: "Success",
+		Data:    response,
+	})
+}
+
+// StartLoginEnrolment returns a provisioning URI to an account that has to
+// enrol before its login can complete
+func (controller *TwoFactorController) StartLoginEnrolment(c *fiber.Ctx) error {
+	var request model.TwoFactorLoginModel
+	if err := c.BodyParser(&request); err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+	if request.ChallengeToken == "" {
+		return exception.BadRequestError{Message: "challengeToken is required"}
+	}
+
+	enrolment, err := controller.TwoFactorService.StartLoginEnrolment(c.Context(), request.ChallengeToken)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(enrolment, "Two-factor enrolment started"))
+}
+
+func (controller *TwoFactorController) Status(c *fiber.Ctx) error {
+	status, err := controller.TwoFactorService.Status(c.Context(), currentUserId(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(status, "Two-factor status retrieved successfully"))
+}
+
+func (controller *TwoFactorController) StartEnrolment(c *fiber.Ctx) error {
+	enrolment, err := controller.TwoFactorService.StartEnrolment(c.Context(), currentUserId(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(enrolment, "Two-factor enrolment started"))
+}
+
+func (controller *TwoFactorController) ConfirmEnrolment(c *fiber.Ctx) error {
+	code, err := twoFactorCode(c)
+	if err != nil {
+		return err
+	}
+	recoveryCodes, err := controller.TwoFactorService.ConfirmEnrolment(c.Context(), currentUserId(c), code)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(fiber.Map{"recoveryCodes": recoveryCodes}, "Two-factor authentication enabled"))
+}
+
+func (controller *TwoFactorController) Disable(c *fiber.Ctx) error {
+	code, err := twoFactorCode(c)
+	if err != nil {
+		return err
+	}
+	if err := controller.TwoFactorService.Disable(c.Context(), currentUserId(c), code); err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(nil, "Two-factor authentication disabled"))
+}
+
+func (controller *TwoFactorController) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	code, err := twoFactorCode(c)
+	if err != nil {
+		return err
+	}
+	recoveryCodes, err := controller.TwoFactorService.RegenerateRecoveryCodes(c.Context(), currentUserId(c), code)
+	if err != nil {
+		return err
+	}
+	return c.JSON(controller.responseBuilder.Success(fiber.Map{"recoveryCodes": recoveryCodes}, "Recovery codes regenerated"))
+}
+
+func twoFactorCode(c *fiber.Ctx) (string, error) {
+	var request model.TwoFactorCodeModel
+	if err := c.BodyParser(&request); err != nil {
+		return "", exception.BadRequestError{Message: "Invalid request body"}
+	}
+	if request.Code == "" {
+		return "", exception.BadRequestError{Message: "code is required"}
+	}
+	return request.Code, nil
+}
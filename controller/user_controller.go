@@ -14,35 +14,47 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func NewUserController(userService *service.UserService, config configuration.Config) *UserController {
-	return &UserController{UserService: *userService, Config: config}
+func NewUserController(userService *service.UserService, tokenService *service.TokenService, twoFactorService *service.TwoFactorService, authorizationService *service.AuthorizationService, accessPolicyService *service.AccessPolicyService, fileService *service.FileService, config configuration.Config) *UserController {
+	return &UserController{UserService: *userService, TokenService: *tokenService, TwoFactorService: *twoFactorService, AuthorizationService: *authorizationService, AccessPolicyService: *accessPolicyService, FileService: *fileService, Config: config}
 }
 
 type UserController struct {
 	service.UserService
+	TokenService         service.TokenService
+	TwoFactorService     service.TwoFactorService
+	AuthorizationService service.AuthorizationService
+	AccessPolicyService  service.AccessPolicyService
+	FileService          service.FileService
 	configuration.Config
 }
 
 func (controller UserController) Route(app *fiber.App) {
 	// Public routes (no auth required)
 	app.Post("/v1/api/authentication", controller.HandleAuthentication)
+	app.Post("/v1/api/refresh-token", controller.HandleRefreshToken)
 	app.Post("/v1/api/register", controller.HandleRegister)
 	app.Post("/v1/api/register-customer", controller.HandleRegisterCustomer)
 	app.Post("/v1/api/reset-password", controller.HandleResetPassword)
 	app.Post("/v1/api/verify-phone", controller.HandleValidatePhoneNumber)
+	app.Post("/v1/api/resend-otp", controller.HandleResendOtp)
 	app.Post("/v1/api/verify-email", controller.HandleVerifyEmail)
 	app.Post("/v1/api/post-new-password", controller.HandleUpdateUserPassword)
-	app.Post("/v1/api/update-fcm-token", controller.HandleUpdateFcmToken)
 
-	// Protected routes (require authentication)
-	protected := app.Group("/v1/api", middleware.ExtractClaims(controller), middleware.RequireClaims())
-	protected.Put("/users/:id", controller.HandleUpdateUser)
-	protected.Post("/change-password", controller.HandleChangePassword)
-	protected.Post("/save-address", controller.HandleSaveAddress)
-	protected.Get("/get-addresses", controller.HandleGetAddresses)
-	protected.Get("/users", controller.ListUsers)
-	protected.Post("/update-profile", controller.HandleUpdateProfile)
-	protected.Get("/users/:id", controller.FindUserById)
+	// Protected routes, claims are extracted for every /v1/api route in main
+	app.Put("/v1/api/users/:id", controller.requirePermission(common.PROFILE_WRITE_PERMISSION), controller.HandleUpdateUser)
+	app.Post("/v1/api/change-password", controller.requirePermission(common.PROFILE_WRITE_PERMISSION), controller.HandleChangePassword)
+	app.Get("/v1/api/users", controller.requirePermission(common.USER_READ_PERMISSION), controller.ListUsers)
+	app.Post("/v1/api/update-profile", controller.requirePermission(common.PROFILE_WRITE_PERMISSION), controller.HandleUpdateProfile)
+	app.Get("/v1/api/users/:id", controller.requirePermission(common.PROFILE_READ_PERMISSION), controller.FindUserById)
+	app.Put("/v1/api/users/:id/status", controller.requirePermission(common.USER_WRITE_PERMISSION), controller.HandleUpdateUserStatus)
+	app.Post("/v1/api/logout", controller.requirePermission(common.PROFILE_WRITE_PERMISSION), controller.HandleLogout)
+	app.Get("/v1/api/sessions", controller.requirePermission(common.PROFILE_READ_PERMISSION), controller.HandleListSessions)
+	app.Delete("/v1/api/sessions/:id", controller.requirePermission(common.PROFILE_WRITE_PERMISSION), controller.HandleRevokeSession)
+	app.Post("/v1/api/update-fcm-token", controller.requirePermission(common.PROFILE_WRITE_PERMISSION), controller.HandleUpdateFcmToken)
+}
+
+func (controller UserController) requirePermission(permission string) fiber.Handler {
+	return middleware.RequirePermission(controller.AuthorizationService, permission)
 }
 
 // Register implements the UserService interface
@@ -75,8 +87,8 @@ func (controller UserController) HandleRegister(c *fiber.Ctx) error {
 }
 
 // RegisterCustomer implements the UserService interface
-func (controller UserController) RegisterCustomer(ctx context.Context, request model.UserModel) interface{} {
-	return controller.UserService.RegisterCustomer(ctx, request)
+func (controller UserController) RegisterCustomer(ctx context.Context, request model.UserModel, ipAddress string) interface{} {
+	return controller.UserService.RegisterCustomer(ctx, request, ipAddress)
 }
 
 // HandleRegisterCustomer handles the HTTP request for customer registration
@@ -86,7 +98,7 @@ func (controller UserController) HandleRegisterCustomer(c *fiber.Ctx) error {
 	exception.PanicLogging(err)
 
 	request.Role = common.CUSTOMER_ROLE
-	user := controller.RegisterCustomer(c.Context(), request)
+	user := controller.RegisterCustomer(c.Context(), request, c.IP())
 
 	return c.Status(fiber.StatusOK).JSON(model.GeneralResponse{
 		Code:    200,
@@ -97,8 +109,8 @@ func (controller UserController) HandleRegisterCustomer(c *fiber.Ctx) error {
 }
 
 // Authentication implements the UserService interface
-func (controller UserController) Authentication(ctx context.Context, model model.LoginModel) entity.User {
-	return controller.UserService.Authentication(ctx, model)
+func (controller UserController) Authentication(ctx context.Context, model model.LoginModel, client model.SessionClientModel) entity.User {
+	return controller.UserService.Authentication(ctx, model, client)
 }
 
 // HandleAuthentication handles the HTTP request for user authentication
@@ -107,30 +119,56 @@ func (controller UserController) HandleAuthentication(c *fiber.Ctx) error {
 	err := c.BodyParser(&request)
 	exception.PanicLogging(err)
 
-	result := controller.Authentication(c.Context(), request)
-	var userRoles []map[string]interface{}
-
-	tokenJwtResult := common.GenerateToken(result.Username, userRoles, result, controller.Config)
-	resultWithToken := map[string]interface{}{
-		"token":        tokenJwtResult,
-		"username":     result.Username,
-		"role":         result.UserRole,
-		"emailAddress": result.Email,
-		"fullName":     result.FirstName + " " + result.LastName,
-		"firstName":    result.FirstName,
-		"lastName":     result.LastName,
-		"phoneNumber":  result.PhoneNumber,
-		"id":           result.ID,
-		"refineryId":   result.RefineryId,
-		"countryCode":  result.CountryCode,
+	result := controller.Authentication(c.Context(), request, sessionClient(c))
+
+	// Accounts with two-factor authentication get a challenge instead of
+	// tokens and finish signing in on /v1/api/authentication/two-factor
+	challenge, err := controller.TwoFactorService.BeginLogin(c.Context(), result, sessionClient(c))
+	if err != nil {
+		return err
 	}
+	if challenge != nil {
+		return c.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+			Code:    200,
+			Message: "Two-factor authentication required",
+			Data: map[string]interface{}{
+				"twoFactorRequired": true,
+				"challengeToken":    challenge.ChallengeToken,
+				"expiresAt":         challenge.ExpiresAt,
+				"enrolmentRequired": challenge.EnrolmentRequired,
+			},
+		})
+	}
+
+	tokens, err := controller.TokenService.IssueTokens(c.Context(), result, sessionClient(c))
+	exception.PanicLogging(err)
 	return c.Status(fiber.StatusOK).JSON(model.GeneralResponse{
 		Code:    200,
 		Message: "Success",
-		Data:    resultWithToken,
+		Data:    loginResponse(tokens, result),
 	})
 }
 
+// loginResponse is the payload returned once a user has fully signed in
+func loginResponse(tokens model.TokenPairModel, user entity.User) map[string]interface{} {
+	return map[string]interface{}{
+		"token":                 tokens.AccessToken,
+		"tokenExpiresAt":        tokens.AccessTokenExpiresAt,
+		"refreshToken":          tokens.RefreshToken,
+		"refreshTokenExpiresAt": tokens.RefreshTokenExpiresAt,
+		"username":              user.Username,
+		"role":                  user.UserRole,
+		"emailAddress":          user.Email,
+		"fullName":              user.FirstName + " " + user.LastName,
+		"firstName":             user.FirstName,
+		"lastName":              user.LastName,
+		"phoneNumber":           user.PhoneNumber,
+		"id":                    user.ID,
+		"refineryId":            user.RefineryId,
+		"countryCode":           user.CountryCode,
+	}
+}
+
 // ChangePassword Authentication func ChangePassword user.
 // @Description change password.
 // @Summary  change password
@@ -238,6 +276,7 @@ func (controller UserController) ListUsers(c *fiber.Ctx) error {
 			Role:         user.UserRole,
 			PhoneNumber:  user.PhoneNumber,
 			FileName:     user.FileName,
+			PhotoUrl:     controller.FileService.SignedUrl(user.FileName),
 		})
 	}
 
@@ -252,28 +291,53 @@ func (controller UserController) ListUsers(c *fiber.Ctx) error {
 func (controller UserController) FindUserById(c *fiber.Ctx) error {
 	var user model.UserModel
 	userId, err := strconv.Atoi(c.Params("id"))
-	exception.PanicLogging(err)
+	if err != nil {
+		return exception.BadRequestError{Message: "Invalid user ID"}
+	}
+
+	// Users may read themselves, anyone else needs the user:read permission
+	claims, _ := middleware.GetClaims(c)
+	if err := controller.AccessPolicyService.CanReadUser(c.Context(), claims, uint(userId)); err != nil {
+		return err
+	}
+
 	user, err = controller.UserService.FindByID(c.Context(), userId)
 	exception.PanicLogging(err)
+	user.PhotoUrl = controller.FileService.SignedUrl(user.FileName)
 	return c.Status(fiber.StatusOK).JSON(user)
 }
 
-// ValidateOtp implements the UserService interface
-func (controller UserController) ValidatePhoneNumber(ctx context.Context, request model.OtpModel) entity.OneTimePassword {
-	return controller.UserService.ValidatePhoneNumber(ctx, request)
-}
-
 // HandleValidatePhoneNumber handles the HTTP request for OTP validation
 func (controller UserController) HandleValidatePhoneNumber(ctx *fiber.Ctx) error {
 	var request model.OtpModel
 	err := ctx.BodyParser(&request)
 	exception.PanicLogging(err)
 
-	otp := controller.ValidatePhoneNumber(ctx.Context(), request)
+	if err := controller.UserService.ValidatePhoneNumber(ctx.Context(), request); err != nil {
+		return err
+	}
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
 		Code:    200,
 		Message: "Success",
-		Data:    otp,
+		Success: true,
+	})
+}
+
+// HandleResendOtp handles the HTTP request for sending a new OTP
+func (controller UserController) HandleResendOtp(ctx *fiber.Ctx) error {
+	var request model.ResendOtpModel
+	err := ctx.BodyParser(&request)
+	if err != nil {
+		return exception.BadRequestError{Message: "Invalid request body"}
+	}
+
+	if err := controller.UserService.ResendOtp(ctx.Context(), request, ctx.IP()); err != nil {
+		return err
+	}
+	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+		Code:    200,
+		Message: "OTP sent",
+		Success: true,
 	})
 }
 
@@ -283,17 +347,19 @@ func (controller UserController) HandleVerifyEmail(ctx *fiber.Ctx) error {
 	err := ctx.BodyParser(&request)
 	exception.PanicLogging(err)
 
-	otp := controller.UserService.VerifyEmail(ctx.Context(), request)
+	if err := controller.UserService.VerifyEmail(ctx.Context(), request); err != nil {
+		return err
+	}
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
 		Code:    200,
 		Message: "Success",
-		Data:    otp,
+		Success: true,
 	})
 }
 
 // ResetPassword implements the UserService interface
-func (controller UserController) ResetPassword(ctx context.Context, request model.UserModel) model.UserModel {
-	return controller.UserService.ResetPassword(ctx, request)
+func (controller UserController) ResetPassword(ctx context.Context, request model.UserModel, ipAddress string) model.UserModel {
+	return controller.UserService.ResetPassword(ctx, request, ipAddress)
 }
 
 // HandleResetPassword handles the HTTP request for password reset
@@ -302,7 +368,7 @@ func (controller UserController) HandleResetPassword(ctx *fiber.Ctx) error {
 	err := ctx.BodyParser(&request)
 	exception.PanicLogging(err)
 
-	user := controller.ResetPassword(ctx.Context(), request)
+	user := controller.ResetPassword(ctx.Context(), request, ctx.IP())
 	user.Password = ""
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
 		Code:    200,
@@ -399,14 +465,33 @@ func (controller UserController) HandleUpdateProfile(ctx *fiber.Ctx) error {
 	})
 }
 
-// SaveAddress implements the UserService interface
-func (controller UserController) SaveAddress(ctx context.Context, request map[string]interface{}) (interface{}, error) {
-	return controller.UserService.SaveAddress(ctx, request)
+// UpdateFcmToken implements the UserService interface
+func (controller UserController) UpdateFcmToken(ctx context.Context, request model.UpdateFcmToken) error {
+	return controller.UserService.UpdateFcmToken(ctx, request)
 }
 
-// HandleSaveAddress handles the HTTP request for saving addresses
-func (controller UserController) HandleSaveAddress(ctx *fiber.Ctx) error {
-	var request map[string]interface{}
+// HandleUpdateFcmToken handles the HTTP request for FCM token updates
+func (controller UserController) HandleUpdateFcmToken(ctx *fiber.Ctx) error {
+	var request model.UpdateFcmToken
+	err := ctx.BodyParser(&request)
+	exception.PanicLogging(err)
+	// The token always belongs to the signed in user
+	request.Id = int64(currentUserId(ctx))
+
+	err = controller.UpdateFcmToken(ctx.Context(), request)
+	exception.PanicLogging(err)
+
+	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+		Code:    200,
+		Message: "Successful",
+		Data:    nil,
+		Success: true,
+	})
+}
+
+// HandleUpdateUser handles the HTTP request for updating a user
+func (controller UserController) HandleUpdateUser(ctx *fiber.Ctx) error {
+	var request model.UserModel
 	err := ctx.BodyParser(&request)
 	if err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(model.GeneralResponse{
@@ -426,18 +511,34 @@ func (controller UserController) HandleSaveAddress(ctx *fiber.Ctx) error {
 		})
 	}
 
-	userId, ok := claims["userId"].(float64)
-	if !ok {
+	// Verify if user has permission to update the requested user
+	userId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(model.GeneralResponse{
 			Code:    fiber.StatusBadRequest,
-			Message: "User ID not found in token",
+			Message: "Invalid user ID",
 			Success: false,
 		})
 	}
 
-	request["userId"] = uint(userId)
+	// Users may update themselves, anyone else needs the user:write permission
+	if err := controller.AccessPolicyService.CanWriteUser(ctx.Context(), claims, uint(userId)); err != nil {
+		return err
+	}
+
+	request.Id = uint(userId)
+
+	// Extract the token from header
+	claims, ok = middleware.GetClaims(ctx)
+	if !ok {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(model.GeneralResponse{
+			Code:    fiber.StatusUnauthorized,
+			Message: "Authentication required",
+			Success: false,
+		})
+	}
 
-	res, err := controller.SaveAddress(ctx.Context(), request)
+	user, err := controller.UpdateProfile(ctx.Context(), request, claims["username"].(string))
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(model.GeneralResponse{
 			Code:    fiber.StatusInternalServerError,
@@ -448,165 +549,145 @@ func (controller UserController) HandleSaveAddress(ctx *fiber.Ctx) error {
 
 	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
 		Code:    fiber.StatusOK,
-		Message: "Address saved successfully",
+		Message: "User updated successfully",
 		Success: true,
-		Data:    res,
+		Data:    user,
 	})
 }
 
-// GetAddresses implements the UserService interface
-func (controller UserController) GetAddresses(ctx context.Context, id uint) (interface{}, error) {
-	return controller.UserService.GetAddresses(ctx, id)
-}
-
-// HandleGetAddresses handles the HTTP request for getting user addresses
-func (controller UserController) HandleGetAddresses(ctx *fiber.Ctx) error {
-	// Get claims from context (set by middleware)
-	claims, ok := middleware.GetClaims(ctx)
-	if !ok {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(model.GeneralResponse{
-			Code:    fiber.StatusUnauthorized,
-			Message: "Authentication required",
-			Success: false,
-		})
-	}
-
-	userId, ok := claims["userId"].(float64)
-	if !ok {
-		return ctx.Status(fiber.StatusBadRequest).JSON(model.GeneralResponse{
+// HandleRefreshToken exchanges a refresh token for a new token pair
+func (controller UserController) HandleRefreshToken(c *fiber.Ctx) error {
+	var request model.RefreshTokenModel
+	err := c.BodyParser(&request)
+	if err != nil || request.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(model.GeneralResponse{
 			Code:    fiber.StatusBadRequest,
-			Message: "User ID not found in token",
+			Message: "Invalid request body",
 			Success: false,
 		})
 	}
 
-	addresses, err := controller.GetAddresses(ctx.Context(), uint(userId))
+	tokens, err := controller.TokenService.RefreshTokens(c.Context(), request.RefreshToken, sessionClient(c))
 	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(model.GeneralResponse{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-			Success: false,
-		})
+		return err
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+	return c.Status(fiber.StatusOK).JSON(model.GeneralResponse{
 		Code:    fiber.StatusOK,
-		Message: "Addresses retrieved successfully",
-		Data:    addresses,
+		Message: "Success",
 		Success: true,
+		Data:    tokens,
 	})
 }
 
-// UpdateFcmToken implements the UserService interface
-func (controller UserController) UpdateFcmToken(ctx context.Context, request model.UpdateFcmToken) error {
-	return controller.UserService.UpdateFcmToken(ctx, request)
+// HandleLogout revokes the session of the current access token
+func (controller UserController) HandleLogout(c *fiber.Ctx) error {
+	claims, _ := middleware.GetClaims(c)
+	if err := controller.TokenService.Logout(c.Context(), claims); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+		Code:    fiber.StatusOK,
+		Message: "Logged out successfully",
+		Success: true,
+	})
 }
 
-// HandleUpdateFcmToken handles the HTTP request for FCM token updates
-func (controller UserController) HandleUpdateFcmToken(ctx *fiber.Ctx) error {
-	var request model.UpdateFcmToken
-	err := ctx.BodyParser(&request)
-	exception.PanicLogging(err)
+// HandleListSessions lists the active sessions of the current user
+func (controller UserController) HandleListSessions(c *fiber.Ctx) error {
+	claims, _ := middleware.GetClaims(c)
+	userId, _ := claims["userId"].(float64)
+	tokenId, _ := claims["jti"].(string)
 
-	err = controller.UpdateFcmToken(ctx.Context(), request)
-	exception.PanicLogging(err)
+	sessions, err := controller.TokenService.ListSessions(c.Context(), uint(userId), tokenId)
+	if err != nil {
+		return err
+	}
 
-	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
-		Code:    200,
-		Message: "Successful",
-		Data:    nil,
+	return c.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+		Code:    fiber.StatusOK,
+		Message: "Success",
 		Success: true,
+		Data:    sessions,
 	})
 }
 
-// HandleUpdateUser handles the HTTP request for updating a user
-func (controller UserController) HandleUpdateUser(ctx *fiber.Ctx) error {
-	var request model.UserModel
-	err := ctx.BodyParser(&request)
+// HandleRevokeSession signs the current user out of one of their sessions
+func (controller UserController) HandleRevokeSession(c *fiber.Ctx) error {
+	sessionId, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(model.GeneralResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(model.GeneralResponse{
 			Code:    fiber.StatusBadRequest,
-			Message: "Invalid request body",
+			Message: "Invalid session ID",
 			Success: false,
 		})
 	}
 
-	// Get claims from context (set by middleware)
-	claims, ok := middleware.GetClaims(ctx)
-	if !ok {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(model.GeneralResponse{
-			Code:    fiber.StatusUnauthorized,
-			Message: "Authentication required",
+	claims, _ := middleware.GetClaims(c)
+	userId, _ := claims["userId"].(float64)
+	if err := controller.TokenService.RevokeSession(c.Context(), uint(userId), uint(sessionId)); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+		Code:    fiber.StatusOK,
+		Message: "Session revoked successfully",
+		Success: true,
+	})
+}
+
+// HandleUpdateUserStatus activates or deactivates a user. Deactivating a user
+// revokes all of their sessions.
+func (controller UserController) HandleUpdateUserStatus(c *fiber.Ctx) error {
+	var request model.ToggleUserStatusModel
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(model.GeneralResponse{
+			Code:    fiber.StatusBadRequest,
+			Message: "Invalid request body",
 			Success: false,
 		})
 	}
 
-	// Verify if user has permission to update the requested user
-	userId, err := strconv.Atoi(ctx.Params("id"))
+	userId, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return ctx.Status(fiber.StatusBadRequest).JSON(model.GeneralResponse{
+		return c.Status(fiber.StatusBadRequest).JSON(model.GeneralResponse{
 			Code:    fiber.StatusBadRequest,
 			Message: "Invalid user ID",
 			Success: false,
 		})
 	}
-
-	// Check if the authenticated user has permission to update this user
-	if claimUserId, ok := claims["userId"].(float64); !ok || uint(claimUserId) != uint(userId) {
-		// Check if user has admin role
-		roles, ok := claims["roles"].([]interface{})
-		if !ok {
-			return ctx.Status(fiber.StatusForbidden).JSON(model.GeneralResponse{
-				Code:    fiber.StatusForbidden,
-				Message: "Access denied",
-				Success: false,
-			})
-		}
-
-		hasAdminRole := false
-		for _, role := range roles {
-			if roleMap, ok := role.(map[string]interface{}); ok {
-				if roleMap["role"] == "ADMIN" {
-					hasAdminRole = true
-					break
-				}
-			}
-		}
-
-		if !hasAdminRole {
-			return ctx.Status(fiber.StatusForbidden).JSON(model.GeneralResponse{
-				Code:    fiber.StatusForbidden,
-				Message: "Access denied",
-				Success: false,
-			})
-		}
-	}
-
 	request.Id = uint(userId)
 
-	// Extract the token from header
-	claims, ok = middleware.GetClaims(ctx)
-	if !ok {
-		return ctx.Status(fiber.StatusUnauthorized).JSON(model.GeneralResponse{
-			Code:    fiber.StatusUnauthorized,
-			Message: "Authentication required",
-			Success: false,
-		})
-	}
-
-	user, err := controller.UpdateProfile(ctx.Context(), request, claims["username"].(string))
-	if err != nil {
-		return ctx.Status(fiber.StatusInternalServerError).JSON(model.GeneralResponse{
-			Code:    fiber.StatusInternalServerError,
-			Message: err.Error(),
-			Success: false,
-		})
+	if err := controller.UserService.UpdateUserStatus(c.Context(), request); err != nil {
+		return err
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(model.GeneralResponse{
+	return c.Status(fiber.StatusOK).JSON(model.GeneralResponse{
 		Code:    fiber.StatusOK,
-		Message: "User updated successfully",
+		Message: "User status updated successfully",
 		Success: true,
-		Data:    user,
 	})
 }
+
+// currentUserId returns the id of the signed in user from the claims
+func currentUserId(c *fiber.Ctx) uint {
+	claims, _ := middleware.GetClaims(c)
+	userId, _ := claims["userId"].(float64)
+	return uint(userId)
+}
+
+// currentRefineryId returns the refinery of the signed in user from the
+// claims, refinery-scoped endpoints never take it from the request
+func currentRefineryId(c *fiber.Ctx) uint {
+	claims, _ := middleware.GetClaims(c)
+	refineryId, _ := claims["refineryId"].(float64)
+	return uint(refineryId)
+}
+
+func sessionClient(c *fiber.Ctx) model.SessionClientModel {
+	return model.SessionClientModel{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IpAddress: c.IP(),
+	}
+}
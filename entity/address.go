@@ -23,7 +23,8 @@ func (j *JSONB) Scan(value interface{}) error {
 
 type Address struct {
 	gorm.Model
-	UserId       uint   `gorm:"column:user_id"`
+	UserId       uint   `gorm:"column:user_id;index;uniqueIndex:idx_address_user_place,where:deleted_at IS NULL AND place_id <> ''"`
+	Label        string `gorm:"column:label;type:varchar(20)"`
 	Street       string `gorm:"column:street;type:varchar(100)"`
 	City         string `gorm:"column:city;type:varchar(100)"`
 	PostalCode   string `gorm:"column:postal_code;type:varchar(10)"`
@@ -34,7 +35,7 @@ type Address struct {
 	Raw          JSONB  `gorm:"column:raw;type:jsonb"`
 	Description  string `gorm:"column:description;type:varchar(100)"`
 	StreetNumber string `gorm:"column:street_number;type:varchar(100)"`
-	PlaceId      string `gorm:"column:place_id;type:varchar(100)"`
+	PlaceId      string `gorm:"column:place_id;type:varchar(100);uniqueIndex:idx_address_user_place,where:deleted_at IS NULL AND place_id <> ''"`
 	CountryCode  string `gorm:"column:country_code;type:varchar(10)"`
 }
 
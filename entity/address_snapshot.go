@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// AddressSnapshot is the delivery address as it was when an order was placed.
+// It is stored on the order so that later edits or deletes in the customer's
+// address book do not change past orders.
+type AddressSnapshot struct {
+	AddressId   uint    `json:"addressId"`
+	Label       string  `json:"label"`
+	Description string  `json:"description"`
+	PlaceId     string  `json:"placeId"`
+	Street      string  `json:"street"`
+	City        string  `json:"city"`
+	Region      string  `json:"region"`
+	CountryCode string  `json:"countryCode"`
+	Longitude   float64 `json:"longitude"`
+	Latitude    float64 `json:"latitude"`
+}
+
+func NewAddressSnapshot(address Address) AddressSnapshot {
+	longitude, _ := strconv.ParseFloat(address.Longitude, 64)
+	latitude, _ := strconv.ParseFloat(address.Latitude, 64)
+	return AddressSnapshot{
+		AddressId:   address.ID,
+		Label:       address.Label,
+		Description: address.Description,
+		PlaceId:     address.PlaceId,
+		Street:      address.Street,
+		City:        address.City,
+		Region:      address.Region,
+		CountryCode: address.CountryCode,
+		Longitude:   longitude,
+		Latitude:    latitude,
+	}
+}
+
+func (a AddressSnapshot) IsEmpty() bool {
+	return a.PlaceId == "" && a.Description == ""
+}
+
+func (a AddressSnapshot) Value() (driver.Value, error) {
+	if a.IsEmpty() {
+		return nil, nil
+	}
+	value, err := json.Marshal(a)
+	return string(value), err
+}
+
+func (a *AddressSnapshot) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*a = AddressSnapshot{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	default:
+		return errors.New("unsupported address snapshot value")
+	}
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Campaign is a marketing message sent to the customers of a segment.
+// Channels is a comma separated list, Segment and Data are JSON.
+type Campaign struct {
+	gorm.Model
+	Name        string     `gorm:"column:name;type:varchar(100)"`
+	Title       string     `gorm:"column:title;type:varchar(255)"`
+	Body        string     `gorm:"column:body;type:text"`
+	Html        string     `gorm:"column:html;type:text"`
+	Data        string     `gorm:"column:data;type:text"`
+	Channels    string     `gorm:"column:channels;type:varchar(50)"`
+	Segment     string     `gorm:"column:segment;type:text"`
+	Status      string     `gorm:"column:status;type:varchar(20);index"`
+	ScheduledAt *time.Time `gorm:"column:scheduled_at"`
+	StartedAt   *time.Time `gorm:"column:started_at"`
+	CompletedAt *time.Time `gorm:"column:completed_at"`
+	// Recipients and TotalBatches are known once every batch was queued at
+	// QueuedAt, the campaign completes when ProcessedBatches, the number of
+	// CampaignBatch rows of the current attempt, catches up
+	QueuedAt         *time.Time `gorm:"column:queued_at"`
+	Recipients       int        `gorm:"column:recipients;type:int"`
+	TotalBatches     int        `gorm:"column:total_batches;type:int"`
+	ProcessedBatches int        `gorm:"column:processed_batches;type:int"`
+	CreatedBy        string     `gorm:"column:created_by;type:varchar(100)"`
+}
+
+func (Campaign) TableName() string {
+	return "tb_campaigns"
+}
+
+// CampaignDelivery is the outcome of a campaign for one user on one channel,
+// Status is one of the common.DISPATCH_* outcomes
+type CampaignDelivery struct {
+	gorm.Model
+	CampaignId uint       `gorm:"column:campaign_id;uniqueIndex:idx_campaign_delivery"`
+	UserId     uint       `gorm:"column:user_id;uniqueIndex:idx_campaign_delivery"`
+	Channel    string     `gorm:"column:channel;type:varchar(10);uniqueIndex:idx_campaign_delivery"`
+	Status     string     `gorm:"column:status;type:varchar(20)"`
+	OpenedAt   *time.Time `gorm:"column:opened_at"`
+}
+
+func (CampaignDelivery) TableName() string {
+	return "tb_campaign_deliveries"
+}
+
+// CampaignBatch records that a batch of a queueing attempt was processed, so
+// that a redelivered batch is not counted twice
+type CampaignBatch struct {
+	gorm.Model
+	CampaignId uint      `gorm:"column:campaign_id;uniqueIndex:idx_campaign_batch"`
+	StartedAt  time.Time `gorm:"column:started_at;uniqueIndex:idx_campaign_batch"`
+	Number     int       `gorm:"column:number;uniqueIndex:idx_campaign_batch"`
+}
+
+func (CampaignBatch) TableName() string {
+	return "tb_campaign_batches"
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DataExport is a user's request for a copy of their personal data. The
+// bundle is built in the background and can be downloaded until ExpiresAt.
+type DataExport struct {
+	gorm.Model
+	UserId      uint       `gorm:"column:user_id;type:int;index"`
+	Status      string     `gorm:"column:status;type:varchar(20)"`
+	FilePath    string     `gorm:"column:file_path;type:varchar(255)"`
+	FileSize    int64      `gorm:"column:file_size;type:bigint"`
+	Error       string     `gorm:"column:error;type:varchar(255)"`
+	CompletedAt *time.Time `gorm:"column:completed_at"`
+	ExpiresAt   *time.Time `gorm:"column:expires_at"`
+}
+
+func (DataExport) TableName() string {
+	return "tb_data_exports"
+}
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Device is an FCM registration token of one of a user's app installs. A
+// token belongs to the user that registered it last.
+type Device struct {
+	gorm.Model
+	UserId     uint      `gorm:"column:user_id;index"`
+	Token      string    `gorm:"column:token;type:text;uniqueIndex"`
+	Platform   string    `gorm:"column:platform;type:varchar(10)"`
+	AppVersion string    `gorm:"column:app_version;type:varchar(50)"`
+	LastSeenAt time.Time `gorm:"column:last_seen_at"`
+}
+
+func (Device) TableName() string {
+	return "tb_devices"
+}
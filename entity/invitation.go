@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Invitation lets a refinery admin or truck driver set their own password.
+// Only the SHA-256 hash of the single-use token is stored.
+type Invitation struct {
+	gorm.Model
+	UserId     uint       `gorm:"column:user_id;index"`
+	User       User       `gorm:"foreignKey:UserId"`
+	Role       string     `gorm:"column:role;type:varchar(100)"`
+	TokenHash  string     `gorm:"column:token_hash;type:varchar(64);uniqueIndex"`
+	ExpiresAt  time.Time  `gorm:"column:expires_at"`
+	AcceptedAt *time.Time `gorm:"column:accepted_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at"`
+	SendCount  int        `gorm:"column:send_count;type:int"`
+	LastSentAt time.Time  `gorm:"column:last_sent_at"`
+}
+
+func (Invitation) TableName() string {
+	return "tb_invitations"
+}
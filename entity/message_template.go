@@ -2,13 +2,19 @@ package entity
 
 import "gorm.io/gorm"
 
+// MessageTemplate identifies a message by name and declares the variables
+// its versions may use. The text itself lives in MessageTemplateVersion,
+// Subject and Message are the original single language text that the first
+// English version is seeded from.
 type MessageTemplate struct {
 	gorm.Model
-	Name           string `gorm:"column:template_name;type:varchar(100)"`
-	Subject        string `gorm:"column:subject;type:varchar(100)"`
-	Message        string `gorm:"column:message;type:text"`
-	IsEmailMessage bool   `gorm:"column:is_email_message;type:bool"`
-	IsSMSMessage   bool   `gorm:"column:is_sms_message;type:bool"`
+	Name           string            `gorm:"column:template_name;type:varchar(100)"`
+	Subject        string            `gorm:"column:subject;type:varchar(100)"`
+	Message        string            `gorm:"column:message;type:text"`
+	IsEmailMessage bool              `gorm:"column:is_email_message;type:bool"`
+	IsSMSMessage   bool              `gorm:"column:is_sms_message;type:bool"`
+	Description    string            `gorm:"column:description;type:varchar(255)"`
+	Variables      TemplateVariables `gorm:"column:variables;type:jsonb"`
 }
 
 func (MessageTemplate) TableName() string {
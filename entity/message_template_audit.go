@@ -0,0 +1,21 @@
+package entity
+
+import "gorm.io/gorm"
+
+// MessageTemplateAudit records every change made to a message template or
+// one of its versions. Changes is a JSON object of the changed fields with
+// their old and new values.
+type MessageTemplateAudit struct {
+	gorm.Model
+	TemplateId    uint   `gorm:"column:template_id;type:int;index"`
+	TemplateName  string `gorm:"column:template_name;type:varchar(100)"`
+	VersionId     uint   `gorm:"column:version_id;type:int"`
+	Action        string `gorm:"column:action;type:varchar(20)"`
+	Changes       string `gorm:"column:changes;type:text"`
+	ActorId       uint   `gorm:"column:actor_id;type:int"`
+	ActorUsername string `gorm:"column:actor_username;type:varchar(100)"`
+}
+
+func (MessageTemplateAudit) TableName() string {
+	return "tb_message_template_audits"
+}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MessageTemplateVersion is the text of a template in one language. Only one
+// version per template and language is published at a time, the versions it
+// replaced are archived so that they can be rolled back to.
+type MessageTemplateVersion struct {
+	gorm.Model
+	TemplateId  uint       `gorm:"column:template_id;type:int;uniqueIndex:idx_template_version"`
+	Language    string     `gorm:"column:language;type:varchar(5);uniqueIndex:idx_template_version"`
+	Version     int        `gorm:"column:version;type:int;uniqueIndex:idx_template_version"`
+	Status      string     `gorm:"column:status;type:varchar(20)"`
+	Subject     string     `gorm:"column:subject;type:text"`
+	TextBody    string     `gorm:"column:text_body;type:text"`
+	HtmlBody    string     `gorm:"column:html_body;type:text"`
+	CreatedBy   uint       `gorm:"column:created_by;type:int"`
+	PublishedBy uint       `gorm:"column:published_by;type:int"`
+	PublishedAt *time.Time `gorm:"column:published_at"`
+}
+
+func (MessageTemplateVersion) TableName() string {
+	return "tb_message_template_versions"
+}
@@ -6,15 +6,19 @@ import (
 	"gorm.io/gorm"
 )
 
-// MessageType represents the type of message (email or SMS)
+// MessageType represents the channel of a message (email, SMS, push or
+// in-app)
 type MessageType string
 
 const (
-	EMAIL MessageType = "email"
-	SMS   MessageType = "sms"
+	EMAIL  MessageType = "email"
+	SMS    MessageType = "sms"
+	PUSH   MessageType = "push"
+	IN_APP MessageType = "in_app"
 )
 
-// Notification represents a record of a message sent to a user
+// Notification represents a record of a message sent to a user. The messages
+// with a UserID make up the user's inbox, Subject is shown as the title.
 type Notification struct {
 	gorm.Model
 	UserID    uint        `gorm:"column:user_id;index"`
@@ -22,8 +26,18 @@ type Notification struct {
 	Recipient string      `gorm:"column:recipient;type:varchar(255)"`
 	Subject   string      `gorm:"column:subject;type:varchar(255)"`
 	Content   string      `gorm:"column:content;type:text"`
-	Status    string      `gorm:"column:status;type:varchar(20);default:'sent'"` // sent, failed, delivered
+	Status    string      `gorm:"column:status;type:varchar(20);default:'queued'"` // queued, sent, delivered, failed
 	SentAt    time.Time   `gorm:"column:sent_at"`
+	// CountryCode is the dialling code of SMS recipients, used for failure
+	// rates per country
+	CountryCode       string     `gorm:"column:country_code;type:varchar(10)"`
+	Provider          string     `gorm:"column:provider;type:varchar(30)"`
+	ProviderMessageId string     `gorm:"column:provider_message_id;type:varchar(100);index"`
+	FailureReason     string     `gorm:"column:failure_reason;type:varchar(255)"`
+	DeliveredAt       *time.Time `gorm:"column:delivered_at"`
+	// Data is the JSON encoded deep-link data of a push notification
+	Data   string     `gorm:"column:data;type:text"`
+	ReadAt *time.Time `gorm:"column:read_at"`
 }
 
 func (Notification) TableName() string {
@@ -0,0 +1,32 @@
+package entity
+
+import "gorm.io/gorm"
+
+// NotificationPreference turns one channel of one category on or off for a
+// user, channels without a row use the category default
+type NotificationPreference struct {
+	gorm.Model
+	UserId   uint   `gorm:"column:user_id;uniqueIndex:idx_notification_preference"`
+	Category string `gorm:"column:category;type:varchar(30);uniqueIndex:idx_notification_preference"`
+	Channel  string `gorm:"column:channel;type:varchar(10);uniqueIndex:idx_notification_preference"`
+	Enabled  bool   `gorm:"column:enabled;type:boolean"`
+}
+
+func (NotificationPreference) TableName() string {
+	return "tb_notification_preferences"
+}
+
+// NotificationSetting holds a user's quiet hours as HH:MM in Timezone, an
+// empty Timezone falls back to the one of the user's country. Quiet hours
+// are off while start and end are empty or equal.
+type NotificationSetting struct {
+	gorm.Model
+	UserId          uint   `gorm:"column:user_id;uniqueIndex"`
+	Timezone        string `gorm:"column:timezone;type:varchar(50)"`
+	QuietHoursStart string `gorm:"column:quiet_hours_start;type:varchar(5)"`
+	QuietHoursEnd   string `gorm:"column:quiet_hours_end;type:varchar(5)"`
+}
+
+func (NotificationSetting) TableName() string {
+	return "tb_notification_settings"
+}
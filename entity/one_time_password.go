@@ -7,8 +7,10 @@ import (
 
 type OneTimePassword struct {
 	gorm.Model
-	UserId    uint      `gorm:"column:user_id;type:int"`
-	Code      string    `gorm:"column:code;type:varchar(10)"`
+	UserId    uint      `gorm:"column:user_id;type:int;index:idx_otp_user_purpose"`
+	Purpose   string    `gorm:"column:purpose;type:varchar(32);index:idx_otp_user_purpose"`
+	CodeHash  string    `gorm:"column:code_hash;type:varchar(64)" json:"-"`
+	Attempts  uint      `gorm:"column:attempts;type:int;default:0"`
 	IsUsed    bool      `gorm:"column:is_used;type:boolean"`
 	ExpiredAt time.Time `gorm:"column:expired_at;type:timestamp"`
 }
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the state
+// change it describes. The outbox relay publishes it to the broker afterwards
+// and sets PublishedAt, events of one aggregate are published in ID order.
+type OutboxEvent struct {
+	gorm.Model
+	EventId       string     `gorm:"column:event_id;type:varchar(36);uniqueIndex"`
+	AggregateType string     `gorm:"column:aggregate_type;type:varchar(50);index:idx_outbox_aggregate"`
+	AggregateId   string     `gorm:"column:aggregate_id;type:varchar(50);index:idx_outbox_aggregate"`
+	Topic         string     `gorm:"column:topic;type:varchar(100)"`
+	Version       int        `gorm:"column:version;type:int;default:1"`
+	Payload       string     `gorm:"column:payload;type:text"`
+	Attempts      int        `gorm:"column:attempts;type:int;default:0"`
+	LastError     string     `gorm:"column:last_error;type:varchar(255)"`
+	NextAttemptAt time.Time  `gorm:"column:next_attempt_at;index"`
+	PublishedAt   *time.Time `gorm:"column:published_at;index"`
+}
+
+func (OutboxEvent) TableName() string {
+	return "tb_outbox_events"
+}
@@ -6,9 +6,11 @@ import (
 
 type PaymentConfiguration struct {
 	gorm.Model
-	CountryCode string `gorm:"column:country_code;type:varchar(10);unique"`
-	SecretKey   string `gorm:"column:secret_key;type:text"`
-	PublicKey   string `gorm:"column:public_key;type:text"`
+	CountryCode      string `gorm:"column:country_code;type:varchar(10);unique"`
+	SecretKey        string `gorm:"column:secret_key;type:text"`
+	EncryptedDataKey string `gorm:"column:encrypted_data_key;type:text"`
+	KeyVersion       string `gorm:"column:key_version;type:varchar(50)"`
+	PublicKey        string `gorm:"column:public_key;type:text"`
 }
 
 func (PaymentConfiguration) TableName() string {
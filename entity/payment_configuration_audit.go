@@ -0,0 +1,19 @@
+package entity
+
+import "gorm.io/gorm"
+
+// PaymentConfigurationAudit records every change made to a payment configuration.
+// Secret values are never written here, only the names of the fields that changed.
+type PaymentConfigurationAudit struct {
+	gorm.Model
+	CountryCode   string `gorm:"column:country_code;type:varchar(10);index"`
+	Action        string `gorm:"column:action;type:varchar(20)"` // create, update, delete, rotate
+	ChangedFields string `gorm:"column:changed_fields;type:text"`
+	KeyVersion    string `gorm:"column:key_version;type:varchar(50)"`
+	ActorId       uint   `gorm:"column:actor_id;type:int"`
+	ActorUsername string `gorm:"column:actor_username;type:varchar(100)"`
+}
+
+func (PaymentConfigurationAudit) TableName() string {
+	return "tb_payment_configuration_audits"
+}
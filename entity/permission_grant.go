@@ -0,0 +1,14 @@
+package entity
+
+import "gorm.io/gorm"
+
+// PermissionGrant records that an introduced permission has been granted to
+// its default roles, so that it is only ever granted once
+type PermissionGrant struct {
+	gorm.Model
+	Permission string `gorm:"column:permission;type:varchar(100);uniqueIndex"`
+}
+
+func (PermissionGrant) TableName() string {
+	return "tb_permission_grants"
+}
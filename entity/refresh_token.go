@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a login session. The refresh token is rotated on every use,
+// so only the SHA-256 hash of the current and previous token are stored.
+type RefreshToken struct {
+	gorm.Model
+	UserId               uint       `gorm:"column:user_id;index"`
+	TokenHash            string     `gorm:"column:token_hash;type:varchar(64);uniqueIndex"`
+	PreviousTokenHash    string     `gorm:"column:previous_token_hash;type:varchar(64);index"`
+	AccessTokenId        string     `gorm:"column:access_token_id;type:varchar(64);index"`
+	AccessTokenExpiresAt time.Time  `gorm:"column:access_token_expires_at"`
+	ExpiresAt            time.Time  `gorm:"column:expires_at"`
+	LastUsedAt           time.Time  `gorm:"column:last_used_at"`
+	RevokedAt            *time.Time `gorm:"column:revoked_at"`
+	UserAgent            string     `gorm:"column:user_agent;type:varchar(255)"`
+	IpAddress            string     `gorm:"column:ip_address;type:varchar(64)"`
+}
+
+func (RefreshToken) TableName() string {
+	return "tb_refresh_tokens"
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "gorm.io/gorm"
+
+type RolePermission struct {
+	gorm.Model
+	Role       string `gorm:"column:role;type:varchar(50);uniqueIndex:idx_role_permission"`
+	Permission string `gorm:"column:permission;type:varchar(100);uniqueIndex:idx_role_permission"`
+}
+
+func (RolePermission) TableName() string {
+	return "tb_role_permissions"
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "gorm.io/gorm"
+
+// SecurityEvent records an authentication outcome or another security relevant
+// action. Passwords and codes are never written here.
+type SecurityEvent struct {
+	gorm.Model
+	UserId    uint   `gorm:"column:user_id;type:int;index"`
+	Username  string `gorm:"column:username;type:varchar(100);index"`
+	EventType string `gorm:"column:event_type;type:varchar(50);index"`
+	Reason    string `gorm:"column:reason;type:varchar(255)"`
+	IpAddress string `gorm:"column:ip_address;type:varchar(64)"`
+	UserAgent string `gorm:"column:user_agent;type:varchar(255)"`
+}
+
+func (SecurityEvent) TableName() string {
+	return "tb_security_events"
+}
@@ -0,0 +1,23 @@
+package entity
+
+import "gorm.io/gorm"
+
+// StoredFile records an upload kept in the file storage. ReferenceId is the
+// record the file belongs to, the user for profile photos, the truck for
+// driver documents and the order for delivery photos.
+type StoredFile struct {
+	gorm.Model
+	Category     string `gorm:"column:category;type:varchar(30);index:idx_stored_file_reference"`
+	ReferenceId  uint   `gorm:"column:reference_id;type:int;index:idx_stored_file_reference"`
+	DocumentType string `gorm:"column:document_type;type:varchar(50)"`
+	StorageKey   string `gorm:"column:storage_key;type:varchar(255);uniqueIndex"`
+	ThumbnailKey string `gorm:"column:thumbnail_key;type:varchar(255)"`
+	ContentType  string `gorm:"column:content_type;type:varchar(100)"`
+	Size         int64  `gorm:"column:size;type:bigint"`
+	OriginalName string `gorm:"column:original_name;type:varchar(255)"`
+	UploadedBy   uint   `gorm:"column:uploaded_by;type:int"`
+}
+
+func (StoredFile) TableName() string {
+	return "tb_stored_files"
+}
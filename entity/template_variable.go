@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// TemplateVariable declares a value a message template may use, Sample is
+// what previews render it with
+type TemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Sample      string `json:"sample"`
+}
+
+type TemplateVariables []TemplateVariable
+
+func (t TemplateVariables) Value() (driver.Value, error) {
+	if t == nil {
+		t = TemplateVariables{}
+	}
+	value, err := json.Marshal(t)
+	return string(value), err
+}
+
+func (t *TemplateVariables) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*t = TemplateVariables{}
+		return nil
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return errors.New("unsupported type for TemplateVariables")
+	}
+}
+
+func (t TemplateVariables) Names() []string {
+	names := make([]string, 0, len(t))
+	for _, variable := range t {
+		names = append(names, variable.Name)
+	}
+	return names
+}
@@ -14,6 +14,7 @@ type Truck struct {
 	Capacity              int       `gorm:"column:capacity;type:int"`
 	YearOfManufacture     int       `gorm:"column:year_of_manufacture;type:int"`
 	UserId                uint      `gorm:"column:user_id;type:int"`
+	RefineryId            uint      `gorm:"column:refinery_id;type:int;default:NULL"`
 	EngineNumber          string    `gorm:"column:engine_number;type:varchar(100)"`
 	LicenceExpirationDate time.Time `gorm:"column:licence_expiration_date;type:date"`
 	User                  User      `gorm:"foreignKey:UserId;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
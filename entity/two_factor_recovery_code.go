@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TwoFactorRecoveryCode struct {
+	gorm.Model
+	UserId   uint       `gorm:"column:user_id;type:int;index"`
+	CodeHash string     `gorm:"column:code_hash;type:varchar(64);index"`
+	UsedAt   *time.Time `gorm:"column:used_at;type:timestamp"`
+}
+
+func (TwoFactorRecoveryCode) TableName() string {
+	return "tb_two_factor_recovery_codes"
+}
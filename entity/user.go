@@ -1,6 +1,10 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
@@ -20,6 +24,14 @@ type User struct {
 	FcmToken       string    `gorm:"column:fcm_token;type:text"`
 	Region         string    `gorm:"column:region;type:varchar(100)"`
 	EmailValidated bool      `gorm:"column:email_validated;type:boolean"`
+	Language       string    `gorm:"column:language;type:varchar(5)"`
+	// PasswordSeeded marks a seed account that still has the default password
+	// it was created with, it may not enrol two-factor authentication at
+	// login until the password was changed
+	PasswordSeeded bool `gorm:"column:password_seeded;type:boolean;default:false"`
+	// PseudonymisedAt is set when the account was deleted by its owner, the
+	// row is kept so that orders and transactions still resolve
+	PseudonymisedAt *time.Time `gorm:"column:pseudonymised_at"`
 }
 
 func (User) TableName() string {
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// UserTwoFactor holds a user's TOTP secret, encrypted the same way as payment
+// gateway secrets. The record exists but is not enabled while enrolment is
+// pending confirmation.
+type UserTwoFactor struct {
+	gorm.Model
+	UserId           uint       `gorm:"column:user_id;type:int;uniqueIndex"`
+	Secret           string     `gorm:"column:secret;type:text"`
+	EncryptedDataKey string     `gorm:"column:encrypted_data_key;type:text"`
+	KeyVersion       string     `gorm:"column:key_version;type:varchar(50)"`
+	EnabledAt        *time.Time `gorm:"column:enabled_at;type:timestamp"`
+	LastUsedStep     int64      `gorm:"column:last_used_step;type:bigint"`
+}
+
+func (UserTwoFactor) TableName() string {
+	return "tb_user_two_factors"
+}
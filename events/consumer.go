@@ -0,0 +1,41 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+// Handler adapts a typed handler to MessageBrokerService.SubscribeToTopic for
+// one event type. The envelope and the payload are validated against the
+// registry before the payload is decoded into T. An invalid message comes
+// back as a service.PermanentError so that the broker dead-letters it instead
+// of retrying.
+//
+// Delivery is at least once, handle has to tolerate an EventId it has seen.
+func Handler[T any](registry *Registry, eventType string, handle func(ctx context.Context, event model.OutboxMessageModel, payload T) error) func([]byte) error {
+	return func(message []byte) error {
+		var event model.OutboxMessageModel
+		if err := json.Unmarshal(message, &event); err != nil {
+			return service.PermanentError{Err: fmt.Errorf("invalid event envelope: %w", err)}
+		}
+		if event.EventId == "" {
+			return service.PermanentError{Err: fmt.Errorf("event envelope has no eventId")}
+		}
+		if event.Topic != eventType {
+			return service.PermanentError{Err: fmt.Errorf("expected a %s event, got %q", eventType, event.Topic)}
+		}
+		if err := registry.Validate(event.Topic, event.Version, event.Payload); err != nil {
+			return service.PermanentError{Err: err}
+		}
+
+		var payload T
+		if err := json.Unmarshal(event.Payload, &payload); err != nil {
+			return service.PermanentError{Err: fmt.Errorf("%s v%d payload does not decode: %w", event.Topic, event.Version, err)}
+		}
+		return handle(context.Background(), event, payload)
+	}
+}
@@ -0,0 +1,133 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+type widgetCreated struct {
+	WidgetId uint `json:"widgetId"`
+}
+
+func widgetRegistry(t *testing.T) *Registry {
+	t.Helper()
+	registry, err := NewRegistry(fstest.MapFS{
+		"schemas/widget.created.v1.json": {Data: []byte(widgetSchemaV1)},
+		"schemas/widget.created.v2.json": {Data: []byte(widgetSchemaV2)},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	return registry
+}
+
+func envelope(t *testing.T, event model.OutboxMessageModel) []byte {
+	t.Helper()
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+	return body
+}
+
+func TestHandlerDecodesValidEvents(t *testing.T) {
+	var handled []widgetCreated
+	var eventIds []string
+	handler := Handler(widgetRegistry(t), "widget.created", func(ctx context.Context, event model.OutboxMessageModel, payload widgetCreated) error {
+		eventIds = append(eventIds, event.EventId)
+		handled = append(handled, payload)
+		return nil
+	})
+
+	err := handler(envelope(t, model.OutboxMessageModel{
+		EventId:    "event-1",
+		Topic:      "widget.created",
+		Version:    1,
+		OccurredAt: time.Now(),
+		Payload:    json.RawMessage(`{"widgetId": 4}`),
+	}))
+	if err != nil {
+		t.Fatalf("handler = %v", err)
+	}
+	if len(handled) != 1 || handled[0].WidgetId != 4 || eventIds[0] != "event-1" {
+		t.Errorf("handled %+v of %v", handled, eventIds)
+	}
+}
+
+func TestHandlerValidatesAgainstTheEnvelopeVersion(t *testing.T) {
+	handler := Handler(widgetRegistry(t), "widget.created", func(ctx context.Context, event model.OutboxMessageModel, payload widgetCreated) error {
+		return nil
+	})
+
+	// v1 has no name, v2 requires it
+	v1 := model.OutboxMessageModel{EventId: "event-1", Topic: "widget.created", Version: 1, Payload: json.RawMessage(`{"widgetId": 4}`)}
+	if err := handler(envelope(t, v1)); err != nil {
+		t.Errorf("v1 payload = %v", err)
+	}
+	v2 := v1
+	v2.Version = 2
+	var permanent service.PermanentError
+	if err := handler(envelope(t, v2)); !errors.As(err, &permanent) {
+		t.Errorf("v2 payload without a name = %v, want a PermanentError", err)
+	}
+}
+
+func TestHandlerRejectsInvalidMessagesPermanently(t *testing.T) {
+	called := false
+	handler := Handler(widgetRegistry(t), "widget.created", func(ctx context.Context, event model.OutboxMessageModel, payload widgetCreated) error {
+		called = true
+		return nil
+	})
+
+	valid := model.OutboxMessageModel{EventId: "event-1", Topic: "widget.created", Version: 1, Payload: json.RawMessage(`{"widgetId": 4}`)}
+	withoutId := valid
+	withoutId.EventId = ""
+	otherTopic := valid
+	otherTopic.Topic = "widget.deleted"
+	unknownVersion := valid
+	unknownVersion.Version = 9
+	invalidPayload := valid
+	invalidPayload.Payload = json.RawMessage(`{"widgetId": 0}`)
+
+	tests := []struct {
+		name    string
+		message []byte
+	}{
+		{"not JSON", []byte(`{"eventId":`)},
+		{"no event id", envelope(t, withoutId)},
+		{"other topic", envelope(t, otherTopic)},
+		{"unknown version", envelope(t, unknownVersion)},
+		{"payload breaks the schema", envelope(t, invalidPayload)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var permanent service.PermanentError
+			if err := handler(test.message); !errors.As(err, &permanent) {
+				t.Errorf("handler = %v, want a PermanentError", err)
+			}
+		})
+	}
+	if called {
+		t.Error("the handler was called with an invalid message")
+	}
+}
+
+func TestHandlerReturnsHandleErrorsForRetry(t *testing.T) {
+	failure := errors.New("database is down")
+	handler := Handler(widgetRegistry(t), "widget.created", func(ctx context.Context, event model.OutboxMessageModel, payload widgetCreated) error {
+		return failure
+	})
+
+	err := handler(envelope(t, model.OutboxMessageModel{EventId: "event-1", Topic: "widget.created", Version: 1, Payload: json.RawMessage(`{"widgetId": 4}`)}))
+	var permanent service.PermanentError
+	if !errors.Is(err, failure) || errors.As(err, &permanent) {
+		t.Errorf("handler = %v, want the handle error as it is", err)
+	}
+}
@@ -0,0 +1,175 @@
+// Package events documents the domain events published to the message
+// broker. Every event type has a JSON schema per payload version in
+// schemas/<type>.v<version>.json, a change that breaks consumers adds a new
+// version instead of editing a published one.
+package events
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+var schemaFileName = regexp.MustCompile(`^([a-z_]+(?:\.[a-z_]+)+)\.v([1-9][0-9]*)\.json$`)
+
+// Definition is one version of an event type. AggregateType comes from the
+// x-aggregate keyword, events of one aggregate are published in order.
+type Definition struct {
+	Type          string          `json:"type"`
+	Version       int             `json:"version"`
+	AggregateType string          `json:"aggregateType"`
+	Description   string          `json:"description"`
+	Schema        json.RawMessage `json:"schema"`
+	schema        *schema
+}
+
+// ValidationError lists why a payload does not match its schema
+type ValidationError struct {
+	Type     string
+	Version  int
+	Problems []string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s v%d payload is invalid: %s", e.Type, e.Version, strings.Join(e.Problems, "; "))
+}
+
+type Registry struct {
+	definitions map[string]map[int]*Definition
+}
+
+var defaultRegistry *Registry
+
+func init() {
+	registry, err := NewRegistry(schemaFiles)
+	if err != nil {
+		panic(err)
+	}
+	defaultRegistry = registry
+}
+
+// DefaultRegistry holds the schemas shipped in this package
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// NewRegistry loads every *.json schema in the root or a schemas directory
+// of files
+func NewRegistry(files fs.FS) (*Registry, error) {
+	names, err := fs.Glob(files, "schemas/*.json")
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		if names, err = fs.Glob(files, "*.json"); err != nil {
+			return nil, err
+		}
+	}
+
+	registry := &Registry{definitions: map[string]map[int]*Definition{}}
+	for _, name := range names {
+		match := schemaFileName.FindStringSubmatch(path.Base(name))
+		if match == nil {
+			return nil, fmt.Errorf("schema file %s is not named <type>.v<version>.json", name)
+		}
+		version, _ := strconv.Atoi(match[2])
+
+		document, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := parseSchema(document)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+		var annotations struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Aggregate   string `json:"x-aggregate"`
+		}
+		if err := json.Unmarshal(document, &annotations); err != nil {
+			return nil, fmt.Errorf("schema %s: %w", name, err)
+		}
+		if annotations.Title != match[1] {
+			return nil, fmt.Errorf("schema %s: title must be %s", name, match[1])
+		}
+		if annotations.Aggregate == "" {
+			return nil, fmt.Errorf("schema %s: x-aggregate is required", name)
+		}
+
+		if registry.definitions[match[1]] == nil {
+			registry.definitions[match[1]] = map[int]*Definition{}
+		}
+		registry.definitions[match[1]][version] = &Definition{
+			Type:          match[1],
+			Version:       version,
+			AggregateType: annotations.Aggregate,
+			Description:   annotations.Description,
+			Schema:        document,
+			schema:        parsed,
+		}
+	}
+	return registry, nil
+}
+
+func (r *Registry) Lookup(eventType string, version int) (*Definition, bool) {
+	definition, ok := r.definitions[eventType][version]
+	return definition, ok
+}
+
+// Latest is the version producers publish
+func (r *Registry) Latest(eventType string) (*Definition, bool) {
+	var latest *Definition
+	for _, definition := range r.definitions[eventType] {
+		if latest == nil || definition.Version > latest.Version {
+			latest = definition
+		}
+	}
+	return latest, latest != nil
+}
+
+// Definitions lists every version of every event type
+func (r *Registry) Definitions() []Definition {
+	definitions := make([]Definition, 0)
+	for _, versions := range r.definitions {
+		for _, definition := range versions {
+			definitions = append(definitions, *definition)
+		}
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		if definitions[i].Type != definitions[j].Type {
+			return definitions[i].Type < definitions[j].Type
+		}
+		return definitions[i].Version < definitions[j].Version
+	})
+	return definitions
+}
+
+// Validate checks a JSON payload against the schema of its type and version
+func (r *Registry) Validate(eventType string, version int, payload []byte) error {
+	definition, ok := r.Lookup(eventType, version)
+	if !ok {
+		return fmt.Errorf("no schema registered for %s v%d", eventType, version)
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return ValidationError{Type: eventType, Version: version, Problems: []string{"payload is not JSON: " + err.Error()}}
+	}
+	if problems := definition.schema.validate(value, ""); len(problems) > 0 {
+		return ValidationError{Type: eventType, Version: version, Problems: problems}
+	}
+	return nil
+}
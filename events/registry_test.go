@@ -0,0 +1,137 @@
+package events
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const widgetSchemaV1 = `{
+  "title": "widget.created",
+  "description": "A widget was created.",
+  "x-aggregate": "widget",
+  "type": "object",
+  "required": ["widgetId"],
+  "properties": {
+    "widgetId": {"type": "integer", "minimum": 1}
+  }
+}`
+
+const widgetSchemaV2 = `{
+  "title": "widget.created",
+  "x-aggregate": "widget",
+  "type": "object",
+  "required": ["widgetId", "name"],
+  "properties": {
+    "widgetId": {"type": "integer", "minimum": 1},
+    "name": {"type": "string"}
+  }
+}`
+
+func TestNewRegistryLoadsVersions(t *testing.T) {
+	registry, err := NewRegistry(fstest.MapFS{
+		"schemas/widget.created.v1.json": {Data: []byte(widgetSchemaV1)},
+		"schemas/widget.created.v2.json": {Data: []byte(widgetSchemaV2)},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+
+	definition, ok := registry.Lookup("widget.created", 1)
+	if !ok {
+		t.Fatal("widget.created v1 is not registered")
+	}
+	if definition.AggregateType != "widget" || definition.Description != "A widget was created." {
+		t.Errorf("v1 annotations = %q, %q", definition.AggregateType, definition.Description)
+	}
+	if _, ok := registry.Lookup("widget.created", 3); ok {
+		t.Error("v3 is registered")
+	}
+
+	latest, ok := registry.Latest("widget.created")
+	if !ok || latest.Version != 2 {
+		t.Errorf("Latest = %+v, %v, want v2", latest, ok)
+	}
+	if _, ok := registry.Latest("widget.deleted"); ok {
+		t.Error("Latest found an unknown type")
+	}
+
+	definitions := registry.Definitions()
+	if len(definitions) != 2 || definitions[0].Version != 1 || definitions[1].Version != 2 {
+		t.Errorf("Definitions = %+v, want v1 then v2", definitions)
+	}
+}
+
+func TestNewRegistryReadsRootWithoutSchemasDirectory(t *testing.T) {
+	registry, err := NewRegistry(fstest.MapFS{
+		"widget.created.v1.json": {Data: []byte(widgetSchemaV1)},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	if _, ok := registry.Lookup("widget.created", 1); !ok {
+		t.Error("widget.created v1 is not registered")
+	}
+}
+
+func TestNewRegistryRejectsInvalidDocuments(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		document string
+		want     string
+	}{
+		{"file name without version", "schemas/widget.created.json", widgetSchemaV1, "is not named"},
+		{"version zero", "schemas/widget.created.v0.json", widgetSchemaV1, "is not named"},
+		{"title of another type", "schemas/widget.deleted.v1.json", widgetSchemaV1, "title must be widget.deleted"},
+		{"missing aggregate", "schemas/widget.created.v1.json", `{"title": "widget.created", "type": "object"}`, "x-aggregate is required"},
+		{"unsupported keyword", "schemas/widget.created.v1.json", `{"title": "widget.created", "x-aggregate": "widget", "pattern": "^a"}`, `unsupported schema keyword "pattern"`},
+		{"unsupported nested keyword", "schemas/widget.created.v1.json", `{"title": "widget.created", "x-aggregate": "widget", "properties": {"tags": {"type": "array", "items": {"format": "uuid"}}}}`, `tags[]: unsupported schema keyword "format"`},
+		{"not an object", "schemas/widget.created.v1.json", `[]`, "schema must be an object"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewRegistry(fstest.MapFS{test.file: {Data: []byte(test.document)}})
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("NewRegistry error = %v, want it to contain %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsUnknownVersion(t *testing.T) {
+	registry, err := NewRegistry(fstest.MapFS{
+		"schemas/widget.created.v1.json": {Data: []byte(widgetSchemaV1)},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+
+	err = registry.Validate("widget.created", 2, []byte(`{"widgetId": 1}`))
+	var validationError ValidationError
+	if err == nil || errors.As(err, &validationError) {
+		t.Errorf("Validate of an unknown version = %v, want a lookup error", err)
+	}
+	if err := registry.Validate("widget.created", 1, []byte(`{"widgetId": 1}`)); err != nil {
+		t.Errorf("Validate of a valid payload = %v", err)
+	}
+}
+
+func TestDefaultRegistryDocumentsEveryEvent(t *testing.T) {
+	registry := DefaultRegistry()
+	for _, eventType := range []string{
+		"order.created", "order.approved", "order.rejected", "order.assigned",
+		"order.ready_for_delivery", "order.delivered",
+		"payment.succeeded", "payment.failed", "refund.completed",
+	} {
+		definition, ok := registry.Latest(eventType)
+		if !ok {
+			t.Errorf("%s has no schema", eventType)
+			continue
+		}
+		if definition.AggregateType == "" {
+			t.Errorf("%s has no aggregate type", eventType)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// schema is the subset of JSON Schema the registry documents use. Loading a
+// document with any other validation keyword fails so that a schema never
+// silently checks less than it says.
+type schema struct {
+	Type                 schemaTypes        `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Enum                 []interface{}      `json:"enum"`
+	Const                json.RawMessage    `json:"const"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+}
+
+var supportedKeywords = []string{
+	"type", "properties", "required", "additionalProperties", "items", "enum", "const",
+	"minimum", "maximum", "minLength", "maxLength",
+	// annotations
+	"$schema", "$id", "title", "description", "examples",
+}
+
+// schemaTypes accepts both "type": "string" and "type": ["string", "null"]
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	*t = many
+	return nil
+}
+
+func parseSchema(document []byte) (*schema, error) {
+	if err := checkKeywords(document, ""); err != nil {
+		return nil, err
+	}
+	var parsed schema
+	if err := json.Unmarshal(document, &parsed); err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// checkKeywords walks the nested schemas and rejects unsupported keywords,
+// x- prefixed extensions are allowed
+func checkKeywords(document []byte, path string) error {
+	var keywords map[string]json.RawMessage
+	if err := json.Unmarshal(document, &keywords); err != nil {
+		return fmt.Errorf("%s: schema must be an object", displayPath(path))
+	}
+	for keyword, value := range keywords {
+		if strings.HasPrefix(keyword, "x-") {
+			continue
+		}
+		if !containsString(supportedKeywords, keyword) {
+			return fmt.Errorf("%s: unsupported schema keyword %q", displayPath(path), keyword)
+		}
+		switch keyword {
+		case "properties":
+			var properties map[string]json.RawMessage
+			if err := json.Unmarshal(value, &properties); err != nil {
+				return fmt.Errorf("%s: properties must be an object", displayPath(path))
+			}
+			for name, property := range properties {
+				if err := checkKeywords(property, path+"."+name); err != nil {
+					return err
+				}
+			}
+		case "items":
+			if err := checkKeywords(value, path+"[]"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validate returns every problem found in value, which must have been decoded
+// with json.Decoder.UseNumber
+func (s *schema) validate(value interface{}, path string) []string {
+	var problems []string
+	if len(s.Type) > 0 && !s.matchesType(value) {
+		return []string{fmt.Sprintf("%s must be %s", displayPath(path), strings.Join(s.Type, " or "))}
+	}
+	if len(s.Const) > 0 && !jsonEqual(s.Const, value) {
+		problems = append(problems, fmt.Sprintf("%s must be %s", displayPath(path), string(s.Const)))
+	}
+	if len(s.Enum) > 0 {
+		allowed := false
+		for _, candidate := range s.Enum {
+			encoded, _ := json.Marshal(candidate)
+			if jsonEqual(encoded, value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			encoded, _ := json.Marshal(s.Enum)
+			problems = append(problems, fmt.Sprintf("%s must be one of %s", displayPath(path), string(encoded)))
+		}
+	}
+
+	switch typed := value.(type) {
+	case json.Number:
+		number, _ := typed.Float64()
+		if s.Minimum != nil && number < *s.Minimum {
+			problems = append(problems, fmt.Sprintf("%s must be at least %v", displayPath(path), *s.Minimum))
+		}
+		if s.Maximum != nil && number > *s.Maximum {
+			problems = append(problems, fmt.Sprintf("%s must be at most %v", displayPath(path), *s.Maximum))
+		}
+	case string:
+		length := len([]rune(typed))
+		if s.MinLength != nil && length < *s.MinLength {
+			problems = append(problems, fmt.Sprintf("%s must be at least %d characters", displayPath(path), *s.MinLength))
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			problems = append(problems, fmt.Sprintf("%s must be at most %d characters", displayPath(path), *s.MaxLength))
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range typed {
+				problems = append(problems, s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := typed[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s is required", displayPath(path+"."+name)))
+			}
+		}
+		names := make([]string, 0, len(typed))
+		for name := range typed {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			property, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					problems = append(problems, fmt.Sprintf("%s is not allowed", displayPath(path+"."+name)))
+				}
+				continue
+			}
+			problems = append(problems, property.validate(typed[name], path+"."+name)...)
+		}
+	}
+	return problems
+}
+
+func (s *schema) matchesType(value interface{}) bool {
+	for _, expected := range s.Type {
+		switch typed := value.(type) {
+		case nil:
+			if expected == "null" {
+				return true
+			}
+		case bool:
+			if expected == "boolean" {
+				return true
+			}
+		case string:
+			if expected == "string" {
+				return true
+			}
+		case json.Number:
+			if expected == "number" {
+				return true
+			}
+			if expected == "integer" {
+				number, err := typed.Float64()
+				if err == nil && number == math.Trunc(number) {
+					return true
+				}
+			}
+		case []interface{}:
+			if expected == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if expected == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jsonEqual compares a schema literal with a decoded value
+func jsonEqual(literal json.RawMessage, value interface{}) bool {
+	var expected interface{}
+	decoder := json.NewDecoder(bytes.NewReader(literal))
+	decoder.UseNumber()
+	if err := decoder.Decode(&expected); err != nil {
+		return false
+	}
+	if expectedNumber, ok := expected.(json.Number); ok {
+		actualNumber, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		a, _ := expectedNumber.Float64()
+		b, _ := actualNumber.Float64()
+		return a == b
+	}
+	return reflect.DeepEqual(expected, value)
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "payload"
+	}
+	return strings.TrimPrefix(path, ".")
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
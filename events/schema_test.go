@@ -0,0 +1,108 @@
+package events
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+const accountSchema = `{
+  "title": "account.updated",
+  "x-aggregate": "account",
+  "type": "object",
+  "required": ["accountId", "status"],
+  "additionalProperties": false,
+  "properties": {
+    "accountId": {"type": "integer", "minimum": 1, "maximum": 1000},
+    "status": {"type": "string", "enum": ["active", "closed"]},
+    "kind": {"type": "string", "const": "person"},
+    "name": {"type": "string", "minLength": 2, "maxLength": 5},
+    "balance": {"type": "number", "minimum": 0},
+    "note": {"type": ["string", "null"]},
+    "verified": {"type": "boolean"},
+    "tags": {"type": "array", "items": {"type": "string"}},
+    "address": {
+      "type": "object",
+      "required": ["city"],
+      "properties": {"city": {"type": "string"}}
+    }
+  }
+}`
+
+func accountRegistry(t *testing.T) *Registry {
+	t.Helper()
+	registry, err := NewRegistry(fstest.MapFS{
+		"schemas/account.updated.v1.json": {Data: []byte(accountSchema)},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	return registry
+}
+
+func TestValidateAcceptsMatchingPayloads(t *testing.T) {
+	registry := accountRegistry(t)
+	for _, payload := range []string{
+		`{"accountId": 1, "status": "active"}`,
+		`{"accountId": 1000, "status": "closed", "kind": "person", "name": "Ada", "balance": 0.5, "note": null, "verified": true, "tags": ["a", "b"], "address": {"city": "Accra"}}`,
+		`{"accountId": 7.0, "status": "active", "note": "text"}`,
+		`{"accountId": 7, "status": "active", "name": "éèêëü"}`,
+	} {
+		if err := registry.Validate("account.updated", 1, []byte(payload)); err != nil {
+			t.Errorf("Validate(%s) = %v", payload, err)
+		}
+	}
+}
+
+func TestValidateListsEveryProblem(t *testing.T) {
+	registry := accountRegistry(t)
+	tests := []struct {
+		name    string
+		payload string
+		want    []string
+	}{
+		{"not JSON", `{"accountId":`, nil},
+		{"not an object", `[]`, []string{"payload must be object"}},
+		{"missing required", `{}`, []string{"accountId is required", "status is required"}},
+		{"wrong type", `{"accountId": "1", "status": "active"}`, []string{"accountId must be integer"}},
+		{"fraction for integer", `{"accountId": 1.5, "status": "active"}`, []string{"accountId must be integer"}},
+		{"out of range", `{"accountId": 0, "status": "active", "balance": -1}`, []string{"accountId must be at least 1", "balance must be at least 0"}},
+		{"above maximum", `{"accountId": 1001, "status": "active"}`, []string{"accountId must be at most 1000"}},
+		{"not in enum", `{"accountId": 1, "status": "open"}`, []string{`status must be one of ["active","closed"]`}},
+		{"not the const", `{"accountId": 1, "status": "active", "kind": "company"}`, []string{`kind must be "person"`}},
+		{"length", `{"accountId": 1, "status": "active", "name": "A"}`, []string{"name must be at least 2 characters"}},
+		{"too long", `{"accountId": 1, "status": "active", "name": "Adelaide"}`, []string{"name must be at most 5 characters"}},
+		{"null not allowed", `{"accountId": 1, "status": "active", "verified": null}`, []string{"verified must be boolean"}},
+		{"array items", `{"accountId": 1, "status": "active", "tags": ["a", 2]}`, []string{"tags[1] must be string"}},
+		{"nested object", `{"accountId": 1, "status": "active", "address": {}}`, []string{"address.city is required"}},
+		{"additional property", `{"accountId": 1, "status": "active", "owner": "x"}`, []string{"owner is not allowed"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := registry.Validate("account.updated", 1, []byte(test.payload))
+			var validationError ValidationError
+			if !errors.As(err, &validationError) {
+				t.Fatalf("Validate = %v, want a ValidationError", err)
+			}
+			if validationError.Type != "account.updated" || validationError.Version != 1 {
+				t.Errorf("error is for %s v%d", validationError.Type, validationError.Version)
+			}
+			if test.want != nil && !reflect.DeepEqual(validationError.Problems, test.want) {
+				t.Errorf("problems = %q, want %q", validationError.Problems, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateAllowsUndeclaredPropertiesByDefault(t *testing.T) {
+	registry, err := NewRegistry(fstest.MapFS{
+		"schemas/widget.created.v1.json": {Data: []byte(widgetSchemaV1)},
+	})
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	if err := registry.Validate("widget.created", 1, []byte(`{"widgetId": 1, "colour": "red"}`)); err != nil {
+		t.Errorf("Validate = %v", err)
+	}
+}
@@ -53,6 +53,24 @@ func ErrorHandler(ctx *fiber.Ctx, err error) error {
 		})
 	}
 
+	_, forbiddenError := err.(ForbiddenError)
+	if forbiddenError {
+		return ctx.Status(fiber.StatusForbidden).JSON(model.GeneralResponse{
+			Code:    403,
+			Message: "Forbidden",
+			Data:    err.Error(),
+		})
+	}
+
+	_, tooManyRequestsError := err.(TooManyRequestsError)
+	if tooManyRequestsError {
+		return ctx.Status(fiber.StatusTooManyRequests).JSON(model.GeneralResponse{
+			Code:    429,
+			Message: "Too Many Requests",
+			Data:    err.Error(),
+		})
+	}
+
 	_, badRequest := err.(BadRequestError)
 	if badRequest {
 		return ctx.Status(fiber.StatusBadRequest).JSON(model.GeneralResponse{
@@ -0,0 +1,9 @@
+package exception
+
+type TooManyRequestsError struct {
+	Message string
+}
+
+func (tooManyRequestsError TooManyRequestsError) Error() string {
+	return tooManyRequestsError.Message
+}
@@ -7,9 +7,11 @@ import (
 	"os"
 
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/client/restclient"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/controller"
 	_ "github.com/RizkiMufrizal/gofiber-clean-architecture/docs"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/events"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/middleware"
@@ -55,10 +57,11 @@ func main() {
 
 	database := configuration.NewDatabase(config)
 	redis := configuration.NewRedis(config)
-	rabbitMQ := configuration.NewRabbitMQ(config)
 
-	// Initialize Redis and RabbitMQ services
-	rabbitMQService := service.NewRabbitMQService(rabbitMQ, "aqua_wizz_exchange", "topic")
+	// Initialize the message broker, RabbitMQ unless MESSAGE_BROKER_DRIVER
+	// selects the in-process one
+	messageBroker, err := service.NewMessageBroker(config)
+	exception.PanicLogging(err)
 	// Redis service initialization if needed in the future
 	redisService := service.NewRedisService(redis)
 
@@ -74,60 +77,124 @@ func main() {
 	orderRepository := repository.NewOrderRepository(database)
 	paymentMethodRepository := repository.NewPaymentMethodRepository(database)
 	paymentConfigRepository := repository.NewPaymentConfigRepository(database, redis)
+	paymentConfigAuditRepository := repository.NewPaymentConfigAuditRepository(database)
+	refreshTokenRepository := repository.NewRefreshTokenRepository(database)
+	oneTimePasswordRepository := repository.NewOneTimePasswordRepository(database)
+	securityEventRepository := repository.NewSecurityEventRepository(database)
+	twoFactorRepository := repository.NewTwoFactorRepository(database)
+	invitationRepository := repository.NewInvitationRepository(database)
+	addressRepository := repository.NewAddressRepository(database)
+	rolePermissionRepository := repository.NewRolePermissionRepository(database)
 	notificationRepository := repository.NewNotificationRepository(database)
 	settingRepository := repository.NewSettingRepository(database)
+	dataExportRepository := repository.NewDataExportRepository(database)
+	storedFileRepository := repository.NewStoredFileRepository(database)
+	messageTemplateVersionRepository := repository.NewMessageTemplateVersionRepository(database)
+	messageTemplateAuditRepository := repository.NewMessageTemplateAuditRepository(database)
+	outboxRepository := repository.NewOutboxRepository(database)
+	transactor := repository.NewTransactor(database)
+	notificationPreferenceRepository := repository.NewNotificationPreferenceRepository(database)
+	campaignRepository := repository.NewCampaignRepository(database)
+	deviceRepository := repository.NewDeviceRepository(database)
 
 	//rest client
 	httpRestClient := restclient.NewHttpRestClient(config)
 
+	//secret encryption
+	secretCipher, err := common.NewSecretCipher(config)
+	exception.PanicLogging(err)
+
+	//file storage
+	fileStorage, err := service.NewFileStorage(config)
+	exception.PanicLogging(err)
+
 	//service
-	notificationService := service.NewNotificationService(config.Get("FCM_CREDENTIALS_PATH"))
+	notificationService := service.NewNotificationService(config, notificationRepository, deviceRepository)
+	notificationInboxService := service.NewNotificationInboxService(notificationRepository)
+	deviceService := service.NewDeviceService(deviceRepository)
 	httpService := service.NewHttpBinServiceImpl(&httpRestClient)
-	messageService := service.NewMessageServiceImpl(config, messageTemplateRepository, &httpService, rabbitMQService, notificationRepository)
+	smsRouter, err := service.NewSMSRouter(config)
+	exception.PanicLogging(err)
+	messageService := service.NewMessageServiceImpl(config, messageTemplateRepository, smsRouter, messageBroker, notificationRepository)
+	outboxService := service.NewOutboxService(outboxRepository, messageBroker, config)
+	notificationDispatcher := service.NewNotificationDispatcher(notificationPreferenceRepository, notificationRepository, userRepository, messageService, notificationService, outboxService)
+	notificationPreferenceService := service.NewNotificationPreferenceService(notificationPreferenceRepository, userRepository)
+	campaignService := service.NewCampaignService(campaignRepository, notificationDispatcher, messageBroker, config)
+	domainEventService := service.NewDomainEventService(outboxService, events.DefaultRegistry())
+	smsConsumerService := service.NewSMSConsumerService(smsRouter, messageBroker, notificationRepository)
 	// Initialize the email consumer service
-	emailConsumerService := service.NewEmailConsumerService(config, rabbitMQService)
-	transactionService := service.NewTransactionServiceImpl(&transactionRepository, &orderRepository, &paymentMethodRepository, &httpService, config, &notificationService, &paymentConfigRepository)
+	emailConsumerService := service.NewEmailConsumerService(config, messageBroker, notificationRepository)
+	paymentConfigService := service.NewPaymentConfigService(paymentConfigRepository, paymentConfigAuditRepository, secretCipher)
+	transactionService := service.NewTransactionServiceImpl(&transactionRepository, &orderRepository, &paymentMethodRepository, &httpService, config, notificationDispatcher, &paymentConfigService, transactor, domainEventService)
 	transactionDetailService := service.NewTransactionDetailServiceImpl(&transactionDetailRepository)
 	localGovernmentService := service.NewLocalGovernmentServiceImpl(&localGovernmentRepository, config)
-	userService := service.NewUserServiceImpl(&userRepository, &messageService, &localGovernmentService, config)
-	truckService := service.NewTruckServiceImpl(&truckRepository, &userService, &messageService)
+	addressService := service.NewAddressService(addressRepository, localGovernmentService)
+	tokenService := service.NewTokenService(refreshTokenRepository, userRepository, redisService, config)
+	otpService := service.NewOtpService(oneTimePasswordRepository, redisService, config)
+	securityService := service.NewSecurityService(securityEventRepository, redisService, notificationDispatcher, config)
+	twoFactorService := service.NewTwoFactorService(twoFactorRepository, userRepository, redisService, securityService, secretCipher, config)
+	messageTemplateService := service.NewMessageTemplateService(messageTemplateRepository, messageTemplateVersionRepository, messageTemplateAuditRepository, messageService)
+	invitationService := service.NewInvitationService(invitationRepository, userRepository, messageService, transactor, config)
+	userService := service.NewUserServiceImpl(&userRepository, &messageService, &localGovernmentService, &tokenService, &otpService, &securityService, &messageTemplateService, &invitationService, config)
+	refineryStaffService := service.NewRefineryStaffService(userRepository, invitationService, tokenService)
+	truckService := service.NewTruckServiceImpl(&truckRepository, &userService, &messageService, &invitationService)
 	settingService := service.NewSettingService(settingRepository, redisService, validator.New())
-	refineryService := service.NewRefineryServiceImpl(&refineryRepository, &userService, &messageService, &settingService, config)
+	refineryService := service.NewRefineryServiceImpl(&refineryRepository, &userService, &messageService, &settingService, &invitationService, config)
 	paymentService := service.NewPaymentService(&paymentRepository)
-	paymentConfigService := service.NewPaymentConfigService(paymentConfigRepository)
+	fileService, err := service.NewFileService(fileStorage, storedFileRepository, userRepository, truckRepository, config)
+	exception.PanicLogging(err)
+	accountService := service.NewAccountService(dataExportRepository, userRepository, addressRepository, orderRepository, transactionRepository, notificationRepository, messageBroker, tokenService, invitationService, securityService, fileService, config)
 
 	// Google Maps Service
 	mapsService := service.NewGoogleMapsService(config)
 
 	// Authorization Service
-	authorizationService := service.NewAuthorizationService()
+	authorizationService := service.NewAuthorizationService(rolePermissionRepository, redisService)
+	accessPolicyService := service.NewAccessPolicyService(orderRepository, userRepository, transactionRepository, transactionDetailRepository, truckRepository, authorizationService)
 
 	//controller
-	transactionController := controller.NewTransactionController(&transactionService, &userService, &authorizationService, config)
-	transactionDetailController := controller.NewTransactionDetailController(&transactionDetailService, config)
-	userController := controller.NewUserController(&userService, config)
-	messageController := controller.NewMessageController(&messageService)
-	truckController := controller.NewTruckController(&truckService, config)
-	refineryController := controller.NewRefineryController(&refineryService, config)
-	paymentController := controller.NewPaymentController(&paymentService, &userService, config)
-	paymentConfigController := controller.NewPaymentConfigController(paymentConfigService)
-	localGovernmentController := controller.NewLocalGovernmentAreaController(&localGovernmentService)
-	settingController := controller.NewSettingController(settingService)
+	transactionController := controller.NewTransactionController(&transactionService, &userService, &authorizationService, &accessPolicyService, config)
+	transactionDetailController := controller.NewTransactionDetailController(&transactionDetailService, &authorizationService, &accessPolicyService, config)
+	userController := controller.NewUserController(&userService, &tokenService, &twoFactorService, &authorizationService, &accessPolicyService, &fileService, config)
+	messageController := controller.NewMessageController(&messageService, &userService, &authorizationService)
+	truckController := controller.NewTruckController(&truckService, &authorizationService, &accessPolicyService, config)
+	refineryController := controller.NewRefineryController(&refineryService, &authorizationService, &accessPolicyService, &refineryStaffService, config)
+	paymentController := controller.NewPaymentController(&paymentService, &userService, &authorizationService, config)
+	paymentConfigController := controller.NewPaymentConfigController(paymentConfigService, authorizationService)
+	localGovernmentController := controller.NewLocalGovernmentAreaController(&localGovernmentService, &authorizationService)
+	settingController := controller.NewSettingController(settingService, authorizationService)
+	rolePermissionController := controller.NewRolePermissionController(authorizationService)
+	securityController := controller.NewSecurityController(securityService, authorizationService)
+	twoFactorController := controller.NewTwoFactorController(twoFactorService, tokenService, authorizationService)
+	invitationController := controller.NewInvitationController(invitationService, authorizationService)
+	addressController := controller.NewAddressController(addressService, authorizationService)
+	accountController := controller.NewAccountController(accountService, authorizationService)
+	fileController := controller.NewFileController(fileService, authorizationService, accessPolicyService)
+	messageTemplateController := controller.NewMessageTemplateController(messageTemplateService, authorizationService)
+	deliveryReportController := controller.NewDeliveryReportController(messageService, authorizationService, config)
+	deadLetterController := controller.NewDeadLetterController(messageBroker, authorizationService)
+	healthController := controller.NewHealthController(messageBroker)
 
 	// Google Maps Controller
-	mapsController := controller.NewGoogleMapsController(mapsService)
+	mapsController := controller.NewGoogleMapsController(mapsService, authorizationService)
 
-	// Notification Controller
-	notificationController := controller.NewNotificationController(notificationService, userRepository)
+	// Notification Controllers
+	notificationInboxController := controller.NewNotificationInboxController(notificationInboxService, authorizationService)
+	notificationPreferenceController := controller.NewNotificationPreferenceController(notificationPreferenceService, authorizationService)
+	campaignController := controller.NewCampaignController(campaignService, authorizationService)
+	deviceController := controller.NewDeviceController(deviceService, authorizationService)
 
 	//setup fiber
 	app := fiber.New(configuration.NewFiberConfiguration())
 
 	app.Use(recover.New())
 	app.Use(cors.New())
-	app.Static("/uploads", "./uploads")
 	app.Use(middleware.RequestLogger)
 
+	// Every /v1/api route except the public ones needs a valid token, the
+	// permission each route requires is declared in its controller
+	app.Use("/v1/api", middleware.ExtractClaims(userService), middleware.RequireClaims())
+
 	//routing
 	transactionController.Route(app)
 	transactionDetailController.Route(app)
@@ -142,8 +209,24 @@ func main() {
 	mapsController.RegisterRoutes(app)
 	// Register setting routes
 	settingController.Route(app)
+	// Register role permission routes
+	rolePermissionController.Route(app)
+	// Register security routes
+	securityController.Route(app)
+	twoFactorController.Route(app)
+	invitationController.Route(app)
+	addressController.Route(app)
+	accountController.Route(app)
+	fileController.Route(app)
+	messageTemplateController.Route(app)
+	deliveryReportController.Route(app)
+	deadLetterController.Route(app)
+	healthController.Route(app)
 	// Register notification routes
-	notificationController.Route(app)
+	notificationInboxController.Route(app)
+	notificationPreferenceController.Route(app)
+	campaignController.Route(app)
+	deviceController.Route(app)
 
 	// Payment configuration routes are registered through the controller's Route method
 
@@ -157,13 +240,13 @@ func main() {
 	//logger.Logger.Info("Cron jobs scheduled and started")
 
 	// Start the email consumer service
-	err := emailConsumerService.StartConsumer()
+	err = emailConsumerService.StartConsumer()
 	if err != nil {
 		logger.Logger.Error("Failed to start email consumer service: " + err.Error())
 	}
 
 	// Set up example RabbitMQ subscription
-	err = rabbitMQService.SubscribeToTopic("notifications", func(message []byte) error {
+	err = messageBroker.SubscribeToTopic("notifications", func(message []byte) error {
 		logger.Logger.Info("Received notification message: " + string(message))
 		return nil
 	})
@@ -172,26 +255,55 @@ func main() {
 	}
 
 	// Start the SMS consumer service
-	err = rabbitMQService.SubscribeToTopic("sms.send", func(message []byte) error {
-		var sms model.SMSMessageModel
-		if err := json.Unmarshal(message, &sms); err != nil {
-			logger.Logger.Error("Failed to unmarshal SMS message: " + err.Error())
-			return err
+	err = smsConsumerService.StartConsumer()
+	if err != nil {
+		logger.Logger.Error("Failed to start SMS consumer service: " + err.Error())
+	}
+
+	// Start the data export worker
+	err = messageBroker.SubscribeToTopic(common.DATA_EXPORT_TOPIC, func(message []byte) error {
+		var job model.DataExportJobModel
+		if err := json.Unmarshal(message, &job); err != nil {
+			logger.Logger.Error("Failed to unmarshal data export job: " + err.Error())
+			return nil
 		}
-		if err := messageService.SendSMSDirect(sms); err != nil {
-			logger.Logger.Error("Failed to send SMS: " + err.Error())
+		return accountService.ProcessExport(context.Background(), job.ExportId)
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to subscribe to data export topic: " + err.Error())
+	}
+
+	// Start the campaign delivery worker
+	err = messageBroker.SubscribeToTopic(common.CAMPAIGN_DELIVERY_TOPIC, func(message []byte) error {
+		var batch model.CampaignBatchModel
+		if err := json.Unmarshal(message, &batch); err != nil {
+			logger.Logger.Error("Failed to unmarshal campaign batch: " + err.Error())
 			return nil
 		}
-		logger.Logger.Info("SMS sent to: " + sms.PhoneNumber)
-		return nil
+		return campaignService.ProcessBatch(context.Background(), batch)
 	})
 	if err != nil {
-		logger.Logger.Error("Failed to subscribe to sms.send topic: " + err.Error())
+		logger.Logger.Error("Failed to subscribe to campaign delivery topic: " + err.Error())
 	}
 
-	// Properly close connections when app terminates
+	// Start the worker sending the notifications recorded in the outbox
+	err = messageBroker.SubscribeToTopic(common.NOTIFICATION_DISPATCH_TOPIC, notificationDispatcher.HandleQueued)
+	if err != nil {
+		logger.Logger.Error("Failed to subscribe to notification dispatch topic: " + err.Error())
+	}
+
+	// Publish the events recorded in the outbox
+	outboxService.StartRelay()
+	// Queue the campaigns that are due
+	campaignService.StartScheduler()
+
+	// Properly close connections when app terminates, the relay and the
+	// campaign scheduler stop first so that they do not publish to a closed
+	// broker
 	defer func() {
-		rabbitMQService.Close()
+		campaignService.StopScheduler()
+		outboxService.StopRelay()
+		messageBroker.Close()
 	}()
 	err = messageService.SendSMSDirect(model.SMSMessageModel{
 		CountryCode: "+234",
@@ -202,6 +314,8 @@ func main() {
 	logger.Logger.Info("Application Started")
 
 	userService.SeedUser(context.TODO())
+	authorizationService.SeedRolePermissions(context.TODO())
+	messageTemplateService.SeedVersions(context.TODO())
 
 	err = app.Listen(config.Get("SERVER.PORT"))
 	exception.PanicLogging(err)
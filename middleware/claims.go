@@ -35,6 +35,12 @@ func ExtractClaims(userService service.UserService) fiber.Handler {
 			}
 		}
 
+		if userService.IsTokenRevoked(c.Context(), claims) {
+			return exception.UnauthorizedError{
+				Message: "Token has been revoked",
+			}
+		}
+
 		// Store claims in locals for easy access in controller methods
 		c.Locals("claims", claims)
 		return c.Next()
@@ -45,14 +51,17 @@ func ExtractClaims(userService service.UserService) fiber.Handler {
 func isPublicRoute(path string) bool {
 	publicPaths := []string{
 		"/v1/api/authentication",
+		"/v1/api/refresh-token",
 		"/v1/api/register",
 		"/v1/api/register-customer",
 		"/v1/api/reset-password",
 		"/v1/api/verify-otp",
 		"/v1/api/post-new-password",
 		"/v1/api/verify-phone",
-		"/v1/api/update-fcm-token",
+		"/v1/api/resend-otp",
+		"/v1/api/invitations/activate",
 		"/v1/api/user/reset-password",
+		"/v1/api/sms/delivery-reports",
 		"/swagger",
 	}
 
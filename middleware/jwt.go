@@ -1,61 +0,0 @@
-package middleware
-
-import (
-	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
-	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
-	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
-	"github.com/gofiber/fiber/v2"
-	jwtware "github.com/gofiber/jwt/v3"
-	"github.com/golang-jwt/jwt/v4"
-)
-
-func AuthenticateJWT(role string, config configuration.Config) func(*fiber.Ctx) error {
-	jwtSecret := config.Get("JWT_SECRET_KEY")
-	return jwtware.New(jwtware.Config{
-		SigningKey: []byte(jwtSecret),
-		SuccessHandler: func(ctx *fiber.Ctx) error {
-			user := ctx.Locals("user").(*jwt.Token)
-			claims := user.Claims.(jwt.MapClaims)
-			roles := claims["roles"].([]interface{})
-
-			if role != "" {
-				common.NewLogger().Info("role function ", role, " role user ", roles)
-				for _, roleInterface := range roles {
-					roleMap := roleInterface.(map[string]interface{})
-					if roleMap["role"] == role {
-						return ctx.Next()
-					}
-				}
-			} else {
-				return ctx.Next()
-			}
-
-			return ctx.
-				Status(fiber.StatusUnauthorized).
-				JSON(model.GeneralResponse{
-					Code:    401,
-					Message: "Unauthorized",
-					Data:    "Invalid Role",
-				})
-		},
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			if err.Error() == "Missing or malformed JWT" {
-				return c.
-					Status(fiber.StatusBadRequest).
-					JSON(model.GeneralResponse{
-						Code:    400,
-						Message: "Bad Request",
-						Data:    "Missing or malformed JWT",
-					})
-			} else {
-				return c.
-					Status(fiber.StatusUnauthorized).
-					JSON(model.GeneralResponse{
-						Code:    401,
-						Message: "Unauthorized",
-						Data:    "Invalid or expired JWT",
-					})
-			}
-		},
-	})
-}
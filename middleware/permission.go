@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission rejects the request unless the role of the authenticated
+// user has been granted permission. It must run after ExtractClaims.
+func RequirePermission(authorizationService service.AuthorizationService, permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, ok := GetClaims(c)
+		if !ok {
+			return exception.UnauthorizedError{
+				Message: "Authentication required",
+			}
+		}
+
+		if !authorizationService.HasPermission(c.Context(), claims, permission) {
+			return exception.ForbiddenError{
+				Message: "Missing permission " + permission,
+			}
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,88 @@
+package model
+
+import "time"
+
+type DataExportModel struct {
+	Id          uint       `json:"id"`
+	Status      string     `json:"status"`
+	FileSize    int64      `json:"fileSize"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	ExpiresAt   *time.Time `json:"expiresAt"`
+}
+
+// DataExportJobModel is the message queued for the export worker
+type DataExportJobModel struct {
+	ExportId uint `json:"exportId"`
+}
+
+type DeleteAccountModel struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// The Export* models are the files of the personal data bundle
+
+type ExportProfileModel struct {
+	Id          uint      `json:"id"`
+	Username    string    `json:"username"`
+	FirstName   string    `json:"firstName"`
+	LastName    string    `json:"lastName"`
+	PhoneNumber string    `json:"phoneNumber"`
+	AreaCode    string    `json:"areaCode"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	CountryCode string    `json:"countryCode"`
+	Region      string    `json:"region"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type ExportOrderModel struct {
+	Id              uint        `json:"id"`
+	TransactionId   uint        `json:"transactionId"`
+	Refinery        string      `json:"refinery"`
+	Amount          float64     `json:"amount"`
+	WaterCost       float64     `json:"waterCost"`
+	DeliveryFee     float64     `json:"deliveryFee"`
+	Currency        string      `json:"currency"`
+	Capacity        string      `json:"capacity"`
+	Type            string      `json:"type"`
+	WaterType       string      `json:"waterType"`
+	Status          uint        `json:"status"`
+	DeliveryAddress interface{} `json:"deliveryAddress"`
+	CreatedAt       time.Time   `json:"createdAt"`
+}
+
+type ExportTransactionModel struct {
+	Id          uint      `json:"id"`
+	Reference   string    `json:"reference"`
+	Amount      float64   `json:"amount"`
+	WaterCost   float64   `json:"waterCost"`
+	DeliveryFee float64   `json:"deliveryFee"`
+	TotalAmount float64   `json:"totalAmount"`
+	Currency    string    `json:"currency"`
+	Provider    string    `json:"provider"`
+	PaymentType string    `json:"paymentType"`
+	Scheme      string    `json:"scheme"`
+	PhoneNumber string    `json:"phoneNumber"`
+	Email       string    `json:"email"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"createdAt"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+type ExportNotificationModel struct {
+	Type      string    `json:"type"`
+	Recipient string    `json:"recipient"`
+	Subject   string    `json:"subject"`
+	Content   string    `json:"content"`
+	Status    string    `json:"status"`
+	SentAt    time.Time `json:"sentAt"`
+}
+
+type ExportRatingModel struct {
+	OrderId   uint      `json:"orderId"`
+	Rating    uint      `json:"rating"`
+	Review    string    `json:"review"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
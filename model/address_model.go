@@ -35,9 +35,40 @@ type AddressModel struct {
 
 type AddressResponseModel struct {
 	Id          uint    `json:"id"`
+	Label       string  `json:"label"`
+	IsMain      bool    `json:"isMain"`
 	Description string  `json:"description"`
 	PlaceId     string  `json:"placeId"`
+	Street      string  `json:"street"`
+	City        string  `json:"city"`
+	Region      string  `json:"region"`
+	PostalCode  string  `json:"postalCode"`
 	Longitude   float64 `json:"longitude"`
 	Latitude    float64 `json:"latitude"`
 	CountryCode string  `json:"countryCode"`
 }
+
+// AddressRequestModel creates or updates an address book entry. Coordinates
+// are looked up from the place when both are left at zero.
+type AddressRequestModel struct {
+	Label       string  `json:"label"`
+	Description string  `json:"description"`
+	PlaceId     string  `json:"placeId" validate:"required"`
+	Street      string  `json:"street"`
+	City        string  `json:"city"`
+	Region      string  `json:"region"`
+	PostalCode  string  `json:"postalCode"`
+	Longitude   float64 `json:"longitude"`
+	Latitude    float64 `json:"latitude"`
+	CountryCode string  `json:"countryCode" validate:"required,len=2"`
+	IsMain      bool    `json:"isMain"`
+}
+
+// SaveAddressModel is the body of the older /v1/api/save-address route
+type SaveAddressModel struct {
+	Description string  `json:"description"`
+	PlaceId     string  `json:"place_id"`
+	Longitude   float64 `json:"longitude"`
+	Latitude    float64 `json:"latitude"`
+	CountryCode string  `json:"country_code"`
+}
@@ -3,5 +3,5 @@ package model
 type ApproveOrRejectOrderModel struct {
 	OrderId uint   `json:"orderId" validate:"required"`
 	Action  string `json:"action" validate:"required"`
-	TruckId uint   `json:"truckId""`
+	TruckId uint   `json:"truckId"`
 }
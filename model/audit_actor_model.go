@@ -0,0 +1,7 @@
+package model
+
+// AuditActor identifies the user responsible for a change
+type AuditActor struct {
+	UserId   uint   `json:"userId"`
+	Username string `json:"username"`
+}
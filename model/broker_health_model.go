@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type BrokerHealthModel struct {
+	State            string    `json:"state"`
+	Since            time.Time `json:"since"`
+	LastError        string    `json:"lastError,omitempty"`
+	Reconnects       int       `json:"reconnects"`
+	Subscriptions    int       `json:"subscriptions"`
+	BufferedMessages int       `json:"bufferedMessages"`
+}
@@ -0,0 +1,96 @@
+package model
+
+import "time"
+
+// CampaignSegmentModel selects the active customers a campaign goes to,
+// empty fields do not filter. Order recency and count consider all of a
+// customer's orders, WaterTypes keeps customers that ever ordered one of them.
+type CampaignSegmentModel struct {
+	Countries  []string `json:"countries,omitempty"`
+	Regions    []string `json:"regions,omitempty"`
+	WaterTypes []string `json:"waterTypes,omitempty"`
+	// LastOrderWithinDays keeps customers that ordered in the last N days,
+	// NoOrderWithinDays those that did not, including who never ordered
+	LastOrderWithinDays int  `json:"lastOrderWithinDays,omitempty"`
+	NoOrderWithinDays   int  `json:"noOrderWithinDays,omitempty"`
+	MinOrders           int  `json:"minOrders,omitempty"`
+	MaxOrders           *int `json:"maxOrders,omitempty"`
+}
+
+type CampaignRequestModel struct {
+	Name     string               `json:"name"`
+	Title    string               `json:"title"`
+	Body     string               `json:"body"`
+	Html     string               `json:"html"`
+	Data     map[string]string    `json:"data"`
+	Channels []string             `json:"channels"`
+	Segment  CampaignSegmentModel `json:"segment"`
+}
+
+// CampaignScheduleModel sends the campaign at ScheduledAt, right away when
+// it is empty
+type CampaignScheduleModel struct {
+	ScheduledAt *time.Time `json:"scheduledAt"`
+}
+
+type CampaignModel struct {
+	Id          uint                 `json:"id"`
+	Name        string               `json:"name"`
+	Title       string               `json:"title"`
+	Body        string               `json:"body"`
+	Html        string               `json:"html,omitempty"`
+	Data        map[string]string    `json:"data,omitempty"`
+	Channels    []string             `json:"channels"`
+	Segment     CampaignSegmentModel `json:"segment"`
+	Status      string               `json:"status"`
+	ScheduledAt *time.Time           `json:"scheduledAt,omitempty"`
+	StartedAt   *time.Time           `json:"startedAt,omitempty"`
+	CompletedAt *time.Time           `json:"completedAt,omitempty"`
+	Recipients  int                  `json:"recipients"`
+	CreatedBy   string               `json:"createdBy"`
+	CreatedAt   time.Time            `json:"createdAt"`
+}
+
+type CampaignAudienceModel struct {
+	Recipients int64 `json:"recipients"`
+}
+
+// CampaignStatsModel counts the outcome per channel, OpenRate is the share
+// of recipients that opened the campaign on any channel
+type CampaignStatsModel struct {
+	CampaignId uint                                 `json:"campaignId"`
+	Status     string                               `json:"status"`
+	Recipients int                                  `json:"recipients"`
+	Opened     int64                                `json:"opened"`
+	OpenRate   float64                              `json:"openRate"`
+	Channels   map[string]CampaignChannelStatsModel `json:"channels"`
+}
+
+type CampaignChannelStatsModel struct {
+	Sent        int64 `json:"sent"`
+	Failed      int64 `json:"failed"`
+	OptedOut    int64 `json:"optedOut"`
+	QuietHours  int64 `json:"quietHours"`
+	Unavailable int64 `json:"unavailable"`
+	Opened      int64 `json:"opened"`
+}
+
+// CampaignDeliveryStatModel is one row of the delivery counts per channel
+// and status
+type CampaignDeliveryStatModel struct {
+	Channel string
+	Status  string
+	Total   int64
+	Opened  int64
+}
+
+// CampaignBatchModel is the job queued for a batch of recipients. StartedAt
+// is when the campaign was claimed for queueing, a batch left over from an
+// earlier attempt that was queued again is ignored. Number counts the
+// batches of an attempt from 1.
+type CampaignBatchModel struct {
+	CampaignId uint      `json:"campaignId"`
+	StartedAt  time.Time `json:"startedAt"`
+	Number     int       `json:"number"`
+	UserIds    []uint    `json:"userIds"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type DeadLetterQueueModel struct {
+	Topic    string `json:"topic"`
+	Queue    string `json:"queue"`
+	Messages int    `json:"messages"`
+}
+
+type DeadLetterModel struct {
+	MessageId string          `json:"messageId"`
+	Topic     string          `json:"topic"`
+	Attempts  int             `json:"attempts"`
+	Error     string          `json:"error"`
+	FailedAt  *time.Time      `json:"failedAt"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// DeadLetterActionModel selects the dead letters to replay or purge, all of
+// them when MessageIds is empty
+type DeadLetterActionModel struct {
+	MessageIds []string `json:"messageIds"`
+}
@@ -0,0 +1,26 @@
+package model
+
+// SMSDeliveryReportModel is the form Africa's Talking posts to the delivery
+// report callback
+type SMSDeliveryReportModel struct {
+	Id            string `form:"id"`
+	Status        string `form:"status"`
+	PhoneNumber   string `form:"phoneNumber"`
+	NetworkCode   string `form:"networkCode"`
+	FailureReason string `form:"failureReason"`
+}
+
+// DeliveryStatModel counts notifications by status for one message type,
+// country and provider. FailureRate is the share of failed messages among
+// those that left the queue.
+type DeliveryStatModel struct {
+	Type        string  `json:"type"`
+	CountryCode string  `json:"countryCode"`
+	Provider    string  `json:"provider"`
+	Total       int64   `json:"total"`
+	Queued      int64   `json:"queued"`
+	Sent        int64   `json:"sent"`
+	Delivered   int64   `json:"delivered"`
+	Failed      int64   `json:"failed"`
+	FailureRate float64 `json:"failureRate" gorm:"-"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type RegisterDeviceModel struct {
+	Token      string `json:"token"`
+	Platform   string `json:"platform"`
+	AppVersion string `json:"appVersion"`
+}
+
+type DeviceModel struct {
+	Id         uint      `json:"id"`
+	Token      string    `json:"token"`
+	Platform   string    `json:"platform"`
+	AppVersion string    `json:"appVersion"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -0,0 +1,32 @@
+package model
+
+// OrderEventModel is the payload of the order.* events
+type OrderEventModel struct {
+	OrderId       uint    `json:"orderId"`
+	TransactionId uint    `json:"transactionId"`
+	CustomerId    uint    `json:"customerId"`
+	RefineryId    uint    `json:"refineryId"`
+	TruckId       uint    `json:"truckId"`
+	Status        uint    `json:"status"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	WaterCost     float64 `json:"waterCost"`
+	DeliveryFee   float64 `json:"deliveryFee"`
+	Capacity      string  `json:"capacity"`
+	WaterType     string  `json:"waterType"`
+	Reason        string  `json:"reason,omitempty"`
+}
+
+// PaymentEventModel is the payload of the payment.* and refund.* events
+type PaymentEventModel struct {
+	TransactionId uint    `json:"transactionId"`
+	CustomerId    uint    `json:"customerId"`
+	OrderId       uint    `json:"orderId"`
+	Reference     string  `json:"reference"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	Provider      string  `json:"provider"`
+	Channel       string  `json:"channel"`
+	Status        string  `json:"status"`
+	CountryCode   string  `json:"countryCode"`
+}
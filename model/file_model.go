@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// FileUploadModel is an uploaded file read from a multipart form
+type FileUploadModel struct {
+	FileName string
+	Data     []byte
+}
+
+type StoredFileModel struct {
+	Id           uint      `json:"id"`
+	Category     string    `json:"category"`
+	DocumentType string    `json:"documentType,omitempty"`
+	ContentType  string    `json:"contentType"`
+	Size         int64     `json:"size"`
+	OriginalName string    `json:"originalName"`
+	Url          string    `json:"url"`
+	ThumbnailUrl string    `json:"thumbnailUrl,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
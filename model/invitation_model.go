@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+type InvitationModel struct {
+	Id          uint      `json:"id"`
+	UserId      uint      `json:"userId"`
+	FirstName   string    `json:"firstName"`
+	LastName    string    `json:"lastName"`
+	PhoneNumber string    `json:"phoneNumber"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	IsExpired   bool      `json:"isExpired"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	SendCount   int       `json:"sendCount"`
+	LastSentAt  time.Time `json:"lastSentAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type ActivateInvitationModel struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
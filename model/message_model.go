@@ -6,10 +6,14 @@ type EmailMessageModel struct {
 	Message  string
 	File     []byte
 	FileName string
+	// UserId puts the email in the recipient's inbox when set
+	UserId uint
 }
 
 type SMSMessageModel struct {
 	PhoneNumber string `json:"phone_number" json:"phoneNumber"`
 	Message     string `json:"message"`
 	CountryCode string `json:"country_code" json:"countryCode"`
+	// UserId puts the SMS in the recipient's inbox when set
+	UserId uint `json:"user_id,omitempty"`
 }
@@ -1,9 +1,93 @@
 package model
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// MessageTemplateModel describes a template and the variables its versions
+// may use. On create a non empty Message becomes the first English draft.
 type MessageTemplateModel struct {
-	Name    string `json:"name"`
-	Subject string `json:"subject"`
-	Message string `json:"message"`
-	IsEmail bool   `json:"isEmail"`
-	IsSms   bool   `json:"isSms"`
+	Id          uint                    `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description"`
+	Subject     string                  `json:"subject"`
+	Message     string                  `json:"message"`
+	IsEmail     bool                    `json:"isEmail"`
+	IsSms       bool                    `json:"isSms"`
+	Variables   []TemplateVariableModel `json:"variables"`
+	CreatedAt   time.Time               `json:"createdAt"`
+	UpdatedAt   time.Time               `json:"updatedAt"`
+}
+
+type TemplateVariableModel struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Sample      string `json:"sample"`
+}
+
+type MessageTemplateVersionModel struct {
+	Id          uint       `json:"id"`
+	TemplateId  uint       `json:"templateId"`
+	Language    string     `json:"language"`
+	Version     int        `json:"version"`
+	Status      string     `json:"status"`
+	Subject     string     `json:"subject"`
+	TextBody    string     `json:"textBody"`
+	HtmlBody    string     `json:"htmlBody"`
+	CreatedBy   uint       `json:"createdBy"`
+	PublishedBy uint       `json:"publishedBy"`
+	PublishedAt *time.Time `json:"publishedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// MessageTemplateDraftModel is the text of a draft version. TextBody is a
+// text/template used for SMS and plain text, HtmlBody an html/template used
+// for email.
+type MessageTemplateDraftModel struct {
+	Language string `json:"language"`
+	Subject  string `json:"subject"`
+	TextBody string `json:"textBody"`
+	HtmlBody string `json:"htmlBody"`
+}
+
+type MessageTemplateRollbackModel struct {
+	Language string `json:"language"`
+}
+
+// MessageTemplatePreviewModel overrides the declared sample values
+type MessageTemplatePreviewModel struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+type RenderedMessageModel struct {
+	Language string `json:"language"`
+	Version  int    `json:"version"`
+	Subject  string `json:"subject"`
+	Text     string `json:"text"`
+	Html     string `json:"html"`
+}
+
+// MessageTemplateTestModel sends a rendered version to a single recipient.
+// The published version in Language is used when VersionId is empty.
+type MessageTemplateTestModel struct {
+	VersionId   uint                   `json:"versionId"`
+	Language    string                 `json:"language"`
+	Email       string                 `json:"email"`
+	CountryCode string                 `json:"countryCode"`
+	PhoneNumber string                 `json:"phoneNumber"`
+	Data        map[string]interface{} `json:"data"`
+}
+
+type MessageTemplateAuditModel struct {
+	Id            uint            `json:"id"`
+	TemplateId    uint            `json:"templateId"`
+	TemplateName  string          `json:"templateName"`
+	VersionId     uint            `json:"versionId"`
+	Action        string          `json:"action"`
+	Changes       json.RawMessage `json:"changes"`
+	ActorId       uint            `json:"actorId"`
+	ActorUsername string          `json:"actorUsername"`
+	CreatedAt     time.Time       `json:"createdAt"`
 }
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type NotificationModel struct {
 	Title       string            `json:"title"`
 	Body        string            `json:"body"`
@@ -8,4 +10,41 @@ type NotificationModel struct {
 	Data        map[string]string `json:"data,omitempty"`
 	ImageURL    string            `json:"imageUrl,omitempty"`
 	ClickAction string            `json:"clickAction,omitempty"`
+	// UserId is the recipient the push is recorded for in the inbox, pushes
+	// without one are not recorded
+	UserId uint `json:"userId,omitempty"`
+}
+
+// PushResultModel is the outcome of a push sent to several devices, with
+// one result per token in the order the tokens were given
+type PushResultModel struct {
+	SuccessCount int                    `json:"successCount"`
+	FailureCount int                    `json:"failureCount"`
+	Results      []PushTokenResultModel `json:"results"`
+}
+
+// PushTokenResultModel is the outcome for one token, Removed is set when FCM
+// reported the token as no longer valid and its device was removed
+type PushTokenResultModel struct {
+	Token     string `json:"token"`
+	MessageId string `json:"messageId,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Removed   bool   `json:"removed,omitempty"`
+}
+
+// InboxNotificationModel is a message in the user's inbox, Channel is email,
+// sms or push
+type InboxNotificationModel struct {
+	Id        uint              `json:"id"`
+	Channel   string            `json:"channel"`
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data,omitempty"`
+	IsRead    bool              `json:"isRead"`
+	ReadAt    *time.Time        `json:"readAt,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+}
+
+type UnreadNotificationCountModel struct {
+	Unread int64 `json:"unread"`
 }
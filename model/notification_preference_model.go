@@ -0,0 +1,50 @@
+package model
+
+// NotificationPreferencesModel maps each category to whether each of its
+// channels is on. Timezone is the one quiet hours apply in.
+type NotificationPreferencesModel struct {
+	Channels   map[string]map[string]bool `json:"channels"`
+	Timezone   string                     `json:"timezone"`
+	QuietHours QuietHoursModel            `json:"quietHours"`
+}
+
+// QuietHoursModel is a daily window as HH:MM, it runs over midnight when End
+// is before Start. Both empty turns quiet hours off.
+type QuietHoursModel struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// UpdateNotificationPreferencesModel changes only what it contains, an empty
+// Timezone goes back to the default of the user's country
+type UpdateNotificationPreferencesModel struct {
+	Channels   map[string]map[string]bool `json:"channels"`
+	Timezone   *string                    `json:"timezone"`
+	QuietHours *QuietHoursModel           `json:"quietHours"`
+}
+
+// DispatchMessageModel is a message for one user, the dispatcher picks its
+// channels. Html replaces Body in emails, Data and ClickAction are used by
+// push and the inbox.
+type DispatchMessageModel struct {
+	Category    string
+	Title       string
+	Body        string
+	Html        string
+	Data        map[string]string
+	ClickAction string
+	// Channels limits the message to these channels, all when empty
+	Channels []string
+}
+
+// NotificationIntentModel is a message recorded in the outbox, it is
+// dispatched to the user once the transaction that recorded it committed
+type NotificationIntentModel struct {
+	UserId  uint                 `json:"userId"`
+	Message DispatchMessageModel `json:"message"`
+}
+
+// DispatchResultModel has the outcome per channel, see common.DISPATCH_SENT
+type DispatchResultModel struct {
+	Channels map[string]string `json:"channels"`
+}
@@ -5,3 +5,8 @@ type OtpModel struct {
 	UserId    int    `json:"userId" validate:"required"`
 	Operation string `json:"operationType" validate:"required"`
 }
+
+type ResendOtpModel struct {
+	UserId  uint   `json:"userId" validate:"required"`
+	Purpose string `json:"purpose" validate:"required"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEventModel is an event to record in the outbox, Topic is the routing
+// key it is published with and Version the version of its payload schema
+type OutboxEventModel struct {
+	AggregateType string
+	AggregateId   string
+	Topic         string
+	Version       int
+	Payload       interface{}
+}
+
+// OutboxMessageModel is the envelope the outbox relay publishes. Delivery is
+// at least once, consumers skip an EventId they already handled.
+type OutboxMessageModel struct {
+	EventId       string          `json:"eventId"`
+	Topic         string          `json:"topic"`
+	Version       int             `json:"version"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateId   string          `json:"aggregateId"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Payload       json.RawMessage `json:"payload"`
+}
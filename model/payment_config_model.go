@@ -1,8 +1,22 @@
 package model
 
+import "time"
+
 type PaymentConfigModel struct {
 	ID          uint   `json:"id"`
 	CountryCode string `json:"countryCode"`
 	SecretKey   string `json:"secretKey"`
 	PublicKey   string `json:"publicKey"`
+	KeyVersion  string `json:"keyVersion"`
+}
+
+type PaymentConfigAuditModel struct {
+	ID            uint      `json:"id"`
+	CountryCode   string    `json:"countryCode"`
+	Action        string    `json:"action"`
+	ChangedFields string    `json:"changedFields"`
+	KeyVersion    string    `json:"keyVersion"`
+	ActorId       uint      `json:"actorId"`
+	ActorUsername string    `json:"actorUsername"`
+	CreatedAt     time.Time `json:"createdAt"`
 }
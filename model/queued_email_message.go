@@ -6,4 +6,6 @@ type QueuedEmailMessage struct {
 	Subject string `json:"subject"`
 	Message string `json:"message"`
 	From    string `json:"from,omitempty"`
+	// NotificationId is the notification whose status the consumer updates
+	NotificationId uint `json:"notification_id,omitempty"`
 }
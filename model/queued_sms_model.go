@@ -6,4 +6,6 @@ type QueuedSMSMessage struct {
 	CountryCode string `json:"country_code"`
 	Message     string `json:"message"`
 	UserID      uint   `json:"user_id,omitempty"` // Optional user ID to associate the message with
+	// NotificationId is the notification whose status the consumer updates
+	NotificationId uint `json:"notification_id,omitempty"`
 }
@@ -0,0 +1,28 @@
+package model
+
+type RefineryStaffModel struct {
+	Id          uint   `json:"id"`
+	FirstName   string `json:"firstName"`
+	LastName    string `json:"lastName"`
+	PhoneNumber string `json:"phoneNumber"`
+	Email       string `json:"email"`
+	Role        string `json:"role"`
+	IsActive    bool   `json:"isActive"`
+}
+
+type InviteRefineryStaffModel struct {
+	FirstName   string `json:"firstName" validate:"required"`
+	LastName    string `json:"lastName" validate:"required"`
+	PhoneNumber string `json:"phoneNumber" validate:"required"`
+	Email       string `json:"email"`
+	Role        string `json:"role" validate:"required"`
+}
+
+type UpdateRefineryStaffRoleModel struct {
+	Role string `json:"role" validate:"required"`
+}
+
+type RefineryPricingModel struct {
+	DomesticCostPerThousandLitre   float64 `json:"domesticCostPerThousandLitre"`
+	IndustrialCostPerThousandLitre float64 `json:"industrialCostPerThousandLitre"`
+}
@@ -0,0 +1,10 @@
+package model
+
+type RolePermissionModel struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRolePermissionsModel struct {
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+type SecurityEventModel struct {
+	Id        uint      `json:"id"`
+	UserId    uint      `json:"userId"`
+	Username  string    `json:"username"`
+	EventType string    `json:"eventType"`
+	Reason    string    `json:"reason"`
+	IpAddress string    `json:"ipAddress"`
+	UserAgent string    `json:"userAgent"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type SecurityEventFilterModel struct {
+	Username  string
+	EventType string
+	IpAddress string
+}
+
+type LoginLockoutModel struct {
+	Type      string    `json:"type"`
+	Value     string    `json:"value"`
+	Failures  int64     `json:"failures"`
+	LockedAt  time.Time `json:"lockedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
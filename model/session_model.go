@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+type TokenPairModel struct {
+	AccessToken           string    `json:"token"`
+	AccessTokenExpiresAt  time.Time `json:"tokenExpiresAt"`
+	RefreshToken          string    `json:"refreshToken"`
+	RefreshTokenExpiresAt time.Time `json:"refreshTokenExpiresAt"`
+}
+
+type RefreshTokenModel struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+type SessionClientModel struct {
+	UserAgent string
+	IpAddress string
+}
+
+type SessionModel struct {
+	Id         uint      `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IpAddress  string    `json:"ipAddress"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	Current    bool      `json:"current"`
+}
+
+type ToggleUserStatusModel struct {
+	Id     uint `json:"id"`
+	Status bool `json:"status"`
+}
@@ -0,0 +1,15 @@
+package model
+
+// SMSProviderMessage is a single SMS as handed to a provider, To is in
+// international format and From is the sender ID of the destination country
+type SMSProviderMessage struct {
+	To      string
+	From    string
+	Message string
+}
+
+// SMSProviderResult identifies the message at the provider that accepted it
+type SMSProviderResult struct {
+	Provider  string `json:"provider"`
+	MessageId string `json:"messageId"`
+}
@@ -0,0 +1,9 @@
+package model
+
+// TransactionScopeModel narrows a transaction listing. Zero values mean the
+// listing is not restricted on that field.
+type TransactionScopeModel struct {
+	CountryCode string
+	RefineryId  uint
+	UserId      uint
+}
@@ -11,6 +11,7 @@ type TruckModel struct {
 	PlateNumber           string    `json:"plateNumber"`
 	EngineNumber          string    `json:"engineNumber"`
 	LicenceExpirationDate time.Time `json:"licenceExpirationDate"`
+	RefineryId            uint      `json:"refineryId"`
 
 	FirstName   string    `json:"firstName"`
 	LastName    string    `json:"lastName"`
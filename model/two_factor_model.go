@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+type TwoFactorEnrolmentModel struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioningUri"`
+}
+
+type TwoFactorCodeModel struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorChallengeModel struct {
+	ChallengeToken    string    `json:"challengeToken"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+	EnrolmentRequired bool      `json:"enrolmentRequired"`
+}
+
+type TwoFactorLoginModel struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	Code           string `json:"code"`
+}
+
+type TwoFactorStatusModel struct {
+	Enabled  bool `json:"enabled"`
+	Required bool `json:"required"`
+}
@@ -1,6 +1,7 @@
 package model
 
 type UpdateFcmToken struct {
-	Id       int64  `json:"id"`
+	// Id is the signed in user, it is never read from the request
+	Id       int64  `json:"-"`
 	FcmToken string `json:"fcmToken"`
 }
@@ -14,6 +14,7 @@ type UserModel struct {
 	Address      string `json:"address"`
 	Image        string `json:"image"`
 	FileName     string `json:"fileName"`
+	PhotoUrl     string `json:"photoUrl,omitempty"`
 
 	Street     string `json:"street"`
 	Region     string `json:"region"`
@@ -22,8 +23,8 @@ type UserModel struct {
 	RefineryId uint   `json:"refineryId"`
 
 	CountryCode string `json:"countryCode"`
-	OtpCode     string `json:"otpCode"`
 	AreaCode    string `json:"areaCode"`
+	Language    string `json:"language"`
 }
 
 type LoginModel struct {
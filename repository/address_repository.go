@@ -2,10 +2,16 @@ package repository
 
 import (
 	"context"
+
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
 )
 
 type AddressRepository interface {
-	FindById(ctx context.Context, id int, userId int) (entity.Address, error)
-	FindAll(ctx context.Context, userId int) ([]entity.Address, error)
+	FindById(ctx context.Context, id uint) (entity.Address, error)
+	FindByUserId(ctx context.Context, userId uint) ([]entity.Address, error)
+	FindByPlaceId(ctx context.Context, userId uint, placeId string) (entity.Address, error)
+	Create(ctx context.Context, address entity.Address) (entity.Address, error)
+	Update(ctx context.Context, address entity.Address) (entity.Address, error)
+	Delete(ctx context.Context, address entity.Address) error
+	SetMain(ctx context.Context, userId uint, addressId uint) error
 }
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+type CampaignRepository interface {
+	Create(ctx context.Context, campaign entity.Campaign) (entity.Campaign, error)
+	// UpdateContent saves the message, channels and segment of the campaign
+	// only while it is in one of fromStatuses and reports whether it did
+	UpdateContent(ctx context.Context, campaign entity.Campaign, fromStatuses []string) (bool, error)
+	FindById(ctx context.Context, id uint) (entity.Campaign, error)
+	FindPaginated(ctx context.Context, status string, page, limit int) ([]entity.Campaign, int64, error)
+	// UpdateStatus applies the non zero fields of update, which include the
+	// new status, only while the campaign is in one of fromStatuses and
+	// reports whether it did
+	UpdateStatus(ctx context.Context, id uint, fromStatuses []string, update entity.Campaign) (bool, error)
+	// FindQueueable returns the scheduled campaigns whose send time has come
+	// and the sending ones whose queueing started before staleBefore without
+	// finishing, as the process queueing them stopped
+	FindQueueable(ctx context.Context, now time.Time, staleBefore time.Time) ([]entity.Campaign, error)
+	// ClaimForQueueing moves a queueable campaign to sending with a new start
+	// time and reports whether this caller got it
+	ClaimForQueueing(ctx context.Context, id uint, now time.Time, staleBefore time.Time) (bool, error)
+	// FindSegmentUserIds pages through the ids of the segment's users in
+	// ascending order, starting after afterId
+	FindSegmentUserIds(ctx context.Context, segment model.CampaignSegmentModel, now time.Time, afterId uint, limit int) ([]uint, error)
+	CountSegment(ctx context.Context, segment model.CampaignSegmentModel, now time.Time) (int64, error)
+	FindUsers(ctx context.Context, ids []uint) ([]entity.User, error)
+	// FinishQueueing records how many recipients and batches were queued
+	FinishQueueing(ctx context.Context, id uint, recipients int, batches int, queuedAt time.Time) error
+	// MarkBatchProcessed records batch number of the queueing attempt that
+	// started at startedAt and counts it in ProcessedBatches the first time
+	MarkBatchProcessed(ctx context.Context, id uint, startedAt time.Time, number int) error
+	// CompleteIfDone completes a sending campaign once all of its queued
+	// batches were processed
+	CompleteIfDone(ctx context.Context, id uint, completedAt time.Time) (bool, error)
+	// FindDeliveredUserIds returns which of userIds already have deliveries
+	// of the campaign, so that a redelivered batch is not sent twice
+	FindDeliveredUserIds(ctx context.Context, campaignId uint, userIds []uint) ([]uint, error)
+	CreateDeliveries(ctx context.Context, deliveries []entity.CampaignDelivery) error
+	// MarkOpened records the first time the user opened the campaign and
+	// returns how many deliveries were changed
+	MarkOpened(ctx context.Context, campaignId uint, userId uint, openedAt time.Time) (int64, error)
+	DeliveryStats(ctx context.Context, campaignId uint) ([]model.CampaignDeliveryStatModel, error)
+	CountOpenedUsers(ctx context.Context, campaignId uint) (int64, error)
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type DataExportRepository interface {
+	Create(ctx context.Context, export entity.DataExport) (entity.DataExport, error)
+	Update(ctx context.Context, export entity.DataExport) (entity.DataExport, error)
+	FindById(ctx context.Context, id uint) (entity.DataExport, error)
+	FindByUserId(ctx context.Context, userId uint) ([]entity.DataExport, error)
+	FindUnfinishedByUserId(ctx context.Context, userId uint) ([]entity.DataExport, error)
+	FindExpired(ctx context.Context, now time.Time) ([]entity.DataExport, error)
+	DeleteByUserId(ctx context.Context, userId uint) error
+}
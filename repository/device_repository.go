@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type DeviceRepository interface {
+	// Save registers the device's token or, when it is known, moves it to
+	// the device's user and updates its platform, version and last seen time
+	Save(ctx context.Context, device entity.Device) (entity.Device, error)
+	FindById(ctx context.Context, id uint) (entity.Device, error)
+	// FindByUserId returns the user's devices, the most recently seen first
+	FindByUserId(ctx context.Context, userId uint) ([]entity.Device, error)
+	// DeleteByTokens removes the devices of the tokens and clears them from
+	// the users that still have them as their single legacy token
+	DeleteByTokens(ctx context.Context, tokens []string) (int64, error)
+}
@@ -0,0 +1,83 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type addressRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewAddressRepository(db *gorm.DB) repository.AddressRepository {
+	return &addressRepositoryImpl{DB: db}
+}
+
+func (r *addressRepositoryImpl) FindById(ctx context.Context, id uint) (entity.Address, error) {
+	var address entity.Address
+	err := r.DB.WithContext(ctx).Where("id = ?", id).First(&address).Error
+	return address, err
+}
+
+// FindByUserId lists the address book with the main address first
+func (r *addressRepositoryImpl) FindByUserId(ctx context.Context, userId uint) ([]entity.Address, error) {
+	var addresses []entity.Address
+	err := r.DB.WithContext(ctx).
+		Where("user_id = ?", userId).
+		Order("is_main desc, created_at desc").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *addressRepositoryImpl) FindByPlaceId(ctx context.Context, userId uint, placeId string) (entity.Address, error) {
+	var address entity.Address
+	err := r.DB.WithContext(ctx).Where("user_id = ? AND place_id = ?", userId, placeId).First(&address).Error
+	return address, err
+}
+
+// Create returns the address already saved for the same place when a
+// concurrent request created it first
+func (r *addressRepositoryImpl) Create(ctx context.Context, address entity.Address) (entity.Address, error) {
+	result := r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "user_id"}, {Name: "place_id"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_at IS NULL AND place_id <> ''"}}},
+		DoNothing:   true,
+	}).Create(&address)
+	if result.Error != nil {
+		return address, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.FindByPlaceId(ctx, address.UserId, address.PlaceId)
+	}
+	return address, nil
+}
+
+func (r *addressRepositoryImpl) Update(ctx context.Context, address entity.Address) (entity.Address, error) {
+	err := r.DB.WithContext(ctx).Save(&address).Error
+	return address, err
+}
+
+// Delete soft deletes the address so that transactions referencing it keep
+// their foreign key
+func (r *addressRepositoryImpl) Delete(ctx context.Context, address entity.Address) error {
+	return r.DB.WithContext(ctx).Delete(&address).Error
+}
+
+// SetMain makes one address the main address of the user in one transaction
+func (r *addressRepositoryImpl) SetMain(ctx context.Context, userId uint, addressId uint) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.Address{}).
+			Where("user_id = ? AND id <> ?", userId, addressId).
+			Update("is_main", false).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&entity.Address{}).
+			Where("user_id = ? AND id = ?", userId, addressId).
+			Update("is_main", true).Error
+	})
+}
@@ -0,0 +1,227 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// userOrders selects the orders of the user of the outer query
+const userOrders = `FROM tb_orders o JOIN tb_transactions t ON t.id = o.transaction_id
+	WHERE t.user_id = tb_users.id AND o.deleted_at IS NULL`
+
+type campaignRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewCampaignRepository(db *gorm.DB) repository.CampaignRepository {
+	return &campaignRepositoryImpl{DB: db}
+}
+
+func (r *campaignRepositoryImpl) Create(ctx context.Context, campaign entity.Campaign) (entity.Campaign, error) {
+	err := r.DB.WithContext(ctx).Create(&campaign).Error
+	return campaign, err
+}
+
+func (r *campaignRepositoryImpl) UpdateContent(ctx context.Context, campaign entity.Campaign, fromStatuses []string) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&entity.Campaign{}).
+		Where("id = ? AND status IN ?", campaign.ID, fromStatuses).
+		Updates(map[string]interface{}{
+			"name":     campaign.Name,
+			"title":    campaign.Title,
+			"body":     campaign.Body,
+			"html":     campaign.Html,
+			"data":     campaign.Data,
+			"channels": campaign.Channels,
+			"segment":  campaign.Segment,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *campaignRepositoryImpl) FindById(ctx context.Context, id uint) (entity.Campaign, error) {
+	var campaign entity.Campaign
+	err := r.DB.WithContext(ctx).First(&campaign, id).Error
+	return campaign, err
+}
+
+func (r *campaignRepositoryImpl) FindPaginated(ctx context.Context, status string, page, limit int) ([]entity.Campaign, int64, error) {
+	var campaigns []entity.Campaign
+	var totalCount int64
+	query := r.DB.WithContext(ctx).Model(&entity.Campaign{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&campaigns).Error
+	return campaigns, totalCount, err
+}
+
+func (r *campaignRepositoryImpl) UpdateStatus(ctx context.Context, id uint, fromStatuses []string, update entity.Campaign) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&entity.Campaign{}).
+		Where("id = ? AND status IN ?", id, fromStatuses).
+		Updates(update)
+	return result.RowsAffected > 0, result.Error
+}
+
+// queueable matches the campaigns FindQueueable returns
+const queueable = `((status = ? AND scheduled_at <= ?) OR (status = ? AND queued_at IS NULL AND started_at < ?))`
+
+func (r *campaignRepositoryImpl) FindQueueable(ctx context.Context, now time.Time, staleBefore time.Time) ([]entity.Campaign, error) {
+	var campaigns []entity.Campaign
+	err := r.DB.WithContext(ctx).
+		Where(queueable, common.CAMPAIGN_STATUS_SCHEDULED, now, common.CAMPAIGN_STATUS_SENDING, staleBefore).
+		Order("scheduled_at, id").
+		Find(&campaigns).Error
+	return campaigns, err
+}
+
+func (r *campaignRepositoryImpl) ClaimForQueueing(ctx context.Context, id uint, now time.Time, staleBefore time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&entity.Campaign{}).
+		Where("id = ?", id).
+		Where(queueable, common.CAMPAIGN_STATUS_SCHEDULED, now, common.CAMPAIGN_STATUS_SENDING, staleBefore).
+		Updates(map[string]interface{}{
+			"status":            common.CAMPAIGN_STATUS_SENDING,
+			"started_at":        now,
+			"processed_batches": 0,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// segmentQuery selects the active customers of the segment, pseudonymised
+// users are left out as they cannot be contacted any more
+func (r *campaignRepositoryImpl) segmentQuery(ctx context.Context, segment model.CampaignSegmentModel, now time.Time) *gorm.DB {
+	query := r.DB.WithContext(ctx).Model(&entity.User{}).
+		Where("is_active = ? AND pseudonymised_at IS NULL AND user_roles = ?", true, common.CUSTOMER_ROLE)
+	if len(segment.Countries) > 0 {
+		query = query.Where("country_code IN ?", segment.Countries)
+	}
+	if len(segment.Regions) > 0 {
+		query = query.Where("region IN ?", segment.Regions)
+	}
+	if len(segment.WaterTypes) > 0 {
+		query = query.Where("EXISTS (SELECT 1 "+userOrders+" AND o.water_type IN ?)", segment.WaterTypes)
+	}
+	if segment.LastOrderWithinDays > 0 {
+		query = query.Where("EXISTS (SELECT 1 "+userOrders+" AND o.created_at >= ?)", now.AddDate(0, 0, -segment.LastOrderWithinDays))
+	}
+	if segment.NoOrderWithinDays > 0 {
+		query = query.Where("NOT EXISTS (SELECT 1 "+userOrders+" AND o.created_at >= ?)", now.AddDate(0, 0, -segment.NoOrderWithinDays))
+	}
+	if segment.MinOrders > 0 {
+		query = query.Where("(SELECT COUNT(*) "+userOrders+") >= ?", segment.MinOrders)
+	}
+	if segment.MaxOrders != nil {
+		query = query.Where("(SELECT COUNT(*) "+userOrders+") <= ?", *segment.MaxOrders)
+	}
+	return query
+}
+
+func (r *campaignRepositoryImpl) FindSegmentUserIds(ctx context.Context, segment model.CampaignSegmentModel, now time.Time, afterId uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.segmentQuery(ctx, segment, now).
+		Where("id > ?", afterId).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *campaignRepositoryImpl) CountSegment(ctx context.Context, segment model.CampaignSegmentModel, now time.Time) (int64, error) {
+	var count int64
+	err := r.segmentQuery(ctx, segment, now).Count(&count).Error
+	return count, err
+}
+
+func (r *campaignRepositoryImpl) FindUsers(ctx context.Context, ids []uint) ([]entity.User, error) {
+	var users []entity.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := r.DB.WithContext(ctx).Where("id IN ?", ids).Order("id").Find(&users).Error
+	return users, err
+}
+
+func (r *campaignRepositoryImpl) FinishQueueing(ctx context.Context, id uint, recipients int, batches int, queuedAt time.Time) error {
+	return r.DB.WithContext(ctx).Model(&entity.Campaign{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"recipients":    recipients,
+			"total_batches": batches,
+			"queued_at":     queuedAt,
+		}).Error
+}
+
+func (r *campaignRepositoryImpl) MarkBatchProcessed(ctx context.Context, id uint, startedAt time.Time, number int) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entity.CampaignBatch{
+			CampaignId: id,
+			StartedAt:  startedAt,
+			Number:     number,
+		})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		// A batch of an earlier attempt does not count towards this one
+		return tx.Model(&entity.Campaign{}).Where("id = ? AND started_at = ?", id, startedAt).
+			Update("processed_batches", gorm.Expr("processed_batches + 1")).Error
+	})
+}
+
+func (r *campaignRepositoryImpl) CompleteIfDone(ctx context.Context, id uint, completedAt time.Time) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&entity.Campaign{}).
+		Where("id = ? AND status = ? AND queued_at IS NOT NULL AND processed_batches >= total_batches", id, common.CAMPAIGN_STATUS_SENDING).
+		Updates(map[string]interface{}{"status": common.CAMPAIGN_STATUS_COMPLETED, "completed_at": completedAt})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *campaignRepositoryImpl) FindDeliveredUserIds(ctx context.Context, campaignId uint, userIds []uint) ([]uint, error) {
+	var ids []uint
+	if len(userIds) == 0 {
+		return ids, nil
+	}
+	err := r.DB.WithContext(ctx).Model(&entity.CampaignDelivery{}).
+		Where("campaign_id = ? AND user_id IN ?", campaignId, userIds).
+		Distinct().
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+func (r *campaignRepositoryImpl) CreateDeliveries(ctx context.Context, deliveries []entity.CampaignDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+func (r *campaignRepositoryImpl) MarkOpened(ctx context.Context, campaignId uint, userId uint, openedAt time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Model(&entity.CampaignDelivery{}).
+		Where("campaign_id = ? AND user_id = ? AND status = ? AND opened_at IS NULL", campaignId, userId, common.DISPATCH_SENT).
+		Update("opened_at", openedAt)
+	return result.RowsAffected, result.Error
+}
+
+func (r *campaignRepositoryImpl) DeliveryStats(ctx context.Context, campaignId uint) ([]model.CampaignDeliveryStatModel, error) {
+	var stats []model.CampaignDeliveryStatModel
+	err := r.DB.WithContext(ctx).Model(&entity.CampaignDelivery{}).
+		Select("channel, status, COUNT(*) AS total, COUNT(opened_at) AS opened").
+		Where("campaign_id = ?", campaignId).
+		Group("channel, status").
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *campaignRepositoryImpl) CountOpenedUsers(ctx context.Context, campaignId uint) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&entity.CampaignDelivery{}).
+		Where("campaign_id = ? AND opened_at IS NOT NULL", campaignId).
+		Distinct("user_id").
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,63 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+)
+
+type dataExportRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewDataExportRepository(db *gorm.DB) repository.DataExportRepository {
+	return &dataExportRepositoryImpl{DB: db}
+}
+
+func (r *dataExportRepositoryImpl) Create(ctx context.Context, export entity.DataExport) (entity.DataExport, error) {
+	err := r.DB.WithContext(ctx).Create(&export).Error
+	return export, err
+}
+
+func (r *dataExportRepositoryImpl) Update(ctx context.Context, export entity.DataExport) (entity.DataExport, error) {
+	err := r.DB.WithContext(ctx).Save(&export).Error
+	return export, err
+}
+
+func (r *dataExportRepositoryImpl) FindById(ctx context.Context, id uint) (entity.DataExport, error) {
+	var export entity.DataExport
+	err := r.DB.WithContext(ctx).Where("id = ?", id).First(&export).Error
+	return export, err
+}
+
+func (r *dataExportRepositoryImpl) FindByUserId(ctx context.Context, userId uint) ([]entity.DataExport, error) {
+	var exports []entity.DataExport
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userId).Order("created_at desc").Find(&exports).Error
+	return exports, err
+}
+
+func (r *dataExportRepositoryImpl) FindUnfinishedByUserId(ctx context.Context, userId uint) ([]entity.DataExport, error) {
+	var exports []entity.DataExport
+	err := r.DB.WithContext(ctx).
+		Where("user_id = ? AND status IN ?", userId, []string{common.DATA_EXPORT_STATUS_PENDING, common.DATA_EXPORT_STATUS_PROCESSING}).
+		Find(&exports).Error
+	return exports, err
+}
+
+// FindExpired returns completed exports whose download window has passed and
+// whose file has not been removed yet
+func (r *dataExportRepositoryImpl) FindExpired(ctx context.Context, now time.Time) ([]entity.DataExport, error) {
+	var exports []entity.DataExport
+	err := r.DB.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", common.DATA_EXPORT_STATUS_COMPLETED, now).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (r *dataExportRepositoryImpl) DeleteByUserId(ctx context.Context, userId uint) error {
+	return r.DB.WithContext(ctx).Unscoped().Where("user_id = ?", userId).Delete(&entity.DataExport{}).Error
+}
@@ -0,0 +1,59 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type deviceRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewDeviceRepository(db *gorm.DB) repository.DeviceRepository {
+	return &deviceRepositoryImpl{DB: db}
+}
+
+func (r *deviceRepositoryImpl) Save(ctx context.Context, device entity.Device) (entity.Device, error) {
+	err := r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "app_version", "last_seen_at", "updated_at"}),
+	}).Create(&device).Error
+	if err != nil {
+		return device, err
+	}
+	// The id of an existing row is not returned by every driver on conflict
+	err = r.DB.WithContext(ctx).Where("token = ?", device.Token).First(&device).Error
+	return device, err
+}
+
+func (r *deviceRepositoryImpl) FindById(ctx context.Context, id uint) (entity.Device, error) {
+	var device entity.Device
+	err := r.DB.WithContext(ctx).First(&device, id).Error
+	return device, err
+}
+
+func (r *deviceRepositoryImpl) FindByUserId(ctx context.Context, userId uint) ([]entity.Device, error) {
+	var devices []entity.Device
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userId).Order("last_seen_at DESC, id DESC").Find(&devices).Error
+	return devices, err
+}
+
+func (r *deviceRepositoryImpl) DeleteByTokens(ctx context.Context, tokens []string) (int64, error) {
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+	var deleted int64
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("token IN ?", tokens).Delete(&entity.Device{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return tx.Model(&entity.User{}).Where("fcm_token IN ?", tokens).Update("fcm_token", "").Error
+	})
+	return deleted, err
+}
@@ -0,0 +1,74 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+)
+
+type invitationRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) repository.InvitationRepository {
+	return &invitationRepositoryImpl{DB: db}
+}
+
+func (r *invitationRepositoryImpl) Create(ctx context.Context, invitation entity.Invitation) (entity.Invitation, error) {
+	err := dbFor(ctx, r.DB).Create(&invitation).Error
+	return invitation, err
+}
+
+func (r *invitationRepositoryImpl) Update(ctx context.Context, invitation entity.Invitation) (entity.Invitation, error) {
+	err := r.DB.WithContext(ctx).Omit("User").Save(&invitation).Error
+	return invitation, err
+}
+
+func (r *invitationRepositoryImpl) FindById(ctx context.Context, id uint) (entity.Invitation, error) {
+	var invitation entity.Invitation
+	err := r.DB.WithContext(ctx).Preload("User").Where("id = ?", id).First(&invitation).Error
+	return invitation, err
+}
+
+func (r *invitationRepositoryImpl) FindByTokenHash(ctx context.Context, tokenHash string) (entity.Invitation, error) {
+	var invitation entity.Invitation
+	err := r.DB.WithContext(ctx).Preload("User").Where("token_hash = ?", tokenHash).First(&invitation).Error
+	return invitation, err
+}
+
+// FindPending returns invitations that have been neither accepted nor revoked,
+// expired ones included so that admins can resend them
+func (r *invitationRepositoryImpl) FindPending(ctx context.Context, page, limit int) ([]entity.Invitation, int64, error) {
+	var invitations []entity.Invitation
+	var totalCount int64
+
+	query := r.DB.WithContext(ctx).Model(&entity.Invitation{}).
+		Where("accepted_at IS NULL AND revoked_at IS NULL")
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Preload("User").
+		Order("created_at desc").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&invitations).Error
+	return invitations, totalCount, err
+}
+
+// MarkAccepted consumes a pending invitation, reporting false when a
+// concurrent request already accepted or revoked it
+func (r *invitationRepositoryImpl) MarkAccepted(ctx context.Context, id uint) (bool, error) {
+	result := dbFor(ctx, r.DB).Model(&entity.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("accepted_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *invitationRepositoryImpl) RevokePendingByUserId(ctx context.Context, userId uint) error {
+	return dbFor(ctx, r.DB).Model(&entity.Invitation{}).
+		Where("user_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", userId).
+		Update("revoked_at", time.Now()).Error
+}
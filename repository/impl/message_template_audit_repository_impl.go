@@ -0,0 +1,28 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+)
+
+type messageTemplateAuditRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewMessageTemplateAuditRepository(db *gorm.DB) repository.MessageTemplateAuditRepository {
+	return &messageTemplateAuditRepositoryImpl{DB: db}
+}
+
+func (r *messageTemplateAuditRepositoryImpl) Create(ctx context.Context, audit entity.MessageTemplateAudit) (entity.MessageTemplateAudit, error) {
+	err := r.DB.WithContext(ctx).Create(&audit).Error
+	return audit, err
+}
+
+func (r *messageTemplateAuditRepositoryImpl) FindByTemplateId(ctx context.Context, templateId uint) ([]entity.MessageTemplateAudit, error) {
+	var audits []entity.MessageTemplateAudit
+	err := r.DB.WithContext(ctx).Where("template_id = ?", templateId).Order("created_at DESC").Find(&audits).Error
+	return audits, err
+}
@@ -2,13 +2,8 @@ package impl
 
 import (
 	"context"
-	"fmt"
-	"math/rand"
-	"time"
 
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
-	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
-	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
 	"gorm.io/gorm"
 )
@@ -26,60 +21,31 @@ type messageTemplateRepositoryImpl struct {
 func (m messageTemplateRepositoryImpl) FindByName(ctx context.Context, name string) (entity.MessageTemplate, error) {
 	var message entity.MessageTemplate
 	err := m.DB.WithContext(ctx).Where("template_name = ?", name).First(&message).Error
-	exception.PanicLogging(err)
-	return message, nil
+	return message, err
 }
 
 func (m messageTemplateRepositoryImpl) FindById(ctx context.Context, i int) (entity.MessageTemplate, error) {
 	var message entity.MessageTemplate
-	err := m.DB.WithContext(ctx).Where("id = ?", i).First(&message)
-	exception.PanicLogging(err)
-	return message, nil
+	err := m.DB.WithContext(ctx).Where("id = ?", i).First(&message).Error
+	return message, err
 }
 
 func (m messageTemplateRepositoryImpl) FindAll(ctx context.Context) ([]entity.MessageTemplate, error) {
 	var messages []entity.MessageTemplate
-	err := m.DB.WithContext(ctx).Find(&messages)
-	exception.PanicLogging(err)
-	return messages, nil
+	err := m.DB.WithContext(ctx).Order("template_name").Find(&messages).Error
+	return messages, err
 }
 
-func (m messageTemplateRepositoryImpl) Create(ctx context.Context, model model.MessageTemplateModel) error {
-	message := entity.MessageTemplate{
-		Subject: model.Subject,
-		Message: model.Message,
-		Name:    model.Name,
-	}
-	err := m.DB.WithContext(ctx).Create(&message)
-	exception.PanicLogging(err)
-	return nil
-}
-
-func (m messageTemplateRepositoryImpl) Update(ctx context.Context, templateModel model.MessageTemplateModel) error {
-	var message entity.MessageTemplate
-	err := m.DB.WithContext(ctx).Where("name = ?", templateModel.Name).First(&message)
-	exception.PanicLogging(err)
-	message.Subject = templateModel.Subject
-	message.Message = templateModel.Message
-	err = m.DB.WithContext(ctx).Save(&message)
-	exception.PanicLogging(err)
-	return nil
+func (m messageTemplateRepositoryImpl) Create(ctx context.Context, template entity.MessageTemplate) (entity.MessageTemplate, error) {
+	err := m.DB.WithContext(ctx).Create(&template).Error
+	return template, err
 }
 
-func (m messageTemplateRepositoryImpl) GenerateOneTImePassword(ctx context.Context, userId uint) (entity.OneTimePassword, error) {
-	var otp entity.OneTimePassword
-	code := generateOTP()
-	otp.Code = code
-	otp.UserId = userId
-	otp.IsUsed = false
-	otp.ExpiredAt = time.Now().Add(15 * time.Minute)
-	err := m.DB.WithContext(ctx).Create(&otp).Error
-	exception.PanicLogging(err)
-	return otp, nil
+func (m messageTemplateRepositoryImpl) Update(ctx context.Context, template entity.MessageTemplate) (entity.MessageTemplate, error) {
+	err := m.DB.WithContext(ctx).Save(&template).Error
+	return template, err
 }
 
-func generateOTP() string {
-	// Generate a random 6-digit OTP
-	otp := fmt.Sprintf("%04d", rand.Intn(10000))
-	return otp
+func (m messageTemplateRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return m.DB.WithContext(ctx).Delete(&entity.MessageTemplate{}, id).Error
 }
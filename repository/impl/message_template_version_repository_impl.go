@@ -0,0 +1,91 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+)
+
+type messageTemplateVersionRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewMessageTemplateVersionRepository(db *gorm.DB) repository.MessageTemplateVersionRepository {
+	return &messageTemplateVersionRepositoryImpl{DB: db}
+}
+
+func (r *messageTemplateVersionRepositoryImpl) Create(ctx context.Context, version entity.MessageTemplateVersion) (entity.MessageTemplateVersion, error) {
+	err := r.DB.WithContext(ctx).Create(&version).Error
+	return version, err
+}
+
+func (r *messageTemplateVersionRepositoryImpl) Update(ctx context.Context, version entity.MessageTemplateVersion) (entity.MessageTemplateVersion, error) {
+	err := r.DB.WithContext(ctx).Save(&version).Error
+	return version, err
+}
+
+func (r *messageTemplateVersionRepositoryImpl) FindById(ctx context.Context, id uint) (entity.MessageTemplateVersion, error) {
+	var version entity.MessageTemplateVersion
+	err := r.DB.WithContext(ctx).Where("id = ?", id).First(&version).Error
+	return version, err
+}
+
+func (r *messageTemplateVersionRepositoryImpl) FindByTemplateId(ctx context.Context, templateId uint, language string) ([]entity.MessageTemplateVersion, error) {
+	var versions []entity.MessageTemplateVersion
+	query := r.DB.WithContext(ctx).Where("template_id = ?", templateId)
+	if language != "" {
+		query = query.Where("language = ?", language)
+	}
+	err := query.Order("language, version desc").Find(&versions).Error
+	return versions, err
+}
+
+func (r *messageTemplateVersionRepositoryImpl) FindPublished(ctx context.Context, templateId uint, language string) (entity.MessageTemplateVersion, error) {
+	var version entity.MessageTemplateVersion
+	err := r.DB.WithContext(ctx).
+		Where("template_id = ? AND language = ? AND status = ?", templateId, language, common.TEMPLATE_VERSION_STATUS_PUBLISHED).
+		First(&version).Error
+	return version, err
+}
+
+func (r *messageTemplateVersionRepositoryImpl) FindArchivedBefore(ctx context.Context, templateId uint, language string, version int) (entity.MessageTemplateVersion, error) {
+	var archived entity.MessageTemplateVersion
+	err := r.DB.WithContext(ctx).
+		Where("template_id = ? AND language = ? AND status = ? AND version < ?", templateId, language, common.TEMPLATE_VERSION_STATUS_ARCHIVED, version).
+		Order("version desc").
+		First(&archived).Error
+	return archived, err
+}
+
+func (r *messageTemplateVersionRepositoryImpl) NextVersionNumber(ctx context.Context, templateId uint, language string) (int, error) {
+	var latest int
+	err := r.DB.WithContext(ctx).Model(&entity.MessageTemplateVersion{}).
+		Unscoped().
+		Where("template_id = ? AND language = ?", templateId, language).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error
+	return latest + 1, err
+}
+
+func (r *messageTemplateVersionRepositoryImpl) CountByTemplateId(ctx context.Context, templateId uint) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&entity.MessageTemplateVersion{}).Where("template_id = ?", templateId).Count(&count).Error
+	return count, err
+}
+
+func (r *messageTemplateVersionRepositoryImpl) Publish(ctx context.Context, version entity.MessageTemplateVersion) (entity.MessageTemplateVersion, error) {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.MessageTemplateVersion{}).
+			Where("template_id = ? AND language = ? AND status = ? AND id <> ?", version.TemplateId, version.Language, common.TEMPLATE_VERSION_STATUS_PUBLISHED, version.ID).
+			Update("status", common.TEMPLATE_VERSION_STATUS_ARCHIVED).Error
+		if err != nil {
+			return err
+		}
+		version.Status = common.TEMPLATE_VERSION_STATUS_PUBLISHED
+		return tx.Save(&version).Error
+	})
+	return version, err
+}
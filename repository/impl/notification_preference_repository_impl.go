@@ -0,0 +1,54 @@
+package impl
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type notificationPreferenceRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewNotificationPreferenceRepository(db *gorm.DB) repository.NotificationPreferenceRepository {
+	return &notificationPreferenceRepositoryImpl{DB: db}
+}
+
+func (r *notificationPreferenceRepositoryImpl) FindByUserId(ctx context.Context, userId uint) ([]entity.NotificationPreference, error) {
+	var preferences []entity.NotificationPreference
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userId).Find(&preferences).Error
+	return preferences, err
+}
+
+func (r *notificationPreferenceRepositoryImpl) FindSettingByUserId(ctx context.Context, userId uint) (entity.NotificationSetting, error) {
+	var setting entity.NotificationSetting
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userId).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.NotificationSetting{UserId: userId}, nil
+	}
+	return setting, err
+}
+
+func (r *notificationPreferenceRepositoryImpl) Save(ctx context.Context, userId uint, preferences []entity.NotificationPreference, setting entity.NotificationSetting) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, preference := range preferences {
+			preference.UserId = userId
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}, {Name: "channel"}},
+				DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+			}).Create(&preference).Error
+			if err != nil {
+				return err
+			}
+		}
+		setting.UserId = userId
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"timezone", "quiet_hours_start", "quiet_hours_end", "updated_at"}),
+		}).Create(&setting).Error
+	})
+}
@@ -2,8 +2,11 @@ package impl
 
 import (
 	"context"
+	"time"
 
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
 	"gorm.io/gorm"
 )
@@ -23,10 +26,43 @@ func (r *notificationRepository) Create(ctx context.Context, notification entity
 	return notification, err
 }
 
-func (r *notificationRepository) FindByUserID(ctx context.Context, userID uint) ([]entity.Notification, error) {
+func (r *notificationRepository) FindById(ctx context.Context, id uint) (entity.Notification, error) {
+	var notification entity.Notification
+	err := r.DB.WithContext(ctx).First(&notification, id).Error
+	return notification, err
+}
+
+func (r *notificationRepository) FindByUserID(ctx context.Context, userID uint, page, limit int) ([]entity.Notification, int64, error) {
 	var notifications []entity.Notification
-	err := r.DB.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&notifications).Error
-	return notifications, err
+	var totalCount int64
+	query := r.DB.WithContext(ctx).Model(&entity.Notification{}).Where("user_id = ?", userID)
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	query = query.Order("created_at DESC, id DESC")
+	if limit > 0 {
+		query = query.Offset((page - 1) * limit).Limit(limit)
+	}
+	err := query.Find(&notifications).Error
+	return notifications, totalCount, err
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&entity.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepository) MarkRead(ctx context.Context, userID uint, ids []uint) (int64, error) {
+	query := r.DB.WithContext(ctx).Model(&entity.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
 }
 
 func (r *notificationRepository) FindAll(ctx context.Context, page, size int) ([]entity.Notification, error) {
@@ -41,3 +77,37 @@ func (r *notificationRepository) CountAll() (int64, error) {
 	err := r.DB.Model(&entity.Notification{}).Count(&count).Error
 	return count, err
 }
+
+func (r *notificationRepository) FindByProviderMessageId(ctx context.Context, provider string, providerMessageId string) (entity.Notification, error) {
+	var notification entity.Notification
+	err := r.DB.WithContext(ctx).
+		Where("provider = ? AND provider_message_id = ?", provider, providerMessageId).
+		First(&notification).Error
+	return notification, err
+}
+
+func (r *notificationRepository) UpdateStatus(ctx context.Context, id uint, fromStatuses []string, update entity.Notification) error {
+	return r.DB.WithContext(ctx).Model(&entity.Notification{}).
+		Where("id = ? AND status IN ?", id, fromStatuses).
+		Updates(update).Error
+}
+
+func (r *notificationRepository) DeliveryStats(ctx context.Context, messageType string, from time.Time, to time.Time) ([]model.DeliveryStatModel, error) {
+	var stats []model.DeliveryStatModel
+	query := r.DB.WithContext(ctx).Model(&entity.Notification{}).
+		Select(`type, country_code, provider, COUNT(*) AS total,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS queued,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS sent,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS delivered,
+			SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS failed`,
+			common.NOTIFICATION_STATUS_QUEUED, common.NOTIFICATION_STATUS_SENT,
+			common.NOTIFICATION_STATUS_DELIVERED, common.NOTIFICATION_STATUS_FAILED).
+		Where("created_at >= ? AND created_at < ?", from, to)
+	if messageType != "" {
+		query = query.Where("type = ?", messageType)
+	}
+	err := query.Group("type, country_code, provider").
+		Order("type, country_code, provider").
+		Scan(&stats).Error
+	return stats, err
+}
@@ -0,0 +1,64 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type oneTimePasswordRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewOneTimePasswordRepository(db *gorm.DB) repository.OneTimePasswordRepository {
+	return &oneTimePasswordRepositoryImpl{DB: db}
+}
+
+func (r *oneTimePasswordRepositoryImpl) Create(ctx context.Context, otp entity.OneTimePassword) (entity.OneTimePassword, error) {
+	err := r.DB.WithContext(ctx).Create(&otp).Error
+	return otp, err
+}
+
+func (r *oneTimePasswordRepositoryImpl) Update(ctx context.Context, otp entity.OneTimePassword) (entity.OneTimePassword, error) {
+	err := r.DB.WithContext(ctx).Save(&otp).Error
+	return otp, err
+}
+
+// FindActive returns the latest unused, unexpired OTP of the user for purpose
+func (r *oneTimePasswordRepositoryImpl) FindActive(ctx context.Context, userId uint, purpose string) (entity.OneTimePassword, error) {
+	var otp entity.OneTimePassword
+	err := r.DB.WithContext(ctx).
+		Where("user_id = ? AND purpose = ? AND is_used = ? AND expired_at > ?", userId, purpose, false, time.Now()).
+		Order("created_at desc").
+		First(&otp).Error
+	return otp, err
+}
+
+// InvalidateActive marks every outstanding OTP of the user for purpose as used
+func (r *oneTimePasswordRepositoryImpl) InvalidateActive(ctx context.Context, userId uint, purpose string) error {
+	return r.DB.WithContext(ctx).Model(&entity.OneTimePassword{}).
+		Where("user_id = ? AND purpose = ? AND is_used = ?", userId, purpose, false).
+		Update("is_used", true).Error
+}
+
+func (r *oneTimePasswordRepositoryImpl) Consume(ctx context.Context, id uint) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&entity.OneTimePassword{}).
+		Where("id = ? AND is_used = ?", id, false).
+		Update("is_used", true)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *oneTimePasswordRepositoryImpl) RecordFailedAttempt(ctx context.Context, id uint, maxAttempts uint) (entity.OneTimePassword, bool, error) {
+	var otp entity.OneTimePassword
+	result := r.DB.WithContext(ctx).Model(&otp).Clauses(clause.Returning{}).
+		Where("id = ? AND is_used = ? AND attempts < ?", id, false, maxAttempts).
+		Updates(map[string]interface{}{
+			"attempts": gorm.Expr("attempts + 1"),
+			"is_used":  gorm.Expr("attempts + 1 >= ?", maxAttempts),
+		})
+	return otp, result.RowsAffected > 0, result.Error
+}
@@ -40,7 +40,7 @@ func (o OrderRepositoryImpl) GetUserOrders(ctx context.Context, u uint) ([]entit
 
 func (o OrderRepositoryImpl) FindByTransactionId(ctx context.Context, transactionId uint) (entity.Order, error) {
 	var order entity.Order
-	err := o.DB.WithContext(ctx).Where("transaction_id = ?", transactionId).First(&order).Error
+	err := dbFor(ctx, &o.DB).Where("transaction_id = ?", transactionId).First(&order).Error
 	if err != nil {
 		return order, err
 	}
@@ -48,7 +48,7 @@ func (o OrderRepositoryImpl) FindByTransactionId(ctx context.Context, transactio
 }
 
 func (o OrderRepositoryImpl) Insert(ctx context.Context, order entity.Order) entity.Order {
-	err := o.DB.WithContext(ctx).Create(&order).Error
+	err := dbFor(ctx, &o.DB).Create(&order).Error
 	if err != nil {
 		return order
 	}
@@ -57,9 +57,10 @@ func (o OrderRepositoryImpl) Insert(ctx context.Context, order entity.Order) ent
 
 func (o OrderRepositoryImpl) FindById(ctx context.Context, id uint) (entity.Order, error) {
 	var order entity.Order
-	err := o.DB.WithContext(ctx).
+	err := dbFor(ctx, &o.DB).
 		Preload("Transaction").
 		Preload("Refinery").
+		Preload("Truck").
 		Preload("Transaction.User").
 		Preload("Transaction.Address").
 		Joins("JOIN tb_transactions ON tb_transactions.id = tb_orders.transaction_id").
@@ -72,7 +73,7 @@ func (o OrderRepositoryImpl) FindById(ctx context.Context, id uint) (entity.Orde
 	return order, nil
 }
 func (o OrderRepositoryImpl) Update(ctx context.Context, order entity.Order) error {
-	err := o.DB.WithContext(ctx).Save(&order).Error
+	err := dbFor(ctx, &o.DB).Save(&order).Error
 	if err != nil {
 		return err
 	}
@@ -191,9 +192,9 @@ func (o *OrderRepositoryImpl) FindInitiatedOrders(ctx context.Context, duration
 	return orders, nil
 }
 
-func (o OrderRepositoryImpl) MarkOrderReadyForDelivery(id string) (entity.Order, error) {
+func (o OrderRepositoryImpl) MarkOrderReadyForDelivery(ctx context.Context, id string) (entity.Order, error) {
 	var order entity.Order
-	err := o.DB.WithContext(context.Background()).
+	err := dbFor(ctx, &o.DB).
 		Preload("Transaction").
 		Preload("Refinery").
 		Preload("Transaction.User").
@@ -211,13 +212,13 @@ func (o OrderRepositoryImpl) MarkOrderReadyForDelivery(id string) (entity.Order,
 		return entity.Order{}, err
 	}
 	order.Status = 3
-	err = o.DB.Save(&order).Error
+	err = dbFor(ctx, &o.DB).Save(&order).Error
 	return order, err
 }
 
-func (o OrderRepositoryImpl) CloseOrder(id string) (entity.Order, error) {
+func (o OrderRepositoryImpl) CloseOrder(ctx context.Context, id string) (entity.Order, error) {
 	var order entity.Order
-	err := o.DB.WithContext(context.Background()).
+	err := dbFor(ctx, &o.DB).
 		Preload("Transaction").
 		Preload("Refinery").
 		Preload("Transaction.User").
@@ -231,6 +232,30 @@ func (o OrderRepositoryImpl) CloseOrder(id string) (entity.Order, error) {
 		return entity.Order{}, err
 	}
 	order.Status = 4
-	err = o.DB.Save(&order).Error
+	err = dbFor(ctx, &o.DB).Save(&order).Error
 	return order, err
 }
+
+// FindByUserId returns every order the customer placed, unlike GetUserOrders
+// it does not require the delivery address to still exist
+func (o OrderRepositoryImpl) FindByUserId(ctx context.Context, userId uint) ([]entity.Order, error) {
+	var orders []entity.Order
+	err := o.DB.WithContext(ctx).
+		Preload("Refinery").
+		Joins("JOIN tb_transactions ON tb_transactions.id = tb_orders.transaction_id").
+		Where("tb_transactions.user_id = ?", userId).
+		Order("tb_orders.id desc").
+		Find(&orders).Error
+	return orders, err
+}
+
+// CountOpenByUserId counts the customer's orders that have not been delivered
+// yet, 4 is the status CloseOrder sets
+func (o OrderRepositoryImpl) CountOpenByUserId(ctx context.Context, userId uint) (int64, error) {
+	var count int64
+	err := o.DB.WithContext(ctx).Model(&entity.Order{}).
+		Joins("JOIN tb_transactions ON tb_transactions.id = tb_orders.transaction_id").
+		Where("tb_transactions.user_id = ? AND tb_orders.status < ?", userId, 4).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,80 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type outboxRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) repository.OutboxRepository {
+	return &outboxRepositoryImpl{DB: db}
+}
+
+func (r *outboxRepositoryImpl) Create(ctx context.Context, events ...entity.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return dbFor(ctx, r.DB).Create(&events).Error
+}
+
+func (r *outboxRepositoryImpl) ClaimRelayable(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]entity.OutboxEvent, error) {
+	var events []entity.OutboxEvent
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// An event waits for every earlier event of its aggregate, including
+		// one that is leased or backing off, which keeps them in order
+		err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND next_attempt_at <= ?", now).
+			Where(`NOT EXISTS (SELECT 1 FROM tb_outbox_events earlier
+				WHERE earlier.aggregate_type = tb_outbox_events.aggregate_type
+				AND earlier.aggregate_id = tb_outbox_events.aggregate_id
+				AND earlier.published_at IS NULL
+				AND earlier.deleted_at IS NULL
+				AND earlier.id < tb_outbox_events.id)`).
+			Order("id").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		ids := make([]uint, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		return tx.Model(&entity.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return events, err
+}
+
+func (r *outboxRepositoryImpl) MarkPublished(ctx context.Context, id uint, publishedAt time.Time) error {
+	return r.DB.WithContext(ctx).Model(&entity.OutboxEvent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"published_at": publishedAt, "last_error": ""}).Error
+}
+
+func (r *outboxRepositoryImpl) MarkFailed(ctx context.Context, id uint, lastError string, nextAttemptAt time.Time) error {
+	return r.DB.WithContext(ctx).Model(&entity.OutboxEvent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+		}).Error
+}
+
+func (r *outboxRepositoryImpl) CountPending(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&entity.OutboxEvent{}).Where("published_at IS NULL").Count(&count).Error
+	return count, err
+}
+
+func (r *outboxRepositoryImpl) DeletePublishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.DB.WithContext(ctx).Unscoped().Where("published_at < ?", cutoff).Delete(&entity.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,28 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+)
+
+type paymentConfigAuditRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewPaymentConfigAuditRepository(db *gorm.DB) repository.PaymentConfigAuditRepository {
+	return &paymentConfigAuditRepositoryImpl{DB: db}
+}
+
+func (r *paymentConfigAuditRepositoryImpl) Create(ctx context.Context, audit entity.PaymentConfigurationAudit) (entity.PaymentConfigurationAudit, error) {
+	err := r.DB.WithContext(ctx).Create(&audit).Error
+	return audit, err
+}
+
+func (r *paymentConfigAuditRepositoryImpl) FindByCountryCode(ctx context.Context, countryCode string) ([]entity.PaymentConfigurationAudit, error) {
+	var audits []entity.PaymentConfigurationAudit
+	err := r.DB.WithContext(ctx).Where("country_code = ?", countryCode).Order("created_at DESC").Find(&audits).Error
+	return audits, err
+}
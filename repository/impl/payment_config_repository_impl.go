@@ -93,6 +93,8 @@ func (r *PaymentConfigRepositoryImpl) UpdatePaymentConfig(ctx context.Context, c
 	// Update fields
 	existing.PublicKey = config.PublicKey
 	existing.SecretKey = config.SecretKey
+	existing.EncryptedDataKey = config.EncryptedDataKey
+	existing.KeyVersion = config.KeyVersion
 	existing.UpdatedAt = time.Now()
 	if err := r.DB.WithContext(ctx).Updates(existing).Error; err != nil {
 		return err
@@ -100,7 +102,7 @@ func (r *PaymentConfigRepositoryImpl) UpdatePaymentConfig(ctx context.Context, c
 
 	// Update cache with default expiry
 	cacheKey := fmt.Sprintf("%s_%s", paymentConfigPrefix, config.CountryCode)
-	if configBytes, err := json.Marshal(config); err == nil {
+	if configBytes, err := json.Marshal(existing); err == nil {
 		r.Redis.Set(ctx, cacheKey, configBytes, defaultCacheExpiry)
 	}
 
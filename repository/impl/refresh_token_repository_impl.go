@@ -0,0 +1,55 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+)
+
+type refreshTokenRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) repository.RefreshTokenRepository {
+	return &refreshTokenRepositoryImpl{DB: db}
+}
+
+func (r *refreshTokenRepositoryImpl) Create(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error) {
+	err := r.DB.WithContext(ctx).Create(&token).Error
+	return token, err
+}
+
+func (r *refreshTokenRepositoryImpl) Update(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error) {
+	err := r.DB.WithContext(ctx).Save(&token).Error
+	return token, err
+}
+
+func (r *refreshTokenRepositoryImpl) FindByTokenHash(ctx context.Context, tokenHash string) (entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	err := r.DB.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	return token, err
+}
+
+func (r *refreshTokenRepositoryImpl) FindByPreviousTokenHash(ctx context.Context, tokenHash string) (entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	err := r.DB.WithContext(ctx).Where("previous_token_hash = ?", tokenHash).First(&token).Error
+	return token, err
+}
+
+func (r *refreshTokenRepositoryImpl) FindByAccessTokenId(ctx context.Context, accessTokenId string) (entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	err := r.DB.WithContext(ctx).Where("access_token_id = ?", accessTokenId).First(&token).Error
+	return token, err
+}
+
+func (r *refreshTokenRepositoryImpl) FindActiveByUserId(ctx context.Context, userId uint) ([]entity.RefreshToken, error) {
+	var tokens []entity.RefreshToken
+	err := r.DB.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now()).
+		Order("last_used_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
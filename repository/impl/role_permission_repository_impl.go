@@ -0,0 +1,66 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type rolePermissionRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewRolePermissionRepository(db *gorm.DB) repository.RolePermissionRepository {
+	return &rolePermissionRepositoryImpl{DB: db}
+}
+
+func (r *rolePermissionRepositoryImpl) FindAll(ctx context.Context) ([]entity.RolePermission, error) {
+	var permissions []entity.RolePermission
+	err := r.DB.WithContext(ctx).Order("role, permission").Find(&permissions).Error
+	return permissions, err
+}
+
+func (r *rolePermissionRepositoryImpl) FindByRole(ctx context.Context, role string) ([]entity.RolePermission, error) {
+	var permissions []entity.RolePermission
+	err := r.DB.WithContext(ctx).Where("role = ?", role).Order("permission").Find(&permissions).Error
+	return permissions, err
+}
+
+// ReplaceForRole swaps the whole permission set of a role in one transaction
+func (r *rolePermissionRepositoryImpl) ReplaceForRole(ctx context.Context, role string, permissions []string) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("role = ?", role).Delete(&entity.RolePermission{}).Error; err != nil {
+			return err
+		}
+		for _, permission := range permissions {
+			if err := tx.Create(&entity.RolePermission{Role: role, Permission: permission}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *rolePermissionRepositoryImpl) IsGranted(ctx context.Context, permission string) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Model(&entity.PermissionGrant{}).Where("permission = ?", permission).Count(&count).Error
+	return count > 0, err
+}
+
+// Grant adds a permission to the given roles and records the grant, roles
+// that already hold the permission are left as they are
+func (r *rolePermissionRepositoryImpl) Grant(ctx context.Context, permission string, roles []string) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, role := range roles {
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&entity.RolePermission{Role: role, Permission: permission}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Create(&entity.PermissionGrant{Permission: permission}).Error
+	})
+}
@@ -0,0 +1,48 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+)
+
+type securityEventRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewSecurityEventRepository(db *gorm.DB) repository.SecurityEventRepository {
+	return &securityEventRepositoryImpl{DB: db}
+}
+
+func (r *securityEventRepositoryImpl) Create(ctx context.Context, event entity.SecurityEvent) (entity.SecurityEvent, error) {
+	err := r.DB.WithContext(ctx).Create(&event).Error
+	return event, err
+}
+
+func (r *securityEventRepositoryImpl) FindPaginated(ctx context.Context, filter model.SecurityEventFilterModel, page, limit int) ([]entity.SecurityEvent, int64, error) {
+	var events []entity.SecurityEvent
+	var totalCount int64
+
+	query := r.DB.WithContext(ctx).Model(&entity.SecurityEvent{})
+	if filter.Username != "" {
+		query = query.Where("username = ?", filter.Username)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+	if filter.IpAddress != "" {
+		query = query.Where("ip_address = ?", filter.IpAddress)
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at desc").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&events).Error
+	return events, totalCount, err
+}
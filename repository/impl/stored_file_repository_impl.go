@@ -0,0 +1,47 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+)
+
+type storedFileRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewStoredFileRepository(db *gorm.DB) repository.StoredFileRepository {
+	return &storedFileRepositoryImpl{DB: db}
+}
+
+func (r *storedFileRepositoryImpl) Create(ctx context.Context, file entity.StoredFile) (entity.StoredFile, error) {
+	err := r.DB.WithContext(ctx).Create(&file).Error
+	return file, err
+}
+
+func (r *storedFileRepositoryImpl) FindById(ctx context.Context, id uint) (entity.StoredFile, error) {
+	var file entity.StoredFile
+	err := r.DB.WithContext(ctx).Where("id = ?", id).First(&file).Error
+	return file, err
+}
+
+func (r *storedFileRepositoryImpl) FindByKey(ctx context.Context, key string) (entity.StoredFile, error) {
+	var file entity.StoredFile
+	err := r.DB.WithContext(ctx).Where("storage_key = ? OR thumbnail_key = ?", key, key).First(&file).Error
+	return file, err
+}
+
+func (r *storedFileRepositoryImpl) FindByReference(ctx context.Context, category string, referenceId uint) ([]entity.StoredFile, error) {
+	var files []entity.StoredFile
+	err := r.DB.WithContext(ctx).
+		Where("category = ? AND reference_id = ?", category, referenceId).
+		Order("created_at desc").
+		Find(&files).Error
+	return files, err
+}
+
+func (r *storedFileRepositoryImpl) Delete(ctx context.Context, file entity.StoredFile) error {
+	return r.DB.WithContext(ctx).Delete(&file).Error
+}
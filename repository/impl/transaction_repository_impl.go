@@ -7,6 +7,7 @@ import (
 
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
 	"gorm.io/gorm"
 )
@@ -75,9 +76,13 @@ func (transactionRepository *transactionRepositoryImpl) GetAdminDashboardData(ct
 
 func (transactionRepository *transactionRepositoryImpl) FindByReference(ctx context.Context, id string) (entity.Transaction, error) {
 	var transaction entity.Transaction
-	result := transactionRepository.DB.Where("tb_transactions.id = ?", id).
+	result := dbFor(ctx, transactionRepository.DB).Where("tb_transactions.id = ?", id).
 		Joins(" join tb_users ON tb_users.id = tb_transactions.user_id").
 		Preload("User").
+		Preload("Address", func(db *gorm.DB) *gorm.DB {
+			// The address may have been removed from the address book since
+			return db.Unscoped()
+		}).
 		First(&transaction)
 	if result.RowsAffected == 0 {
 		return entity.Transaction{}, errors.New("transaction Not Found")
@@ -121,7 +126,7 @@ func (transactionRepository *transactionRepositoryImpl) FindAll(ctx context.Cont
 }
 
 func (transactionRepository *transactionRepositoryImpl) Update(ctx context.Context, transaction entity.Transaction) error {
-	err := transactionRepository.DB.WithContext(ctx).Save(&transaction).Error
+	err := dbFor(ctx, transactionRepository.DB).Save(&transaction).Error
 	if err != nil {
 		return err
 	}
@@ -196,8 +201,8 @@ func (transactionRepository *transactionRepositoryImpl) FindAllPaginated(ctx con
 	return transactions, totalCount
 }
 
-// FindByCountryCode returns transactions filtered by country code or all if code is empty
-func (transactionRepository *transactionRepositoryImpl) FindByCountryCode(ctx context.Context, countryCode string, page, limit int) ([]entity.Transaction, int64) {
+// FindByScope returns transactions filtered by the fields set in scope
+func (transactionRepository *transactionRepositoryImpl) FindByScope(ctx context.Context, scope model.TransactionScopeModel, page, limit int) ([]entity.Transaction, int64) {
 	var transactions []entity.Transaction
 	var totalCount int64
 
@@ -207,8 +212,14 @@ func (transactionRepository *transactionRepositoryImpl) FindByCountryCode(ctx co
 	query := transactionRepository.DB.WithContext(ctx).Model(&entity.Transaction{})
 
 	// Apply country code filter if provided
-	if countryCode != "" {
-		query = query.Where("country_code = ?", countryCode)
+	if scope.CountryCode != "" {
+		query = query.Where("country_code = ?", scope.CountryCode)
+	}
+	if scope.RefineryId != 0 {
+		query = query.Where("id IN (?)", transactionRepository.DB.Model(&entity.Order{}).Select("transaction_id").Where("refinery_id = ?", scope.RefineryId))
+	}
+	if scope.UserId != 0 {
+		query = query.Where("user_id = ?", scope.UserId)
 	}
 
 	// Get total count with the filter applied
@@ -222,3 +233,12 @@ func (transactionRepository *transactionRepositoryImpl) FindByCountryCode(ctx co
 
 	return transactions, totalCount
 }
+
+func (transactionRepository *transactionRepositoryImpl) FindByUserId(ctx context.Context, userId uint) ([]entity.Transaction, error) {
+	var transactions []entity.Transaction
+	err := transactionRepository.DB.WithContext(ctx).
+		Where("user_id = ?", userId).
+		Order("created_at desc").
+		Find(&transactions).Error
+	return transactions, err
+}
@@ -0,0 +1,35 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+)
+
+type transactionKey struct{}
+
+type transactorImpl struct {
+	DB *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) repository.Transactor {
+	return &transactorImpl{DB: db}
+}
+
+func (t *transactorImpl) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return t.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, transactionKey{}, tx))
+	})
+}
+
+// dbFor returns the transaction ctx carries, or db outside of a transaction
+func dbFor(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
@@ -20,9 +20,17 @@ func NewTruckRepositoryImpl(db *gorm.DB) repository.TruckRepository {
 
 }
 
-func (t TruckRepositoryImpl) ListTrucks(ctx context.Context) ([]model.TruckModel, error) {
+// inRefinery limits a truck query to one refinery, 0 leaves it unscoped
+func inRefinery(query *gorm.DB, refineryId uint) *gorm.DB {
+	if refineryId == 0 {
+		return query
+	}
+	return query.Where("tb_trucks.refinery_id = ?", refineryId)
+}
+
+func (t TruckRepositoryImpl) ListTrucks(ctx context.Context, refineryId uint) ([]model.TruckModel, error) {
 	var trucks []entity.Truck
-	err := t.DB.WithContext(ctx).
+	err := inRefinery(t.DB.WithContext(ctx), refineryId).
 		Preload("User").
 		Where("is_active = ?", true).
 		Find(&trucks).Error
@@ -54,8 +62,8 @@ func (t TruckRepositoryImpl) ListTrucks(ctx context.Context) ([]model.TruckModel
 	return truckModels, nil
 }
 
-func (t TruckRepositoryImpl) Create(truck entity.Truck) (entity.Truck, error) {
-	err := t.DB.Create(&truck).Error
+func (t TruckRepositoryImpl) Create(ctx context.Context, truck entity.Truck) (entity.Truck, error) {
+	err := dbFor(ctx, t.DB).Create(&truck).Error
 	exception.PanicLogging(err)
 	return truck, nil
 }
@@ -70,7 +78,10 @@ func (t TruckRepositoryImpl) UpdateTruck(truck model.TruckModel) (model.TruckMod
 	capacity, err := strconv.Atoi(truck.Capacity)
 	truckUpdate.Capacity = capacity
 	truckUpdate.PlateNumber = truck.PlateNumber
-	truckUpdate.UserId = truck.UserId
+	// 0 keeps the driver, refinery staff cannot choose another one
+	if truck.UserId != 0 {
+		truckUpdate.UserId = truck.UserId
+	}
 	err = t.DB.Save(&truckUpdate).Error
 	exception.PanicLogging(err)
 	return truck, nil
@@ -87,17 +98,17 @@ func (t TruckRepositoryImpl) GetActiveTruck(ctx context.Context) (entity.Truck,
 }
 
 // ListTrucksByCountryCode returns active trucks whose owner's area_code matches the provided countryCode.
-func (t TruckRepositoryImpl) ListTrucksByCountryCode(ctx context.Context, countryCode string) ([]model.TruckModel, error) {
+func (t TruckRepositoryImpl) ListTrucksByCountryCode(ctx context.Context, countryCode string, refineryId uint) ([]model.TruckModel, error) {
 	var trucks []entity.Truck
 	var err error
 	if countryCode == "All" {
-		err = t.DB.WithContext(ctx).
+		err = inRefinery(t.DB.WithContext(ctx), refineryId).
 			Preload("User").
 			Joins("JOIN tb_users ON tb_users.id = tb_trucks.user_id").
 			Where("tb_trucks.is_active = ? ", true).
 			Find(&trucks).Error
 	} else {
-		err = t.DB.WithContext(ctx).
+		err = inRefinery(t.DB.WithContext(ctx), refineryId).
 			Preload("User").
 			Joins("JOIN tb_users ON tb_users.id = tb_trucks.user_id").
 			Where("tb_trucks.is_active = ? AND tb_users.country_code = ?", true, countryCode).
@@ -131,3 +142,9 @@ func (t TruckRepositoryImpl) ListTrucksByCountryCode(ctx context.Context, countr
 	}
 	return truckModels, nil
 }
+
+func (t TruckRepositoryImpl) FindById(ctx context.Context, id uint) (entity.Truck, error) {
+	var truck entity.Truck
+	err := t.DB.WithContext(ctx).Where("id = ?", id).First(&truck).Error
+	return truck, err
+}
@@ -0,0 +1,61 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"gorm.io/gorm"
+)
+
+type twoFactorRepositoryImpl struct {
+	DB *gorm.DB
+}
+
+func NewTwoFactorRepository(db *gorm.DB) repository.TwoFactorRepository {
+	return &twoFactorRepositoryImpl{DB: db}
+}
+
+func (r *twoFactorRepositoryImpl) FindByUserId(ctx context.Context, userId uint) (entity.UserTwoFactor, error) {
+	var twoFactor entity.UserTwoFactor
+	err := r.DB.WithContext(ctx).Where("user_id = ?", userId).First(&twoFactor).Error
+	return twoFactor, err
+}
+
+func (r *twoFactorRepositoryImpl) Save(ctx context.Context, twoFactor entity.UserTwoFactor) (entity.UserTwoFactor, error) {
+	err := r.DB.WithContext(ctx).Save(&twoFactor).Error
+	return twoFactor, err
+}
+
+// DeleteByUserId removes the TOTP secret and every recovery code of the user
+func (r *twoFactorRepositoryImpl) DeleteByUserId(ctx context.Context, userId uint) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userId).Delete(&entity.TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", userId).Delete(&entity.UserTwoFactor{}).Error
+	})
+}
+
+// ReplaceRecoveryCodes drops all earlier recovery codes of the user
+func (r *twoFactorRepositoryImpl) ReplaceRecoveryCodes(ctx context.Context, userId uint, codeHashes []string) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userId).Delete(&entity.TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]entity.TwoFactorRecoveryCode, 0, len(codeHashes))
+		for _, codeHash := range codeHashes {
+			codes = append(codes, entity.TwoFactorRecoveryCode{UserId: userId, CodeHash: codeHash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseRecoveryCode marks an unused recovery code as used, reporting whether one matched
+func (r *twoFactorRepositoryImpl) UseRecoveryCode(ctx context.Context, userId uint, codeHash string) (bool, error) {
+	result := r.DB.WithContext(ctx).Model(&entity.TwoFactorRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
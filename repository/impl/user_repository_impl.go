@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
@@ -16,6 +17,7 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func NewUserRepositoryImpl(DB *gorm.DB) repository.UserRepository {
@@ -33,6 +35,10 @@ func (u userRepositoryImpl) Update(ctx context.Context, userData entity.User) (e
 	}
 	return userData, nil
 }
+func (u *userRepositoryImpl) UpdateActiveStatus(ctx context.Context, id uint, isActive bool) error {
+	return u.DB.WithContext(ctx).Model(&entity.User{}).Where("id = ?", id).Update("is_active", isActive).Error
+}
+
 func (u *userRepositoryImpl) FineAddressById(ctx context.Context, id uint) (entity.Address, error) {
 	var address entity.Address
 	err := u.DB.WithContext(ctx).Where("id = ?", id).First(&address).Error
@@ -52,67 +58,6 @@ func (u *userRepositoryImpl) FindByEmailOrPhone(ctx context.Context, userModel m
 	return user, nil
 }
 
-func (u *userRepositoryImpl) FindAllAddress(ctx context.Context, id uint) ([]model.AddressResponseModel, error) {
-	var addresses []entity.Address
-	err := u.Where("user_id = ?", id).Find(&addresses).Error
-	exception.PanicLogging(err)
-	var result []model.AddressResponseModel
-	for _, address := range addresses {
-		result = append(result, model.AddressResponseModel{
-			Description: address.Description,
-			PlaceId:     address.PlaceId,
-			Id:          address.ID,
-			Longitude:   toFloat64(address.Longitude),
-			Latitude:    toFloat64(address.Latitude),
-			CountryCode: address.CountryCode,
-		})
-	}
-	return result, nil
-}
-
-func (u *userRepositoryImpl) SaveAddress(ctx context.Context, request map[string]interface{}) (entity.Address, error) {
-	var user entity.User
-	err := u.DB.WithContext(ctx).Where("id = ?", request["userId"]).First(&user).Error
-	if err != nil {
-		return entity.Address{}, errors.New("user not found")
-	}
-
-	// Check for existing address by PlaceId or Description
-	var existingAddress entity.Address
-	err = u.DB.WithContext(ctx).Where("user_id = ? AND (place_id = ? OR description = ?)", request["userId"], request["placeId"], request["description"]).First(&existingAddress).Error
-	if err == nil {
-		return existingAddress, nil
-	} else if err != gorm.ErrRecordNotFound {
-		return entity.Address{}, err
-	}
-
-	streetNumber := "" //request.Terms[0].Value
-	city := ""
-	street := "  "   //request.Terms[1].Value
-	postalCode := "" //r equest.Terms[0].Value
-	region := ""     // request.Terms[2].Value
-
-	address := entity.Address{
-		StreetNumber: streetNumber,
-		City:         city,
-		Street:       street,
-		IsMain:       true,
-		PostalCode:   postalCode,
-		Region:       region,
-		UserId:       request["userId"].(uint),
-		Description:  request["description"].(string),
-		PlaceId:      request["place_id"].(string),
-		Longitude:    strconv.FormatFloat(request["longitude"].(float64), 'f', -1, 64),
-		Latitude:     strconv.FormatFloat(request["latitude"].(float64), 'f', -1, 64),
-		CountryCode:  request["country_code"].(string),
-	}
-
-	err = u.DB.WithContext(ctx).Create(&address).Error
-	exception.PanicLogging(err)
-
-	return address, nil
-}
-
 func (u *userRepositoryImpl) UpdateProfile(ctx context.Context, request model.UserModel) (model.UserModel, error) {
 	var user entity.User
 	err := u.DB.WithContext(ctx).Where("id = ?", request.Id).First(&user).Error
@@ -140,6 +85,9 @@ func (u *userRepositoryImpl) UpdateProfile(ctx context.Context, request model.Us
 	user.LastName = request.LastName
 	user.Region = request.Region
 	user.CountryCode = request.CountryCode
+	if common.IsSupportedLanguage(request.Language) {
+		user.Language = request.Language
+	}
 
 	err = u.DB.WithContext(ctx).Updates(user).Error
 	if err != nil {
@@ -156,21 +104,28 @@ func (u *userRepositoryImpl) UpdateProfile(ctx context.Context, request model.Us
 	}, nil
 }
 
-func (u *userRepositoryImpl) SetPassword(id int, password string) (model.UserModel, error) {
+// SetPassword joins the transaction in ctx, so that an invitation is only
+// accepted together with the password it was accepted with
+func (u *userRepositoryImpl) SetPassword(ctx context.Context, id int, password string) (model.UserModel, error) {
 	var user entity.User
-	err := u.DB.Where("id = ?", id).First(&user).Error
+	err := dbFor(ctx, u.DB).Where("id = ?", id).First(&user).Error
 	if err != nil {
 		return model.UserModel{}, errors.New("user not found")
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	exception.PanicLogging(err)
+	if err != nil {
+		return model.UserModel{}, err
+	}
 	user.Password = string(hashedPassword)
+	user.PasswordSeeded = false
 	user.IsActive = true
 	user.EmailValidated = true
 
-	err = u.DB.Save(&user).Error
-	exception.PanicLogging(err)
+	err = dbFor(ctx, u.DB).Save(&user).Error
+	if err != nil {
+		return model.UserModel{}, err
+	}
 
 	return model.UserModel{
 		Id:        user.ID,
@@ -206,30 +161,6 @@ func (u *userRepositoryImpl) ResetPassword(ctx context.Context, request model.Us
 	}, nil
 }
 
-func (u *userRepositoryImpl) ValidateOtp(ctx context.Context, request model.OtpModel) (entity.OneTimePassword, error) {
-	var otp entity.OneTimePassword
-	err := u.DB.WithContext(ctx).Where("code = ? and user_id = ?", request.Code, request.UserId).First(&otp).Error
-	if err != nil {
-		return otp, errors.New("invalid OTP")
-	}
-	if otp.IsUsed {
-		return otp, errors.New("OTP already used")
-	}
-	if otp.ExpiredAt.Before(time.Now()) {
-		return otp, errors.New("OTP expired")
-	}
-	if otp.Code != request.Code {
-		return otp, errors.New("invalid OTP code")
-	}
-	otp.IsUsed = true
-	err = u.DB.Save(&otp).Error
-	if err != nil {
-		return otp, errors.New("failed to update OTP status")
-	}
-
-	return otp, nil
-}
-
 func (u *userRepositoryImpl) FindById(ctx context.Context, id int) (entity.User, error) {
 	var user entity.User
 	err := u.DB.WithContext(ctx).Where("id = ?", id).First(&user).Error
@@ -260,16 +191,18 @@ func (u *userRepositoryImpl) ChangePassword(ctx context.Context, claims map[stri
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(passwordModel.NewPassword), bcrypt.DefaultCost)
 	exception.PanicLogging(err)
 	userResult.Password = string(hashedPassword)
+	userResult.PasswordSeeded = false
 	err = u.DB.Save(&userResult).Error
 	exception.PanicLogging(err)
 	return userResult, nil
 }
 
-func (u *userRepositoryImpl) Create(model model.UserModel) (entity.User, error) {
+func (u *userRepositoryImpl) Create(ctx context.Context, model model.UserModel) (entity.User, error) {
+	db := dbFor(ctx, u.DB)
 	var user entity.User
 
 	// First, check if user exists with the same phone number or username
-	err := u.DB.Where("username = ? or phone_number = ?", model.PhoneNumber, model.PhoneNumber).Find(&user).Error
+	err := db.Where("username = ? or phone_number = ?", model.PhoneNumber, model.PhoneNumber).Find(&user).Error
 	if user.Username != "" {
 		return entity.User{}, errors.New("User with phone number already exists")
 	}
@@ -277,7 +210,7 @@ func (u *userRepositoryImpl) Create(model model.UserModel) (entity.User, error)
 	// Check if user exists with the same email address
 	if model.EmailAddress != "" {
 		var userWithEmail entity.User
-		err := u.DB.Where("email = ?", model.EmailAddress).Find(&userWithEmail).Error
+		err := db.Where("email = ?", model.EmailAddress).Find(&userWithEmail).Error
 		if err == nil && userWithEmail.ID != 0 {
 			return entity.User{}, errors.New("User with email address already exists")
 		}
@@ -313,7 +246,7 @@ func (u *userRepositoryImpl) Create(model model.UserModel) (entity.User, error)
 		user.Addresses = addresses
 	}
 
-	err = u.DB.Create(&user).Error
+	err = db.Create(&user).Error
 	exception.PanicLogging(err)
 
 	//Todo: Send email/sms notification to user
@@ -343,62 +276,63 @@ func (u *userRepositoryImpl) Authentication(ctx context.Context, username string
 	return userResult, nil
 }
 
-func (u *userRepositoryImpl) SeedUser(ctx context.Context) {
-	//hashedPassword, err := bcrypt.GenerateFromPassword([]byte("admin"), bcrypt.DefaultCost)
-	//if err != nil {
-	//	return
-	//}
-	adminModel := model.UserModel{
+// seedPasswords are the passwords the seed accounts used to be created with,
+// they are public and must never let anyone in
+var seedPasswords = map[string]string{
+	"08011111111": "admin",
+	"08099999999": "Vnp-1234",
+}
+
+func (u *userRepositoryImpl) SeedUser(ctx context.Context) ([]model.UserModel, error) {
+	seeds := []model.UserModel{{
 		Username:     "08011111111",
-		Password:     "admin",
 		FirstName:    "Admin",
 		LastName:     "User",
 		EmailAddress: "walenewera@gmail.com",
 		PhoneNumber:  "08011111111",
 		Role:         "admin",
-		IsActive:     true,
-	}
-	var user entity.User
-	_ = u.DB.WithContext(ctx).
-		Where("(tb_users.username = ?  or tb_users.phone_number = ?) ", adminModel.Username, adminModel.PhoneNumber).
-		First(&user).Error
-	//exception.PanicLogging(err)
-
-	if user.FirstName == "" {
-		_, err := u.Create(adminModel)
-		if err == nil {
-			logger.Logger.Info("Admin user created successfully")
-		}
-	}
+	}}
 
 	// Seed refinery_admin user attached to the only active refinery
 	var activeRefinery entity.Refinery
 	err := u.DB.WithContext(ctx).Where("is_active = ?", true).First(&activeRefinery).Error
 	if err == nil && activeRefinery.ID != 0 {
-		refineryAdminModel := model.UserModel{
+		seeds = append(seeds, model.UserModel{
 			Username:     "08099999999",
-			Password:     "Vnp-1234",
 			FirstName:    "Refinery",
 			LastName:     "Admin",
 			EmailAddress: "refineryadmin@example.com",
 			PhoneNumber:  "08099999999",
 			Role:         "refinery_admin",
-			IsActive:     true,
 			RefineryId:   activeRefinery.ID,
+		})
+	}
+
+	missing := make([]model.UserModel, 0, len(seeds))
+	for _, seed := range seeds {
+		var user entity.User
+		result := u.DB.WithContext(ctx).
+			Where("(tb_users.username = ?  or tb_users.phone_number = ?)", seed.Username, seed.PhoneNumber).
+			Limit(1).Find(&user)
+		if result.Error != nil {
+			return nil, result.Error
 		}
-		var refineryAdmin entity.User
-		_ = u.DB.WithContext(ctx).
-			Where("(tb_users.username = ?  or tb_users.phone_number = ?)", refineryAdminModel.Username, refineryAdminModel.PhoneNumber).
-			First(&refineryAdmin).Error
-		if refineryAdmin.FirstName == "" {
-			_, err := u.Create(refineryAdminModel)
-			if err == nil {
-				logger.Logger.Info("Refinery admin user created successfully")
-			}
+		if result.RowsAffected == 0 {
+			missing = append(missing, seed)
+			continue
 		}
+		if user.PasswordSeeded || bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(seedPasswords[seed.Username])) != nil {
+			continue
+		}
+		err := u.DB.WithContext(ctx).Model(&entity.User{}).Where("id = ?", user.ID).Update("password_seeded", true).Error
+		if err != nil {
+			return nil, err
+		}
+		logger.Logger.Warn(fmt.Sprintf("Seed account %s still has its default password, reset it before signing in", seed.Username))
 	}
-
+	return missing, nil
 }
+
 func (u *userRepositoryImpl) GetClaimsFromToken(tokenString string) (map[string]interface{}, error) {
 	if tokenString == "" {
 		return nil, fmt.Errorf("missing token")
@@ -424,6 +358,9 @@ func (u *userRepositoryImpl) GetClaimsFromToken(tokenString string) (map[string]
 	return nil, fmt.Errorf("invalid token")
 }
 
+// UpdateFcmToken keeps the single legacy token of apps that do not register
+// their devices and adds it to the user's devices, so that it keeps getting
+// pushes next to the devices registered later
 func (u *userRepositoryImpl) UpdateFcmToken(ctx context.Context, request model.UpdateFcmToken) error {
 	var user entity.User
 	err := u.DB.WithContext(ctx).Where("id = ?", request.Id).First(&user).Error
@@ -432,21 +369,146 @@ func (u *userRepositoryImpl) UpdateFcmToken(ctx context.Context, request model.U
 	}
 	user.FcmToken = request.FcmToken
 	err = u.DB.Save(&user).Error
+	if err != nil || request.FcmToken == "" {
+		return err
+	}
 
-	return err
+	now := time.Now()
+	return u.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "last_seen_at", "updated_at"}),
+	}).Create(&entity.Device{
+		UserId:     user.ID,
+		Token:      request.FcmToken,
+		Platform:   common.DEVICE_PLATFORM_UNKNOWN,
+		LastSeenAt: now,
+	}).Error
 }
-func (u *userRepositoryImpl) FindAllWithFcmToken(ctx context.Context) ([]entity.User, error) {
+
+func (u *userRepositoryImpl) FindByRefineryId(ctx context.Context, refineryId uint) ([]entity.User, error) {
 	var users []entity.User
-	err := u.DB.WithContext(ctx).Where("fcm_token IS NOT NULL AND fcm_token != ''").Find(&users).Error
-	if err != nil {
-		return nil, err
-	}
-	return users, nil
+	err := u.DB.WithContext(ctx).Where("refinery_id = ?", refineryId).Order("created_at").Find(&users).Error
+	return users, err
 }
-func toFloat64(value string) float64 {
-	floatValue, err := strconv.ParseFloat(value, 64)
-	if err != nil {
-		return 0.0 // or handle the error as needed
+
+// Pseudonymise replaces the personal data of a deleted account in one
+// transaction. Amounts, references and statuses on transactions and orders
+// are kept for the financial records, everything that identifies the person
+// is cleared and stored credentials are removed.
+func (u *userRepositoryImpl) Pseudonymise(ctx context.Context, id uint, alias string) error {
+	return u.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user entity.User
+		if err := tx.Where("id = ?", id).First(&user).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		err := tx.Model(&entity.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"username":         alias,
+			"phone_number":     alias,
+			"first_name":       "Deleted",
+			"last_name":        "User",
+			"email":            "",
+			"password":         "",
+			"is_active":        false,
+			"email_validated":  false,
+			"fcm_token":        "",
+			"file_name":        "",
+			"region":           "",
+			"pseudonymised_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&entity.Device{}).Error; err != nil {
+			return err
+		}
+
+		err = tx.Unscoped().Model(&entity.Address{}).Where("user_id = ?", id).Updates(map[string]interface{}{
+			"label":         "",
+			"street":        "",
+			"street_number": "",
+			"description":   "",
+			"postal_code":   "",
+			"place_id":      "",
+			"longitude":     "",
+			"latitude":      "",
+			"raw":           nil,
+			"is_main":       false,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", id).Delete(&entity.Address{}).Error; err != nil {
+			return err
+		}
+
+		err = tx.Model(&entity.Transaction{}).Where("user_id = ?", id).Updates(map[string]interface{}{
+			"phone_number": "",
+			"email":        "",
+			"payment_id":   "",
+			"raw_request":  "",
+			"raw_response": "",
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		// Orders keep the coarse location (city, region, country) for reporting
+		err = tx.Model(&entity.Order{}).
+			Where("transaction_id IN (?)", tx.Model(&entity.Transaction{}).Select("id").Where("user_id = ?", id)).
+			Updates(map[string]interface{}{
+				"delivery_address":  "",
+				"delivery_place_id": "",
+				"address_snapshot":  gorm.Expr("address_snapshot - 'description' - 'placeId' - 'street' - 'longitude' - 'latitude'"),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		// SMS and emails are kept out of the inbox without a user id, they are
+		// found by the addresses they were sent to
+		notifications := tx.Model(&entity.Notification{}).Where("user_id = ?", id)
+		if recipients := notificationRecipients(user); len(recipients) > 0 {
+			notifications = notifications.Or("recipient IN ?", recipients)
+		}
+		err = notifications.Updates(map[string]interface{}{
+			"recipient": "",
+			"subject":   "",
+			"content":   "",
+			"data":      "",
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&entity.PaymentMethod{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&entity.UserTwoFactor{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", id).Delete(&entity.TwoFactorRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", id).Delete(&entity.OneTimePassword{}).Error
+	})
+}
+
+// notificationRecipients lists the addresses messages to the user were sent
+// to, the phone number in the formats SendSMS records it in
+func notificationRecipients(user entity.User) []string {
+	var recipients []string
+	if user.Email != "" {
+		recipients = append(recipients, user.Email)
+	}
+	phoneNumber := strings.TrimSpace(user.PhoneNumber)
+	if phoneNumber == "" {
+		return recipients
+	}
+	recipients = append(recipients, phoneNumber, user.AreaCode+phoneNumber)
+	if areaCode := strings.TrimPrefix(user.AreaCode, "+"); areaCode != "" && phoneNumber[0] != '+' {
+		recipients = append(recipients, "+"+areaCode+strings.TrimPrefix(phoneNumber, "0"))
 	}
-	return floatValue
+	return recipients
 }
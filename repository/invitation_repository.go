@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation entity.Invitation) (entity.Invitation, error)
+	Update(ctx context.Context, invitation entity.Invitation) (entity.Invitation, error)
+	FindById(ctx context.Context, id uint) (entity.Invitation, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (entity.Invitation, error)
+	FindPending(ctx context.Context, page, limit int) ([]entity.Invitation, int64, error)
+	MarkAccepted(ctx context.Context, id uint) (bool, error)
+	RevokePendingByUserId(ctx context.Context, userId uint) error
+}
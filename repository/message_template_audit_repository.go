@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type MessageTemplateAuditRepository interface {
+	Create(ctx context.Context, audit entity.MessageTemplateAudit) (entity.MessageTemplateAudit, error)
+	FindByTemplateId(ctx context.Context, templateId uint) ([]entity.MessageTemplateAudit, error)
+}
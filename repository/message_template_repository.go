@@ -2,15 +2,15 @@ package repository
 
 import (
 	"context"
+
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
-	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 )
 
 type MessageTemplateRepository interface {
 	FindById(context.Context, int) (entity.MessageTemplate, error)
 	FindAll(context.Context) ([]entity.MessageTemplate, error)
-	Create(context.Context, model.MessageTemplateModel) error
-	Update(context.Context, model.MessageTemplateModel) error
-	GenerateOneTImePassword(context.Context, uint) (entity.OneTimePassword, error)
+	Create(ctx context.Context, template entity.MessageTemplate) (entity.MessageTemplate, error)
+	Update(ctx context.Context, template entity.MessageTemplate) (entity.MessageTemplate, error)
+	Delete(ctx context.Context, id uint) error
 	FindByName(ctx context.Context, name string) (entity.MessageTemplate, error)
 }
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type MessageTemplateVersionRepository interface {
+	Create(ctx context.Context, version entity.MessageTemplateVersion) (entity.MessageTemplateVersion, error)
+	Update(ctx context.Context, version entity.MessageTemplateVersion) (entity.MessageTemplateVersion, error)
+	FindById(ctx context.Context, id uint) (entity.MessageTemplateVersion, error)
+	// FindByTemplateId lists the versions newest first, all languages when
+	// language is empty
+	FindByTemplateId(ctx context.Context, templateId uint, language string) ([]entity.MessageTemplateVersion, error)
+	FindPublished(ctx context.Context, templateId uint, language string) (entity.MessageTemplateVersion, error)
+	// FindArchivedBefore returns the newest archived version older than the
+	// given version number
+	FindArchivedBefore(ctx context.Context, templateId uint, language string, version int) (entity.MessageTemplateVersion, error)
+	NextVersionNumber(ctx context.Context, templateId uint, language string) (int, error)
+	CountByTemplateId(ctx context.Context, templateId uint) (int64, error)
+	// Publish makes the version the published one for its language and
+	// archives the version it replaces
+	Publish(ctx context.Context, version entity.MessageTemplateVersion) (entity.MessageTemplateVersion, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type NotificationPreferenceRepository interface {
+	FindByUserId(ctx context.Context, userId uint) ([]entity.NotificationPreference, error)
+	// FindSettingByUserId returns an empty setting for users that never
+	// saved one
+	FindSettingByUserId(ctx context.Context, userId uint) (entity.NotificationSetting, error)
+	// Save upserts the given preferences and the setting in one transaction,
+	// preferences that are not given are left as they are
+	Save(ctx context.Context, userId uint, preferences []entity.NotificationPreference, setting entity.NotificationSetting) error
+}
@@ -2,13 +2,27 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 )
 
 type NotificationRepository interface {
 	Create(ctx context.Context, notification entity.Notification) (entity.Notification, error)
-	FindByUserID(ctx context.Context, userID uint) ([]entity.Notification, error)
+	FindById(ctx context.Context, id uint) (entity.Notification, error)
+	// FindByUserID returns the user's notifications newest first and how many
+	// there are in total, a limit of 0 returns all of them
+	FindByUserID(ctx context.Context, userID uint, page, limit int) ([]entity.Notification, int64, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	// MarkRead marks the unread notifications among ids that belong to the
+	// user as read and returns how many were changed, no ids marks all
+	MarkRead(ctx context.Context, userID uint, ids []uint) (int64, error)
 	FindAll(ctx context.Context, page, size int) ([]entity.Notification, error)
 	CountAll() (int64, error)
+	FindByProviderMessageId(ctx context.Context, provider string, providerMessageId string) (entity.Notification, error)
+	// UpdateStatus applies the non zero fields of update, which include the
+	// new status, only while the notification is in one of fromStatuses
+	UpdateStatus(ctx context.Context, id uint, fromStatuses []string, update entity.Notification) error
+	DeliveryStats(ctx context.Context, messageType string, from time.Time, to time.Time) ([]model.DeliveryStatModel, error)
 }
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type OneTimePasswordRepository interface {
+	Create(ctx context.Context, otp entity.OneTimePassword) (entity.OneTimePassword, error)
+	Update(ctx context.Context, otp entity.OneTimePassword) (entity.OneTimePassword, error)
+	FindActive(ctx context.Context, userId uint, purpose string) (entity.OneTimePassword, error)
+	InvalidateActive(ctx context.Context, userId uint, purpose string) error
+	// Consume marks the OTP as used and reports whether this caller did, so
+	// that a code is only accepted once
+	Consume(ctx context.Context, id uint) (bool, error)
+	// RecordFailedAttempt counts a wrong guess in one statement and burns the
+	// OTP when it reaches maxAttempts. It reports false when the OTP was used
+	// or out of attempts already.
+	RecordFailedAttempt(ctx context.Context, id uint, maxAttempts uint) (entity.OneTimePassword, bool, error)
+}
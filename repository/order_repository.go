@@ -6,6 +6,9 @@ import (
 	"time"
 )
 
+// OrderRepository reads and writes orders. Insert, Update, FindById,
+// FindByTransactionId, MarkOrderReadyForDelivery and CloseOrder join the
+// transaction of ctx, see Transactor.
 type OrderRepository interface {
 	FindByTransactionId(ctx context.Context, transactionId uint) (entity.Order, error)
 	Insert(ctx context.Context, order entity.Order) entity.Order
@@ -17,6 +20,8 @@ type OrderRepository interface {
 	FindCompletedDriverOrdersByUserId(ctx context.Context, id float64, stage uint) ([]entity.Order, error)
 	GetUserOrders(ctx context.Context, u uint) ([]entity.Order, error)
 	FindInitiatedOrders(ctx context.Context, duration time.Duration) ([]entity.Order, error)
-	MarkOrderReadyForDelivery(id string) (entity.Order, error)
-	CloseOrder(id string) (entity.Order, error)
+	MarkOrderReadyForDelivery(ctx context.Context, id string) (entity.Order, error)
+	CloseOrder(ctx context.Context, id string) (entity.Order, error)
+	FindByUserId(ctx context.Context, userId uint) ([]entity.Order, error)
+	CountOpenByUserId(ctx context.Context, userId uint) (int64, error)
 }
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type OutboxRepository interface {
+	// Create joins the transaction of ctx, see Transactor
+	Create(ctx context.Context, events ...entity.OutboxEvent) error
+	// ClaimRelayable returns up to limit events that are due and are the
+	// oldest unpublished event of their aggregate. They are leased until
+	// now+lease so that other relays skip them meanwhile.
+	ClaimRelayable(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]entity.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id uint, publishedAt time.Time) error
+	MarkFailed(ctx context.Context, id uint, lastError string, nextAttemptAt time.Time) error
+	CountPending(ctx context.Context) (int64, error)
+	// DeletePublishedBefore removes published events for good
+	DeletePublishedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type PaymentConfigAuditRepository interface {
+	Create(ctx context.Context, audit entity.PaymentConfigurationAudit) (entity.PaymentConfigurationAudit, error)
+	FindByCountryCode(ctx context.Context, countryCode string) ([]entity.PaymentConfigurationAudit, error)
+}
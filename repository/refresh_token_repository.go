@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error)
+	Update(ctx context.Context, token entity.RefreshToken) (entity.RefreshToken, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (entity.RefreshToken, error)
+	FindByPreviousTokenHash(ctx context.Context, tokenHash string) (entity.RefreshToken, error)
+	FindByAccessTokenId(ctx context.Context, accessTokenId string) (entity.RefreshToken, error)
+	FindActiveByUserId(ctx context.Context, userId uint) ([]entity.RefreshToken, error)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type RolePermissionRepository interface {
+	FindAll(ctx context.Context) ([]entity.RolePermission, error)
+	FindByRole(ctx context.Context, role string) ([]entity.RolePermission, error)
+	ReplaceForRole(ctx context.Context, role string, permissions []string) error
+	IsGranted(ctx context.Context, permission string) (bool, error)
+	Grant(ctx context.Context, permission string, roles []string) error
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+type SecurityEventRepository interface {
+	Create(ctx context.Context, event entity.SecurityEvent) (entity.SecurityEvent, error)
+	FindPaginated(ctx context.Context, filter model.SecurityEventFilterModel, page, limit int) ([]entity.SecurityEvent, int64, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type StoredFileRepository interface {
+	Create(ctx context.Context, file entity.StoredFile) (entity.StoredFile, error)
+	FindById(ctx context.Context, id uint) (entity.StoredFile, error)
+	// FindByKey matches either the file or its thumbnail
+	FindByKey(ctx context.Context, key string) (entity.StoredFile, error)
+	FindByReference(ctx context.Context, category string, referenceId uint) ([]entity.StoredFile, error)
+	Delete(ctx context.Context, file entity.StoredFile) error
+}
@@ -5,8 +5,11 @@ import (
 	"time"
 
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 )
 
+// TransactionRepository reads and writes payment transactions. Update and
+// FindByReference join the transaction of ctx, see Transactor.
 type TransactionRepository interface {
 	Insert(ctx context.Context, transaction entity.Transaction) entity.Transaction
 	Delete(ctx context.Context, transaction entity.Transaction)
@@ -18,5 +21,6 @@ type TransactionRepository interface {
 	GetRefineryDashboardData(ctx context.Context, u uint) (map[string]interface{}, error)
 	GetAdminDashboardData(ctx context.Context) map[string]interface{}
 	FindPendingTransactionsOlderThan(ctx context.Context, duration time.Duration) ([]entity.Transaction, error)
-	FindByCountryCode(ctx context.Context, countryCode string, page, limit int) ([]entity.Transaction, int64)
+	FindByScope(ctx context.Context, scope model.TransactionScopeModel, page, limit int) ([]entity.Transaction, int64)
+	FindByUserId(ctx context.Context, userId uint) ([]entity.Transaction, error)
 }
@@ -0,0 +1,10 @@
+package repository
+
+import "context"
+
+// Transactor runs fn in a database transaction that commits when fn returns
+// nil. Repositories called with the context fn receives take part in the
+// transaction, nested calls join the outer one.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -7,9 +7,10 @@ import (
 )
 
 type TruckRepository interface {
-	ListTrucks(ctx context.Context) ([]model.TruckModel, error)
-	Create(truck entity.Truck) (entity.Truck, error)
+	ListTrucks(ctx context.Context, refineryId uint) ([]model.TruckModel, error)
+	Create(ctx context.Context, truck entity.Truck) (entity.Truck, error)
 	UpdateTruck(truck model.TruckModel) (model.TruckModel, error)
 	GetActiveTruck(ctx context.Context) (entity.Truck, error)
-	ListTrucksByCountryCode(ctx context.Context, countryCode string) ([]model.TruckModel, error)
+	ListTrucksByCountryCode(ctx context.Context, countryCode string, refineryId uint) ([]model.TruckModel, error)
+	FindById(ctx context.Context, id uint) (entity.Truck, error)
 }
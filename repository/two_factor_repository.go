@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+)
+
+type TwoFactorRepository interface {
+	FindByUserId(ctx context.Context, userId uint) (entity.UserTwoFactor, error)
+	Save(ctx context.Context, twoFactor entity.UserTwoFactor) (entity.UserTwoFactor, error)
+	DeleteByUserId(ctx context.Context, userId uint) error
+	ReplaceRecoveryCodes(ctx context.Context, userId uint, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userId uint, codeHash string) (bool, error)
+}
@@ -10,20 +10,21 @@ import (
 type UserRepository interface {
 	Authentication(ctx context.Context, username string) (entity.User, error)
 	ChangePassword(ctx context.Context, claims map[string]interface{}, passwordModel model.ChangePasswordModel) (entity.User, error)
-	Create(model model.UserModel) (entity.User, error)
+	Create(ctx context.Context, model model.UserModel) (entity.User, error)
 	DeleteAll()
-	SeedUser(ctx context.Context)
+	// SeedUser returns the seed accounts that do not exist yet and flags the
+	// existing ones that still have their old default password
+	SeedUser(ctx context.Context) ([]model.UserModel, error)
 	List(ctx context.Context) ([]entity.User, error)
 	FindById(ctx context.Context, id int) (entity.User, error)
-	ValidateOtp(ctx context.Context, request model.OtpModel) (entity.OneTimePassword, error)
 	ResetPassword(ctx context.Context, request model.UserModel) (model.UserModel, error)
-	SetPassword(id int, password string) (model.UserModel, error)
+	SetPassword(ctx context.Context, id int, password string) (model.UserModel, error)
 	UpdateProfile(ctx context.Context, request model.UserModel) (model.UserModel, error)
-	SaveAddress(ctx context.Context, request map[string]interface{}) (entity.Address, error)
-	FindAllAddress(ctx context.Context, id uint) ([]model.AddressResponseModel, error)
 	FindByEmailOrPhone(ctx context.Context, userModel model.UserModel) (entity.User, error)
 	UpdateFcmToken(ctx context.Context, request model.UpdateFcmToken) error
 	FineAddressById(ctx context.Context, id uint) (entity.Address, error)
-	FindAllWithFcmToken(ctx context.Context) ([]entity.User, error)
+	FindByRefineryId(ctx context.Context, refineryId uint) ([]entity.User, error)
 	Update(ctx context.Context, user entity.User) (entity.User, error)
+	UpdateActiveStatus(ctx context.Context, id uint, isActive bool) error
+	Pseudonymise(ctx context.Context, id uint, alias string) error
 }
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// AccessPolicyService decides whether the user in claims may act on a
+// specific record. Route permissions say what a role may do in general, the
+// policies here narrow that to the records the user is related to.
+type AccessPolicyService interface {
+	CanReadOrder(ctx context.Context, claims map[string]interface{}, orderId uint) error
+	CanManageOrder(ctx context.Context, claims map[string]interface{}, orderId uint) error
+	CanDeliverOrder(ctx context.Context, claims map[string]interface{}, orderId uint) error
+	CanRateOrder(ctx context.Context, claims map[string]interface{}, orderId uint) error
+	CanReadUser(ctx context.Context, claims map[string]interface{}, userId uint) error
+	CanWriteUser(ctx context.Context, claims map[string]interface{}, userId uint) error
+	CanUseAddress(ctx context.Context, claims map[string]interface{}, addressId uint) error
+	CanManageTruck(ctx context.Context, claims map[string]interface{}, truckId uint) error
+	CanAssignTruck(ctx context.Context, claims map[string]interface{}, orderId uint, truckId uint) error
+	CanReadTransaction(ctx context.Context, claims map[string]interface{}, transactionId string) error
+	CanReadTransactionDetail(ctx context.Context, claims map[string]interface{}, transactionDetailId string) error
+	TransactionScope(ctx context.Context, claims map[string]interface{}) model.TransactionScopeModel
+}
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// AccountService covers the account holder's own data rights, exporting a
+// copy of their personal data and deleting the account
+type AccountService interface {
+	RequestExport(ctx context.Context, userId uint) (model.DataExportModel, error)
+	ListExports(ctx context.Context, userId uint) ([]model.DataExportModel, error)
+	GetExport(ctx context.Context, userId uint, id uint) (model.DataExportModel, error)
+	// ExportFile returns the path of a completed, unexpired bundle and the file
+	// name to download it as
+	ExportFile(ctx context.Context, userId uint, id uint) (string, string, error)
+	// ProcessExport builds the bundle, it is called by the export job consumer
+	ProcessExport(ctx context.Context, id uint) error
+	DeleteAccount(ctx context.Context, userId uint, request model.DeleteAccountModel, client model.SessionClientModel) error
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// AddressService manages the address book of the signed in customer
+type AddressService interface {
+	List(ctx context.Context, userId uint) ([]model.AddressResponseModel, error)
+	Get(ctx context.Context, userId uint, id uint) (model.AddressResponseModel, error)
+	Create(ctx context.Context, userId uint, request model.AddressRequestModel) (model.AddressResponseModel, error)
+	Update(ctx context.Context, userId uint, id uint, request model.AddressRequestModel) (model.AddressResponseModel, error)
+	Delete(ctx context.Context, userId uint, id uint) error
+	SetMain(ctx context.Context, userId uint, id uint) error
+}
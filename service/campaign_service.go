@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// CampaignService sends marketing campaigns to the customers of a segment.
+// The scheduler queues the recipients of due campaigns in batches on
+// common.CAMPAIGN_DELIVERY_TOPIC and ProcessBatch delivers them at a limited
+// rate through the NotificationDispatcher, which honours opt-outs and quiet
+// hours.
+type CampaignService interface {
+	Create(ctx context.Context, request model.CampaignRequestModel, actor model.AuditActor) (model.CampaignModel, error)
+	// Update changes a campaign that has not started sending yet
+	Update(ctx context.Context, id uint, request model.CampaignRequestModel) (model.CampaignModel, error)
+	Get(ctx context.Context, id uint) (model.CampaignModel, error)
+	List(ctx context.Context, status string, page, limit int) ([]model.CampaignModel, int64, error)
+	// PreviewAudience counts the users a segment currently selects
+	PreviewAudience(ctx context.Context, segment model.CampaignSegmentModel) (model.CampaignAudienceModel, error)
+	Schedule(ctx context.Context, id uint, request model.CampaignScheduleModel) (model.CampaignModel, error)
+	// Cancel stops a campaign, batches that were not delivered yet are dropped
+	Cancel(ctx context.Context, id uint) (model.CampaignModel, error)
+	Stats(ctx context.Context, id uint) (model.CampaignStatsModel, error)
+	// MarkOpened records that the user opened a campaign sent to them
+	MarkOpened(ctx context.Context, id uint, userId uint) error
+	// QueueDue queues the batches of the campaigns that are due and returns
+	// how many campaigns were queued
+	QueueDue(ctx context.Context) (int, error)
+	ProcessBatch(ctx context.Context, batch model.CampaignBatchModel) error
+	// StartScheduler queues due campaigns until StopScheduler is called
+	StartScheduler()
+	StopScheduler()
+}
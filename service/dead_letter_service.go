@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// DeadLetterService inspects the messages a broker gave up on after their
+// last retry. Topics are the routing keys consumers subscribe to, an empty
+// list of message ids applies an action to every dead letter of the topic.
+type DeadLetterService interface {
+	ListDeadLetterQueues(ctx context.Context) ([]model.DeadLetterQueueModel, error)
+	ListDeadLetters(ctx context.Context, topic string, limit int) ([]model.DeadLetterModel, error)
+	// ReplayDeadLetters puts the messages back on the queue of the topic with
+	// a fresh attempt count
+	ReplayDeadLetters(ctx context.Context, topic string, messageIds []string) (int, error)
+	PurgeDeadLetters(ctx context.Context, topic string, messageIds []string) (int, error)
+}
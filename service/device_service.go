@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// DeviceService keeps the registry of the devices a user gets pushes on.
+// Apps register their token on every start, which also marks the device as
+// seen, and remove it when the user signs out.
+type DeviceService interface {
+	Register(ctx context.Context, userId uint, request model.RegisterDeviceModel) (model.DeviceModel, error)
+	List(ctx context.Context, userId uint) ([]model.DeviceModel, error)
+	Remove(ctx context.Context, userId uint, id uint) error
+}
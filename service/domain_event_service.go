@@ -0,0 +1,11 @@
+package service
+
+import "context"
+
+// DomainEventService records the domain events other systems consume. The
+// payload is validated against the latest schema version of the event type
+// and added to the outbox, so Record has to be called with the context of
+// the transaction that made the change.
+type DomainEventService interface {
+	Record(ctx context.Context, eventType string, aggregateId uint, payload interface{}) error
+}
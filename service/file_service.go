@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// FileService validates uploads, keeps them in the FileStorage and hands out
+// signed, expiring URLs to read them
+type FileService interface {
+	UploadProfilePhoto(ctx context.Context, userId uint, upload model.FileUploadModel) (model.StoredFileModel, error)
+	// DeleteProfilePhotos removes the user's photos, used when the account
+	// is deleted
+	DeleteProfilePhotos(ctx context.Context, userId uint) error
+	UploadDriverDocument(ctx context.Context, truckId uint, documentType string, upload model.FileUploadModel, uploadedBy uint) (model.StoredFileModel, error)
+	ListDriverDocuments(ctx context.Context, truckId uint) ([]model.StoredFileModel, error)
+	DeleteDriverDocument(ctx context.Context, truckId uint, id uint) error
+	UploadDeliveryPhoto(ctx context.Context, orderId uint, upload model.FileUploadModel, uploadedBy uint) (model.StoredFileModel, error)
+	ListDeliveryPhotos(ctx context.Context, orderId uint) ([]model.StoredFileModel, error)
+	// SignedUrl returns a URL for the key that stops working after
+	// FILE_URL_TTL_MINUTES, or an empty string for an empty key
+	SignedUrl(key string) string
+	// OpenSigned verifies a signed URL and opens the file, returning its
+	// content type
+	OpenSigned(ctx context.Context, key string, expires string, signature string) (io.ReadCloser, string, error)
+}
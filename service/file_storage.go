@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"io"
+)
+
+// FileStorage stores uploaded files by key. Keys are slash separated paths
+// such as "profile_photo/12/<uuid>.jpg", the backend is chosen through the
+// FILE_STORAGE_DRIVER configuration.
+type FileStorage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,249 @@
+package impl
+
+import (
+	"context"
+	"errors"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"gorm.io/gorm"
+)
+
+type accessPolicyServiceImpl struct {
+	repository.OrderRepository
+	repository.UserRepository
+	service.AuthorizationService
+	transactionRepository       repository.TransactionRepository
+	transactionDetailRepository repository.TransactionDetailRepository
+	truckRepository             repository.TruckRepository
+}
+
+// NewAccessPolicyService creates a new access policy service instance
+func NewAccessPolicyService(orderRepository repository.OrderRepository, userRepository repository.UserRepository, transactionRepository repository.TransactionRepository, transactionDetailRepository repository.TransactionDetailRepository, truckRepository repository.TruckRepository, authorizationService service.AuthorizationService) service.AccessPolicyService {
+	return &accessPolicyServiceImpl{
+		OrderRepository:             orderRepository,
+		UserRepository:              userRepository,
+		AuthorizationService:        authorizationService,
+		transactionRepository:       transactionRepository,
+		transactionDetailRepository: transactionDetailRepository,
+		truckRepository:             truckRepository,
+	}
+}
+
+// CanReadOrder allows the customer who placed the order, the driver of the
+// assigned truck, staff of the fulfilling refinery and anyone who may read all
+// transactions
+func (a *accessPolicyServiceImpl) CanReadOrder(ctx context.Context, claims map[string]interface{}, orderId uint) error {
+	order, err := a.findOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if a.AuthorizationService.HasPermission(ctx, claims, common.TRANSACTION_READ_ALL_PERMISSION) {
+		return nil
+	}
+	if isCustomerOf(claims, order) || isDriverOf(claims, order) || isStaffOf(claims, order) {
+		return nil
+	}
+	return accessDenied()
+}
+
+// CanManageOrder allows staff of the refinery fulfilling the order to approve,
+// reject and dispatch it
+func (a *accessPolicyServiceImpl) CanManageOrder(ctx context.Context, claims map[string]interface{}, orderId uint) error {
+	order, err := a.findOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if a.AuthorizationService.CanAccessRefinery(ctx, claims, order.RefineryId) {
+		return nil
+	}
+	return accessDenied()
+}
+
+// CanDeliverOrder allows the driver of the assigned truck to close the order,
+// the fulfilling refinery may close it on the driver's behalf
+func (a *accessPolicyServiceImpl) CanDeliverOrder(ctx context.Context, claims map[string]interface{}, orderId uint) error {
+	order, err := a.findOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if isDriverOf(claims, order) || a.AuthorizationService.CanAccessRefinery(ctx, claims, order.RefineryId) {
+		return nil
+	}
+	return accessDenied()
+}
+
+// CanRateOrder allows only the customer who placed the order to rate it
+func (a *accessPolicyServiceImpl) CanRateOrder(ctx context.Context, claims map[string]interface{}, orderId uint) error {
+	order, err := a.findOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if isCustomerOf(claims, order) {
+		return nil
+	}
+	return accessDenied()
+}
+
+func (a *accessPolicyServiceImpl) CanReadUser(ctx context.Context, claims map[string]interface{}, userId uint) error {
+	if isSelf(claims, userId) || a.AuthorizationService.HasPermission(ctx, claims, common.USER_READ_PERMISSION) {
+		return nil
+	}
+	return accessDenied()
+}
+
+func (a *accessPolicyServiceImpl) CanWriteUser(ctx context.Context, claims map[string]interface{}, userId uint) error {
+	if isSelf(claims, userId) || a.AuthorizationService.HasPermission(ctx, claims, common.USER_WRITE_PERMISSION) {
+		return nil
+	}
+	return accessDenied()
+}
+
+// CanUseAddress allows users to quote and order against their own saved
+// addresses only
+func (a *accessPolicyServiceImpl) CanUseAddress(ctx context.Context, claims map[string]interface{}, addressId uint) error {
+	address, err := a.UserRepository.FineAddressById(ctx, addressId)
+	if err != nil {
+		return exception.NotFoundError{Message: "Address not found"}
+	}
+	if isSelf(claims, address.UserId) || a.AuthorizationService.HasPermission(ctx, claims, common.USER_READ_PERMISSION) {
+		return nil
+	}
+	return accessDenied()
+}
+
+// CanManageTruck allows staff of the refinery the truck is registered to and
+// anyone who manages all refineries. Trucks registered before they were tied
+// to a refinery are left to the latter.
+func (a *accessPolicyServiceImpl) CanManageTruck(ctx context.Context, claims map[string]interface{}, truckId uint) error {
+	truck, err := a.truckRepository.FindById(ctx, truckId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return exception.NotFoundError{Message: "Truck not found"}
+	}
+	if err != nil {
+		return err
+	}
+	if a.AuthorizationService.HasPermission(ctx, claims, common.REFINERY_MANAGE_PERMISSION) {
+		return nil
+	}
+	if truck.RefineryId != 0 && a.AuthorizationService.CanAccessRefinery(ctx, claims, truck.RefineryId) {
+		return nil
+	}
+	return accessDenied()
+}
+
+// CanAssignTruck allows whoever may manage the order to assign one of the
+// trucks of the order's refinery. The driver of the truck gets access to the
+// order, so a truck of another refinery is refused whoever assigns it.
+func (a *accessPolicyServiceImpl) CanAssignTruck(ctx context.Context, claims map[string]interface{}, orderId uint, truckId uint) error {
+	if err := a.CanManageOrder(ctx, claims, orderId); err != nil {
+		return err
+	}
+	order, err := a.findOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	truck, err := a.truckRepository.FindById(ctx, truckId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return exception.NotFoundError{Message: "Truck not found"}
+	}
+	if err != nil {
+		return err
+	}
+	if truck.RefineryId == 0 || truck.RefineryId != order.RefineryId {
+		return exception.BadRequestError{Message: "The truck does not belong to the refinery of the order"}
+	}
+	return nil
+}
+
+// CanReadTransaction allows the customer who paid, staff of the refinery the
+// payment is for and anyone who may read all transactions
+func (a *accessPolicyServiceImpl) CanReadTransaction(ctx context.Context, claims map[string]interface{}, transactionId string) error {
+	transaction, err := a.transactionRepository.FindByReference(ctx, transactionId)
+	if err != nil {
+		return exception.NotFoundError{Message: "Transaction not found"}
+	}
+	if a.AuthorizationService.HasPermission(ctx, claims, common.TRANSACTION_READ_ALL_PERMISSION) {
+		return nil
+	}
+	if isSelf(claims, transaction.UserId) {
+		return nil
+	}
+	if refineryId := claimsRefineryId(claims); refineryId != 0 && transaction.RefineryId == refineryId {
+		return nil
+	}
+	return accessDenied()
+}
+
+// CanReadTransactionDetail allows only those who may read all transactions,
+// a detail is keyed by the id of the legacy product transactions and cannot
+// be traced to a customer or refinery
+func (a *accessPolicyServiceImpl) CanReadTransactionDetail(ctx context.Context, claims map[string]interface{}, transactionDetailId string) error {
+	if _, err := a.transactionDetailRepository.FindById(ctx, transactionDetailId); err != nil {
+		return exception.NotFoundError{Message: "Transaction detail not found"}
+	}
+	if a.AuthorizationService.HasPermission(ctx, claims, common.TRANSACTION_READ_ALL_PERMISSION) {
+		return nil
+	}
+	return accessDenied()
+}
+
+// TransactionScope restricts transaction listings to the caller's country,
+// further narrowed to their refinery or to their own transactions unless they
+// may read all transactions
+func (a *accessPolicyServiceImpl) TransactionScope(ctx context.Context, claims map[string]interface{}) model.TransactionScopeModel {
+	scope := model.TransactionScopeModel{}
+	scope.CountryCode, _ = claims["countryCode"].(string)
+	if a.AuthorizationService.HasPermission(ctx, claims, common.TRANSACTION_READ_ALL_PERMISSION) {
+		return scope
+	}
+	if refineryId := claimsRefineryId(claims); refineryId != 0 {
+		scope.RefineryId = refineryId
+		return scope
+	}
+	scope.UserId = claimsUserId(claims)
+	return scope
+}
+
+func (a *accessPolicyServiceImpl) findOrder(ctx context.Context, orderId uint) (entity.Order, error) {
+	order, err := a.OrderRepository.FindById(ctx, orderId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return order, exception.NotFoundError{Message: "Order not found"}
+	}
+	return order, err
+}
+
+func isSelf(claims map[string]interface{}, userId uint) bool {
+	return userId != 0 && claimsUserId(claims) == userId
+}
+
+func isCustomerOf(claims map[string]interface{}, order entity.Order) bool {
+	return isSelf(claims, order.Transaction.UserId)
+}
+
+func isDriverOf(claims map[string]interface{}, order entity.Order) bool {
+	return order.TruckId != 0 && isSelf(claims, order.Truck.UserId)
+}
+
+func isStaffOf(claims map[string]interface{}, order entity.Order) bool {
+	refineryId := claimsRefineryId(claims)
+	return refineryId != 0 && order.RefineryId == refineryId
+}
+
+func claimsUserId(claims map[string]interface{}) uint {
+	userId, _ := claims["userId"].(float64)
+	return uint(userId)
+}
+
+func claimsRefineryId(claims map[string]interface{}) uint {
+	refineryId, _ := claims["refineryId"].(float64)
+	return uint(refineryId)
+}
+
+func accessDenied() error {
+	return exception.ForbiddenError{Message: "Access denied"}
+}
@@ -0,0 +1,299 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"gorm.io/gorm"
+)
+
+const (
+	policyCustomerId      = 10
+	policyOtherUserId     = 11
+	policyDriverId        = 20
+	policyRefineryId      = 30
+	policyOtherRefineryId = 31
+	policyTransactionId   = "40"
+	policyOrderId         = 50
+	policyDetailId        = "detail-1"
+	policyMissingOrderId  = 99
+	policyTruckId         = 60
+	policyLegacyTruckId   = 61
+	policyForeignTruckId  = 62
+)
+
+// The fakes embed the interfaces they stand in for, calling a method the
+// policies are not expected to use panics on the nil interface
+
+type policyOrderRepository struct {
+	repository.OrderRepository
+}
+
+func (policyOrderRepository) FindById(ctx context.Context, id uint) (entity.Order, error) {
+	if id != policyOrderId {
+		return entity.Order{}, gorm.ErrRecordNotFound
+	}
+	order := entity.Order{RefineryId: policyRefineryId, TruckId: 1}
+	order.ID = policyOrderId
+	order.Transaction.UserId = policyCustomerId
+	order.Truck.UserId = policyDriverId
+	return order, nil
+}
+
+type policyTransactionRepository struct {
+	repository.TransactionRepository
+}
+
+func (policyTransactionRepository) FindByReference(ctx context.Context, id string) (entity.Transaction, error) {
+	if id != policyTransactionId {
+		return entity.Transaction{}, errors.New("transaction Not Found")
+	}
+	return entity.Transaction{UserId: policyCustomerId, RefineryId: policyRefineryId}, nil
+}
+
+type policyTransactionDetailRepository struct {
+	repository.TransactionDetailRepository
+}
+
+func (policyTransactionDetailRepository) FindById(ctx context.Context, id string) (entity.TransactionDetail, error) {
+	if id != policyDetailId {
+		return entity.TransactionDetail{}, errors.New("transaction Detail Not Found")
+	}
+	return entity.TransactionDetail{}, nil
+}
+
+type policyTruckRepository struct {
+	repository.TruckRepository
+}
+
+func (policyTruckRepository) FindById(ctx context.Context, id uint) (entity.Truck, error) {
+	switch id {
+	case policyTruckId:
+		return entity.Truck{UserId: policyDriverId, RefineryId: policyRefineryId}, nil
+	case policyLegacyTruckId:
+		return entity.Truck{UserId: policyDriverId}, nil
+	case policyForeignTruckId:
+		return entity.Truck{UserId: policyOtherUserId, RefineryId: policyOtherRefineryId}, nil
+	}
+	return entity.Truck{}, gorm.ErrRecordNotFound
+}
+
+// policyAuthorizationService grants the default permissions of each role
+type policyAuthorizationService struct {
+	service.AuthorizationService
+}
+
+func (policyAuthorizationService) HasPermission(ctx context.Context, claims map[string]interface{}, permission string) bool {
+	role, _ := claims["role"].(string)
+	return contains(common.DefaultRolePermissions[role], permission)
+}
+
+func (a policyAuthorizationService) CanAccessRefinery(ctx context.Context, claims map[string]interface{}, refineryId uint) bool {
+	if a.HasPermission(ctx, claims, common.REFINERY_MANAGE_PERMISSION) {
+		return true
+	}
+	return claimsRefineryId(claims) == refineryId
+}
+
+func newTestAccessPolicy() service.AccessPolicyService {
+	return NewAccessPolicyService(policyOrderRepository{}, nil, policyTransactionRepository{}, policyTransactionDetailRepository{}, policyTruckRepository{}, policyAuthorizationService{})
+}
+
+func policyClaims(role string, userId uint, refineryId uint) map[string]interface{} {
+	claims := map[string]interface{}{"role": role, "userId": float64(userId)}
+	if refineryId != 0 {
+		claims["refineryId"] = float64(refineryId)
+	}
+	return claims
+}
+
+var (
+	policyOwner         = policyClaims(common.CUSTOMER_ROLE, policyCustomerId, 0)
+	policyOtherCustomer = policyClaims(common.CUSTOMER_ROLE, policyOtherUserId, 0)
+	policyDriver        = policyClaims(common.TRUCK_DRIVER_ROLE, policyDriverId, 0)
+	policyRefineryStaff = policyClaims(common.REFINERY_ADMIN_ROLE, policyOtherUserId, policyRefineryId)
+	policyOtherRefinery = policyClaims(common.REFINERY_ADMIN_ROLE, policyOtherUserId, policyOtherRefineryId)
+	policyAdmin         = policyClaims(common.ADMIN_ROLE, policyOtherUserId, 0)
+)
+
+type policyCase struct {
+	name    string
+	claims  map[string]interface{}
+	allowed bool
+}
+
+func assertPolicy(t *testing.T, cases []policyCase, check func(map[string]interface{}) error) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := check(tc.claims)
+			if tc.allowed && err != nil {
+				t.Fatalf("expected access, got %v", err)
+			}
+			if !tc.allowed {
+				var forbidden exception.ForbiddenError
+				if !errors.As(err, &forbidden) {
+					t.Fatalf("expected access to be denied, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestCanReadOrder(t *testing.T) {
+	policy := newTestAccessPolicy()
+	assertPolicy(t, []policyCase{
+		{"owner", policyOwner, true},
+		{"other customer", policyOtherCustomer, false},
+		{"driver of the truck", policyDriver, true},
+		{"staff of the refinery", policyRefineryStaff, true},
+		{"staff of another refinery", policyOtherRefinery, false},
+		{"admin", policyAdmin, true},
+	}, func(claims map[string]interface{}) error {
+		return policy.CanReadOrder(context.Background(), claims, policyOrderId)
+	})
+}
+
+func TestCanManageOrder(t *testing.T) {
+	policy := newTestAccessPolicy()
+	assertPolicy(t, []policyCase{
+		{"owner", policyOwner, false},
+		{"driver of the truck", policyDriver, false},
+		{"staff of the refinery", policyRefineryStaff, true},
+		{"staff of another refinery", policyOtherRefinery, false},
+		{"admin", policyAdmin, true},
+	}, func(claims map[string]interface{}) error {
+		return policy.CanManageOrder(context.Background(), claims, policyOrderId)
+	})
+}
+
+func TestCanRateOrder(t *testing.T) {
+	policy := newTestAccessPolicy()
+	assertPolicy(t, []policyCase{
+		{"owner", policyOwner, true},
+		{"other customer", policyOtherCustomer, false},
+		{"staff of the refinery", policyRefineryStaff, false},
+		{"admin", policyAdmin, false},
+	}, func(claims map[string]interface{}) error {
+		return policy.CanRateOrder(context.Background(), claims, policyOrderId)
+	})
+}
+
+func TestCanReadOrderNotFound(t *testing.T) {
+	err := newTestAccessPolicy().CanReadOrder(context.Background(), policyAdmin, policyMissingOrderId)
+	var notFound exception.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestCanReadTransaction(t *testing.T) {
+	policy := newTestAccessPolicy()
+	assertPolicy(t, []policyCase{
+		{"owner", policyOwner, true},
+		{"other customer", policyOtherCustomer, false},
+		{"driver", policyDriver, false},
+		{"staff of the refinery", policyRefineryStaff, true},
+		{"staff of another refinery", policyOtherRefinery, false},
+		{"admin", policyAdmin, true},
+	}, func(claims map[string]interface{}) error {
+		return policy.CanReadTransaction(context.Background(), claims, policyTransactionId)
+	})
+
+	err := policy.CanReadTransaction(context.Background(), policyAdmin, "41")
+	var notFound exception.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestCanReadTransactionDetail(t *testing.T) {
+	policy := newTestAccessPolicy()
+	assertPolicy(t, []policyCase{
+		{"customer", policyOwner, false},
+		{"staff of a refinery", policyRefineryStaff, false},
+		{"admin", policyAdmin, true},
+	}, func(claims map[string]interface{}) error {
+		return policy.CanReadTransactionDetail(context.Background(), claims, policyDetailId)
+	})
+}
+
+func TestCanReadAndWriteUser(t *testing.T) {
+	policy := newTestAccessPolicy()
+	assertPolicy(t, []policyCase{
+		{"self", policyOwner, true},
+		{"other customer", policyOtherCustomer, false},
+		{"staff of a refinery", policyRefineryStaff, false},
+		{"admin", policyAdmin, true},
+	}, func(claims map[string]interface{}) error {
+		if err := policy.CanReadUser(context.Background(), claims, policyCustomerId); err != nil {
+			return err
+		}
+		return policy.CanWriteUser(context.Background(), claims, policyCustomerId)
+	})
+}
+
+func TestCanManageTruck(t *testing.T) {
+	policy := newTestAccessPolicy()
+	assertPolicy(t, []policyCase{
+		{"staff of the refinery", policyRefineryStaff, true},
+		{"staff of another refinery", policyOtherRefinery, false},
+		{"driver", policyDriver, false},
+		{"customer", policyOwner, false},
+		{"admin", policyAdmin, true},
+	}, func(claims map[string]interface{}) error {
+		return policy.CanManageTruck(context.Background(), claims, policyTruckId)
+	})
+
+	// A truck without a refinery must not match claims without one either
+	assertPolicy(t, []policyCase{
+		{"staff of a refinery", policyRefineryStaff, false},
+		{"customer", policyOwner, false},
+		{"admin", policyAdmin, true},
+	}, func(claims map[string]interface{}) error {
+		return policy.CanManageTruck(context.Background(), claims, policyLegacyTruckId)
+	})
+
+	err := policy.CanManageTruck(context.Background(), policyAdmin, policyMissingOrderId)
+	var notFound exception.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestCanAssignTruck(t *testing.T) {
+	policy := newTestAccessPolicy()
+	assertPolicy(t, []policyCase{
+		{"staff of the refinery", policyRefineryStaff, true},
+		{"staff of another refinery", policyOtherRefinery, false},
+		{"driver", policyDriver, false},
+		{"customer", policyOwner, false},
+		{"admin", policyAdmin, true},
+	}, func(claims map[string]interface{}) error {
+		return policy.CanAssignTruck(context.Background(), claims, policyOrderId, policyTruckId)
+	})
+
+	// The driver of a truck of another refinery would get access to the
+	// order, nobody may assign it
+	for _, truckId := range []uint{policyForeignTruckId, policyLegacyTruckId} {
+		for _, claims := range []map[string]interface{}{policyRefineryStaff, policyAdmin} {
+			err := policy.CanAssignTruck(context.Background(), claims, policyOrderId, truckId)
+			var badRequest exception.BadRequestError
+			if !errors.As(err, &badRequest) {
+				t.Errorf("assigning truck %d: expected a bad request, got %v", truckId, err)
+			}
+		}
+	}
+
+	err := policy.CanAssignTruck(context.Background(), policyAdmin, policyOrderId, policyMissingOrderId)
+	var notFound exception.NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
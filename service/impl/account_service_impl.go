@@ -0,0 +1,447 @@
+package impl
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type accountServiceImpl struct {
+	repository.DataExportRepository
+	repository.UserRepository
+	repository.AddressRepository
+	repository.OrderRepository
+	repository.TransactionRepository
+	repository.NotificationRepository
+	service.MessageBrokerService
+	service.TokenService
+	service.InvitationService
+	service.SecurityService
+	service.FileService
+	configuration.Config
+}
+
+func NewAccountService(dataExportRepository repository.DataExportRepository, userRepository repository.UserRepository, addressRepository repository.AddressRepository, orderRepository repository.OrderRepository, transactionRepository repository.TransactionRepository, notificationRepository repository.NotificationRepository, messageBrokerService service.MessageBrokerService, tokenService service.TokenService, invitationService service.InvitationService, securityService service.SecurityService, fileService service.FileService, config configuration.Config) service.AccountService {
+	return &accountServiceImpl{
+		DataExportRepository:   dataExportRepository,
+		UserRepository:         userRepository,
+		AddressRepository:      addressRepository,
+		OrderRepository:        orderRepository,
+		TransactionRepository:  transactionRepository,
+		NotificationRepository: notificationRepository,
+		MessageBrokerService:   messageBrokerService,
+		TokenService:           tokenService,
+		InvitationService:      invitationService,
+		SecurityService:        securityService,
+		FileService:            fileService,
+		Config:                 config,
+	}
+}
+
+// RequestExport queues a new export, a user can only have one in progress
+func (a *accountServiceImpl) RequestExport(ctx context.Context, userId uint) (model.DataExportModel, error) {
+	unfinished, err := a.DataExportRepository.FindUnfinishedByUserId(ctx, userId)
+	if err != nil {
+		return model.DataExportModel{}, err
+	}
+	if len(unfinished) > 0 {
+		return model.DataExportModel{}, exception.BadRequestError{Message: "An export is already in progress"}
+	}
+
+	export, err := a.DataExportRepository.Create(ctx, entity.DataExport{
+		UserId: userId,
+		Status: common.DATA_EXPORT_STATUS_PENDING,
+	})
+	if err != nil {
+		return model.DataExportModel{}, err
+	}
+
+	if err := a.MessageBrokerService.PublishMessage(common.DATA_EXPORT_TOPIC, model.DataExportJobModel{ExportId: export.ID}); err != nil {
+		a.fail(ctx, export, err)
+		return model.DataExportModel{}, errors.New("failed to queue the data export")
+	}
+	return dataExportModel(export), nil
+}
+
+func (a *accountServiceImpl) ListExports(ctx context.Context, userId uint) ([]model.DataExportModel, error) {
+	exports, err := a.DataExportRepository.FindByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.DataExportModel, 0, len(exports))
+	for _, export := range exports {
+		result = append(result, dataExportModel(export))
+	}
+	return result, nil
+}
+
+func (a *accountServiceImpl) GetExport(ctx context.Context, userId uint, id uint) (model.DataExportModel, error) {
+	export, err := a.findOwnExport(ctx, userId, id)
+	if err != nil {
+		return model.DataExportModel{}, err
+	}
+	return dataExportModel(export), nil
+}
+
+func (a *accountServiceImpl) ExportFile(ctx context.Context, userId uint, id uint) (string, string, error) {
+	export, err := a.findOwnExport(ctx, userId, id)
+	if err != nil {
+		return "", "", err
+	}
+	if export.Status == common.DATA_EXPORT_STATUS_EXPIRED || (export.ExpiresAt != nil && time.Now().After(*export.ExpiresAt)) {
+		return "", "", exception.BadRequestError{Message: "The export has expired, please request a new one"}
+	}
+	if export.Status != common.DATA_EXPORT_STATUS_COMPLETED {
+		return "", "", exception.BadRequestError{Message: "The export is not ready yet"}
+	}
+	return export.FilePath, fmt.Sprintf("aquawizz-data-%s.zip", export.CreatedAt.Format("2006-01-02")), nil
+}
+
+func (a *accountServiceImpl) ProcessExport(ctx context.Context, id uint) error {
+	export, err := a.DataExportRepository.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The account was deleted after the export was requested
+			return nil
+		}
+		return err
+	}
+	// A redelivered job for an export that was already picked up
+	if export.Status != common.DATA_EXPORT_STATUS_PENDING {
+		return nil
+	}
+
+	export.Status = common.DATA_EXPORT_STATUS_PROCESSING
+	if export, err = a.DataExportRepository.Update(ctx, export); err != nil {
+		return err
+	}
+	a.purgeExpired(ctx)
+
+	path, size, err := a.writeBundle(ctx, export)
+	if err != nil {
+		a.fail(ctx, export, err)
+		return nil
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(intConfig(a.Config, "DATA_EXPORT_TTL_HOURS", 72)) * time.Hour)
+	export.Status = common.DATA_EXPORT_STATUS_COMPLETED
+	export.FilePath = path
+	export.FileSize = size
+	export.CompletedAt = &now
+	export.ExpiresAt = &expiresAt
+	_, err = a.DataExportRepository.Update(ctx, export)
+	return err
+}
+
+// DeleteAccount pseudonymises the caller's account after confirming their
+// password. Only customers can delete themselves, staff accounts are removed
+// by their administrators.
+func (a *accountServiceImpl) DeleteAccount(ctx context.Context, userId uint, request model.DeleteAccountModel, client model.SessionClientModel) error {
+	user, err := a.UserRepository.FindById(ctx, int(userId))
+	if err != nil {
+		return err
+	}
+	if user.PseudonymisedAt != nil {
+		return exception.BadRequestError{Message: "The account has already been deleted"}
+	}
+	if user.UserRole != common.CUSTOMER_ROLE {
+		return exception.ForbiddenError{Message: "Staff accounts can only be removed by an administrator"}
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(request.Password)) != nil {
+		return exception.BadRequestError{Message: "Incorrect password"}
+	}
+
+	openOrders, err := a.OrderRepository.CountOpenByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if openOrders > 0 {
+		return exception.BadRequestError{Message: "The account cannot be deleted while it has orders that have not been delivered"}
+	}
+
+	if err := a.removeExports(ctx, userId); err != nil {
+		return err
+	}
+	if err := a.InvitationService.RevokeForUser(ctx, userId); err != nil {
+		return err
+	}
+	if err := a.FileService.DeleteProfilePhotos(ctx, userId); err != nil {
+		return err
+	}
+
+	alias := fmt.Sprintf("del-%d", userId)
+	if err := a.UserRepository.Pseudonymise(ctx, userId, alias); err != nil {
+		return err
+	}
+	if err := a.TokenService.RevokeAllSessions(ctx, userId); err != nil {
+		return err
+	}
+
+	// The event is logged under the alias so the log keeps no personal data
+	a.SecurityService.LogEvent(ctx, model.SecurityEventModel{
+		UserId:    userId,
+		Username:  alias,
+		EventType: common.SECURITY_EVENT_ACCOUNT_DELETED,
+		Reason:    "deleted by the account holder",
+		IpAddress: client.IpAddress,
+		UserAgent: client.UserAgent,
+	})
+	return nil
+}
+
+func (a *accountServiceImpl) findOwnExport(ctx context.Context, userId uint, id uint) (entity.DataExport, error) {
+	export, err := a.DataExportRepository.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.DataExport{}, exception.NotFoundError{Message: "Export not found"}
+		}
+		return entity.DataExport{}, err
+	}
+	if export.UserId != userId {
+		return entity.DataExport{}, exception.NotFoundError{Message: "Export not found"}
+	}
+	return export, nil
+}
+
+func (a *accountServiceImpl) fail(ctx context.Context, export entity.DataExport, cause error) {
+	logger.Logger.Error(fmt.Sprintf("Data export %d failed: %s", export.ID, cause.Error()))
+	export.Status = common.DATA_EXPORT_STATUS_FAILED
+	export.Error = truncate(cause.Error(), 255)
+	if _, err := a.DataExportRepository.Update(ctx, export); err != nil {
+		logger.Logger.Error("Failed to record data export failure: " + err.Error())
+	}
+}
+
+// purgeExpired removes the files of exports past their download window
+func (a *accountServiceImpl) purgeExpired(ctx context.Context) {
+	exports, err := a.DataExportRepository.FindExpired(ctx, time.Now())
+	if err != nil {
+		logger.Logger.Error("Failed to load expired data exports: " + err.Error())
+		return
+	}
+	for _, export := range exports {
+		if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+			logger.Logger.Error("Failed to remove expired data export: " + err.Error())
+			continue
+		}
+		export.Status = common.DATA_EXPORT_STATUS_EXPIRED
+		export.FilePath = ""
+		if _, err := a.DataExportRepository.Update(ctx, export); err != nil {
+			logger.Logger.Error("Failed to mark data export expired: " + err.Error())
+		}
+	}
+}
+
+func (a *accountServiceImpl) removeExports(ctx context.Context, userId uint) error {
+	exports, err := a.DataExportRepository.FindByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if export.FilePath == "" {
+			continue
+		}
+		if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return a.DataExportRepository.DeleteByUserId(ctx, userId)
+}
+
+// writeBundle collects the user's data and writes it as a ZIP of JSON files.
+// The directory must not be one that is served statically.
+func (a *accountServiceImpl) writeBundle(ctx context.Context, export entity.DataExport) (string, int64, error) {
+	files, err := a.collect(ctx, export.UserId)
+	if err != nil {
+		return "", 0, err
+	}
+
+	directory := a.Config.Get("DATA_EXPORT_DIR")
+	if directory == "" {
+		directory = "./exports"
+	}
+	if err := os.MkdirAll(directory, 0o700); err != nil {
+		return "", 0, err
+	}
+	path := filepath.Join(directory, fmt.Sprintf("data-export-%d.zip", export.ID))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+	archive := zip.NewWriter(file)
+	for _, name := range []string{"profile", "addresses", "orders", "transactions", "notifications", "ratings"} {
+		writer, err := archive.Create(name + ".json")
+		if err == nil {
+			encoder := json.NewEncoder(writer)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(files[name])
+		}
+		if err != nil {
+			archive.Close()
+			file.Close()
+			os.Remove(path)
+			return "", 0, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		file.Close()
+		os.Remove(path)
+		return "", 0, err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return "", 0, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
+
+// collect loads the contents of each bundle file. Transactions leave out the
+// raw gateway requests and responses.
+func (a *accountServiceImpl) collect(ctx context.Context, userId uint) (map[string]interface{}, error) {
+	user, err := a.UserRepository.FindById(ctx, int(userId))
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := a.AddressRepository.FindByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := a.OrderRepository.FindByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := a.TransactionRepository.FindByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	notifications, _, err := a.NotificationRepository.FindByUserID(ctx, userId, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	addressModels := make([]model.AddressResponseModel, 0, len(addresses))
+	for _, address := range addresses {
+		addressModels = append(addressModels, addressResponse(address))
+	}
+
+	orderModels := make([]model.ExportOrderModel, 0, len(orders))
+	ratings := make([]model.ExportRatingModel, 0)
+	for _, order := range orders {
+		var address interface{} = order.DeliveryAddress
+		if !order.AddressSnapshot.IsEmpty() {
+			address = order.AddressSnapshot
+		}
+		orderModels = append(orderModels, model.ExportOrderModel{
+			Id:              order.ID,
+			TransactionId:   order.TransactionId,
+			Refinery:        order.Refinery.Name,
+			Amount:          order.Amount,
+			WaterCost:       order.WaterCost,
+			DeliveryFee:     order.DeliveryFee,
+			Currency:        order.Currency,
+			Capacity:        order.Capacity,
+			Type:            order.Type,
+			WaterType:       order.WaterType,
+			Status:          order.Status,
+			DeliveryAddress: address,
+			CreatedAt:       order.CreatedAt,
+		})
+		if order.Rating > 0 || order.Review != "" {
+			ratings = append(ratings, model.ExportRatingModel{
+				OrderId:   order.ID,
+				Rating:    order.Rating,
+				Review:    order.Review,
+				UpdatedAt: order.UpdatedAt,
+			})
+		}
+	}
+
+	transactionModels := make([]model.ExportTransactionModel, 0, len(transactions))
+	for _, transaction := range transactions {
+		transactionModels = append(transactionModels, model.ExportTransactionModel{
+			Id:          transaction.ID,
+			Reference:   transaction.Reference,
+			Amount:      transaction.Amount,
+			WaterCost:   transaction.WaterCost,
+			DeliveryFee: transaction.DeliveryFee,
+			TotalAmount: transaction.TotalAmount,
+			Currency:    transaction.Currency,
+			Provider:    transaction.Provider,
+			PaymentType: transaction.PaymentType,
+			Scheme:      transaction.Scheme,
+			PhoneNumber: transaction.PhoneNumber,
+			Email:       transaction.Email,
+			Status:      transaction.Status,
+			CreatedAt:   transaction.CreatedAt,
+			CompletedAt: transaction.CompletedAt,
+		})
+	}
+
+	notificationModels := make([]model.ExportNotificationModel, 0, len(notifications))
+	for _, notification := range notifications {
+		notificationModels = append(notificationModels, model.ExportNotificationModel{
+			Type:      string(notification.Type),
+			Recipient: notification.Recipient,
+			Subject:   notification.Subject,
+			Content:   notification.Content,
+			Status:    notification.Status,
+			SentAt:    notification.SentAt,
+		})
+	}
+
+	return map[string]interface{}{
+		"profile": model.ExportProfileModel{
+			Id:          user.ID,
+			Username:    user.Username,
+			FirstName:   user.FirstName,
+			LastName:    user.LastName,
+			PhoneNumber: user.PhoneNumber,
+			AreaCode:    user.AreaCode,
+			Email:       user.Email,
+			Role:        user.UserRole,
+			CountryCode: user.CountryCode,
+			Region:      user.Region,
+			CreatedAt:   user.CreatedAt,
+		},
+		"addresses":     addressModels,
+		"orders":        orderModels,
+		"transactions":  transactionModels,
+		"notifications": notificationModels,
+		"ratings":       ratings,
+	}, nil
+}
+
+func dataExportModel(export entity.DataExport) model.DataExportModel {
+	return model.DataExportModel{
+		Id:          export.ID,
+		Status:      export.Status,
+		FileSize:    export.FileSize,
+		Error:       export.Error,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+	}
+}
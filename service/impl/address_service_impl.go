@@ -0,0 +1,230 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"gorm.io/gorm"
+)
+
+type addressServiceImpl struct {
+	repository.AddressRepository
+	service.LocalGovernmentService
+}
+
+func NewAddressService(addressRepository repository.AddressRepository, localGovernmentService service.LocalGovernmentService) service.AddressService {
+	return &addressServiceImpl{
+		AddressRepository:      addressRepository,
+		LocalGovernmentService: localGovernmentService,
+	}
+}
+
+func (a *addressServiceImpl) List(ctx context.Context, userId uint) ([]model.AddressResponseModel, error) {
+	addresses, err := a.AddressRepository.FindByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.AddressResponseModel, 0, len(addresses))
+	for _, address := range addresses {
+		result = append(result, addressResponse(address))
+	}
+	return result, nil
+}
+
+func (a *addressServiceImpl) Get(ctx context.Context, userId uint, id uint) (model.AddressResponseModel, error) {
+	address, err := a.findOwned(ctx, userId, id)
+	if err != nil {
+		return model.AddressResponseModel{}, err
+	}
+	return addressResponse(address), nil
+}
+
+// Create adds an address to the book. Saving a place that is already in the
+// book returns the existing entry instead of a duplicate. The first address
+// becomes the main address.
+func (a *addressServiceImpl) Create(ctx context.Context, userId uint, request model.AddressRequestModel) (model.AddressResponseModel, error) {
+	if err := a.normalise(ctx, &request, nil); err != nil {
+		return model.AddressResponseModel{}, err
+	}
+
+	address, err := a.AddressRepository.FindByPlaceId(ctx, userId, request.PlaceId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.AddressResponseModel{}, err
+	}
+	if address.ID == 0 {
+		existing, err := a.AddressRepository.FindByUserId(ctx, userId)
+		if err != nil {
+			return model.AddressResponseModel{}, err
+		}
+		request.IsMain = request.IsMain || len(existing) == 0
+
+		address = entity.Address{UserId: userId}
+		applyAddressRequest(&address, request)
+		address, err = a.AddressRepository.Create(ctx, address)
+		if err != nil {
+			return model.AddressResponseModel{}, err
+		}
+	}
+
+	if request.IsMain && !address.IsMain {
+		if err := a.AddressRepository.SetMain(ctx, userId, address.ID); err != nil {
+			return model.AddressResponseModel{}, err
+		}
+		address.IsMain = true
+	}
+	return addressResponse(address), nil
+}
+
+func (a *addressServiceImpl) Update(ctx context.Context, userId uint, id uint, request model.AddressRequestModel) (model.AddressResponseModel, error) {
+	address, err := a.findOwned(ctx, userId, id)
+	if err != nil {
+		return model.AddressResponseModel{}, err
+	}
+	if err := a.normalise(ctx, &request, &address); err != nil {
+		return model.AddressResponseModel{}, err
+	}
+
+	if request.PlaceId != address.PlaceId {
+		duplicate, err := a.AddressRepository.FindByPlaceId(ctx, userId, request.PlaceId)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.AddressResponseModel{}, err
+		}
+		if duplicate.ID != 0 {
+			return model.AddressResponseModel{}, exception.BadRequestError{Message: "This place is already in your address book"}
+		}
+	}
+
+	applyAddressRequest(&address, request)
+	address, err = a.AddressRepository.Update(ctx, address)
+	if err != nil {
+		return model.AddressResponseModel{}, err
+	}
+
+	// The main flag is only ever set here, it moves away when another
+	// address is chosen as main
+	if request.IsMain && !address.IsMain {
+		if err := a.AddressRepository.SetMain(ctx, userId, address.ID); err != nil {
+			return model.AddressResponseModel{}, err
+		}
+		address.IsMain = true
+	}
+	return addressResponse(address), nil
+}
+
+// Delete removes the address from the book. When it was the main address the
+// most recently added remaining address takes its place.
+func (a *addressServiceImpl) Delete(ctx context.Context, userId uint, id uint) error {
+	address, err := a.findOwned(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+	if err := a.AddressRepository.Delete(ctx, address); err != nil {
+		return err
+	}
+	if !address.IsMain {
+		return nil
+	}
+
+	remaining, err := a.AddressRepository.FindByUserId(ctx, userId)
+	if err != nil || len(remaining) == 0 {
+		return err
+	}
+	return a.AddressRepository.SetMain(ctx, userId, remaining[0].ID)
+}
+
+func (a *addressServiceImpl) SetMain(ctx context.Context, userId uint, id uint) error {
+	if _, err := a.findOwned(ctx, userId, id); err != nil {
+		return err
+	}
+	return a.AddressRepository.SetMain(ctx, userId, id)
+}
+
+func (a *addressServiceImpl) findOwned(ctx context.Context, userId uint, id uint) (entity.Address, error) {
+	address, err := a.AddressRepository.FindById(ctx, id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.Address{}, err
+	}
+	if address.ID == 0 || address.UserId != userId {
+		return entity.Address{}, exception.NotFoundError{Message: "Address not found"}
+	}
+	return address, nil
+}
+
+// normalise validates the request, fills in the label and looks up missing
+// coordinates. Existing is the address being updated, if any.
+func (a *addressServiceImpl) normalise(ctx context.Context, request *model.AddressRequestModel, existing *entity.Address) error {
+	request.PlaceId = strings.TrimSpace(request.PlaceId)
+	if request.PlaceId == "" {
+		return exception.BadRequestError{Message: "placeId is required"}
+	}
+
+	request.Label = strings.ToLower(strings.TrimSpace(request.Label))
+	if request.Label == "" {
+		request.Label = common.ADDRESS_LABEL_HOME
+		if existing != nil && existing.Label != "" {
+			request.Label = existing.Label
+		}
+	}
+	if !contains(common.AddressLabels, request.Label) {
+		return exception.BadRequestError{Message: "label must be one of " + strings.Join(common.AddressLabels, ", ")}
+	}
+
+	request.CountryCode = strings.ToUpper(strings.TrimSpace(request.CountryCode))
+	bounds, ok := common.SupportedCountryBounds[request.CountryCode]
+	if !ok {
+		return exception.BadRequestError{Message: "Unsupported country " + request.CountryCode}
+	}
+
+	if request.Latitude == 0 && request.Longitude == 0 {
+		if existing != nil && existing.PlaceId == request.PlaceId {
+			request.Latitude = common.ToFloat64(existing.Latitude)
+			request.Longitude = common.ToFloat64(existing.Longitude)
+		} else {
+			location := a.LocalGovernmentService.GetPlaceDetail(ctx, request.PlaceId).Result.Geometry.Location
+			request.Latitude = location.Lat
+			request.Longitude = location.Lng
+		}
+	}
+	if !bounds.Contains(request.Latitude, request.Longitude) {
+		return exception.BadRequestError{Message: "The coordinates are not in " + request.CountryCode}
+	}
+	return nil
+}
+
+func applyAddressRequest(address *entity.Address, request model.AddressRequestModel) {
+	address.Label = request.Label
+	address.Description = request.Description
+	address.PlaceId = request.PlaceId
+	address.Street = request.Street
+	address.City = request.City
+	address.Region = request.Region
+	address.PostalCode = request.PostalCode
+	address.CountryCode = request.CountryCode
+	address.Longitude = strconv.FormatFloat(request.Longitude, 'f', -1, 64)
+	address.Latitude = strconv.FormatFloat(request.Latitude, 'f', -1, 64)
+}
+
+func addressResponse(address entity.Address) model.AddressResponseModel {
+	return model.AddressResponseModel{
+		Id:          address.ID,
+		Label:       address.Label,
+		IsMain:      address.IsMain,
+		Description: address.Description,
+		PlaceId:     address.PlaceId,
+		Street:      address.Street,
+		City:        address.City,
+		Region:      address.Region,
+		PostalCode:  address.PostalCode,
+		Longitude:   common.ToFloat64(address.Longitude),
+		Latitude:    common.ToFloat64(address.Latitude),
+		CountryCode: address.CountryCode,
+	}
+}
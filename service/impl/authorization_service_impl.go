@@ -1,87 +1,197 @@
 package impl
 
 import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 )
 
-type authorizationServiceImpl struct{}
+const rolePermissionCachePrefix = "role_permissions:"
+
+type authorizationServiceImpl struct {
+	repository.RolePermissionRepository
+	service.RedisService
+}
 
 // NewAuthorizationService creates a new authorization service instance
-func NewAuthorizationService() service.AuthorizationService {
-	return &authorizationServiceImpl{}
+func NewAuthorizationService(rolePermissionRepository repository.RolePermissionRepository, redisService service.RedisService) service.AuthorizationService {
+	return &authorizationServiceImpl{
+		RolePermissionRepository: rolePermissionRepository,
+		RedisService:             redisService,
+	}
 }
 
-// IsAdmin checks if the user has admin privileges
-func (a *authorizationServiceImpl) IsAdmin(claims map[string]interface{}) bool {
+// HasPermission checks whether the role in claims has been granted permission
+func (a *authorizationServiceImpl) HasPermission(ctx context.Context, claims map[string]interface{}, permission string) bool {
 	if claims == nil {
 		return false
 	}
+	role, _ := claims["role"].(string)
+	if role == "" {
+		return false
+	}
 
-	// Check the role in claims
-	if claims["roles"] != nil {
-		roles, ok := claims["roles"].([]interface{})
-		if ok && len(roles) > 0 {
-			for _, role := range roles {
-				if roleMap, ok := role.(map[string]interface{}); ok {
-					if roleVal, exists := roleMap["role"].(string); exists && roleVal == "admin" {
-						return true
-					}
-				}
-			}
+	for _, granted := range a.permissionsForRole(ctx, role) {
+		if granted == permission {
+			return true
 		}
 	}
+	return false
+}
+
+// CanAccessRefinery checks if the user can access a specific refinery
+func (a *authorizationServiceImpl) CanAccessRefinery(ctx context.Context, claims map[string]interface{}, refineryId uint) bool {
+	if claims == nil {
+		return false
+	}
 
-	// Check user role field directly
-	if userRole, exists := claims["role"].(string); exists && userRole == "admin" {
+	// Users who manage refineries can access all of them
+	if a.HasPermission(ctx, claims, common.REFINERY_MANAGE_PERMISSION) {
 		return true
 	}
 
+	// Check if user is associated with this refinery
+	if userRefineryId, ok := claims["refineryId"].(float64); ok {
+		return uint(userRefineryId) == refineryId
+	}
+
 	return false
 }
 
-// HasRole checks if the user has a specific role
-func (a *authorizationServiceImpl) HasRole(claims map[string]interface{}, role string) bool {
-	if claims == nil {
-		return false
+func (a *authorizationServiceImpl) ListPermissions() []string {
+	return common.Permissions
+}
+
+func (a *authorizationServiceImpl) ListRolePermissions(ctx context.Context) ([]model.RolePermissionModel, error) {
+	rows, err := a.RolePermissionRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check the role in claims
-	if claims["roles"] != nil {
-		roles, ok := claims["roles"].([]interface{})
-		if ok && len(roles) > 0 {
-			for _, r := range roles {
-				if roleMap, ok := r.(map[string]interface{}); ok {
-					if roleVal, exists := roleMap["role"].(string); exists && roleVal == role {
-						return true
-					}
-				}
+	grouped := make(map[string][]string)
+	for _, row := range rows {
+		grouped[row.Role] = append(grouped[row.Role], row.Permission)
+	}
+
+	var result []model.RolePermissionModel
+	for _, role := range common.Roles {
+		result = append(result, model.RolePermissionModel{Role: role, Permissions: grouped[role]})
+	}
+	return result, nil
+}
+
+func (a *authorizationServiceImpl) UpdateRolePermissions(ctx context.Context, role string, permissions []string) (model.RolePermissionModel, error) {
+	if !contains(common.Roles, role) {
+		return model.RolePermissionModel{}, exception.NotFoundError{Message: "Role not found"}
+	}
+
+	unique := make(map[string]bool)
+	for _, permission := range permissions {
+		if !contains(common.Permissions, permission) {
+			return model.RolePermissionModel{}, exception.BadRequestError{Message: "Unknown permission " + permission}
+		}
+		unique[permission] = true
+	}
+	if role == common.ADMIN_ROLE {
+		for _, permission := range common.LockedAdminPermissions {
+			if !unique[permission] {
+				return model.RolePermissionModel{}, exception.BadRequestError{Message: "The admin role must keep the " + permission + " permission"}
 			}
 		}
 	}
 
-	// Check user role field directly
-	if userRole, exists := claims["role"].(string); exists && userRole == role {
-		return true
+	var granted []string
+	for permission := range unique {
+		granted = append(granted, permission)
 	}
+	sort.Strings(granted)
 
-	return false
+	if err := a.RolePermissionRepository.ReplaceForRole(ctx, role, granted); err != nil {
+		return model.RolePermissionModel{}, err
+	}
+	if err := a.RedisService.Delete(rolePermissionCachePrefix + role); err != nil {
+		logger.Logger.Error("Failed to invalidate permission cache for role " + role + ": " + err.Error())
+	}
+
+	return model.RolePermissionModel{Role: role, Permissions: granted}, nil
 }
 
-// CanAccessRefinery checks if the user can access a specific refinery
-func (a *authorizationServiceImpl) CanAccessRefinery(claims map[string]interface{}, refineryId uint) bool {
-	if claims == nil {
-		return false
+// SeedRolePermissions stores the default mapping for roles that have none yet
+func (a *authorizationServiceImpl) SeedRolePermissions(ctx context.Context) {
+	for _, role := range common.Roles {
+		existing, err := a.RolePermissionRepository.FindByRole(ctx, role)
+		exception.PanicLogging(err)
+		if len(existing) > 0 {
+			continue
+		}
+		err = a.RolePermissionRepository.ReplaceForRole(ctx, role, common.DefaultRolePermissions[role])
+		exception.PanicLogging(err)
+		logger.Logger.Info("Seeded default permissions for role " + role)
 	}
 
-	// Admin can access all refineries
-	if a.IsAdmin(claims) {
-		return true
+	for _, permission := range common.IntroducedPermissions {
+		granted, err := a.RolePermissionRepository.IsGranted(ctx, permission)
+		exception.PanicLogging(err)
+		if granted {
+			continue
+		}
+
+		var roles []string
+		for _, role := range common.Roles {
+			if contains(common.DefaultRolePermissions[role], permission) {
+				roles = append(roles, role)
+			}
+		}
+		err = a.RolePermissionRepository.Grant(ctx, permission, roles)
+		exception.PanicLogging(err)
+		for _, role := range roles {
+			if err := a.RedisService.Delete(rolePermissionCachePrefix + role); err != nil {
+				logger.Logger.Error("Failed to invalidate permission cache for role " + role + ": " + err.Error())
+			}
+		}
+		logger.Logger.Info("Granted introduced permission " + permission)
 	}
+}
 
-	// Check if user is associated with this refinery
-	if userRefineryId, ok := claims["refineryId"].(float64); ok {
-		return uint(userRefineryId) == refineryId
+// permissionsForRole reads the permissions of a role through the Redis cache
+func (a *authorizationServiceImpl) permissionsForRole(ctx context.Context, role string) []string {
+	cacheKey := rolePermissionCachePrefix + role
+	if cached, err := a.RedisService.Get(cacheKey); err == nil {
+		var permissions []string
+		if err := json.Unmarshal([]byte(cached), &permissions); err == nil {
+			return permissions
+		}
 	}
 
+	rows, err := a.RolePermissionRepository.FindByRole(ctx, role)
+	if err != nil {
+		logger.Logger.Error("Failed to load permissions for role " + role + ": " + err.Error())
+		return nil
+	}
+
+	permissions := make([]string, 0, len(rows))
+	for _, row := range rows {
+		permissions = append(permissions, row.Permission)
+	}
+	if err := a.RedisService.Set(cacheKey, permissions, 60*time.Minute); err != nil {
+		logger.Logger.Error("Failed to cache permissions for role " + role + ": " + err.Error())
+	}
+	return permissions
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
 	return false
 }
@@ -0,0 +1,573 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"gorm.io/gorm"
+)
+
+type campaignServiceImpl struct {
+	repository.CampaignRepository
+	service.NotificationDispatcher
+	service.MessageBrokerService
+	interval     time.Duration
+	batchSize    int
+	queueLease   time.Duration
+	sendInterval time.Duration
+	// nextSend is when the next message may go out, shared by all batches
+	// this process delivers
+	sendMu   sync.Mutex
+	nextSend time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+	stopped  sync.WaitGroup
+}
+
+func NewCampaignService(campaignRepository repository.CampaignRepository, notificationDispatcher service.NotificationDispatcher, messageBrokerService service.MessageBrokerService, config configuration.Config) service.CampaignService {
+	sendRate := intConfig(config, "CAMPAIGN_SEND_RATE_PER_SECOND", 20)
+	if sendRate <= 0 {
+		sendRate = 20
+	}
+	return &campaignServiceImpl{
+		CampaignRepository:     campaignRepository,
+		NotificationDispatcher: notificationDispatcher,
+		MessageBrokerService:   messageBrokerService,
+		interval:               time.Duration(intConfig(config, "CAMPAIGN_SCHEDULER_INTERVAL_SECONDS", 30)) * time.Second,
+		batchSize:              intConfig(config, "CAMPAIGN_BATCH_SIZE", 200),
+		queueLease:             time.Duration(intConfig(config, "CAMPAIGN_QUEUE_LEASE_SECONDS", 600)) * time.Second,
+		sendInterval:           time.Second / time.Duration(sendRate),
+		stop:                   make(chan struct{}),
+	}
+}
+
+func (c *campaignServiceImpl) Create(ctx context.Context, request model.CampaignRequestModel, actor model.AuditActor) (model.CampaignModel, error) {
+	campaign := entity.Campaign{Status: common.CAMPAIGN_STATUS_DRAFT, CreatedBy: actor.Username}
+	if err := applyCampaignRequest(&campaign, request); err != nil {
+		return model.CampaignModel{}, err
+	}
+	campaign, err := c.CampaignRepository.Create(ctx, campaign)
+	if err != nil {
+		return model.CampaignModel{}, err
+	}
+	return campaignModel(campaign), nil
+}
+
+func (c *campaignServiceImpl) Update(ctx context.Context, id uint, request model.CampaignRequestModel) (model.CampaignModel, error) {
+	campaign, err := c.findCampaign(ctx, id)
+	if err != nil {
+		return model.CampaignModel{}, err
+	}
+	if campaign.Status != common.CAMPAIGN_STATUS_DRAFT && campaign.Status != common.CAMPAIGN_STATUS_SCHEDULED {
+		return model.CampaignModel{}, exception.BadRequestError{Message: "A campaign that is " + campaign.Status + " cannot be changed"}
+	}
+	if err := applyCampaignRequest(&campaign, request); err != nil {
+		return model.CampaignModel{}, err
+	}
+	// The status is checked again as the campaign may have started since
+	updated, err := c.CampaignRepository.UpdateContent(ctx, campaign,
+		[]string{common.CAMPAIGN_STATUS_DRAFT, common.CAMPAIGN_STATUS_SCHEDULED})
+	if err != nil {
+		return model.CampaignModel{}, err
+	}
+	if !updated {
+		return model.CampaignModel{}, exception.BadRequestError{Message: "The campaign started sending and cannot be changed"}
+	}
+	return c.Get(ctx, id)
+}
+
+func (c *campaignServiceImpl) Get(ctx context.Context, id uint) (model.CampaignModel, error) {
+	campaign, err := c.findCampaign(ctx, id)
+	if err != nil {
+		return model.CampaignModel{}, err
+	}
+	return campaignModel(campaign), nil
+}
+
+func (c *campaignServiceImpl) List(ctx context.Context, status string, page, limit int) ([]model.CampaignModel, int64, error) {
+	campaigns, totalCount, err := c.CampaignRepository.FindPaginated(ctx, status, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	result := make([]model.CampaignModel, 0, len(campaigns))
+	for _, campaign := range campaigns {
+		result = append(result, campaignModel(campaign))
+	}
+	return result, totalCount, nil
+}
+
+func (c *campaignServiceImpl) PreviewAudience(ctx context.Context, segment model.CampaignSegmentModel) (model.CampaignAudienceModel, error) {
+	segment, err := normaliseSegment(segment)
+	if err != nil {
+		return model.CampaignAudienceModel{}, err
+	}
+	count, err := c.CampaignRepository.CountSegment(ctx, segment, time.Now())
+	if err != nil {
+		return model.CampaignAudienceModel{}, err
+	}
+	return model.CampaignAudienceModel{Recipients: count}, nil
+}
+
+func (c *campaignServiceImpl) Schedule(ctx context.Context, id uint, request model.CampaignScheduleModel) (model.CampaignModel, error) {
+	now := time.Now()
+	scheduledAt := now
+	if request.ScheduledAt != nil {
+		// A little slack for clock differences, anything older is a mistake
+		if request.ScheduledAt.Before(now.Add(-time.Minute)) {
+			return model.CampaignModel{}, exception.BadRequestError{Message: "scheduledAt must not be in the past"}
+		}
+		scheduledAt = *request.ScheduledAt
+	}
+
+	updated, err := c.CampaignRepository.UpdateStatus(ctx, id,
+		[]string{common.CAMPAIGN_STATUS_DRAFT, common.CAMPAIGN_STATUS_SCHEDULED},
+		entity.Campaign{Status: common.CAMPAIGN_STATUS_SCHEDULED, ScheduledAt: &scheduledAt})
+	if err != nil {
+		return model.CampaignModel{}, err
+	}
+	campaign, err := c.findCampaign(ctx, id)
+	if err != nil {
+		return model.CampaignModel{}, err
+	}
+	if !updated {
+		return model.CampaignModel{}, exception.BadRequestError{Message: "A campaign that is " + campaign.Status + " cannot be scheduled"}
+	}
+	return campaignModel(campaign), nil
+}
+
+func (c *campaignServiceImpl) Cancel(ctx context.Context, id uint) (model.CampaignModel, error) {
+	now := time.Now()
+	updated, err := c.CampaignRepository.UpdateStatus(ctx, id,
+		[]string{common.CAMPAIGN_STATUS_DRAFT, common.CAMPAIGN_STATUS_SCHEDULED, common.CAMPAIGN_STATUS_SENDING},
+		entity.Campaign{Status: common.CAMPAIGN_STATUS_CANCELLED, CompletedAt: &now})
+	if err != nil {
+		return model.CampaignModel{}, err
+	}
+	campaign, err := c.findCampaign(ctx, id)
+	if err != nil {
+		return model.CampaignModel{}, err
+	}
+	if !updated {
+		return model.CampaignModel{}, exception.BadRequestError{Message: "A campaign that is " + campaign.Status + " cannot be cancelled"}
+	}
+	return campaignModel(campaign), nil
+}
+
+func (c *campaignServiceImpl) Stats(ctx context.Context, id uint) (model.CampaignStatsModel, error) {
+	campaign, err := c.findCampaign(ctx, id)
+	if err != nil {
+		return model.CampaignStatsModel{}, err
+	}
+	rows, err := c.CampaignRepository.DeliveryStats(ctx, id)
+	if err != nil {
+		return model.CampaignStatsModel{}, err
+	}
+	opened, err := c.CampaignRepository.CountOpenedUsers(ctx, id)
+	if err != nil {
+		return model.CampaignStatsModel{}, err
+	}
+
+	stats := model.CampaignStatsModel{
+		CampaignId: campaign.ID,
+		Status:     campaign.Status,
+		Recipients: campaign.Recipients,
+		Opened:     opened,
+		Channels:   make(map[string]model.CampaignChannelStatsModel),
+	}
+	for _, channel := range campaignChannels(campaign) {
+		stats.Channels[channel] = model.CampaignChannelStatsModel{}
+	}
+	for _, row := range rows {
+		channel := stats.Channels[row.Channel]
+		switch row.Status {
+		case common.DISPATCH_SENT:
+			channel.Sent += row.Total
+		case common.DISPATCH_FAILED:
+			channel.Failed += row.Total
+		case common.DISPATCH_OPTED_OUT:
+			channel.OptedOut += row.Total
+		case common.DISPATCH_QUIET_HOURS:
+			channel.QuietHours += row.Total
+		case common.DISPATCH_UNAVAILABLE:
+			channel.Unavailable += row.Total
+		}
+		channel.Opened += row.Opened
+		stats.Channels[row.Channel] = channel
+	}
+	if campaign.Recipients > 0 {
+		stats.OpenRate = float64(opened) / float64(campaign.Recipients)
+	}
+	return stats, nil
+}
+
+func (c *campaignServiceImpl) MarkOpened(ctx context.Context, id uint, userId uint) error {
+	if _, err := c.findCampaign(ctx, id); err != nil {
+		return err
+	}
+	// Opening twice or a campaign that was not sent to the user changes
+	// nothing, the app reports opens without knowing which were counted
+	_, err := c.CampaignRepository.MarkOpened(ctx, id, userId, time.Now())
+	return err
+}
+
+func (c *campaignServiceImpl) QueueDue(ctx context.Context) (int, error) {
+	// Batches published while the broker is away would only be buffered in
+	// this process, leave the campaigns scheduled until it is back
+	if c.MessageBrokerService.Health().State != common.BROKER_STATE_CONNECTED {
+		return 0, nil
+	}
+
+	now := time.Now()
+	staleBefore := now.Add(-c.queueLease)
+	campaigns, err := c.CampaignRepository.FindQueueable(ctx, now, staleBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, campaign := range campaigns {
+		// The start time tells the batches of this attempt apart, it is kept
+		// at the precision the database stores
+		startedAt := time.Now().Truncate(time.Microsecond)
+		claimed, err := c.CampaignRepository.ClaimForQueueing(ctx, campaign.ID, startedAt, staleBefore)
+		if err != nil {
+			return queued, err
+		}
+		if !claimed {
+			continue
+		}
+		// A campaign that fails half way is queued again once the lease ran
+		// out, users that were already delivered are skipped then
+		if err := c.queue(ctx, campaign, startedAt); err != nil {
+			logger.Logger.Error(fmt.Sprintf("Failed to queue campaign %d: %s", campaign.ID, err.Error()))
+			continue
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// queue publishes the campaign's recipients in batches. The segment is
+// evaluated once here, users that join it later do not get the campaign.
+func (c *campaignServiceImpl) queue(ctx context.Context, campaign entity.Campaign, startedAt time.Time) error {
+	segment := campaignSegment(campaign)
+	recipients, batches := 0, 0
+	var afterId uint
+	for {
+		userIds, err := c.CampaignRepository.FindSegmentUserIds(ctx, segment, startedAt, afterId, c.batchSize)
+		if err != nil {
+			return err
+		}
+		if len(userIds) == 0 {
+			break
+		}
+		batches++
+		err = c.MessageBrokerService.PublishMessage(common.CAMPAIGN_DELIVERY_TOPIC, model.CampaignBatchModel{
+			CampaignId: campaign.ID,
+			StartedAt:  startedAt,
+			Number:     batches,
+			UserIds:    userIds,
+		})
+		if err != nil {
+			return err
+		}
+		recipients += len(userIds)
+		afterId = userIds[len(userIds)-1]
+	}
+
+	if err := c.CampaignRepository.FinishQueueing(ctx, campaign.ID, recipients, batches, time.Now()); err != nil {
+		return err
+	}
+	logger.Logger.Info(fmt.Sprintf("Queued campaign %d for %d recipients in %d batches", campaign.ID, recipients, batches))
+	// Every batch may have been processed already, or there were none
+	return c.complete(ctx, campaign.ID)
+}
+
+func (c *campaignServiceImpl) ProcessBatch(ctx context.Context, batch model.CampaignBatchModel) error {
+	campaign, err := c.CampaignRepository.FindById(ctx, batch.CampaignId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Logger.Warn(fmt.Sprintf("Dropping a batch of campaign %d, which does not exist", batch.CampaignId))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Cancelled campaigns and batches of an earlier queueing attempt are
+	// dropped
+	if campaign.Status != common.CAMPAIGN_STATUS_SENDING || campaign.StartedAt == nil || !campaign.StartedAt.Equal(batch.StartedAt) {
+		return nil
+	}
+
+	// A redelivered batch skips the users that already got the campaign
+	delivered, err := c.CampaignRepository.FindDeliveredUserIds(ctx, campaign.ID, batch.UserIds)
+	if err != nil {
+		return err
+	}
+	done := make(map[uint]bool, len(delivered))
+	for _, id := range delivered {
+		done[id] = true
+	}
+	pending := make([]uint, 0, len(batch.UserIds))
+	for _, id := range batch.UserIds {
+		if !done[id] {
+			pending = append(pending, id)
+		}
+	}
+	users, err := c.CampaignRepository.FindUsers(ctx, pending)
+	if err != nil {
+		return err
+	}
+
+	message := campaignMessage(campaign)
+	for _, user := range users {
+		// Users deactivated or erased since the campaign was queued are
+		// left out
+		if !user.IsActive || user.PseudonymisedAt != nil {
+			continue
+		}
+		c.throttle()
+		result := c.NotificationDispatcher.Dispatch(ctx, user, message)
+		deliveries := make([]entity.CampaignDelivery, 0, len(result.Channels))
+		for channel, status := range result.Channels {
+			deliveries = append(deliveries, entity.CampaignDelivery{
+				CampaignId: campaign.ID,
+				UserId:     user.ID,
+				Channel:    channel,
+				Status:     status,
+			})
+		}
+		// Recorded per user so that a batch redelivered after a crash sends
+		// to as few users twice as possible
+		if err := c.CampaignRepository.CreateDeliveries(ctx, deliveries); err != nil {
+			return err
+		}
+	}
+
+	// A redelivered batch is only counted once
+	if err := c.CampaignRepository.MarkBatchProcessed(ctx, campaign.ID, batch.StartedAt, batch.Number); err != nil {
+		return err
+	}
+	return c.complete(ctx, campaign.ID)
+}
+
+func (c *campaignServiceImpl) complete(ctx context.Context, id uint) error {
+	completed, err := c.CampaignRepository.CompleteIfDone(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if completed {
+		logger.Logger.Info(fmt.Sprintf("Campaign %d completed", id))
+	}
+	return nil
+}
+
+// throttle waits until the next message may be sent
+func (c *campaignServiceImpl) throttle() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	now := time.Now()
+	if c.nextSend.After(now) {
+		time.Sleep(c.nextSend.Sub(now))
+		now = c.nextSend
+	}
+	c.nextSend = now.Add(c.sendInterval)
+}
+
+func (c *campaignServiceImpl) StartScheduler() {
+	c.stopped.Add(1)
+	go func() {
+		defer c.stopped.Done()
+		logger.Logger.Info("Starting campaign scheduler")
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.stop:
+				return
+			case <-ticker.C:
+			}
+			if _, err := c.QueueDue(context.Background()); err != nil {
+				logger.Logger.Error("Campaign scheduler failed: " + err.Error())
+			}
+		}
+	}()
+}
+
+func (c *campaignServiceImpl) StopScheduler() {
+	c.stopOnce.Do(func() {
+		close(c.stop)
+	})
+	c.stopped.Wait()
+}
+
+func (c *campaignServiceImpl) findCampaign(ctx context.Context, id uint) (entity.Campaign, error) {
+	campaign, err := c.CampaignRepository.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.Campaign{}, exception.NotFoundError{Message: "Campaign not found"}
+		}
+		return entity.Campaign{}, err
+	}
+	return campaign, nil
+}
+
+// applyCampaignRequest validates the request and copies it to the campaign
+func applyCampaignRequest(campaign *entity.Campaign, request model.CampaignRequestModel) error {
+	request.Name = strings.TrimSpace(request.Name)
+	request.Title = strings.TrimSpace(request.Title)
+	if request.Name == "" || len(request.Name) > 100 {
+		return exception.BadRequestError{Message: "name is required and must be at most 100 characters"}
+	}
+	if request.Title == "" || len(request.Title) > 255 {
+		return exception.BadRequestError{Message: "title is required and must be at most 255 characters"}
+	}
+	if strings.TrimSpace(request.Body) == "" {
+		return exception.BadRequestError{Message: "body is required"}
+	}
+
+	channels := request.Channels
+	if len(channels) == 0 {
+		channels = common.DefaultNotificationChannels[common.NOTIFICATION_CATEGORY_MARKETING]
+	}
+	var unique []string
+	for _, channel := range channels {
+		if !contains(common.NotificationChannels, channel) {
+			return exception.BadRequestError{Message: fmt.Sprintf("Unknown notification channel %s", channel)}
+		}
+		if !contains(unique, channel) {
+			unique = append(unique, channel)
+		}
+	}
+
+	segment, err := normaliseSegment(request.Segment)
+	if err != nil {
+		return err
+	}
+	segmentJson, err := json.Marshal(segment)
+	if err != nil {
+		return err
+	}
+	data := ""
+	if len(request.Data) > 0 {
+		dataJson, err := json.Marshal(request.Data)
+		if err != nil {
+			return err
+		}
+		data = string(dataJson)
+	}
+
+	campaign.Name = request.Name
+	campaign.Title = request.Title
+	campaign.Body = request.Body
+	campaign.Html = request.Html
+	campaign.Data = data
+	campaign.Channels = strings.Join(unique, ",")
+	campaign.Segment = string(segmentJson)
+	return nil
+}
+
+// normaliseSegment checks the segment and upper cases its country codes
+func normaliseSegment(segment model.CampaignSegmentModel) (model.CampaignSegmentModel, error) {
+	countries := make([]string, 0, len(segment.Countries))
+	for _, country := range segment.Countries {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if len(country) != 2 {
+			return segment, exception.BadRequestError{Message: fmt.Sprintf("%s is not a two letter country code", country)}
+		}
+		countries = append(countries, country)
+	}
+	segment.Countries = countries
+	if segment.LastOrderWithinDays < 0 || segment.NoOrderWithinDays < 0 || segment.MinOrders < 0 {
+		return segment, exception.BadRequestError{Message: "Order days and counts must not be negative"}
+	}
+	if segment.LastOrderWithinDays > 0 && segment.NoOrderWithinDays >= segment.LastOrderWithinDays {
+		return segment, exception.BadRequestError{Message: "noOrderWithinDays must be less than lastOrderWithinDays"}
+	}
+	if segment.MaxOrders != nil && *segment.MaxOrders < segment.MinOrders {
+		return segment, exception.BadRequestError{Message: "maxOrders must not be less than minOrders"}
+	}
+	return segment, nil
+}
+
+func campaignChannels(campaign entity.Campaign) []string {
+	if campaign.Channels == "" {
+		return nil
+	}
+	return strings.Split(campaign.Channels, ",")
+}
+
+// campaignSegment reads the segment stored with the campaign, it was
+// validated when it was saved
+func campaignSegment(campaign entity.Campaign) model.CampaignSegmentModel {
+	var segment model.CampaignSegmentModel
+	if campaign.Segment != "" {
+		if err := json.Unmarshal([]byte(campaign.Segment), &segment); err != nil {
+			logger.Logger.Error(fmt.Sprintf("Failed to read the segment of campaign %d: %s", campaign.ID, err.Error()))
+		}
+	}
+	return segment
+}
+
+func campaignData(campaign entity.Campaign) map[string]string {
+	data := map[string]string{}
+	if campaign.Data != "" {
+		if err := json.Unmarshal([]byte(campaign.Data), &data); err != nil {
+			logger.Logger.Error(fmt.Sprintf("Failed to read the data of campaign %d: %s", campaign.ID, err.Error()))
+		}
+	}
+	return data
+}
+
+// campaignMessage is what every recipient gets, campaignId in the data lets
+// the app report the campaign as opened
+func campaignMessage(campaign entity.Campaign) model.DispatchMessageModel {
+	data := campaignData(campaign)
+	data["campaignId"] = strconv.FormatUint(uint64(campaign.ID), 10)
+	return model.DispatchMessageModel{
+		Category: common.NOTIFICATION_CATEGORY_MARKETING,
+		Title:    campaign.Title,
+		Body:     campaign.Body,
+		Html:     campaign.Html,
+		Data:     data,
+		Channels: campaignChannels(campaign),
+	}
+}
+
+func campaignModel(campaign entity.Campaign) model.CampaignModel {
+	var data map[string]string
+	if campaign.Data != "" {
+		data = campaignData(campaign)
+	}
+	return model.CampaignModel{
+		Id:          campaign.ID,
+		Name:        campaign.Name,
+		Title:       campaign.Title,
+		Body:        campaign.Body,
+		Html:        campaign.Html,
+		Data:        data,
+		Channels:    campaignChannels(campaign),
+		Segment:     campaignSegment(campaign),
+		Status:      campaign.Status,
+		ScheduledAt: campaign.ScheduledAt,
+		StartedAt:   campaign.StartedAt,
+		CompletedAt: campaign.CompletedAt,
+		Recipients:  campaign.Recipients,
+		CreatedBy:   campaign.CreatedBy,
+		CreatedAt:   campaign.CreatedAt,
+	}
+}
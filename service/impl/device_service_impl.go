@@ -0,0 +1,87 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"gorm.io/gorm"
+)
+
+type deviceServiceImpl struct {
+	repository.DeviceRepository
+}
+
+func NewDeviceService(deviceRepository repository.DeviceRepository) service.DeviceService {
+	return &deviceServiceImpl{DeviceRepository: deviceRepository}
+}
+
+func (d *deviceServiceImpl) Register(ctx context.Context, userId uint, request model.RegisterDeviceModel) (model.DeviceModel, error) {
+	request.Token = strings.TrimSpace(request.Token)
+	request.Platform = strings.ToLower(strings.TrimSpace(request.Platform))
+	request.AppVersion = strings.TrimSpace(request.AppVersion)
+	if request.Token == "" || len(request.Token) > 4096 {
+		return model.DeviceModel{}, exception.BadRequestError{Message: "token is required and must be at most 4096 characters"}
+	}
+	if !contains(common.DevicePlatforms, request.Platform) {
+		return model.DeviceModel{}, exception.BadRequestError{Message: "platform must be one of " + strings.Join(common.DevicePlatforms, ", ")}
+	}
+	if len(request.AppVersion) > 50 {
+		return model.DeviceModel{}, exception.BadRequestError{Message: "appVersion must be at most 50 characters"}
+	}
+
+	device, err := d.DeviceRepository.Save(ctx, entity.Device{
+		UserId:     userId,
+		Token:      request.Token,
+		Platform:   request.Platform,
+		AppVersion: request.AppVersion,
+		LastSeenAt: time.Now(),
+	})
+	if err != nil {
+		return model.DeviceModel{}, err
+	}
+	return deviceModel(device), nil
+}
+
+func (d *deviceServiceImpl) List(ctx context.Context, userId uint) ([]model.DeviceModel, error) {
+	devices, err := d.DeviceRepository.FindByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.DeviceModel, 0, len(devices))
+	for _, device := range devices {
+		result = append(result, deviceModel(device))
+	}
+	return result, nil
+}
+
+func (d *deviceServiceImpl) Remove(ctx context.Context, userId uint, id uint) error {
+	device, err := d.DeviceRepository.FindById(ctx, id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if device.ID == 0 || device.UserId != userId {
+		return exception.NotFoundError{Message: "Device not found"}
+	}
+	// Removing by token also clears it when it is the user's legacy token
+	_, err = d.DeviceRepository.DeleteByTokens(ctx, []string{device.Token})
+	return err
+}
+
+func deviceModel(device entity.Device) model.DeviceModel {
+	return model.DeviceModel{
+		Id:         device.ID,
+		Token:      device.Token,
+		Platform:   device.Platform,
+		AppVersion: device.AppVersion,
+		LastSeenAt: device.LastSeenAt,
+		CreatedAt:  device.CreatedAt,
+	}
+}
@@ -0,0 +1,44 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/events"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+type domainEventServiceImpl struct {
+	service.OutboxService
+	registry *events.Registry
+}
+
+func NewDomainEventService(outboxService service.OutboxService, registry *events.Registry) service.DomainEventService {
+	return &domainEventServiceImpl{OutboxService: outboxService, registry: registry}
+}
+
+func (d *domainEventServiceImpl) Record(ctx context.Context, eventType string, aggregateId uint, payload interface{}) error {
+	definition, ok := d.registry.Latest(eventType)
+	if !ok {
+		return fmt.Errorf("no schema registered for event %s", eventType)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	// A payload that breaks its schema is a bug in the producer, failing the
+	// transaction keeps it from reaching the consumers
+	if err := d.registry.Validate(eventType, definition.Version, body); err != nil {
+		return err
+	}
+	return d.OutboxService.Enqueue(ctx, model.OutboxEventModel{
+		AggregateType: definition.AggregateType,
+		AggregateId:   strconv.FormatUint(uint64(aggregateId), 10),
+		Topic:         eventType,
+		Version:       definition.Version,
+		Payload:       json.RawMessage(body),
+	})
+}
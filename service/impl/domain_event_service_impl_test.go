@@ -0,0 +1,178 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/events"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"gorm.io/gorm"
+)
+
+// recordingOutbox keeps the events Record enqueues
+type recordingOutbox struct {
+	service.OutboxService
+	events []model.OutboxEventModel
+}
+
+func (o *recordingOutbox) Enqueue(ctx context.Context, events ...model.OutboxEventModel) error {
+	o.events = append(o.events, events...)
+	return nil
+}
+
+func eventOrder() entity.Order {
+	return entity.Order{
+		Model:         gorm.Model{ID: 12},
+		TransactionId: 34,
+		RefineryId:    5,
+		TruckId:       6,
+		Status:        3,
+		Amount:        150.5,
+		Currency:      "GHS",
+		WaterCost:     120,
+		DeliveryFee:   30.5,
+		Capacity:      "5000",
+		WaterType:     "treated",
+	}
+}
+
+func eventTransaction(status string) entity.Transaction {
+	return entity.Transaction{
+		Model:       gorm.Model{ID: 34},
+		UserId:      56,
+		Amount:      150.5,
+		Currency:    "GHS",
+		Provider:    "mtn",
+		Status:      status,
+		PaymentType: "mobile_money",
+		Reference:   "ref-34",
+		CountryCode: "GH",
+	}
+}
+
+// assertDeclared fails for a payload field the schema does not document and
+// for a required property the payload leaves out, schemas accept undeclared
+// properties so validation alone would not notice a renamed field
+func assertDeclared(t *testing.T, definition *events.Definition, payload json.RawMessage) {
+	t.Helper()
+	var document struct {
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(definition.Schema, &document); err != nil {
+		t.Fatalf("%s v%d schema: %v", definition.Type, definition.Version, err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		t.Fatalf("%s payload: %v", definition.Type, err)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := document.Properties[name]; !ok {
+			t.Errorf("%s v%d does not declare %s", definition.Type, definition.Version, name)
+		}
+	}
+	for _, name := range document.Required {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("%s payload has no required %s", definition.Type, name)
+		}
+	}
+}
+
+func TestOrderEventMatchesSchemas(t *testing.T) {
+	outbox := &recordingOutbox{}
+	domainEvents := NewDomainEventService(outbox, events.DefaultRegistry())
+
+	for _, eventType := range []string{
+		common.EVENT_ORDER_CREATED,
+		common.EVENT_ORDER_APPROVED,
+		common.EVENT_ORDER_REJECTED,
+		common.EVENT_ORDER_ASSIGNED,
+		common.EVENT_ORDER_READY_FOR_DELIVERY,
+		common.EVENT_ORDER_DELIVERED,
+	} {
+		t.Run(eventType, func(t *testing.T) {
+			payload := orderEvent(eventOrder(), 56)
+			if eventType == common.EVENT_ORDER_REJECTED {
+				payload.Reason = "out of stock"
+			}
+			if err := domainEvents.Record(context.Background(), eventType, 12, payload); err != nil {
+				t.Fatalf("Record = %v", err)
+			}
+
+			event := outbox.events[len(outbox.events)-1]
+			definition, _ := events.DefaultRegistry().Latest(eventType)
+			if event.Topic != eventType || event.Version != definition.Version || event.AggregateType != "order" || event.AggregateId != "12" {
+				t.Errorf("enqueued %s v%d of %s %s", event.Topic, event.Version, event.AggregateType, event.AggregateId)
+			}
+			assertDeclared(t, definition, event.Payload.(json.RawMessage))
+		})
+	}
+}
+
+func TestPaymentEventMatchesSchemas(t *testing.T) {
+	tests := []struct {
+		status    string
+		eventType string
+	}{
+		{"success", common.EVENT_PAYMENT_SUCCEEDED},
+		{"failed", common.EVENT_PAYMENT_FAILED},
+		{"abandoned", common.EVENT_PAYMENT_FAILED},
+		{"reversed", common.EVENT_REFUND_COMPLETED},
+	}
+	for _, test := range tests {
+		t.Run(test.status, func(t *testing.T) {
+			outbox := &recordingOutbox{}
+			transactions := &transactionServiceImpl{DomainEventService: NewDomainEventService(outbox, events.DefaultRegistry())}
+
+			if err := transactions.recordPaymentEvent(context.Background(), eventTransaction(test.status), 12); err != nil {
+				t.Fatalf("recordPaymentEvent = %v", err)
+			}
+			if len(outbox.events) != 1 || outbox.events[0].Topic != test.eventType {
+				t.Fatalf("enqueued %+v, want one %s", outbox.events, test.eventType)
+			}
+			definition, _ := events.DefaultRegistry().Latest(test.eventType)
+			assertDeclared(t, definition, outbox.events[0].Payload.(json.RawMessage))
+		})
+	}
+}
+
+func TestPaymentEventSkipsPendingTransactions(t *testing.T) {
+	outbox := &recordingOutbox{}
+	transactions := &transactionServiceImpl{DomainEventService: NewDomainEventService(outbox, events.DefaultRegistry())}
+
+	if err := transactions.recordPaymentEvent(context.Background(), eventTransaction("pending"), 12); err != nil {
+		t.Fatalf("recordPaymentEvent = %v", err)
+	}
+	if len(outbox.events) != 0 {
+		t.Errorf("enqueued %+v for a pending transaction", outbox.events)
+	}
+}
+
+func TestRecordRejectsPayloadsThatBreakTheSchema(t *testing.T) {
+	outbox := &recordingOutbox{}
+	domainEvents := NewDomainEventService(outbox, events.DefaultRegistry())
+
+	// An order event without its order id must not reach the outbox
+	payload := orderEvent(eventOrder(), 56)
+	payload.OrderId = 0
+	if err := domainEvents.Record(context.Background(), common.EVENT_ORDER_CREATED, 12, payload); err == nil {
+		t.Error("Record accepted an order without an id")
+	}
+	if err := domainEvents.Record(context.Background(), "order.unknown", 12, payload); err == nil {
+		t.Error("Record accepted an event type without a schema")
+	}
+	if len(outbox.events) != 0 {
+		t.Errorf("enqueued %+v", outbox.events)
+	}
+}
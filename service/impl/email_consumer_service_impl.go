@@ -1,6 +1,7 @@
 package impl
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -8,21 +9,24 @@ import (
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 	"gopkg.in/gomail.v2"
 )
 
 // EmailConsumerServiceImpl implements the EmailConsumerService interface
 type EmailConsumerServiceImpl struct {
-	config          configuration.Config
-	rabbitMQService *RabbitMQService
+	config                 configuration.Config
+	messageBrokerService   service.MessageBrokerService
+	notificationRepository repository.NotificationRepository
 }
 
 // NewEmailConsumerService creates a new EmailConsumerService
-func NewEmailConsumerService(config configuration.Config, rabbitMQService *RabbitMQService) service.EmailConsumerService {
+func NewEmailConsumerService(config configuration.Config, messageBrokerService service.MessageBrokerService, notificationRepository repository.NotificationRepository) service.EmailConsumerService {
 	return &EmailConsumerServiceImpl{
-		config:          config,
-		rabbitMQService: rabbitMQService,
+		config:                 config,
+		messageBrokerService:   messageBrokerService,
+		notificationRepository: notificationRepository,
 	}
 }
 
@@ -31,7 +35,7 @@ func (e *EmailConsumerServiceImpl) StartConsumer() error {
 	logger.Logger.Info("Starting email consumer service...")
 
 	// Subscribe to the email.send routing key
-	err := e.rabbitMQService.SubscribeToTopic("email.send", e.ProcessEmail)
+	err := e.messageBrokerService.SubscribeToTopic("email.send", e.ProcessEmail)
 	if err != nil {
 		logger.Logger.Error(fmt.Sprintf("Failed to start email consumer: %s", err.Error()))
 		return err
@@ -48,41 +52,45 @@ func (e *EmailConsumerServiceImpl) ProcessEmail(emailData []byte) error {
 	err := json.Unmarshal(emailData, &queuedEmail)
 	if err != nil {
 		logger.Logger.Error(fmt.Sprintf("Error parsing email data: %s", err.Error()))
-		return err
+		return service.PermanentError{Err: err}
 	}
 
 	logger.Logger.Info(fmt.Sprintf("Processing email to %s with subject: %s", queuedEmail.To, queuedEmail.Subject))
 
-	// Create a new email message
+	err = deliverEmail(e.config, queuedEmail)
+	recordEmailResult(context.Background(), e.notificationRepository, queuedEmail.NotificationId, err)
+	if err != nil {
+		logger.Logger.Error(fmt.Sprintf("Error sending email: %s", err.Error()))
+		return err
+	}
+
+	logger.Logger.Info(fmt.Sprintf("Email to %s sent successfully", queuedEmail.To))
+	return nil
+}
+
+// deliverEmail sends an email through the configured SMTP server
+func deliverEmail(config configuration.Config, email model.QueuedEmailMessage) error {
 	message := gomail.NewMessage()
 
 	// Set email headers
-	from := queuedEmail.From
+	from := email.From
 	if from == "" {
-		from = e.config.Get("MAIL_FROM")
+		from = config.Get("MAIL_FROM")
 	}
 	message.SetHeader("From", from)
-	message.SetHeader("To", queuedEmail.To)
-	message.SetHeader("Subject", queuedEmail.Subject)
+	message.SetHeader("To", email.To)
+	message.SetHeader("Subject", email.Subject)
 
 	// Set email body
-	message.SetBody("text/html", queuedEmail.Message)
+	message.SetBody("text/html", email.Message)
 
 	// Set up the SMTP connection
-	port, _ := strconv.Atoi(e.config.Get("MAIL_PORT"))
+	port, _ := strconv.Atoi(config.Get("MAIL_PORT"))
 	dialer := gomail.NewDialer(
-		e.config.Get("MAIL_HOST"),
+		config.Get("MAIL_HOST"),
 		port,
-		e.config.Get("MAIL_USERNAME"),
-		e.config.Get("MAIL_PASSWORD"),
+		config.Get("MAIL_USERNAME"),
+		config.Get("MAIL_PASSWORD"),
 	)
-
-	// Send the email
-	if err := dialer.DialAndSend(message); err != nil {
-		logger.Logger.Error(fmt.Sprintf("Error sending email: %s", err.Error()))
-		return err
-	}
-
-	logger.Logger.Info(fmt.Sprintf("Email to %s sent successfully", queuedEmail.To))
-	return nil
+	return dialer.DialAndSend(message)
 }
@@ -0,0 +1,397 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	thumbnailMaxSide = 320
+	// Larger images are refused before decoding so that a small, highly
+	// compressed upload cannot exhaust memory
+	imageMaxPixels = 25_000_000
+)
+
+// File extensions by the content types that may be uploaded
+var imageContentTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+var documentContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+type fileServiceImpl struct {
+	service.FileStorage
+	repository.StoredFileRepository
+	repository.UserRepository
+	repository.TruckRepository
+	configuration.Config
+	secret []byte
+}
+
+func NewFileService(fileStorage service.FileStorage, storedFileRepository repository.StoredFileRepository, userRepository repository.UserRepository, truckRepository repository.TruckRepository, config configuration.Config) (service.FileService, error) {
+	secret := config.Get("FILE_URL_SECRET")
+	if secret == "" {
+		return nil, errors.New("FILE_URL_SECRET is required to sign file URLs")
+	}
+	return &fileServiceImpl{
+		FileStorage:          fileStorage,
+		StoredFileRepository: storedFileRepository,
+		UserRepository:       userRepository,
+		TruckRepository:      truckRepository,
+		Config:               config,
+		secret:               []byte(secret),
+	}, nil
+}
+
+// UploadProfilePhoto replaces the user's photo, the previous one is removed
+func (f *fileServiceImpl) UploadProfilePhoto(ctx context.Context, userId uint, upload model.FileUploadModel) (model.StoredFileModel, error) {
+	previous, err := f.StoredFileRepository.FindByReference(ctx, common.FILE_CATEGORY_PROFILE_PHOTO, userId)
+	if err != nil {
+		return model.StoredFileModel{}, err
+	}
+
+	file, err := f.store(ctx, common.FILE_CATEGORY_PROFILE_PHOTO, userId, "", upload, userId, imageContentTypes)
+	if err != nil {
+		return model.StoredFileModel{}, err
+	}
+	if _, err := f.UserRepository.Update(ctx, entity.User{Model: gorm.Model{ID: userId}, FileName: file.StorageKey}); err != nil {
+		return model.StoredFileModel{}, err
+	}
+
+	for _, old := range previous {
+		if err := f.StoredFileRepository.Delete(ctx, old); err != nil {
+			logger.Logger.Error("Failed to delete previous profile photo: " + err.Error())
+			continue
+		}
+		f.remove(ctx, old)
+	}
+	return f.storedFileModel(file), nil
+}
+
+func (f *fileServiceImpl) DeleteProfilePhotos(ctx context.Context, userId uint) error {
+	photos, err := f.StoredFileRepository.FindByReference(ctx, common.FILE_CATEGORY_PROFILE_PHOTO, userId)
+	if err != nil {
+		return err
+	}
+	for _, photo := range photos {
+		if err := f.StoredFileRepository.Delete(ctx, photo); err != nil {
+			return err
+		}
+		f.remove(ctx, photo)
+	}
+	return nil
+}
+
+func (f *fileServiceImpl) UploadDriverDocument(ctx context.Context, truckId uint, documentType string, upload model.FileUploadModel, uploadedBy uint) (model.StoredFileModel, error) {
+	if !contains(common.DriverDocumentTypes, documentType) {
+		return model.StoredFileModel{}, exception.BadRequestError{Message: "documentType must be one of " + strings.Join(common.DriverDocumentTypes, ", ")}
+	}
+	if err := f.findTruck(ctx, truckId); err != nil {
+		return model.StoredFileModel{}, err
+	}
+
+	file, err := f.store(ctx, common.FILE_CATEGORY_DRIVER_DOCUMENT, truckId, documentType, upload, uploadedBy, documentContentTypes)
+	if err != nil {
+		return model.StoredFileModel{}, err
+	}
+	return f.storedFileModel(file), nil
+}
+
+func (f *fileServiceImpl) ListDriverDocuments(ctx context.Context, truckId uint) ([]model.StoredFileModel, error) {
+	if err := f.findTruck(ctx, truckId); err != nil {
+		return nil, err
+	}
+	return f.list(ctx, common.FILE_CATEGORY_DRIVER_DOCUMENT, truckId)
+}
+
+func (f *fileServiceImpl) DeleteDriverDocument(ctx context.Context, truckId uint, id uint) error {
+	file, err := f.StoredFileRepository.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return exception.NotFoundError{Message: "Document not found"}
+		}
+		return err
+	}
+	if file.Category != common.FILE_CATEGORY_DRIVER_DOCUMENT || file.ReferenceId != truckId {
+		return exception.NotFoundError{Message: "Document not found"}
+	}
+	if err := f.StoredFileRepository.Delete(ctx, file); err != nil {
+		return err
+	}
+	f.remove(ctx, file)
+	return nil
+}
+
+func (f *fileServiceImpl) UploadDeliveryPhoto(ctx context.Context, orderId uint, upload model.FileUploadModel, uploadedBy uint) (model.StoredFileModel, error) {
+	file, err := f.store(ctx, common.FILE_CATEGORY_DELIVERY_PHOTO, orderId, "", upload, uploadedBy, imageContentTypes)
+	if err != nil {
+		return model.StoredFileModel{}, err
+	}
+	return f.storedFileModel(file), nil
+}
+
+func (f *fileServiceImpl) ListDeliveryPhotos(ctx context.Context, orderId uint) ([]model.StoredFileModel, error) {
+	return f.list(ctx, common.FILE_CATEGORY_DELIVERY_PHOTO, orderId)
+}
+
+func (f *fileServiceImpl) SignedUrl(key string) string {
+	if key == "" {
+		return ""
+	}
+	ttl := time.Duration(intConfig(f.Config, "FILE_URL_TTL_MINUTES", 15)) * time.Minute
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return fmt.Sprintf("%s/files/%s?expires=%s&signature=%s",
+		strings.TrimSuffix(f.Config.Get("FILE_BASE_URL"), "/"), strings.Join(segments, "/"), expires, f.signature(key, expires))
+}
+
+func (f *fileServiceImpl) OpenSigned(ctx context.Context, key string, expires string, signature string) (io.ReadCloser, string, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(f.signature(key, expires))) {
+		return nil, "", exception.ForbiddenError{Message: "Invalid file signature"}
+	}
+	if time.Now().Unix() > expiresAt {
+		return nil, "", exception.ForbiddenError{Message: "The file link has expired"}
+	}
+
+	reader, err := f.FileStorage.Open(ctx, key)
+	if err != nil {
+		if errors.Is(err, errInvalidFileKey) || errors.Is(err, os.ErrNotExist) {
+			return nil, "", exception.NotFoundError{Message: "File not found"}
+		}
+		return nil, "", err
+	}
+	return reader, f.contentType(ctx, key), nil
+}
+
+func (f *fileServiceImpl) signature(key string, expires string) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// contentType looks the key up in the stored files, files uploaded before
+// they were recorded fall back to their extension
+func (f *fileServiceImpl) contentType(ctx context.Context, key string) string {
+	file, err := f.StoredFileRepository.FindByKey(ctx, key)
+	if err == nil {
+		if key == file.ThumbnailKey {
+			return "image/jpeg"
+		}
+		return file.ContentType
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(key)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// store validates the upload against the allowed content types and the size
+// limit, writes it and a thumbnail for images, and records it
+func (f *fileServiceImpl) store(ctx context.Context, category string, referenceId uint, documentType string, upload model.FileUploadModel, uploadedBy uint, allowed map[string]string) (entity.StoredFile, error) {
+	maxSize := int64(intConfig(f.Config, "FILE_MAX_UPLOAD_MB", 5)) << 20
+	if len(upload.Data) == 0 {
+		return entity.StoredFile{}, exception.BadRequestError{Message: "The file is empty"}
+	}
+	if int64(len(upload.Data)) > maxSize {
+		return entity.StoredFile{}, exception.BadRequestError{Message: fmt.Sprintf("The file must not be larger than %d MB", maxSize>>20)}
+	}
+
+	// The declared content type is not trusted, the data is sniffed instead
+	contentType := http.DetectContentType(upload.Data)
+	if index := strings.Index(contentType, ";"); index >= 0 {
+		contentType = contentType[:index]
+	}
+	extension, ok := allowed[contentType]
+	if !ok {
+		return entity.StoredFile{}, exception.BadRequestError{Message: "Unsupported file type " + contentType}
+	}
+
+	key := fmt.Sprintf("%s/%d/%s", category, referenceId, uuid.New().String())
+	file := entity.StoredFile{
+		Category:     category,
+		ReferenceId:  referenceId,
+		DocumentType: documentType,
+		StorageKey:   key + extension,
+		ContentType:  contentType,
+		Size:         int64(len(upload.Data)),
+		OriginalName: truncate(filepath.Base(upload.FileName), 255),
+		UploadedBy:   uploadedBy,
+	}
+
+	var thumbnail []byte
+	if strings.HasPrefix(contentType, "image/") {
+		var err error
+		thumbnail, err = makeThumbnail(upload.Data)
+		if err != nil {
+			return entity.StoredFile{}, err
+		}
+		file.ThumbnailKey = key + "_thumb.jpg"
+	}
+
+	if err := f.FileStorage.Put(ctx, file.StorageKey, upload.Data, contentType); err != nil {
+		return entity.StoredFile{}, err
+	}
+	if thumbnail != nil {
+		if err := f.FileStorage.Put(ctx, file.ThumbnailKey, thumbnail, "image/jpeg"); err != nil {
+			f.remove(ctx, file)
+			return entity.StoredFile{}, err
+		}
+	}
+
+	file, err := f.StoredFileRepository.Create(ctx, file)
+	if err != nil {
+		f.remove(ctx, file)
+		return entity.StoredFile{}, err
+	}
+	return file, nil
+}
+
+func (f *fileServiceImpl) list(ctx context.Context, category string, referenceId uint) ([]model.StoredFileModel, error) {
+	files, err := f.StoredFileRepository.FindByReference(ctx, category, referenceId)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.StoredFileModel, 0, len(files))
+	for _, file := range files {
+		result = append(result, f.storedFileModel(file))
+	}
+	return result, nil
+}
+
+// remove deletes the stored objects of a file, failures only leave an
+// orphaned object behind so they are logged
+func (f *fileServiceImpl) remove(ctx context.Context, file entity.StoredFile) {
+	for _, key := range []string{file.StorageKey, file.ThumbnailKey} {
+		if key == "" {
+			continue
+		}
+		if err := f.FileStorage.Delete(ctx, key); err != nil {
+			logger.Logger.Error("Failed to delete stored file " + key + ": " + err.Error())
+		}
+	}
+}
+
+func (f *fileServiceImpl) findTruck(ctx context.Context, truckId uint) error {
+	if _, err := f.TruckRepository.FindById(ctx, truckId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return exception.NotFoundError{Message: "Truck not found"}
+		}
+		return err
+	}
+	return nil
+}
+
+func (f *fileServiceImpl) storedFileModel(file entity.StoredFile) model.StoredFileModel {
+	return model.StoredFileModel{
+		Id:           file.ID,
+		Category:     file.Category,
+		DocumentType: file.DocumentType,
+		ContentType:  file.ContentType,
+		Size:         file.Size,
+		OriginalName: file.OriginalName,
+		Url:          f.SignedUrl(file.StorageKey),
+		ThumbnailUrl: f.SignedUrl(file.ThumbnailKey),
+		CreatedAt:    file.CreatedAt,
+	}
+}
+
+// makeThumbnail decodes an image and returns a JPEG no larger than
+// thumbnailMaxSide on either side
+func makeThumbnail(data []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, exception.BadRequestError{Message: "The image could not be read"}
+	}
+	if config.Width*config.Height > imageMaxPixels {
+		return nil, exception.BadRequestError{Message: "The image dimensions are too large"}
+	}
+	source, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, exception.BadRequestError{Message: "The image could not be read"}
+	}
+
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, scaleDown(source, thumbnailMaxSide), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// scaleDown resizes by averaging the source pixels that fall into each
+// target pixel, images that already fit keep their size
+func scaleDown(source image.Image, maxSide int) image.Image {
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	targetWidth, targetHeight := width, height
+	if width > maxSide || height > maxSide {
+		if width >= height {
+			targetWidth, targetHeight = maxSide, max(1, height*maxSide/width)
+		} else {
+			targetWidth, targetHeight = max(1, width*maxSide/height), maxSide
+		}
+	}
+
+	target := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+	for y := 0; y < targetHeight; y++ {
+		sourceTop := bounds.Min.Y + y*height/targetHeight
+		sourceBottom := max(sourceTop+1, bounds.Min.Y+(y+1)*height/targetHeight)
+		for x := 0; x < targetWidth; x++ {
+			sourceLeft := bounds.Min.X + x*width/targetWidth
+			sourceRight := max(sourceLeft+1, bounds.Min.X+(x+1)*width/targetWidth)
+
+			var r, g, b, a, count uint64
+			for sy := sourceTop; sy < sourceBottom; sy++ {
+				for sx := sourceLeft; sx < sourceRight; sx++ {
+					pr, pg, pb, pa := source.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+			// JPEG has no alpha, transparent areas are flattened onto white
+			transparent := 0xffff - a/count
+			target.Set(x, y, color.RGBA64{R: uint16(r/count + transparent), G: uint16(g/count + transparent), B: uint16(b/count + transparent), A: 0xffff})
+		}
+	}
+	return target
+}
@@ -0,0 +1,92 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+var errInvalidFileKey = errors.New("invalid file key")
+
+// NewFileStorage returns the backend selected by FILE_STORAGE_DRIVER, "local"
+// (the default) or "s3"
+func NewFileStorage(config configuration.Config) (service.FileStorage, error) {
+	switch config.Get("FILE_STORAGE_DRIVER") {
+	case "", "local":
+		directory := config.Get("FILE_STORAGE_LOCAL_DIR")
+		if directory == "" {
+			directory = "./uploads"
+		}
+		return NewLocalFileStorage(directory), nil
+	case "s3":
+		return NewS3FileStorage(config)
+	default:
+		return nil, fmt.Errorf("unknown file storage driver %q", config.Get("FILE_STORAGE_DRIVER"))
+	}
+}
+
+type localFileStorage struct {
+	root string
+}
+
+func NewLocalFileStorage(root string) service.FileStorage {
+	return &localFileStorage{root: root}
+}
+
+func (l *localFileStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o640)
+}
+
+func (l *localFileStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (l *localFileStorage) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path resolves a key inside the storage root, rejecting keys that would
+// escape it
+func (l *localFileStorage) path(key string) (string, error) {
+	if !validFileKey(key) {
+		return "", errInvalidFileKey
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
+
+func validFileKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
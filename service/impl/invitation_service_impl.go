@@ -0,0 +1,279 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"gorm.io/gorm"
+)
+
+const invitationMinPasswordLength = 8
+
+type invitationServiceImpl struct {
+	repository.InvitationRepository
+	repository.UserRepository
+	service.MessageService
+	transactor repository.Transactor
+	configuration.Config
+}
+
+func NewInvitationService(invitationRepository repository.InvitationRepository, userRepository repository.UserRepository, messageService service.MessageService, transactor repository.Transactor, config configuration.Config) service.InvitationService {
+	return &invitationServiceImpl{
+		InvitationRepository: invitationRepository,
+		UserRepository:       userRepository,
+		MessageService:       messageService,
+		transactor:           transactor,
+		Config:               config,
+	}
+}
+
+// Invite creates an inactive account and sends an activation link. The
+// account gets a random password that is never disclosed, the invitee
+// chooses their own when activating.
+func (i *invitationServiceImpl) Invite(ctx context.Context, userModel model.UserModel) (entity.User, error) {
+	return i.InviteWith(ctx, userModel, nil)
+}
+
+// InviteWith runs setup in the transaction that creates the account, so that
+// the account is rolled back when setup fails. The link is only sent once
+// everything has been committed.
+func (i *invitationServiceImpl) InviteWith(ctx context.Context, userModel model.UserModel, setup func(ctx context.Context, user entity.User) error) (entity.User, error) {
+	user, _, err := i.invite(ctx, userModel, setup)
+	return user, err
+}
+
+func (i *invitationServiceImpl) Seed(ctx context.Context, userModel model.UserModel) (string, error) {
+	_, token, err := i.invite(ctx, userModel, nil)
+	if err != nil {
+		return "", err
+	}
+	return i.activationUrl(token), nil
+}
+
+func (i *invitationServiceImpl) invite(ctx context.Context, userModel model.UserModel, setup func(ctx context.Context, user entity.User) error) (entity.User, string, error) {
+	password, err := common.GeneratePassword(32)
+	if err != nil {
+		return entity.User{}, "", err
+	}
+	userModel.Password = password
+	userModel.IsActive = false
+
+	var user entity.User
+	var invitation entity.Invitation
+	var token string
+	err = i.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		user, err = i.UserRepository.Create(ctx, userModel)
+		if err != nil {
+			return exception.BadRequestError{Message: err.Error()}
+		}
+		if setup != nil {
+			if err := setup(ctx, user); err != nil {
+				return err
+			}
+		}
+
+		invitation = entity.Invitation{UserId: user.ID, User: user, Role: user.UserRole}
+		token, err = i.rotateToken(&invitation)
+		if err != nil {
+			return err
+		}
+		invitation, err = i.InvitationRepository.Create(ctx, invitation)
+		return err
+	})
+	if err != nil {
+		return entity.User{}, "", err
+	}
+	invitation.User = user
+
+	i.send(ctx, invitation, token)
+	return user, token, nil
+}
+
+func (i *invitationServiceImpl) Activate(ctx context.Context, request model.ActivateInvitationModel) error {
+	if len(request.Password) < invitationMinPasswordLength {
+		return exception.BadRequestError{Message: fmt.Sprintf("Password must be at least %d characters", invitationMinPasswordLength)}
+	}
+
+	invitation, err := i.InvitationRepository.FindByTokenHash(ctx, hashRefreshToken(request.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return exception.BadRequestError{Message: "Invalid or expired invitation"}
+		}
+		return err
+	}
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil || time.Now().After(invitation.ExpiresAt) {
+		return exception.BadRequestError{Message: "Invalid or expired invitation"}
+	}
+
+	// An invitation that is accepted without its password would leave the
+	// account inactive with no way to activate it
+	return i.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		accepted, err := i.InvitationRepository.MarkAccepted(ctx, invitation.ID)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return exception.BadRequestError{Message: "Invalid or expired invitation"}
+		}
+
+		// SetPassword also activates the account
+		_, err = i.UserRepository.SetPassword(ctx, int(invitation.UserId), request.Password)
+		return err
+	})
+}
+
+func (i *invitationServiceImpl) ListPending(ctx context.Context, page, limit int) ([]model.InvitationModel, int64, error) {
+	invitations, totalCount, err := i.InvitationRepository.FindPending(ctx, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	result := make([]model.InvitationModel, 0, len(invitations))
+	for _, invitation := range invitations {
+		result = append(result, model.InvitationModel{
+			Id:          invitation.ID,
+			UserId:      invitation.UserId,
+			FirstName:   invitation.User.FirstName,
+			LastName:    invitation.User.LastName,
+			PhoneNumber: invitation.User.PhoneNumber,
+			Email:       invitation.User.Email,
+			Role:        invitation.Role,
+			IsExpired:   now.After(invitation.ExpiresAt),
+			ExpiresAt:   invitation.ExpiresAt,
+			SendCount:   invitation.SendCount,
+			LastSentAt:  invitation.LastSentAt,
+			CreatedAt:   invitation.CreatedAt,
+		})
+	}
+	return result, totalCount, nil
+}
+
+// Resend issues a fresh link with a new expiry, invalidating the previous one
+func (i *invitationServiceImpl) Resend(ctx context.Context, id uint) error {
+	invitation, err := i.findPending(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	token, err := i.rotateToken(&invitation)
+	if err != nil {
+		return err
+	}
+	if _, err := i.InvitationRepository.Update(ctx, invitation); err != nil {
+		return err
+	}
+
+	i.send(ctx, invitation, token)
+	return nil
+}
+
+func (i *invitationServiceImpl) Revoke(ctx context.Context, id uint) error {
+	invitation, err := i.findPending(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	invitation.RevokedAt = &now
+	_, err = i.InvitationRepository.Update(ctx, invitation)
+	return err
+}
+
+// RevokeForUser revokes any invitation the user has not accepted yet, so that
+// a removed account cannot be activated afterwards
+func (i *invitationServiceImpl) RevokeForUser(ctx context.Context, userId uint) error {
+	return i.InvitationRepository.RevokePendingByUserId(ctx, userId)
+}
+
+func (i *invitationServiceImpl) findPending(ctx context.Context, id uint) (entity.Invitation, error) {
+	invitation, err := i.InvitationRepository.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.Invitation{}, exception.NotFoundError{Message: "Invitation not found"}
+		}
+		return entity.Invitation{}, err
+	}
+	if invitation.AcceptedAt != nil {
+		return entity.Invitation{}, exception.BadRequestError{Message: "Invitation has already been accepted"}
+	}
+	if invitation.RevokedAt != nil {
+		return entity.Invitation{}, exception.BadRequestError{Message: "Invitation has been revoked"}
+	}
+	return invitation, nil
+}
+
+// rotateToken sets a new token hash and expiry on the invitation and returns
+// the plaintext token, which only ever leaves the server in the link
+func (i *invitationServiceImpl) rotateToken(invitation *entity.Invitation) (string, error) {
+	token, err := generateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	invitation.TokenHash = hashRefreshToken(token)
+	invitation.ExpiresAt = now.Add(time.Duration(intConfig(i.Config, "INVITATION_TTL_HOURS", 72)) * time.Hour)
+	invitation.SendCount++
+	invitation.LastSentAt = now
+	return token, nil
+}
+
+func (i *invitationServiceImpl) send(ctx context.Context, invitation entity.Invitation, token string) {
+	user := invitation.User
+	link := i.activationUrl(token)
+	hours := int(time.Until(invitation.ExpiresAt).Round(time.Hour).Hours())
+	message := fmt.Sprintf("Hello %s, %s Set your password within %d hours at %s", user.FirstName, invitationIntro(invitation.Role), hours, link)
+	// The activation link sets the password, the messages carry no UserId so
+	// that they are not kept in the inbox
+
+	if user.PhoneNumber != "" {
+		err := i.MessageService.SendSMS(ctx, model.SMSMessageModel{
+			PhoneNumber: user.PhoneNumber,
+			CountryCode: user.AreaCode,
+			Message:     message,
+		})
+		if err != nil {
+			logger.Logger.Error("Failed to queue invitation SMS: " + err.Error())
+		}
+	}
+	if user.Email != "" {
+		i.MessageService.SendEmail(ctx, model.EmailMessageModel{
+			To:      user.Email,
+			Subject: "Activate your AquaWizz account",
+			Message: message,
+		})
+	}
+}
+
+func (i *invitationServiceImpl) activationUrl(token string) string {
+	baseUrl := i.Config.Get("INVITATION_BASE_URL")
+	if baseUrl == "" {
+		baseUrl = "https://aqua-wizz.app/activate"
+	}
+	return baseUrl + "?token=" + url.QueryEscape(token)
+}
+
+func invitationIntro(role string) string {
+	switch role {
+	case common.REFINERY_ADMIN_ROLE:
+		return "your refinery has been registered on AquaWizz."
+	case common.REFINERY_DISPATCHER_ROLE, common.REFINERY_FINANCE_ROLE:
+		return "you have been added to your refinery's staff on AquaWizz."
+	case common.TRUCK_DRIVER_ROLE:
+		return "your truck has been registered on AquaWizz."
+	default:
+		return "you have been invited to AquaWizz."
+	}
+}
@@ -0,0 +1,18 @@
+package impl
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// TestMain sets up a logger that discards its output, logger.NewLogger would
+// write log files next to the tests
+func TestMain(m *testing.M) {
+	logger.Logger = logrus.New()
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
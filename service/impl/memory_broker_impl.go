@@ -0,0 +1,368 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/google/uuid"
+)
+
+type memoryMessage struct {
+	id        string
+	body      []byte
+	attempts  int
+	lastError string
+	failedAt  time.Time
+}
+
+// memorySubscription is the queue of one subscribed routing key pattern
+type memorySubscription struct {
+	topic    string
+	handler  func([]byte) error
+	messages chan memoryMessage
+	// dead is guarded by the broker mutex
+	dead []memoryMessage
+}
+
+// memoryBroker routes messages between goroutines of this process with the
+// semantics of the RabbitMQ topic exchange: routing keys match the
+// subscription patterns with * and # wildcards, a message is acknowledged
+// when the handler returns nil and redelivered with backoff when it fails,
+// until it runs out of attempts or fails permanently and is dead-lettered
+type memoryBroker struct {
+	mu             sync.Mutex
+	subscriptions  map[string]*memorySubscription
+	maxAttempts    int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	queueSize      int
+	done           chan struct{}
+	closed         bool
+	since          time.Time
+	workers        sync.WaitGroup
+}
+
+func NewMemoryBroker(config configuration.Config) MessageBroker {
+	return &memoryBroker{
+		subscriptions:  map[string]*memorySubscription{},
+		maxAttempts:    intConfig(config, "MEMORY_BROKER_MAX_ATTEMPTS", 5),
+		retryBaseDelay: time.Duration(intConfig(config, "MEMORY_BROKER_RETRY_BASE_MILLISECONDS", 500)) * time.Millisecond,
+		retryMaxDelay:  time.Duration(intConfig(config, "MEMORY_BROKER_RETRY_MAX_MILLISECONDS", 30000)) * time.Millisecond,
+		queueSize:      intConfig(config, "MEMORY_BROKER_QUEUE_SIZE", 10000),
+		done:           make(chan struct{}),
+		since:          time.Now(),
+	}
+}
+
+func (b *memoryBroker) PublishMessage(topic string, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		logger.Logger.Error(fmt.Sprintf("Error marshaling message: %s", err.Error()))
+		return err
+	}
+
+	// Messages are only ever queued with the mutex held, so the queues cannot
+	// fill up between checking them and delivering
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errors.New("memory broker is closed")
+	}
+	var targets []*memorySubscription
+	for pattern, subscription := range b.subscriptions {
+		if topicMatches(pattern, topic) {
+			targets = append(targets, subscription)
+		}
+	}
+
+	// The message goes to every matching queue or to none of them, so that
+	// publishing it again after an error does not deliver it twice
+	for _, subscription := range targets {
+		if len(subscription.messages) == cap(subscription.messages) {
+			return fmt.Errorf("memory broker queue %s is full", subscription.topic)
+		}
+	}
+	// Like an exchange without a bound queue, a message nobody subscribed to
+	// is dropped
+	for _, subscription := range targets {
+		subscription.messages <- memoryMessage{id: uuid.NewString(), body: body}
+	}
+	logger.Logger.Info(fmt.Sprintf("Published message to memory broker with routing key %s", topic))
+	return nil
+}
+
+// PublishConfirmed is PublishMessage, the queues are the broker
+func (b *memoryBroker) PublishConfirmed(topic string, message interface{}) error {
+	return b.PublishMessage(topic, message)
+}
+
+func (b *memoryBroker) SubscribeToTopic(topic string, handler func([]byte) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return errors.New("memory broker is closed")
+	}
+	if _, ok := b.subscriptions[topic]; ok {
+		return fmt.Errorf("already subscribed to %s", topic)
+	}
+	subscription := &memorySubscription{
+		topic:    topic,
+		handler:  handler,
+		messages: make(chan memoryMessage, b.queueSize),
+	}
+	b.subscriptions[topic] = subscription
+
+	b.workers.Add(1)
+	go func() {
+		defer b.workers.Done()
+		for {
+			select {
+			case <-b.done:
+				return
+			case message := <-subscription.messages:
+				b.deliver(subscription, message)
+			}
+		}
+	}()
+
+	logger.Logger.Info(fmt.Sprintf("Subscribed to memory broker with routing key %s", topic))
+	return nil
+}
+
+func (b *memoryBroker) deliver(subscription *memorySubscription, message memoryMessage) {
+	err := invokeHandler(subscription.handler, message.body)
+	if err == nil {
+		return
+	}
+	logger.Logger.Error(fmt.Sprintf("Error handling message: %s", err.Error()))
+
+	message.attempts++
+	message.lastError = truncate(err.Error(), 255)
+	var permanent service.PermanentError
+	if message.attempts >= b.maxAttempts || errors.As(err, &permanent) {
+		message.failedAt = time.Now()
+		b.mu.Lock()
+		subscription.dead = append(subscription.dead, message)
+		b.mu.Unlock()
+		logger.Logger.Warn(fmt.Sprintf("Dead-lettered message %s from %s after %d attempts", message.id, subscription.topic, message.attempts))
+		return
+	}
+
+	delay := exponentialBackoff(b.retryBaseDelay, b.retryMaxDelay, message.attempts)
+	logger.Logger.Info(fmt.Sprintf("Retrying message %s from %s in %s, attempt %d of %d", message.id, subscription.topic, delay, message.attempts+1, b.maxAttempts))
+	time.AfterFunc(delay, func() {
+		b.requeue(subscription, message)
+	})
+}
+
+// requeue puts a message back on its queue, waiting for room while the queue
+// is full
+func (b *memoryBroker) requeue(subscription *memorySubscription, message memoryMessage) {
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return
+		}
+		select {
+		case subscription.messages <- message:
+			b.mu.Unlock()
+			return
+		default:
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-time.After(b.retryBaseDelay):
+		case <-b.done:
+			return
+		}
+	}
+}
+
+// invokeHandler turns a handler panic into a failed delivery
+func invokeHandler(handler func([]byte) error, body []byte) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("handler panicked: %v", recovered)
+		}
+	}()
+	return handler(body)
+}
+
+// Close stops the consumers once their current message is handled, queued
+// messages are dropped
+func (b *memoryBroker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.since = time.Now()
+	close(b.done)
+	b.mu.Unlock()
+
+	b.workers.Wait()
+	return nil
+}
+
+func (b *memoryBroker) Health() model.BrokerHealthModel {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state := common.BROKER_STATE_CONNECTED
+	if b.closed {
+		state = common.BROKER_STATE_CLOSED
+	}
+	return model.BrokerHealthModel{
+		State:         state,
+		Since:         b.since,
+		Subscriptions: len(b.subscriptions),
+	}
+}
+
+func (b *memoryBroker) ListDeadLetterQueues(ctx context.Context) ([]model.DeadLetterQueueModel, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	topics := make([]string, 0, len(b.subscriptions))
+	for topic := range b.subscriptions {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	queues := make([]model.DeadLetterQueueModel, 0, len(topics))
+	for _, topic := range topics {
+		queues = append(queues, model.DeadLetterQueueModel{
+			Topic:    topic,
+			Queue:    deadLetterQueueName(topic),
+			Messages: len(b.subscriptions[topic].dead),
+		})
+	}
+	return queues, nil
+}
+
+func (b *memoryBroker) ListDeadLetters(ctx context.Context, topic string, limit int) ([]model.DeadLetterModel, error) {
+	if limit <= 0 {
+		limit = defaultDeadLetterLimit
+	}
+	if limit > maxDeadLetterLimit {
+		limit = maxDeadLetterLimit
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subscription, err := b.subscriptionOf(topic)
+	if err != nil {
+		return nil, err
+	}
+	deadLetters := make([]model.DeadLetterModel, 0)
+	for _, message := range subscription.dead {
+		if len(deadLetters) == limit {
+			break
+		}
+		failedAt := message.failedAt
+		deadLetters = append(deadLetters, model.DeadLetterModel{
+			MessageId: message.id,
+			Topic:     topic,
+			Attempts:  message.attempts,
+			Error:     message.lastError,
+			FailedAt:  &failedAt,
+			Body:      deadLetterBody(message.body),
+		})
+	}
+	return deadLetters, nil
+}
+
+func (b *memoryBroker) ReplayDeadLetters(ctx context.Context, topic string, messageIds []string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subscription, err := b.subscriptionOf(topic)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	kept := make([]memoryMessage, 0, len(subscription.dead))
+	for i, message := range subscription.dead {
+		if len(messageIds) > 0 && !contains(messageIds, message.id) {
+			kept = append(kept, message)
+			continue
+		}
+		message.attempts = 0
+		message.lastError = ""
+		select {
+		case subscription.messages <- message:
+			replayed++
+		default:
+			subscription.dead = append(kept, subscription.dead[i:]...)
+			return replayed, fmt.Errorf("memory broker queue %s is full", topic)
+		}
+	}
+	subscription.dead = kept
+	return replayed, nil
+}
+
+func (b *memoryBroker) PurgeDeadLetters(ctx context.Context, topic string, messageIds []string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subscription, err := b.subscriptionOf(topic)
+	if err != nil {
+		return 0, err
+	}
+
+	kept := make([]memoryMessage, 0, len(subscription.dead))
+	for _, message := range subscription.dead {
+		if len(messageIds) > 0 && !contains(messageIds, message.id) {
+			kept = append(kept, message)
+		}
+	}
+	purged := len(subscription.dead) - len(kept)
+	subscription.dead = kept
+	return purged, nil
+}
+
+// subscriptionOf must be called with b.mu held
+func (b *memoryBroker) subscriptionOf(topic string) (*memorySubscription, error) {
+	subscription, ok := b.subscriptions[topic]
+	if !ok {
+		return nil, exception.NotFoundError{Message: "No consumer is subscribed to topic " + topic}
+	}
+	return subscription, nil
+}
+
+// topicMatches applies a topic exchange binding pattern to a routing key,
+// * matches exactly one dot separated word and # matches zero or more
+func topicMatches(pattern string, routingKey string) bool {
+	return matchTopicWords(strings.Split(pattern, "."), strings.Split(routingKey, "."))
+}
+
+func matchTopicWords(pattern []string, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if matchTopicWords(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && matchTopicWords(pattern[1:], words[1:])
+	default:
+		return len(words) > 0 && words[0] == pattern[0] && matchTopicWords(pattern[1:], words[1:])
+	}
+}
@@ -0,0 +1,231 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+func newTestMemoryBroker(queueSize int) *memoryBroker {
+	return &memoryBroker{
+		subscriptions:  map[string]*memorySubscription{},
+		maxAttempts:    3,
+		retryBaseDelay: time.Millisecond,
+		retryMaxDelay:  5 * time.Millisecond,
+		queueSize:      queueSize,
+		done:           make(chan struct{}),
+		since:          time.Now(),
+	}
+}
+
+// waitFor polls condition until it holds or the test times out
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the broker")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestTopicMatches(t *testing.T) {
+	cases := []struct {
+		pattern    string
+		routingKey string
+		matches    bool
+	}{
+		{"order.created", "order.created", true},
+		{"order.created", "order.updated", false},
+		{"order.*", "order.created", true},
+		{"order.*", "order", false},
+		{"order.*", "order.created.v2", false},
+		{"*.created", "order.created", true},
+		{"order.#", "order", true},
+		{"order.#", "order.created.v2", true},
+		{"#", "order.created", true},
+		{"#.created", "order.payment.created", true},
+		{"#.created", "order.payment.failed", false},
+		{"order.#.v2", "order.v2", true},
+		{"order.#.v2", "order.created.v2", true},
+		{"order.*.v2", "order.v2", false},
+	}
+	for _, tc := range cases {
+		if got := topicMatches(tc.pattern, tc.routingKey); got != tc.matches {
+			t.Errorf("topicMatches(%q, %q) = %v, want %v", tc.pattern, tc.routingKey, got, tc.matches)
+		}
+	}
+}
+
+func TestMemoryBrokerRetriesFailedMessages(t *testing.T) {
+	broker := newTestMemoryBroker(10)
+	defer broker.Close()
+
+	var calls int32
+	err := broker.SubscribeToTopic("order.*", func(body []byte) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.PublishMessage("order.created", map[string]string{"id": "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool { return atomic.LoadInt32(&calls) == 3 })
+	time.Sleep(20 * time.Millisecond)
+	if calls := atomic.LoadInt32(&calls); calls != 3 {
+		t.Fatalf("expected the message to be handled 3 times, got %d", calls)
+	}
+	deadLetters, _ := broker.ListDeadLetters(context.Background(), "order.*", 0)
+	if len(deadLetters) != 0 {
+		t.Fatalf("expected no dead letters, got %d", len(deadLetters))
+	}
+}
+
+func TestMemoryBrokerDeadLettersAfterMaxAttempts(t *testing.T) {
+	broker := newTestMemoryBroker(10)
+	defer broker.Close()
+
+	var calls int32
+	err := broker.SubscribeToTopic("order.created", func(body []byte) error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("always fails")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.PublishMessage("order.created", map[string]string{"id": "1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var deadLetters []deadLetterSnapshot
+	waitFor(t, func() bool {
+		deadLetters = snapshotDeadLetters(broker, "order.created")
+		return len(deadLetters) == 1
+	})
+	if deadLetters[0].attempts != broker.maxAttempts {
+		t.Fatalf("expected %d attempts, got %d", broker.maxAttempts, deadLetters[0].attempts)
+	}
+	if calls := atomic.LoadInt32(&calls); int(calls) != broker.maxAttempts {
+		t.Fatalf("expected the handler to run %d times, got %d", broker.maxAttempts, calls)
+	}
+
+	// Replaying puts the message back on the queue with fresh attempts
+	replayed, err := broker.ReplayDeadLetters(context.Background(), "order.created", nil)
+	if err != nil || replayed != 1 {
+		t.Fatalf("expected 1 replayed message, got %d, %v", replayed, err)
+	}
+	waitFor(t, func() bool { return len(snapshotDeadLetters(broker, "order.created")) == 1 })
+	if calls := atomic.LoadInt32(&calls); int(calls) != 2*broker.maxAttempts {
+		t.Fatalf("expected the handler to run %d times, got %d", 2*broker.maxAttempts, calls)
+	}
+}
+
+func TestMemoryBrokerDeadLettersPermanentErrorsAtOnce(t *testing.T) {
+	broker := newTestMemoryBroker(10)
+	defer broker.Close()
+
+	var calls int32
+	err := broker.SubscribeToTopic("order.created", func(body []byte) error {
+		atomic.AddInt32(&calls, 1)
+		return service.PermanentError{Err: errors.New("does not parse")}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.PublishMessage("order.created", "not an order"); err != nil {
+		t.Fatal(err)
+	}
+
+	var deadLetters []deadLetterSnapshot
+	waitFor(t, func() bool {
+		deadLetters = snapshotDeadLetters(broker, "order.created")
+		return len(deadLetters) == 1
+	})
+	if deadLetters[0].attempts != 1 || deadLetters[0].lastError != "does not parse" {
+		t.Fatalf("expected one attempt failing with the handler error, got %+v", deadLetters[0])
+	}
+	if calls := atomic.LoadInt32(&calls); calls != 1 {
+		t.Fatalf("expected the handler to run once, got %d", calls)
+	}
+}
+
+func TestMemoryBrokerPanickingHandlerIsRetried(t *testing.T) {
+	broker := newTestMemoryBroker(10)
+	defer broker.Close()
+
+	var calls int32
+	err := broker.SubscribeToTopic("order.created", func(body []byte) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			panic("boom")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := broker.PublishMessage("order.created", "order"); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return atomic.LoadInt32(&calls) == 2 })
+}
+
+func TestMemoryBrokerPublishesToAllQueuesOrNone(t *testing.T) {
+	// No consumers are started, so the queues only fill up
+	broker := newTestMemoryBroker(1)
+	full := &memorySubscription{topic: "order.*", messages: make(chan memoryMessage, 1)}
+	empty := &memorySubscription{topic: "#.created", messages: make(chan memoryMessage, 1)}
+	unrelated := &memorySubscription{topic: "payment.*", messages: make(chan memoryMessage, 1)}
+	broker.subscriptions = map[string]*memorySubscription{full.topic: full, empty.topic: empty, unrelated.topic: unrelated}
+	full.messages <- memoryMessage{id: "queued"}
+
+	if err := broker.PublishMessage("order.created", "order"); err == nil {
+		t.Fatal("expected publishing to a full queue to fail")
+	}
+	if len(empty.messages) != 0 {
+		t.Fatal("expected no queue to receive the message when one of them is full")
+	}
+
+	<-full.messages
+	if err := broker.PublishMessage("order.created", "order"); err != nil {
+		t.Fatal(err)
+	}
+	if len(full.messages) != 1 || len(empty.messages) != 1 {
+		t.Fatal("expected every matching queue to receive the message")
+	}
+	if len(unrelated.messages) != 0 {
+		t.Fatal("expected queues that do not match to be left alone")
+	}
+}
+
+func TestMemoryBrokerRejectsPublishAfterClose(t *testing.T) {
+	broker := newTestMemoryBroker(1)
+	broker.Close()
+	if err := broker.PublishMessage("order.created", "order"); err == nil {
+		t.Fatal("expected publishing on a closed broker to fail")
+	}
+}
+
+type deadLetterSnapshot struct {
+	attempts  int
+	lastError string
+}
+
+func snapshotDeadLetters(broker *memoryBroker, topic string) []deadLetterSnapshot {
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+	var snapshot []deadLetterSnapshot
+	for _, message := range broker.subscriptions[topic].dead {
+		snapshot = append(snapshot, deadLetterSnapshot{attempts: message.attempts, lastError: message.lastError})
+	}
+	return snapshot
+}
@@ -0,0 +1,36 @@
+package impl
+
+import (
+	"fmt"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/streadway/amqp"
+)
+
+// MessageBroker is a message broker whose dead letters can be managed, every
+// broker driver implements both
+type MessageBroker interface {
+	service.MessageBrokerService
+	service.DeadLetterService
+}
+
+const messageBrokerExchange = "aqua_wizz_exchange"
+
+// NewMessageBroker connects the broker selected by MESSAGE_BROKER_DRIVER,
+// rabbitmq by default. The memory driver runs in process for development and
+// tests, its messages are lost on restart.
+func NewMessageBroker(config configuration.Config) (MessageBroker, error) {
+	switch config.Get("MESSAGE_BROKER_DRIVER") {
+	case "", "rabbitmq":
+		conn, err := amqp.Dial(configuration.RabbitMQURL(config))
+		if err != nil {
+			return nil, err
+		}
+		return NewRabbitMQService(conn, messageBrokerExchange, "topic", config), nil
+	case "memory":
+		return NewMemoryBroker(config), nil
+	default:
+		return nil, fmt.Errorf("unknown message broker driver %q", config.Get("MESSAGE_BROKER_DRIVER"))
+	}
+}
@@ -4,9 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"strconv"
 	"time"
 
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
@@ -14,103 +14,73 @@ import (
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
-	"gopkg.in/gomail.v2"
+	"gorm.io/gorm"
 )
 
-func NewMessageServiceImpl(config configuration.Config, messageTemplateRepository repository.MessageTemplateRepository, httpService *service.HttpService, rabbitMQService *RabbitMQService, notificationRepository repository.NotificationRepository) service.MessageService {
+func NewMessageServiceImpl(config configuration.Config, messageTemplateRepository repository.MessageTemplateRepository, smsRouter service.SMSRouter, messageBrokerService service.MessageBrokerService, notificationRepository repository.NotificationRepository) service.MessageService {
 	return &messageServiceImpl{
 		config:                    config,
 		MessageTemplateRepository: messageTemplateRepository,
-		HttpService:               *httpService,
-		rabbitMQService:           rabbitMQService,
+		smsRouter:                 smsRouter,
+		messageBrokerService:      messageBrokerService,
 		notificationRepository:    notificationRepository,
 	}
 }
 
 type messageServiceImpl struct {
-	service.HttpService
-	config configuration.Config
+	config    configuration.Config
+	smsRouter service.SMSRouter
 	repository.MessageTemplateRepository
-	rabbitMQService        *RabbitMQService
+	messageBrokerService   service.MessageBrokerService
 	notificationRepository repository.NotificationRepository
 }
 
-func (m *messageServiceImpl) GenerateOneTimePassword(context context.Context, uid uint) (entity.OneTimePassword, error) {
-	otp, err := m.MessageTemplateRepository.GenerateOneTImePassword(context, uid)
-	exception.PanicLogging(err)
-	return otp, nil
-}
-
 func (m *messageServiceImpl) SendSMS(ctx context.Context, data model.SMSMessageModel) error {
-	// Create a queued SMS message
-	queuedSMS := model.QueuedSMSMessage{
-		PhoneNumber: data.PhoneNumber,
-		CountryCode: data.CountryCode,
-		Message:     data.Message,
+	recipient, err := internationalPhoneNumber(data.CountryCode, data.PhoneNumber)
+	if err != nil {
+		recipient = data.CountryCode + data.PhoneNumber
 	}
-
-	// Try to publish to RabbitMQ
-	err := m.rabbitMQService.PublishMessage("sms.send", queuedSMS)
+	notification, err := m.notificationRepository.Create(ctx, entity.Notification{
+		UserID:      data.UserId,
+		Type:        entity.SMS,
+		Recipient:   recipient,
+		CountryCode: countryCodeOf(data.CountryCode),
+		Content:     data.Message,
+		Status:      common.NOTIFICATION_STATUS_QUEUED,
+		SentAt:      time.Now(),
+	})
 	if err != nil {
-		logger.Logger.Error(fmt.Sprintf("Failed to queue SMS to %s: %s", data.PhoneNumber, err.Error()))
-		// Fall back to synchronous sending if publishing fails
-		m.SendSMSDirect(data)
-		return err
-	} else {
-		logger.Logger.Info(fmt.Sprintf("SMS to %s queued successfully", data.PhoneNumber))
+		// The message is still sent, only its status cannot be tracked
+		logger.Logger.Error(fmt.Sprintf("Failed to save SMS notification: %s", err.Error()))
 	}
 
-	// Save notification record
-	notification := entity.Notification{
-		Type:      entity.SMS,
-		Recipient: data.CountryCode + data.PhoneNumber,
-		Content:   data.Message,
-		Status:    "sent",
-		SentAt:    time.Now(),
+	queuedSMS := model.QueuedSMSMessage{
+		PhoneNumber:    data.PhoneNumber,
+		CountryCode:    data.CountryCode,
+		Message:        data.Message,
+		UserID:         data.UserId,
+		NotificationId: notification.ID,
 	}
-
-	_, err = m.notificationRepository.Create(ctx, notification)
+	err = m.messageBrokerService.PublishMessage("sms.send", queuedSMS)
 	if err != nil {
-
-		logger.Logger.Error(fmt.Sprintf("Failed to save SMS notification: %s", err.Error()))
+		logger.Logger.Error(fmt.Sprintf("Failed to queue SMS to %s: %s", data.PhoneNumber, err.Error()))
+		// Fall back to synchronous sending if publishing fails
+		result, err := m.smsRouter.Send(ctx, data)
+		recordSMSResult(ctx, m.notificationRepository, notification.ID, result, err)
 		return err
 	}
+	logger.Logger.Info(fmt.Sprintf("SMS to %s queued successfully", data.PhoneNumber))
 	return nil
 }
 
-// sendSMSDirect sends an SMS directly as a fallback mechanism
+// SendSMSDirect sends an SMS through the configured providers without going
+// through the queue
 func (m *messageServiceImpl) SendSMSDirect(data model.SMSMessageModel) error {
-	headers := make(map[string]interface{})
-	headers["apiKey"] = m.config.Get("AFRICAS_TALKING_API_KEY")
-
-	body := make(map[string]interface{})
-	body["username"] = m.config.Get("AFRICAS_TALKING_USERNAME")
-	body["enqueue"] = 1
-	body["message"] = data.Message
-
-	phoneNumber := data.PhoneNumber
-	if phoneNumber == "" {
-		return errors.New("invalid phone number")
-	}
-	if phoneNumber[0] == '0' {
-		phoneNumber = data.CountryCode + phoneNumber[1:]
-	} else if data.CountryCode != "" && phoneNumber[0] != '+' {
-		phoneNumber = data.CountryCode + phoneNumber
-	}
-
-	body["to"] = phoneNumber
-	body["from"] = "AquaWizz"
-
-	url := m.config.Get("AFRICAS_TALKING_BASE_URL") + "/version1/messaging"
-	_, err := m.HttpService.PostMethod(context.Background(), url, "POST", &body, &headers, true)
-
+	_, err := m.smsRouter.Send(context.Background(), data)
 	if err != nil {
 		logger.Logger.Error(fmt.Sprintf("Error sending SMS directly: %s", err.Error()))
-		return err
-	} else {
-		logger.Logger.Info(fmt.Sprintf("SMS sent directly to %s successfully!", phoneNumber))
 	}
-	return nil
+	return err
 }
 
 func (m *messageServiceImpl) FindMessageTemplateById(ctx context.Context, id int) model.MessageTemplateModel {
@@ -118,9 +88,12 @@ func (m *messageServiceImpl) FindMessageTemplateById(ctx context.Context, id int
 	messageTemplate, err := m.MessageTemplateRepository.FindById(ctx, id)
 	exception.PanicLogging(err)
 	result := model.MessageTemplateModel{
+		Id:      messageTemplate.ID,
 		Name:    messageTemplate.Name,
 		Message: messageTemplate.Message,
 		Subject: messageTemplate.Subject,
+		IsEmail: messageTemplate.IsEmailMessage,
+		IsSms:   messageTemplate.IsSMSMessage,
 	}
 	return result
 }
@@ -129,6 +102,7 @@ func (m *messageServiceImpl) FindMessageTemplateByName(ctx context.Context, name
 	messageTemplate, err := m.MessageTemplateRepository.FindByName(ctx, name)
 	exception.PanicLogging(err)
 	messageTemplateModel := model.MessageTemplateModel{
+		Id:      messageTemplate.ID,
 		Name:    messageTemplate.Name,
 		Message: messageTemplate.Message,
 		Subject: messageTemplate.Subject,
@@ -145,84 +119,161 @@ func (m *messageServiceImpl) FindAllMessageTemplate(ctx context.Context) []model
 	var result []model.MessageTemplateModel
 	for _, messageTemplate := range messageTemplates {
 		result = append(result, model.MessageTemplateModel{
+			Id:      messageTemplate.ID,
 			Name:    messageTemplate.Name,
 			Message: messageTemplate.Message,
 			Subject: messageTemplate.Subject,
+			IsEmail: messageTemplate.IsEmailMessage,
+			IsSms:   messageTemplate.IsSMSMessage,
 		})
 	}
 	return result
 }
 
-func (m *messageServiceImpl) CreateMessageTemplate(ctx context.Context, model model.MessageTemplateModel) {
-	var messageTemplate entity.MessageTemplate
-	messageTemplate.Name = model.Name
-	messageTemplate.Message = model.Message
-	messageTemplate.Subject = model.Subject
-	err := m.MessageTemplateRepository.Create(ctx, model)
-	exception.PanicLogging(err)
-}
-
-func (m *messageServiceImpl) UpdateMessageTemplate(ctx context.Context, template model.MessageTemplateModel) {
-	err := m.MessageTemplateRepository.Update(ctx, template)
-	exception.PanicLogging(err)
-}
-
 func (m *messageServiceImpl) SendEmail(ctx context.Context, emailModel model.EmailMessageModel) {
+	notification, err := m.notificationRepository.Create(ctx, entity.Notification{
+		UserID:    emailModel.UserId,
+		Type:      entity.EMAIL,
+		Recipient: emailModel.To,
+		Subject:   emailModel.Subject,
+		Content:   emailModel.Message,
+		Status:    common.NOTIFICATION_STATUS_QUEUED,
+		SentAt:    time.Now(),
+	})
+	if err != nil {
+		logger.Logger.Error(fmt.Sprintf("Failed to save email notification: %s", err.Error()))
+	}
+
 	// Create a queued email message
 	queuedEmail := model.QueuedEmailMessage{
-		To:      emailModel.To,
-		Subject: emailModel.Subject,
-		Message: emailModel.Message,
-		From:    m.config.Get("MAIL_FROM"),
+		To:             emailModel.To,
+		Subject:        emailModel.Subject,
+		Message:        emailModel.Message,
+		From:           m.config.Get("MAIL_FROM"),
+		NotificationId: notification.ID,
 	}
 
 	// Publish to RabbitMQ
-	err := m.rabbitMQService.PublishMessage("email.send", queuedEmail)
+	err = m.messageBrokerService.PublishMessage("email.send", queuedEmail)
 	if err != nil {
 		logger.Logger.Error(fmt.Sprintf("Failed to queue email to %s: %s", emailModel.To, err.Error()))
 		// Fall back to synchronous sending if publishing fails
-		m.sendEmailDirect(queuedEmail)
-	} else {
-		logger.Logger.Info(fmt.Sprintf("Email to %s queued successfully", emailModel.To))
+		err = deliverEmail(m.config, queuedEmail)
+		recordEmailResult(ctx, m.notificationRepository, notification.ID, err)
+		if err != nil {
+			logger.Logger.Error(fmt.Sprintf("Error sending email directly: %s", err.Error()))
+		}
+		return
 	}
+	logger.Logger.Info(fmt.Sprintf("Email to %s queued successfully", emailModel.To))
+}
 
-	// Save notification record
-	notification := entity.Notification{
-		Type:      entity.EMAIL,
-		Recipient: emailModel.To,
-		Subject:   emailModel.Subject,
-		Content:   emailModel.Message,
-		Status:    "sent",
-		SentAt:    time.Now(),
+// HandleSMSDeliveryReport moves the notification of the reported message to
+// delivered or failed. Reports for unknown messages are ignored.
+func (m *messageServiceImpl) HandleSMSDeliveryReport(ctx context.Context, provider string, report model.SMSDeliveryReportModel) error {
+	status, ok := africasTalkingDeliveryStatus[report.Status]
+	if !ok {
+		logger.Logger.Warn(fmt.Sprintf("Ignoring %s delivery report with status %s", provider, report.Status))
+		return nil
+	}
+	notification, err := m.notificationRepository.FindByProviderMessageId(ctx, provider, report.Id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.Logger.Warn(fmt.Sprintf("Delivery report for unknown %s message %s", provider, report.Id))
+			return nil
+		}
+		return err
+	}
+
+	update := entity.Notification{Status: status}
+	switch status {
+	case common.NOTIFICATION_STATUS_DELIVERED:
+		now := time.Now()
+		update.DeliveredAt = &now
+	case common.NOTIFICATION_STATUS_FAILED:
+		update.FailureReason = truncate(report.FailureReason, 255)
 	}
+	return updateNotificationStatus(ctx, m.notificationRepository, notification.ID, update)
+}
 
-	_, err = m.notificationRepository.Create(ctx, notification)
+func (m *messageServiceImpl) DeliveryStats(ctx context.Context, messageType string, from time.Time, to time.Time) ([]model.DeliveryStatModel, error) {
+	stats, err := m.notificationRepository.DeliveryStats(ctx, messageType, from, to)
 	if err != nil {
-		logger.Logger.Error(fmt.Sprintf("Failed to save email notification: %s", err.Error()))
+		return nil, err
+	}
+	for i := range stats {
+		if attempted := stats[i].Total - stats[i].Queued; attempted > 0 {
+			stats[i].FailureRate = float64(stats[i].Failed) / float64(attempted)
+		}
 	}
-	return
+	return stats, nil
 }
 
-// sendEmailDirect sends an email directly as a fallback mechanism
-func (m *messageServiceImpl) sendEmailDirect(email model.QueuedEmailMessage) {
-	message := gomail.NewMessage()
+// africasTalkingDeliveryStatus maps the statuses of Africa's Talking delivery
+// reports, Sent, Submitted and Buffered mean the network still has it
+var africasTalkingDeliveryStatus = map[string]string{
+	"Sent":      common.NOTIFICATION_STATUS_SENT,
+	"Submitted": common.NOTIFICATION_STATUS_SENT,
+	"Buffered":  common.NOTIFICATION_STATUS_SENT,
+	"Success":   common.NOTIFICATION_STATUS_DELIVERED,
+	"Failed":    common.NOTIFICATION_STATUS_FAILED,
+	"Rejected":  common.NOTIFICATION_STATUS_FAILED,
+}
 
-	// Set email headers
-	message.SetHeader("From", email.From)
-	message.SetHeader("To", email.To)
-	message.SetHeader("Subject", email.Subject)
+// notificationStatusFrom lists the statuses a notification may move to each
+// status from. Delivered is final, a failed message can still be sent by a
+// retry and a late delivery report wins over an earlier failure.
+var notificationStatusFrom = map[string][]string{
+	common.NOTIFICATION_STATUS_SENT:      {common.NOTIFICATION_STATUS_QUEUED, common.NOTIFICATION_STATUS_FAILED},
+	common.NOTIFICATION_STATUS_FAILED:    {common.NOTIFICATION_STATUS_QUEUED, common.NOTIFICATION_STATUS_SENT},
+	common.NOTIFICATION_STATUS_DELIVERED: {common.NOTIFICATION_STATUS_QUEUED, common.NOTIFICATION_STATUS_SENT, common.NOTIFICATION_STATUS_FAILED},
+}
+
+func updateNotificationStatus(ctx context.Context, notificationRepository repository.NotificationRepository, id uint, update entity.Notification) error {
+	if id == 0 {
+		return nil
+	}
+	err := notificationRepository.UpdateStatus(ctx, id, notificationStatusFrom[update.Status], update)
+	if err != nil {
+		logger.Logger.Error(fmt.Sprintf("Failed to update notification %d to %s: %s", id, update.Status, err.Error()))
+	}
+	return err
+}
 
-	// Set email body
-	message.SetBody("text/html", email.Message)
+// recordSMSResult stores the outcome of handing an SMS to the providers
+func recordSMSResult(ctx context.Context, notificationRepository repository.NotificationRepository, id uint, result model.SMSProviderResult, err error) {
+	if err != nil {
+		updateNotificationStatus(ctx, notificationRepository, id, entity.Notification{
+			Status:        common.NOTIFICATION_STATUS_FAILED,
+			FailureReason: truncate(err.Error(), 255),
+		})
+		return
+	}
+	updateNotificationStatus(ctx, notificationRepository, id, entity.Notification{
+		Status:            common.NOTIFICATION_STATUS_SENT,
+		Provider:          result.Provider,
+		ProviderMessageId: result.MessageId,
+	})
+}
 
-	// Set up the SMTP
-	port, _ := strconv.Atoi(m.config.Get("MAIL_PORT"))
-	dialer := gomail.NewDialer(m.config.Get("MAIL_HOST"), port, m.config.Get("MAIL_USERNAME"), m.config.Get("MAIL_PASSWORD"))
+func recordEmailResult(ctx context.Context, notificationRepository repository.NotificationRepository, id uint, err error) {
+	if err != nil {
+		updateNotificationStatus(ctx, notificationRepository, id, entity.Notification{
+			Status:        common.NOTIFICATION_STATUS_FAILED,
+			FailureReason: truncate(err.Error(), 255),
+		})
+		return
+	}
+	updateNotificationStatus(ctx, notificationRepository, id, entity.Notification{
+		Status:   common.NOTIFICATION_STATUS_SENT,
+		Provider: "smtp",
+	})
+}
 
-	// Send the email
-	if err := dialer.DialAndSend(message); err != nil {
-		logger.Logger.Error(fmt.Sprintf("Error sending email directly: %s", err.Error()))
-	} else {
-		logger.Logger.Info("Email sent directly successfully!")
+// countryCodeOf normalises a dialling code to the +233 form
+func countryCodeOf(countryCode string) string {
+	if code := dialingCode(countryCode); code != "" {
+		return "+" + code
 	}
+	return ""
 }
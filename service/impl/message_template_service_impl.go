@@ -0,0 +1,914 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmlTemplate "html/template"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	textTemplate "text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"gorm.io/gorm"
+)
+
+// legacyPlaceholder matches the {{Key}} placeholders of the original
+// templates, they become {{.Key}} when the first version is seeded
+var legacyPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+var errNoPublishedVersion = errors.New("no published version")
+
+// templateName is the form of new template names, existing code looks
+// templates up by names such as customer_template
+var templateName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// variableName is a name that can be used as {{.Name}} in a template
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type messageTemplateServiceImpl struct {
+	repository.MessageTemplateRepository
+	repository.MessageTemplateVersionRepository
+	auditRepository repository.MessageTemplateAuditRepository
+	messageService  service.MessageService
+}
+
+func NewMessageTemplateService(messageTemplateRepository repository.MessageTemplateRepository, messageTemplateVersionRepository repository.MessageTemplateVersionRepository, auditRepository repository.MessageTemplateAuditRepository, messageService service.MessageService) service.MessageTemplateService {
+	return &messageTemplateServiceImpl{
+		MessageTemplateRepository:        messageTemplateRepository,
+		MessageTemplateVersionRepository: messageTemplateVersionRepository,
+		auditRepository:                  auditRepository,
+		messageService:                   messageService,
+	}
+}
+
+func (m *messageTemplateServiceImpl) Render(ctx context.Context, name string, language string, data map[string]interface{}) (model.RenderedMessageModel, error) {
+	template, err := m.MessageTemplateRepository.FindByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.RenderedMessageModel{}, exception.NotFoundError{Message: "Message template " + name + " not found"}
+		}
+		return model.RenderedMessageModel{}, err
+	}
+
+	version, err := m.publishedVersion(ctx, template, language)
+	if err != nil {
+		return model.RenderedMessageModel{}, err
+	}
+	return renderVersion(template, version, data)
+}
+
+func (m *messageTemplateServiceImpl) publishedVersion(ctx context.Context, template entity.MessageTemplate, language string) (entity.MessageTemplateVersion, error) {
+	language = common.ResolveLanguage(language, "")
+	version, err := m.MessageTemplateVersionRepository.FindPublished(ctx, template.ID, language)
+	if errors.Is(err, gorm.ErrRecordNotFound) && language != common.LANGUAGE_ENGLISH {
+		version, err = m.MessageTemplateVersionRepository.FindPublished(ctx, template.ID, common.LANGUAGE_ENGLISH)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return entity.MessageTemplateVersion{}, fmt.Errorf("message template %s has %w", template.Name, errNoPublishedVersion)
+	}
+	return version, err
+}
+
+func (m *messageTemplateServiceImpl) ListTemplates(ctx context.Context) ([]model.MessageTemplateModel, error) {
+	templates, err := m.MessageTemplateRepository.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.MessageTemplateModel, 0, len(templates))
+	for _, template := range templates {
+		result = append(result, messageTemplateModel(template))
+	}
+	return result, nil
+}
+
+func (m *messageTemplateServiceImpl) GetTemplate(ctx context.Context, templateId uint) (model.MessageTemplateModel, error) {
+	template, err := m.findTemplate(ctx, templateId)
+	if err != nil {
+		return model.MessageTemplateModel{}, err
+	}
+	return messageTemplateModel(template), nil
+}
+
+func (m *messageTemplateServiceImpl) CreateTemplate(ctx context.Context, request model.MessageTemplateModel, actor model.AuditActor) (model.MessageTemplateModel, error) {
+	request.Name = strings.TrimSpace(request.Name)
+	if !templateName.MatchString(request.Name) || len(request.Name) > 100 {
+		return model.MessageTemplateModel{}, exception.BadRequestError{Message: "name must be at most 100 lowercase letters, digits and underscores"}
+	}
+	if _, err := m.MessageTemplateRepository.FindByName(ctx, request.Name); err == nil {
+		return model.MessageTemplateModel{}, exception.BadRequestError{Message: "A template named " + request.Name + " already exists"}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.MessageTemplateModel{}, err
+	}
+	if err := validateTemplateFields(request); err != nil {
+		return model.MessageTemplateModel{}, err
+	}
+	variables, err := templateVariablesOf(request.Variables)
+	if err != nil {
+		return model.MessageTemplateModel{}, err
+	}
+
+	template := entity.MessageTemplate{
+		Name:           request.Name,
+		Description:    request.Description,
+		Subject:        request.Subject,
+		Message:        request.Message,
+		IsEmailMessage: request.IsEmail,
+		IsSMSMessage:   request.IsSms,
+		Variables:      variables,
+	}
+	// The first English draft is checked before anything is stored
+	var draft *entity.MessageTemplateVersion
+	if strings.TrimSpace(request.Message) != "" {
+		draft = &entity.MessageTemplateVersion{
+			Language:  common.LANGUAGE_ENGLISH,
+			Version:   1,
+			Status:    common.TEMPLATE_VERSION_STATUS_DRAFT,
+			Subject:   request.Subject,
+			TextBody:  request.Message,
+			CreatedBy: actor.UserId,
+		}
+		if template.IsEmailMessage {
+			draft.HtmlBody = request.Message
+		}
+		if err := validateDraft(template, draftOf(*draft)); err != nil {
+			return model.MessageTemplateModel{}, err
+		}
+	}
+
+	template, err = m.MessageTemplateRepository.Create(ctx, template)
+	if err != nil {
+		return model.MessageTemplateModel{}, err
+	}
+	m.writeAudit(ctx, template, 0, common.TEMPLATE_AUDIT_CREATE, map[string]interface{}{
+		"description": template.Description,
+		"isEmail":     template.IsEmailMessage,
+		"isSms":       template.IsSMSMessage,
+		"variables":   template.Variables,
+	}, actor)
+
+	if draft != nil {
+		draft.TemplateId = template.ID
+		version, err := m.MessageTemplateVersionRepository.Create(ctx, *draft)
+		if err != nil {
+			return model.MessageTemplateModel{}, err
+		}
+		m.writeAudit(ctx, template, version.ID, common.TEMPLATE_AUDIT_CREATE_DRAFT, map[string]interface{}{
+			"language": version.Language,
+			"version":  version.Version,
+		}, actor)
+	}
+	return messageTemplateModel(template), nil
+}
+
+func (m *messageTemplateServiceImpl) UpdateTemplate(ctx context.Context, templateId uint, request model.MessageTemplateModel, actor model.AuditActor) (model.MessageTemplateModel, error) {
+	template, err := m.findTemplate(ctx, templateId)
+	if err != nil {
+		return model.MessageTemplateModel{}, err
+	}
+	if request.Name != "" && request.Name != template.Name {
+		return model.MessageTemplateModel{}, exception.BadRequestError{Message: "The name of a template cannot be changed"}
+	}
+	if err := validateTemplateFields(request); err != nil {
+		return model.MessageTemplateModel{}, err
+	}
+	variables, err := templateVariablesOf(request.Variables)
+	if err != nil {
+		return model.MessageTemplateModel{}, err
+	}
+
+	updated := template
+	updated.Description = request.Description
+	updated.IsEmailMessage = request.IsEmail
+	updated.IsSMSMessage = request.IsSms
+	updated.Variables = variables
+
+	// Archived versions are only restored through rollback, which checks them
+	// against the variables declared at that time, so only the live versions
+	// have to keep working now
+	versions, err := m.MessageTemplateVersionRepository.FindByTemplateId(ctx, templateId, "")
+	if err != nil {
+		return model.MessageTemplateModel{}, err
+	}
+	for _, version := range versions {
+		if version.Status == common.TEMPLATE_VERSION_STATUS_ARCHIVED {
+			continue
+		}
+		if err := validateDraft(updated, draftOf(version)); err != nil {
+			return model.MessageTemplateModel{}, exception.BadRequestError{Message: fmt.Sprintf("%s version %d (%s) would break: %s", version.Status, version.Version, version.Language, err.Error())}
+		}
+	}
+
+	changes := map[string]interface{}{}
+	recordChange(changes, "description", template.Description, updated.Description)
+	recordChange(changes, "isEmail", template.IsEmailMessage, updated.IsEmailMessage)
+	recordChange(changes, "isSms", template.IsSMSMessage, updated.IsSMSMessage)
+	if !sameVariables(template.Variables, updated.Variables) {
+		changes["variables"] = map[string]interface{}{"from": template.Variables, "to": updated.Variables}
+	}
+
+	updated, err = m.MessageTemplateRepository.Update(ctx, updated)
+	if err != nil {
+		return model.MessageTemplateModel{}, err
+	}
+	m.writeAudit(ctx, updated, 0, common.TEMPLATE_AUDIT_UPDATE, changes, actor)
+	return messageTemplateModel(updated), nil
+}
+
+func (m *messageTemplateServiceImpl) DeleteTemplate(ctx context.Context, templateId uint, actor model.AuditActor) error {
+	template, err := m.findTemplate(ctx, templateId)
+	if err != nil {
+		return err
+	}
+	if err := m.MessageTemplateRepository.Delete(ctx, templateId); err != nil {
+		return err
+	}
+	m.writeAudit(ctx, template, 0, common.TEMPLATE_AUDIT_DELETE, nil, actor)
+	return nil
+}
+
+func (m *messageTemplateServiceImpl) SendTest(ctx context.Context, templateId uint, request model.MessageTemplateTestModel, actor model.AuditActor) (model.RenderedMessageModel, error) {
+	template, err := m.findTemplate(ctx, templateId)
+	if err != nil {
+		return model.RenderedMessageModel{}, err
+	}
+	if request.Email == "" && request.PhoneNumber == "" {
+		return model.RenderedMessageModel{}, exception.BadRequestError{Message: "email or phoneNumber is required"}
+	}
+	if request.Email != "" {
+		if !sendsEmail(template) {
+			return model.RenderedMessageModel{}, exception.BadRequestError{Message: "This template is not sent by email"}
+		}
+		if _, err := mail.ParseAddress(request.Email); err != nil {
+			return model.RenderedMessageModel{}, exception.BadRequestError{Message: "Invalid email address"}
+		}
+	}
+	if request.PhoneNumber != "" {
+		if !template.IsSMSMessage {
+			return model.RenderedMessageModel{}, exception.BadRequestError{Message: "This template is not sent by SMS"}
+		}
+		if request.CountryCode == "" {
+			return model.RenderedMessageModel{}, exception.BadRequestError{Message: "countryCode is required with phoneNumber"}
+		}
+	}
+
+	var version entity.MessageTemplateVersion
+	if request.VersionId != 0 {
+		version, err = m.findVersion(ctx, templateId, request.VersionId)
+	} else {
+		version, err = m.publishedVersion(ctx, template, request.Language)
+		if errors.Is(err, errNoPublishedVersion) {
+			err = exception.BadRequestError{Message: err.Error()}
+		}
+	}
+	if err != nil {
+		return model.RenderedMessageModel{}, err
+	}
+
+	values := sampleData(template)
+	for key, value := range request.Data {
+		values[key] = value
+	}
+	rendered, err := renderVersion(template, version, values)
+	if err != nil {
+		return model.RenderedMessageModel{}, exception.BadRequestError{Message: err.Error()}
+	}
+
+	recipients := []string{}
+	if request.Email != "" {
+		message := rendered.Html
+		if message == "" {
+			message = rendered.Text
+		}
+		m.messageService.SendEmail(ctx, model.EmailMessageModel{
+			To:      request.Email,
+			Subject: "[Test] " + rendered.Subject,
+			Message: message,
+		})
+		recipients = append(recipients, request.Email)
+	}
+	if request.PhoneNumber != "" {
+		err := m.messageService.SendSMS(ctx, model.SMSMessageModel{
+			CountryCode: request.CountryCode,
+			PhoneNumber: request.PhoneNumber,
+			Message:     rendered.Text,
+		})
+		if err != nil {
+			return model.RenderedMessageModel{}, err
+		}
+		recipients = append(recipients, request.CountryCode+request.PhoneNumber)
+	}
+
+	m.writeAudit(ctx, template, version.ID, common.TEMPLATE_AUDIT_TEST_SEND, map[string]interface{}{
+		"language":   version.Language,
+		"version":    version.Version,
+		"recipients": recipients,
+	}, actor)
+	return rendered, nil
+}
+
+func (m *messageTemplateServiceImpl) ListAudits(ctx context.Context, templateId uint) ([]model.MessageTemplateAuditModel, error) {
+	audits, err := m.auditRepository.FindByTemplateId(ctx, templateId)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.MessageTemplateAuditModel, 0, len(audits))
+	for _, audit := range audits {
+		var changes json.RawMessage
+		if audit.Changes != "" {
+			changes = json.RawMessage(audit.Changes)
+		}
+		result = append(result, model.MessageTemplateAuditModel{
+			Id:            audit.ID,
+			TemplateId:    audit.TemplateId,
+			TemplateName:  audit.TemplateName,
+			VersionId:     audit.VersionId,
+			Action:        audit.Action,
+			Changes:       changes,
+			ActorId:       audit.ActorId,
+			ActorUsername: audit.ActorUsername,
+			CreatedAt:     audit.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+func (m *messageTemplateServiceImpl) ListVersions(ctx context.Context, templateId uint, language string) ([]model.MessageTemplateVersionModel, error) {
+	if _, err := m.findTemplate(ctx, templateId); err != nil {
+		return nil, err
+	}
+	versions, err := m.MessageTemplateVersionRepository.FindByTemplateId(ctx, templateId, language)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.MessageTemplateVersionModel, 0, len(versions))
+	for _, version := range versions {
+		result = append(result, messageTemplateVersionModel(version))
+	}
+	return result, nil
+}
+
+func (m *messageTemplateServiceImpl) CreateDraft(ctx context.Context, templateId uint, request model.MessageTemplateDraftModel, actor model.AuditActor) (model.MessageTemplateVersionModel, error) {
+	template, err := m.findTemplate(ctx, templateId)
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	if !common.IsSupportedLanguage(request.Language) {
+		return model.MessageTemplateVersionModel{}, exception.BadRequestError{Message: "language must be one of " + strings.Join(common.SupportedLanguages, ", ")}
+	}
+	if err := validateDraft(template, request); err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+
+	number, err := m.MessageTemplateVersionRepository.NextVersionNumber(ctx, templateId, request.Language)
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	version, err := m.MessageTemplateVersionRepository.Create(ctx, entity.MessageTemplateVersion{
+		TemplateId: templateId,
+		Language:   request.Language,
+		Version:    number,
+		Status:     common.TEMPLATE_VERSION_STATUS_DRAFT,
+		Subject:    request.Subject,
+		TextBody:   request.TextBody,
+		HtmlBody:   request.HtmlBody,
+		CreatedBy:  actor.UserId,
+	})
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	m.writeAudit(ctx, template, version.ID, common.TEMPLATE_AUDIT_CREATE_DRAFT, map[string]interface{}{
+		"language": version.Language,
+		"version":  version.Version,
+	}, actor)
+	return messageTemplateVersionModel(version), nil
+}
+
+// UpdateDraft changes the text of a draft, the language of a version is fixed
+func (m *messageTemplateServiceImpl) UpdateDraft(ctx context.Context, templateId uint, versionId uint, request model.MessageTemplateDraftModel, actor model.AuditActor) (model.MessageTemplateVersionModel, error) {
+	template, err := m.findTemplate(ctx, templateId)
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	version, err := m.findVersion(ctx, templateId, versionId)
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	if version.Status != common.TEMPLATE_VERSION_STATUS_DRAFT {
+		return model.MessageTemplateVersionModel{}, exception.BadRequestError{Message: "Only drafts can be edited, create a new draft instead"}
+	}
+	if err := validateDraft(template, request); err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+
+	changes := map[string]interface{}{}
+	recordChange(changes, "subject", version.Subject, request.Subject)
+	recordChange(changes, "textBody", version.TextBody, request.TextBody)
+	recordChange(changes, "htmlBody", version.HtmlBody, request.HtmlBody)
+	version.Subject = request.Subject
+	version.TextBody = request.TextBody
+	version.HtmlBody = request.HtmlBody
+	version, err = m.MessageTemplateVersionRepository.Update(ctx, version)
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	m.writeAudit(ctx, template, version.ID, common.TEMPLATE_AUDIT_UPDATE_DRAFT, changes, actor)
+	return messageTemplateVersionModel(version), nil
+}
+
+func (m *messageTemplateServiceImpl) Publish(ctx context.Context, templateId uint, versionId uint, actor model.AuditActor) (model.MessageTemplateVersionModel, error) {
+	template, err := m.findTemplate(ctx, templateId)
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	version, err := m.findVersion(ctx, templateId, versionId)
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	if version.Status != common.TEMPLATE_VERSION_STATUS_DRAFT {
+		return model.MessageTemplateVersionModel{}, exception.BadRequestError{Message: "Only drafts can be published, use rollback to restore an archived version"}
+	}
+	// The declared variables may have changed since the draft was saved
+	if err := validateDraft(template, draftOf(version)); err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	published, err := m.publish(ctx, version, actor.UserId)
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	m.writeAudit(ctx, template, version.ID, common.TEMPLATE_AUDIT_PUBLISH, map[string]interface{}{
+		"language": version.Language,
+		"version":  version.Version,
+	}, actor)
+	return published, nil
+}
+
+func (m *messageTemplateServiceImpl) Rollback(ctx context.Context, templateId uint, language string, actor model.AuditActor) (model.MessageTemplateVersionModel, error) {
+	template, err := m.findTemplate(ctx, templateId)
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	if !common.IsSupportedLanguage(language) {
+		return model.MessageTemplateVersionModel{}, exception.BadRequestError{Message: "language must be one of " + strings.Join(common.SupportedLanguages, ", ")}
+	}
+
+	current, err := m.MessageTemplateVersionRepository.FindPublished(ctx, templateId, language)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.MessageTemplateVersionModel{}, exception.BadRequestError{Message: "There is no published version in " + language}
+		}
+		return model.MessageTemplateVersionModel{}, err
+	}
+	previous, err := m.MessageTemplateVersionRepository.FindArchivedBefore(ctx, templateId, language, current.Version)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.MessageTemplateVersionModel{}, exception.BadRequestError{Message: "There is no earlier version to roll back to"}
+		}
+		return model.MessageTemplateVersionModel{}, err
+	}
+	// The archived version was checked against the variables declared back
+	// then, which may have been removed since
+	if err := validateDraft(template, draftOf(previous)); err != nil {
+		return model.MessageTemplateVersionModel{}, exception.BadRequestError{Message: "Version " + strconv.Itoa(previous.Version) + " cannot be restored: " + err.Error()}
+	}
+	published, err := m.publish(ctx, previous, actor.UserId)
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	m.writeAudit(ctx, template, previous.ID, common.TEMPLATE_AUDIT_ROLLBACK, map[string]interface{}{
+		"language": language,
+		"version":  map[string]int{"from": current.Version, "to": previous.Version},
+	}, actor)
+	return published, nil
+}
+
+func (m *messageTemplateServiceImpl) Preview(ctx context.Context, templateId uint, versionId uint, data map[string]interface{}) (model.RenderedMessageModel, error) {
+	template, err := m.findTemplate(ctx, templateId)
+	if err != nil {
+		return model.RenderedMessageModel{}, err
+	}
+	version, err := m.findVersion(ctx, templateId, versionId)
+	if err != nil {
+		return model.RenderedMessageModel{}, err
+	}
+
+	values := sampleData(template)
+	for key, value := range data {
+		values[key] = value
+	}
+	rendered, err := renderVersion(template, version, values)
+	if err != nil {
+		return model.RenderedMessageModel{}, exception.BadRequestError{Message: err.Error()}
+	}
+	return rendered, nil
+}
+
+func (m *messageTemplateServiceImpl) SeedVersions(ctx context.Context) {
+	templates, err := m.MessageTemplateRepository.FindAll(ctx)
+	if err != nil {
+		logger.Logger.Error("Failed to load message templates: " + err.Error())
+		return
+	}
+
+	for _, template := range templates {
+		count, err := m.MessageTemplateVersionRepository.CountByTemplateId(ctx, template.ID)
+		if err != nil || count > 0 {
+			continue
+		}
+
+		body := legacyPlaceholder.ReplaceAllString(template.Message, "{{.$1}}")
+		subject := legacyPlaceholder.ReplaceAllString(template.Subject, "{{.$1}}")
+		if len(template.Variables) == 0 {
+			template.Variables = legacyVariables(template.Subject + template.Message)
+			if _, err := m.MessageTemplateRepository.Update(ctx, template); err != nil {
+				logger.Logger.Error("Failed to declare variables of template " + template.Name + ": " + err.Error())
+				continue
+			}
+		}
+
+		version := entity.MessageTemplateVersion{
+			TemplateId: template.ID,
+			Language:   common.LANGUAGE_ENGLISH,
+			Version:    1,
+			Status:     common.TEMPLATE_VERSION_STATUS_DRAFT,
+			Subject:    subject,
+			TextBody:   body,
+		}
+		// The original text was sent as an HTML email body
+		if sendsEmail(template) {
+			version.HtmlBody = body
+		}
+		if err := validateDraft(template, draftOf(version)); err != nil {
+			logger.Logger.Error("Template " + template.Name + " could not be converted: " + err.Error())
+			continue
+		}
+
+		version, err = m.MessageTemplateVersionRepository.Create(ctx, version)
+		if err == nil {
+			_, err = m.publish(ctx, version, 0)
+		}
+		if err != nil {
+			logger.Logger.Error("Failed to seed the first version of template " + template.Name + ": " + err.Error())
+		}
+	}
+}
+
+func (m *messageTemplateServiceImpl) publish(ctx context.Context, version entity.MessageTemplateVersion, actorId uint) (model.MessageTemplateVersionModel, error) {
+	now := time.Now()
+	version.PublishedBy = actorId
+	version.PublishedAt = &now
+	version, err := m.MessageTemplateVersionRepository.Publish(ctx, version)
+	if err != nil {
+		return model.MessageTemplateVersionModel{}, err
+	}
+	return messageTemplateVersionModel(version), nil
+}
+
+func (m *messageTemplateServiceImpl) findTemplate(ctx context.Context, templateId uint) (entity.MessageTemplate, error) {
+	template, err := m.MessageTemplateRepository.FindById(ctx, int(templateId))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.MessageTemplate{}, exception.NotFoundError{Message: "Message template not found"}
+		}
+		return entity.MessageTemplate{}, err
+	}
+	return template, nil
+}
+
+func (m *messageTemplateServiceImpl) findVersion(ctx context.Context, templateId uint, versionId uint) (entity.MessageTemplateVersion, error) {
+	version, err := m.MessageTemplateVersionRepository.FindById(ctx, versionId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.MessageTemplateVersion{}, exception.NotFoundError{Message: "Template version not found"}
+		}
+		return entity.MessageTemplateVersion{}, err
+	}
+	if version.TemplateId != templateId {
+		return entity.MessageTemplateVersion{}, exception.NotFoundError{Message: "Template version not found"}
+	}
+	return version, nil
+}
+
+// writeAudit records a template change. A failed audit write is logged
+// rather than returned because the change itself has already been committed.
+func (m *messageTemplateServiceImpl) writeAudit(ctx context.Context, template entity.MessageTemplate, versionId uint, action string, changes map[string]interface{}, actor model.AuditActor) {
+	var encoded string
+	if len(changes) > 0 {
+		value, err := json.Marshal(changes)
+		if err != nil {
+			logger.Logger.Error("Failed to encode message template audit: " + err.Error())
+		}
+		encoded = string(value)
+	}
+	_, err := m.auditRepository.Create(ctx, entity.MessageTemplateAudit{
+		TemplateId:    template.ID,
+		TemplateName:  template.Name,
+		VersionId:     versionId,
+		Action:        action,
+		Changes:       encoded,
+		ActorId:       actor.UserId,
+		ActorUsername: actor.Username,
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to write message template audit for " + template.Name + ": " + err.Error())
+	}
+}
+
+func recordChange(changes map[string]interface{}, field string, from interface{}, to interface{}) {
+	if from != to {
+		changes[field] = map[string]interface{}{"from": from, "to": to}
+	}
+}
+
+func sameVariables(a entity.TemplateVariables, b entity.TemplateVariables) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sendsEmail treats templates with neither channel set as email, which is
+// how the original templates were sent
+func sendsEmail(template entity.MessageTemplate) bool {
+	return template.IsEmailMessage || !template.IsSMSMessage
+}
+
+func validateTemplateFields(request model.MessageTemplateModel) error {
+	if !request.IsEmail && !request.IsSms {
+		return exception.BadRequestError{Message: "isEmail or isSms is required"}
+	}
+	if len(request.Description) > 255 {
+		return exception.BadRequestError{Message: "description must be at most 255 characters"}
+	}
+	if len(request.Subject) > 100 {
+		return exception.BadRequestError{Message: "subject must be at most 100 characters"}
+	}
+	return nil
+}
+
+// templateVariablesOf validates the variable schema of a template, names
+// must be unique and usable as {{.Name}}
+func templateVariablesOf(variables []model.TemplateVariableModel) (entity.TemplateVariables, error) {
+	result := make(entity.TemplateVariables, 0, len(variables))
+	seen := map[string]bool{}
+	for _, variable := range variables {
+		if !variableName.MatchString(variable.Name) {
+			return nil, exception.BadRequestError{Message: "Invalid variable name " + strconv.Quote(variable.Name)}
+		}
+		if seen[variable.Name] {
+			return nil, exception.BadRequestError{Message: "Variable " + variable.Name + " is declared twice"}
+		}
+		seen[variable.Name] = true
+		result = append(result, entity.TemplateVariable{
+			Name:        variable.Name,
+			Description: variable.Description,
+			Sample:      variable.Sample,
+		})
+	}
+	return result, nil
+}
+
+func messageTemplateModel(template entity.MessageTemplate) model.MessageTemplateModel {
+	variables := make([]model.TemplateVariableModel, 0, len(template.Variables))
+	for _, variable := range template.Variables {
+		variables = append(variables, model.TemplateVariableModel{
+			Name:        variable.Name,
+			Description: variable.Description,
+			Sample:      variable.Sample,
+		})
+	}
+	return model.MessageTemplateModel{
+		Id:          template.ID,
+		Name:        template.Name,
+		Description: template.Description,
+		Subject:     template.Subject,
+		Message:     template.Message,
+		IsEmail:     template.IsEmailMessage,
+		IsSms:       template.IsSMSMessage,
+		Variables:   variables,
+		CreatedAt:   template.CreatedAt,
+		UpdatedAt:   template.UpdatedAt,
+	}
+}
+
+// validateDraft checks that the text parses, only uses declared variables
+// and renders with the sample values
+func validateDraft(template entity.MessageTemplate, request model.MessageTemplateDraftModel) error {
+	if strings.TrimSpace(request.TextBody) == "" && strings.TrimSpace(request.HtmlBody) == "" {
+		return exception.BadRequestError{Message: "textBody or htmlBody is required"}
+	}
+	if template.IsSMSMessage && strings.TrimSpace(request.TextBody) == "" {
+		return exception.BadRequestError{Message: "textBody is required for SMS templates"}
+	}
+	if template.IsEmailMessage && strings.TrimSpace(request.Subject) == "" {
+		return exception.BadRequestError{Message: "subject is required for email templates"}
+	}
+
+	declared := template.Variables.Names()
+	for field, source := range map[string]string{"subject": request.Subject, "textBody": request.TextBody, "htmlBody": request.HtmlBody} {
+		used, err := templateVariables(source)
+		if err != nil {
+			return exception.BadRequestError{Message: fmt.Sprintf("%s does not parse: %s", field, err.Error())}
+		}
+		for _, name := range used {
+			if !contains(declared, name) {
+				return exception.BadRequestError{Message: fmt.Sprintf("%s uses undeclared variable %s", field, name)}
+			}
+		}
+	}
+
+	_, err := renderVersion(template, entity.MessageTemplateVersion{
+		Subject:  request.Subject,
+		TextBody: request.TextBody,
+		HtmlBody: request.HtmlBody,
+	}, sampleData(template))
+	if err != nil {
+		return exception.BadRequestError{Message: err.Error()}
+	}
+	return nil
+}
+
+// renderVersion executes the version with the data, declared variables that
+// are missing from the data render empty
+func renderVersion(template entity.MessageTemplate, version entity.MessageTemplateVersion, data map[string]interface{}) (model.RenderedMessageModel, error) {
+	values := make(map[string]interface{}, len(template.Variables)+len(data))
+	for _, variable := range template.Variables {
+		values[variable.Name] = ""
+	}
+	for key, value := range data {
+		values[key] = value
+	}
+
+	rendered := model.RenderedMessageModel{Language: version.Language, Version: version.Version}
+	var err error
+	if rendered.Subject, err = executeText("subject", version.Subject, values); err != nil {
+		return model.RenderedMessageModel{}, err
+	}
+	if rendered.Text, err = executeText("textBody", version.TextBody, values); err != nil {
+		return model.RenderedMessageModel{}, err
+	}
+	if version.HtmlBody != "" {
+		parsed, err := htmlTemplate.New("htmlBody").Option("missingkey=error").Parse(version.HtmlBody)
+		if err != nil {
+			return model.RenderedMessageModel{}, err
+		}
+		var builder strings.Builder
+		if err := parsed.Execute(&builder, values); err != nil {
+			return model.RenderedMessageModel{}, err
+		}
+		rendered.Html = builder.String()
+	}
+	return rendered, nil
+}
+
+func executeText(name string, source string, values map[string]interface{}) (string, error) {
+	parsed, err := textTemplate.New(name).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	if err := parsed.Execute(&builder, values); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// templateVariables lists the top level fields a template reads. Fields
+// inside range and with blocks are relative to a different dot and are not
+// variables.
+func templateVariables(source string) ([]string, error) {
+	trees, err := parse.Parse("template", source, "", "", textTemplate.FuncMap{}, builtinTemplateFuncs())
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]bool{}
+	for _, tree := range trees {
+		collectTemplateFields(tree.Root, fields)
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func collectTemplateFields(node parse.Node, fields map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectTemplateFields(child, fields)
+		}
+	case *parse.ActionNode:
+		collectTemplateFields(n.Pipe, fields)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, command := range n.Cmds {
+			collectTemplateFields(command, fields)
+		}
+	case *parse.CommandNode:
+		for _, argument := range n.Args {
+			collectTemplateFields(argument, fields)
+		}
+	case *parse.ChainNode:
+		collectTemplateFields(n.Node, fields)
+	case *parse.FieldNode:
+		fields[n.Ident[0]] = true
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			fields[n.Ident[1]] = true
+		}
+	case *parse.IfNode:
+		collectTemplateFields(n.Pipe, fields)
+		collectTemplateFields(n.List, fields)
+		collectTemplateFields(n.ElseList, fields)
+	case *parse.RangeNode:
+		collectTemplateFields(n.Pipe, fields)
+		collectTemplateFields(n.ElseList, fields)
+	case *parse.WithNode:
+		collectTemplateFields(n.Pipe, fields)
+		collectTemplateFields(n.ElseList, fields)
+	case *parse.TemplateNode:
+		collectTemplateFields(n.Pipe, fields)
+	}
+}
+
+// builtinTemplateFuncs names the functions text/template predefines so that
+// the parser accepts them
+func builtinTemplateFuncs() map[string]interface{} {
+	funcs := map[string]interface{}{}
+	for _, name := range []string{"and", "call", "html", "index", "slice", "js", "len", "not", "or", "print", "printf", "println", "urlquery", "eq", "ge", "gt", "le", "lt", "ne"} {
+		funcs[name] = fmt.Sprint
+	}
+	return funcs
+}
+
+func sampleData(template entity.MessageTemplate) map[string]interface{} {
+	values := make(map[string]interface{}, len(template.Variables))
+	for _, variable := range template.Variables {
+		values[variable.Name] = variable.Sample
+	}
+	return values
+}
+
+func legacyVariables(text string) entity.TemplateVariables {
+	variables := entity.TemplateVariables{}
+	seen := map[string]bool{}
+	for _, match := range legacyPlaceholder.FindAllStringSubmatch(text, -1) {
+		if seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		variables = append(variables, entity.TemplateVariable{Name: match[1], Sample: match[1]})
+	}
+	return variables
+}
+
+func draftOf(version entity.MessageTemplateVersion) model.MessageTemplateDraftModel {
+	return model.MessageTemplateDraftModel{
+		Language: version.Language,
+		Subject:  version.Subject,
+		TextBody: version.TextBody,
+		HtmlBody: version.HtmlBody,
+	}
+}
+
+func messageTemplateVersionModel(version entity.MessageTemplateVersion) model.MessageTemplateVersionModel {
+	return model.MessageTemplateVersionModel{
+		Id:          version.ID,
+		TemplateId:  version.TemplateId,
+		Language:    version.Language,
+		Version:     version.Version,
+		Status:      version.Status,
+		Subject:     version.Subject,
+		TextBody:    version.TextBody,
+		HtmlBody:    version.HtmlBody,
+		CreatedBy:   version.CreatedBy,
+		PublishedBy: version.PublishedBy,
+		PublishedAt: version.PublishedAt,
+		CreatedAt:   version.CreatedAt,
+		UpdatedAt:   version.UpdatedAt,
+	}
+}
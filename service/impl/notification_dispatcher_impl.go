@@ -0,0 +1,176 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+type notificationDispatcherImpl struct {
+	preferenceRepository   repository.NotificationPreferenceRepository
+	notificationRepository repository.NotificationRepository
+	userRepository         repository.UserRepository
+	messageService         service.MessageService
+	notificationService    service.NotificationService
+	outboxService          service.OutboxService
+}
+
+func NewNotificationDispatcher(preferenceRepository repository.NotificationPreferenceRepository, notificationRepository repository.NotificationRepository, userRepository repository.UserRepository, messageService service.MessageService, notificationService service.NotificationService, outboxService service.OutboxService) service.NotificationDispatcher {
+	return &notificationDispatcherImpl{
+		preferenceRepository:   preferenceRepository,
+		notificationRepository: notificationRepository,
+		userRepository:         userRepository,
+		messageService:         messageService,
+		notificationService:    notificationService,
+		outboxService:          outboxService,
+	}
+}
+
+func (d *notificationDispatcherImpl) Dispatch(ctx context.Context, user entity.User, message model.DispatchMessageModel) model.DispatchResultModel {
+	channels := message.Channels
+	if len(channels) == 0 {
+		channels = common.NotificationChannels
+	}
+	result := model.DispatchResultModel{Channels: make(map[string]string, len(channels))}
+
+	security := message.Category == common.NOTIFICATION_CATEGORY_SECURITY
+	enabled, quiet := d.preferencesOf(ctx, user, message.Category)
+	for _, channel := range channels {
+		switch {
+		case !security && !enabled[channel]:
+			result.Channels[channel] = common.DISPATCH_OPTED_OUT
+		case !security && quiet && (channel == common.NOTIFICATION_CHANNEL_SMS || channel == common.NOTIFICATION_CHANNEL_PUSH):
+			result.Channels[channel] = common.DISPATCH_QUIET_HOURS
+		default:
+			result.Channels[channel] = d.send(ctx, user, channel, message)
+		}
+	}
+	return result
+}
+
+func (d *notificationDispatcherImpl) Enqueue(ctx context.Context, userId uint, message model.DispatchMessageModel) error {
+	return d.outboxService.Enqueue(ctx, model.OutboxEventModel{
+		AggregateType: "user",
+		AggregateId:   strconv.FormatUint(uint64(userId), 10),
+		Topic:         common.NOTIFICATION_DISPATCH_TOPIC,
+		Payload:       model.NotificationIntentModel{UserId: userId, Message: message},
+	})
+}
+
+// HandleQueued sends the message to the user as they are now, so that it
+// honours the preferences and contact details at the time of sending. A
+// redelivered message is sent again, delivery is at least once.
+func (d *notificationDispatcherImpl) HandleQueued(message []byte) error {
+	var event model.OutboxMessageModel
+	if err := json.Unmarshal(message, &event); err != nil {
+		return service.PermanentError{Err: fmt.Errorf("invalid notification envelope: %w", err)}
+	}
+	var intent model.NotificationIntentModel
+	if err := json.Unmarshal(event.Payload, &intent); err != nil {
+		return service.PermanentError{Err: fmt.Errorf("invalid notification %s: %w", event.EventId, err)}
+	}
+	if intent.UserId == 0 {
+		return service.PermanentError{Err: fmt.Errorf("notification %s has no user", event.EventId)}
+	}
+
+	ctx := context.Background()
+	user, err := d.userRepository.FindById(ctx, int(intent.UserId))
+	if err != nil {
+		return err
+	}
+	// An erased user is not contacted any more
+	if user.PseudonymisedAt != nil {
+		return nil
+	}
+	d.Dispatch(ctx, user, intent.Message)
+	return nil
+}
+
+// preferencesOf falls back to the category defaults without quiet hours when
+// the preferences cannot be read, a message is not dropped for it
+func (d *notificationDispatcherImpl) preferencesOf(ctx context.Context, user entity.User, category string) (map[string]bool, bool) {
+	preferences, err := d.preferenceRepository.FindByUserId(ctx, user.ID)
+	if err != nil {
+		logger.Logger.Error(fmt.Sprintf("Failed to load notification preferences of user %d: %s", user.ID, err.Error()))
+		return enabledChannels(nil, category), false
+	}
+	setting, err := d.preferenceRepository.FindSettingByUserId(ctx, user.ID)
+	if err != nil {
+		logger.Logger.Error(fmt.Sprintf("Failed to load quiet hours of user %d: %s", user.ID, err.Error()))
+		return enabledChannels(preferences, category), false
+	}
+	location := common.ResolveTimezone(setting.Timezone, user.CountryCode)
+	return enabledChannels(preferences, category), inQuietHours(setting, location, time.Now())
+}
+
+// send delivers the message on one channel. Only the in-app channel puts it
+// in the inbox, so that a message sent on several channels is listed once.
+func (d *notificationDispatcherImpl) send(ctx context.Context, user entity.User, channel string, message model.DispatchMessageModel) string {
+	var err error
+	switch channel {
+	case common.NOTIFICATION_CHANNEL_SMS:
+		if user.PhoneNumber == "" {
+			return common.DISPATCH_UNAVAILABLE
+		}
+		err = d.messageService.SendSMS(ctx, model.SMSMessageModel{
+			PhoneNumber: user.PhoneNumber,
+			CountryCode: user.AreaCode,
+			Message:     message.Body,
+		})
+	case common.NOTIFICATION_CHANNEL_EMAIL:
+		if user.Email == "" {
+			return common.DISPATCH_UNAVAILABLE
+		}
+		body := message.Html
+		if body == "" {
+			body = message.Body
+		}
+		d.messageService.SendEmail(ctx, model.EmailMessageModel{
+			To:      user.Email,
+			Subject: message.Title,
+			Message: body,
+		})
+	case common.NOTIFICATION_CHANNEL_PUSH:
+		var result model.PushResultModel
+		result, err = d.notificationService.SendToUser(ctx, user, model.NotificationModel{
+			Title:       message.Title,
+			Body:        message.Body,
+			Data:        message.Data,
+			ClickAction: message.ClickAction,
+		})
+		// Every token may also have been removed as FCM no longer knew it
+		if err == nil && result.SuccessCount == 0 {
+			return common.DISPATCH_UNAVAILABLE
+		}
+	case common.NOTIFICATION_CHANNEL_IN_APP:
+		notification := entity.Notification{
+			UserID:  user.ID,
+			Type:    entity.IN_APP,
+			Subject: truncate(message.Title, 255),
+			Content: message.Body,
+			Status:  common.NOTIFICATION_STATUS_DELIVERED,
+			SentAt:  time.Now(),
+		}
+		if len(message.Data) > 0 {
+			data, _ := json.Marshal(message.Data)
+			notification.Data = string(data)
+		}
+		_, err = d.notificationRepository.Create(ctx, notification)
+	default:
+		return common.DISPATCH_UNAVAILABLE
+	}
+	if err != nil {
+		logger.Logger.Error(fmt.Sprintf("Failed to send %s %s notification to user %d: %s", message.Category, channel, user.ID, err.Error()))
+		return common.DISPATCH_FAILED
+	}
+	return common.DISPATCH_SENT
+}
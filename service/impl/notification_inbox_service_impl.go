@@ -0,0 +1,83 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"gorm.io/gorm"
+)
+
+const maxInboxPageSize = 100
+
+type notificationInboxServiceImpl struct {
+	repository.NotificationRepository
+}
+
+func NewNotificationInboxService(notificationRepository repository.NotificationRepository) service.NotificationInboxService {
+	return &notificationInboxServiceImpl{NotificationRepository: notificationRepository}
+}
+
+func (n *notificationInboxServiceImpl) List(ctx context.Context, userId uint, page, limit int) ([]model.InboxNotificationModel, int64, error) {
+	if limit > maxInboxPageSize {
+		limit = maxInboxPageSize
+	}
+	notifications, totalCount, err := n.NotificationRepository.FindByUserID(ctx, userId, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]model.InboxNotificationModel, 0, len(notifications))
+	for _, notification := range notifications {
+		result = append(result, inboxNotification(notification))
+	}
+	return result, totalCount, nil
+}
+
+func (n *notificationInboxServiceImpl) UnreadCount(ctx context.Context, userId uint) (model.UnreadNotificationCountModel, error) {
+	unread, err := n.NotificationRepository.CountUnread(ctx, userId)
+	if err != nil {
+		return model.UnreadNotificationCountModel{}, err
+	}
+	return model.UnreadNotificationCountModel{Unread: unread}, nil
+}
+
+func (n *notificationInboxServiceImpl) MarkRead(ctx context.Context, userId uint, id uint) error {
+	notification, err := n.NotificationRepository.FindById(ctx, id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if notification.ID == 0 || notification.UserID != userId {
+		return exception.NotFoundError{Message: "Notification not found"}
+	}
+	_, err = n.NotificationRepository.MarkRead(ctx, userId, []uint{id})
+	return err
+}
+
+func (n *notificationInboxServiceImpl) MarkAllRead(ctx context.Context, userId uint) (model.UnreadNotificationCountModel, error) {
+	if _, err := n.NotificationRepository.MarkRead(ctx, userId, nil); err != nil {
+		return model.UnreadNotificationCountModel{}, err
+	}
+	return n.UnreadCount(ctx, userId)
+}
+
+func inboxNotification(notification entity.Notification) model.InboxNotificationModel {
+	inbox := model.InboxNotificationModel{
+		Id:        notification.ID,
+		Channel:   string(notification.Type),
+		Title:     notification.Subject,
+		Body:      notification.Content,
+		IsRead:    notification.ReadAt != nil,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+	if notification.Data != "" {
+		_ = json.Unmarshal([]byte(notification.Data), &inbox.Data)
+	}
+	return inbox
+}
@@ -0,0 +1,143 @@
+package impl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+const quietHoursLayout = "15:04"
+
+type notificationPreferenceServiceImpl struct {
+	repository.NotificationPreferenceRepository
+	repository.UserRepository
+}
+
+func NewNotificationPreferenceService(notificationPreferenceRepository repository.NotificationPreferenceRepository, userRepository repository.UserRepository) service.NotificationPreferenceService {
+	return &notificationPreferenceServiceImpl{
+		NotificationPreferenceRepository: notificationPreferenceRepository,
+		UserRepository:                   userRepository,
+	}
+}
+
+func (n *notificationPreferenceServiceImpl) Get(ctx context.Context, userId uint) (model.NotificationPreferencesModel, error) {
+	user, err := n.UserRepository.FindById(ctx, int(userId))
+	if err != nil {
+		return model.NotificationPreferencesModel{}, err
+	}
+	preferences, err := n.NotificationPreferenceRepository.FindByUserId(ctx, userId)
+	if err != nil {
+		return model.NotificationPreferencesModel{}, err
+	}
+	setting, err := n.NotificationPreferenceRepository.FindSettingByUserId(ctx, userId)
+	if err != nil {
+		return model.NotificationPreferencesModel{}, err
+	}
+
+	channels := make(map[string]map[string]bool, len(common.NotificationCategories))
+	for _, category := range common.NotificationCategories {
+		channels[category] = enabledChannels(preferences, category)
+	}
+	return model.NotificationPreferencesModel{
+		Channels: channels,
+		Timezone: common.ResolveTimezone(setting.Timezone, user.CountryCode).String(),
+		QuietHours: model.QuietHoursModel{
+			Start: setting.QuietHoursStart,
+			End:   setting.QuietHoursEnd,
+		},
+	}, nil
+}
+
+func (n *notificationPreferenceServiceImpl) Update(ctx context.Context, userId uint, request model.UpdateNotificationPreferencesModel) (model.NotificationPreferencesModel, error) {
+	var preferences []entity.NotificationPreference
+	for category, channels := range request.Channels {
+		if !contains(common.NotificationCategories, category) {
+			return model.NotificationPreferencesModel{}, exception.BadRequestError{Message: fmt.Sprintf("Unknown notification category %s", category)}
+		}
+		for channel, enabled := range channels {
+			if !contains(common.NotificationChannels, channel) {
+				return model.NotificationPreferencesModel{}, exception.BadRequestError{Message: fmt.Sprintf("Unknown notification channel %s", channel)}
+			}
+			if category == common.NOTIFICATION_CATEGORY_SECURITY && !enabled {
+				return model.NotificationPreferencesModel{}, exception.BadRequestError{Message: "Security notifications cannot be turned off"}
+			}
+			preferences = append(preferences, entity.NotificationPreference{Category: category, Channel: channel, Enabled: enabled})
+		}
+	}
+
+	setting, err := n.NotificationPreferenceRepository.FindSettingByUserId(ctx, userId)
+	if err != nil {
+		return model.NotificationPreferencesModel{}, err
+	}
+	if request.Timezone != nil {
+		timezone := strings.TrimSpace(*request.Timezone)
+		if timezone != "" && !common.IsValidTimezone(timezone) {
+			return model.NotificationPreferencesModel{}, exception.BadRequestError{Message: "timezone must be an IANA timezone such as Africa/Accra"}
+		}
+		setting.Timezone = timezone
+	}
+	if request.QuietHours != nil {
+		start, end := strings.TrimSpace(request.QuietHours.Start), strings.TrimSpace(request.QuietHours.End)
+		if (start == "") != (end == "") {
+			return model.NotificationPreferencesModel{}, exception.BadRequestError{Message: "quietHours needs both a start and an end"}
+		}
+		for _, value := range []string{start, end} {
+			if _, err := time.Parse(quietHoursLayout, value); value != "" && err != nil {
+				return model.NotificationPreferencesModel{}, exception.BadRequestError{Message: "quietHours must be given as HH:MM"}
+			}
+		}
+		setting.QuietHoursStart, setting.QuietHoursEnd = start, end
+	}
+
+	if err := n.NotificationPreferenceRepository.Save(ctx, userId, preferences, setting); err != nil {
+		return model.NotificationPreferencesModel{}, err
+	}
+	return n.Get(ctx, userId)
+}
+
+// enabledChannels applies the user's preferences for category over its
+// defaults
+func enabledChannels(preferences []entity.NotificationPreference, category string) map[string]bool {
+	channels := make(map[string]bool, len(common.NotificationChannels))
+	for _, channel := range common.NotificationChannels {
+		channels[channel] = contains(common.DefaultNotificationChannels[category], channel)
+	}
+	if category == common.NOTIFICATION_CATEGORY_SECURITY {
+		return channels
+	}
+	for _, preference := range preferences {
+		if preference.Category == category {
+			channels[preference.Channel] = preference.Enabled
+		}
+	}
+	return channels
+}
+
+// inQuietHours reports whether at falls in the user's quiet hours, a window
+// that ends before it starts runs over midnight
+func inQuietHours(setting entity.NotificationSetting, location *time.Location, at time.Time) bool {
+	start, err := time.Parse(quietHoursLayout, setting.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(quietHoursLayout, setting.QuietHoursEnd)
+	if err != nil || start.Equal(end) {
+		return false
+	}
+	local := at.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+	return minute >= startMinute || minute < endMinute
+}
@@ -2,27 +2,46 @@ package impl
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 	"google.golang.org/api/option"
 )
 
+// fcmMulticastLimit is the most tokens FCM accepts in one multicast
+const fcmMulticastLimit = 500
+
 type notificationServiceImpl struct {
-	app *firebase.App
+	app                    *firebase.App
+	notificationRepository repository.NotificationRepository
+	deviceRepository       repository.DeviceRepository
+	staleAfter             time.Duration
 }
 
-func NewNotificationService(credentialsPath string) service.NotificationService {
-	opt := option.WithCredentialsFile(credentialsPath)
+func NewNotificationService(config configuration.Config, notificationRepository repository.NotificationRepository, deviceRepository repository.DeviceRepository) service.NotificationService {
+	opt := option.WithCredentialsFile(config.Get("FCM_CREDENTIALS_PATH"))
 	app, err := firebase.NewApp(context.Background(), nil, opt)
 	exception.PanicLogging(err)
 
 	return &notificationServiceImpl{
-		app: app,
+		app:                    app,
+		notificationRepository: notificationRepository,
+		deviceRepository:       deviceRepository,
+		// FCM expires the tokens of Android devices that were offline for
+		// 270 days
+		staleAfter: time.Duration(intConfig(config, "DEVICE_STALE_DAYS", 270)) * 24 * time.Hour,
 	}
 }
 
@@ -37,66 +56,120 @@ func (n *notificationServiceImpl) SendToDevice(ctx context.Context, notification
 	}
 
 	message := &messaging.Message{
-		Notification: &messaging.Notification{
-			Title:    notification.Title,
-			Body:     notification.Body,
-			ImageURL: notification.ImageURL,
-		},
-		Token: notification.Token,
-		Data:  notification.Data,
-		Android: &messaging.AndroidConfig{
-			Priority: "high",
-			Notification: &messaging.AndroidNotification{
-				ClickAction: notification.ClickAction,
-			},
-		},
+		Notification: pushNotification(notification),
+		Token:        notification.Token,
+		Data:         notification.Data,
+		Android:      androidConfig(notification),
+		APNS:         apnsConfig(notification),
 	}
 
-	if notification.ClickAction != "" {
-		message.APNS = &messaging.APNSConfig{
-			Payload: &messaging.APNSPayload{
-				Aps: &messaging.Aps{
-					Alert: &messaging.ApsAlert{
-						Title: notification.Title,
-						Body:  notification.Body,
-					},
-				},
-			},
+	response, err := client.Send(ctx, message)
+	if isStaleToken(err) {
+		n.removeTokens(ctx, []string{notification.Token})
+	}
+	n.recordPush(ctx, notification, notification.UserId, notification.Token, response, err)
+	return err
+}
+
+func (n *notificationServiceImpl) SendToUser(ctx context.Context, user entity.User, notification model.NotificationModel) (model.PushResultModel, error) {
+	devices, err := n.deviceRepository.FindByUserId(ctx, user.ID)
+	if err != nil {
+		return model.PushResultModel{}, err
+	}
+
+	cutoff := time.Now().Add(-n.staleAfter)
+	var tokens, stale []string
+	for _, device := range devices {
+		if device.LastSeenAt.Before(cutoff) {
+			stale = append(stale, device.Token)
+			continue
 		}
+		tokens = append(tokens, device.Token)
+	}
+	// Apps that only set the legacy token have no devices yet
+	if user.FcmToken != "" && !contains(tokens, user.FcmToken) && !contains(stale, user.FcmToken) {
+		tokens = append(tokens, user.FcmToken)
+	}
+	n.removeTokens(ctx, stale)
+	if len(tokens) == 0 {
+		return model.PushResultModel{}, nil
 	}
 
-	response, err := client.Send(ctx, message)
-	fmt.Println(response)
-	return err
+	// The inbox gets the notification once, not once per device
+	userId := notification.UserId
+	notification.UserId = 0
+	notification.Tokens = tokens
+	result, err := n.SendToMultipleDevices(ctx, notification)
+	if userId != 0 {
+		token, messageId := tokens[0], ""
+		for _, tokenResult := range result.Results {
+			if tokenResult.Error == "" {
+				token, messageId = tokenResult.Token, tokenResult.MessageId
+				break
+			}
+		}
+		n.recordPush(ctx, notification, userId, token, messageId, err)
+	}
+	return result, err
 }
 
-func (n *notificationServiceImpl) SendToMultipleDevices(ctx context.Context, notification model.NotificationModel) error {
+func (n *notificationServiceImpl) SendToMultipleDevices(ctx context.Context, notification model.NotificationModel) (model.PushResultModel, error) {
 	if len(notification.Tokens) == 0 {
-		return fmt.Errorf("no device tokens provided")
+		return model.PushResultModel{}, fmt.Errorf("no device tokens provided")
 	}
 
 	client, err := n.app.Messaging(ctx)
 	if err != nil {
-		fmt.Printf("Error initializing Firebase Messaging client: %v\n", err)
-		return err
+		return model.PushResultModel{}, err
 	}
 
-	message := &messaging.Message{
-		Token: notification.Tokens[0],
-		Notification: &messaging.Notification{
-			Title: notification.Title,
-			Body:  notification.Body,
-		},
-	}
+	result := model.PushResultModel{Results: make([]model.PushTokenResultModel, 0, len(notification.Tokens))}
+	var stale []string
+	var lastErr error
+	for start := 0; start < len(notification.Tokens); start += fcmMulticastLimit {
+		end := min(start+fcmMulticastLimit, len(notification.Tokens))
+		tokens := notification.Tokens[start:end]
+		response, err := client.SendEachForMulticast(ctx, &messaging.MulticastMessage{
+			Tokens:       tokens,
+			Notification: pushNotification(notification),
+			Data:         notification.Data,
+			Android:      androidConfig(notification),
+			APNS:         apnsConfig(notification),
+		})
+		if err != nil {
+			// The whole batch failed, later batches are still tried
+			logger.Logger.Error(fmt.Sprintf("Failed to send a multicast to %d devices: %s", len(tokens), err.Error()))
+			lastErr = err
+			for _, token := range tokens {
+				result.Results = append(result.Results, model.PushTokenResultModel{Token: token, Error: err.Error()})
+			}
+			result.FailureCount += len(tokens)
+			continue
+		}
 
-	_, err = client.Send(ctx, message)
-	if err != nil {
-		fmt.Printf("Error sending multicast message: %v\n", err)
-		return err
+		for i, response := range response.Responses {
+			tokenResult := model.PushTokenResultModel{Token: tokens[i], MessageId: response.MessageID}
+			if response.Success {
+				result.SuccessCount++
+			} else {
+				result.FailureCount++
+				tokenResult.Error = response.Error.Error()
+				if isStaleToken(response.Error) {
+					tokenResult.Removed = true
+					stale = append(stale, tokens[i])
+				} else {
+					lastErr = response.Error
+				}
+			}
+			result.Results = append(result.Results, tokenResult)
+		}
 	}
 
-	//fmt.Printf("Successfully sent %d messages, failed to send %d messages\n", response.SuccessCount, response.FailureCount)
-	return nil
+	n.removeTokens(ctx, stale)
+	if result.SuccessCount == 0 {
+		return result, lastErr
+	}
+	return result, nil
 }
 
 func (n *notificationServiceImpl) SendToTopic(ctx context.Context, topic string, notification model.NotificationModel) error {
@@ -109,36 +182,15 @@ func (n *notificationServiceImpl) SendToTopic(ctx context.Context, topic string,
 		return err
 	}
 
-	message := &messaging.MulticastMessage{
-		Notification: &messaging.Notification{
-			Title:    notification.Title,
-			Body:     notification.Body,
-			ImageURL: notification.ImageURL,
-		},
-		//Topic: topic,
-		Data: notification.Data,
-		Android: &messaging.AndroidConfig{
-			Priority: "high",
-			Notification: &messaging.AndroidNotification{
-				ClickAction: notification.ClickAction,
-			},
-		},
-	}
-
-	if notification.ClickAction != "" {
-		message.APNS = &messaging.APNSConfig{
-			Payload: &messaging.APNSPayload{
-				Aps: &messaging.Aps{
-					Alert: &messaging.ApsAlert{
-						Title: notification.Title,
-						Body:  notification.Body,
-					},
-				},
-			},
-		}
+	message := &messaging.Message{
+		Topic:        topic,
+		Notification: pushNotification(notification),
+		Data:         notification.Data,
+		Android:      androidConfig(notification),
+		APNS:         apnsConfig(notification),
 	}
 
-	_, err = client.SendMulticast(ctx, message)
+	_, err = client.Send(ctx, message)
 	return err
 }
 
@@ -176,6 +228,96 @@ func (n *notificationServiceImpl) UnsubscribeFromTopic(ctx context.Context, toke
 	return err
 }
 
+// removeTokens prunes tokens that can no longer receive pushes, a failure is
+// only logged as the tokens are found again on the next push
+func (n *notificationServiceImpl) removeTokens(ctx context.Context, tokens []string) {
+	if len(tokens) == 0 {
+		return
+	}
+	removed, err := n.deviceRepository.DeleteByTokens(ctx, tokens)
+	if err != nil {
+		logger.Logger.Error(fmt.Sprintf("Failed to remove %d stale device tokens: %s", len(tokens), err.Error()))
+		return
+	}
+	logger.Logger.Info(fmt.Sprintf("Removed %d stale device tokens", removed))
+}
+
+// isStaleToken tells whether FCM rejected the token itself. An invalid
+// argument is only blamed on the token when FCM says so, it is also the error
+// of a malformed message.
+func isStaleToken(err error) bool {
+	if err == nil {
+		return false
+	}
+	if messaging.IsUnregistered(err) {
+		return true
+	}
+	return messaging.IsInvalidArgument(err) && strings.Contains(strings.ToLower(err.Error()), "registration token")
+}
+
+func pushNotification(notification model.NotificationModel) *messaging.Notification {
+	return &messaging.Notification{
+		Title:    notification.Title,
+		Body:     notification.Body,
+		ImageURL: notification.ImageURL,
+	}
+}
+
+func androidConfig(notification model.NotificationModel) *messaging.AndroidConfig {
+	return &messaging.AndroidConfig{
+		Priority: "high",
+		Notification: &messaging.AndroidNotification{
+			ClickAction: notification.ClickAction,
+		},
+	}
+}
+
+func apnsConfig(notification model.NotificationModel) *messaging.APNSConfig {
+	if notification.ClickAction == "" {
+		return nil
+	}
+	return &messaging.APNSConfig{
+		Payload: &messaging.APNSPayload{
+			Aps: &messaging.Aps{
+				Alert: &messaging.ApsAlert{
+					Title: notification.Title,
+					Body:  notification.Body,
+				},
+			},
+		},
+	}
+}
+
+// recordPush puts a push in the recipient's inbox, pushes without a user and
+// topic pushes are not recorded. A failure to record does not fail the push.
+func (n *notificationServiceImpl) recordPush(ctx context.Context, notification model.NotificationModel, userId uint, token string, messageId string, sendErr error) {
+	if userId == 0 {
+		return
+	}
+	record := entity.Notification{
+		UserID:            userId,
+		Type:              entity.PUSH,
+		Recipient:         truncate(token, 255),
+		Subject:           truncate(notification.Title, 255),
+		Content:           notification.Body,
+		Status:            common.NOTIFICATION_STATUS_SENT,
+		SentAt:            time.Now(),
+		Provider:          "fcm",
+		ProviderMessageId: truncate(messageId, 100),
+	}
+	if sendErr != nil {
+		record.Status = common.NOTIFICATION_STATUS_FAILED
+		record.FailureReason = truncate(sendErr.Error(), 255)
+	}
+	if len(notification.Data) > 0 {
+		data, _ := json.Marshal(notification.Data)
+		record.Data = string(data)
+	}
+	if _, err := n.notificationRepository.Create(ctx, record); err != nil {
+		logger.Logger.Error(fmt.Sprintf("Failed to save push notification for user %d: %s", userId, err.Error()))
+	}
+}
+
 //func (n *notificationServiceImpl) Send(ctx context.Context, notificationModel model.NotificationModel) error {
 //	if notificationModel.Token == "" {
 //		//return errors.New("user FCM token not found")
@@ -0,0 +1,187 @@
+package impl
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"gorm.io/gorm"
+)
+
+const (
+	otpCooldownPrefix  = "otp_cooldown:"
+	otpLockoutPrefix   = "otp_lockout:"
+	otpPhoneRatePrefix = "otp_rate_phone:"
+	otpIpRatePrefix    = "otp_rate_ip:"
+	otpRateWindow      = time.Hour
+)
+
+type otpServiceImpl struct {
+	repository.OneTimePasswordRepository
+	service.RedisService
+	configuration.Config
+}
+
+func NewOtpService(oneTimePasswordRepository repository.OneTimePasswordRepository, redisService service.RedisService, config configuration.Config) service.OtpService {
+	return &otpServiceImpl{
+		OneTimePasswordRepository: oneTimePasswordRepository,
+		RedisService:              redisService,
+		Config:                    config,
+	}
+}
+
+// Issue creates a new OTP for the purpose and returns the plain code so it can
+// be sent to the user. Only a keyed hash of the code is stored. Any earlier
+// OTP for the same purpose stops being valid.
+func (o *otpServiceImpl) Issue(ctx context.Context, userId uint, phoneNumber string, purpose string, ipAddress string) (string, error) {
+	if err := o.checkLockout(userId, purpose); err != nil {
+		return "", err
+	}
+
+	cooldownKey := otpCooldownPrefix + purpose + ":" + strconv.Itoa(int(userId))
+	if ttl, err := o.RedisService.GetTTL(cooldownKey); err == nil && ttl > 0 {
+		return "", exception.TooManyRequestsError{Message: fmt.Sprintf("Please wait %d seconds before requesting another code", int(ttl.Seconds())+1)}
+	}
+
+	if phoneNumber != "" {
+		if err := o.rateLimit(otpPhoneRatePrefix+phoneNumber, intConfig(o.Config, "OTP_MAX_PER_PHONE_PER_HOUR", 5)); err != nil {
+			return "", err
+		}
+	}
+	if ipAddress != "" {
+		if err := o.rateLimit(otpIpRatePrefix+ipAddress, intConfig(o.Config, "OTP_MAX_PER_IP_PER_HOUR", 20)); err != nil {
+			return "", err
+		}
+	}
+
+	code, err := generateOtpCode()
+	if err != nil {
+		return "", err
+	}
+	if err := o.OneTimePasswordRepository.InvalidateActive(ctx, userId, purpose); err != nil {
+		return "", err
+	}
+	_, err = o.OneTimePasswordRepository.Create(ctx, entity.OneTimePassword{
+		UserId:    userId,
+		Purpose:   purpose,
+		CodeHash:  o.hashCode(userId, purpose, code),
+		ExpiredAt: time.Now().Add(time.Duration(intConfig(o.Config, "OTP_EXPIRE_MINUTES", 15)) * time.Minute),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	cooldown := time.Duration(intConfig(o.Config, "OTP_RESEND_COOLDOWN_SECONDS", 60)) * time.Second
+	if err := o.RedisService.Set(cooldownKey, true, cooldown); err != nil {
+		logger.Logger.Error("Failed to set OTP cooldown: " + err.Error())
+	}
+	return code, nil
+}
+
+// Verify checks a code against the active OTP for the purpose. Every wrong
+// guess counts against the OTP, once the limit is reached the OTP is burnt and
+// the user is locked out of the purpose for a while.
+func (o *otpServiceImpl) Verify(ctx context.Context, userId uint, purpose string, code string) error {
+	if err := o.checkLockout(userId, purpose); err != nil {
+		return err
+	}
+
+	otp, err := o.OneTimePasswordRepository.FindActive(ctx, userId, purpose)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return exception.BadRequestError{Message: "Invalid or expired OTP"}
+	}
+	if err != nil {
+		return err
+	}
+
+	if hmac.Equal([]byte(otp.CodeHash), []byte(o.hashCode(userId, purpose, code))) {
+		consumed, err := o.OneTimePasswordRepository.Consume(ctx, otp.ID)
+		if err != nil {
+			return err
+		}
+		if !consumed {
+			return exception.BadRequestError{Message: "Invalid or expired OTP"}
+		}
+		return nil
+	}
+
+	// Parallel guesses are counted in the database, each of them takes one of
+	// the attempts left
+	maxAttempts := intConfig(o.Config, "OTP_MAX_ATTEMPTS", 5)
+	otp, counted, err := o.OneTimePasswordRepository.RecordFailedAttempt(ctx, otp.ID, uint(maxAttempts))
+	if err != nil {
+		return err
+	}
+	// A guess that finds the OTP used up already was beaten by a parallel one
+	if !counted {
+		return exception.BadRequestError{Message: "Invalid or expired OTP"}
+	}
+	if otp.IsUsed {
+		lockout := time.Duration(intConfig(o.Config, "OTP_LOCKOUT_MINUTES", 30)) * time.Minute
+		if err := o.RedisService.Set(otpLockoutPrefix+purpose+":"+strconv.Itoa(int(userId)), true, lockout); err != nil {
+			logger.Logger.Error("Failed to set OTP lockout: " + err.Error())
+		}
+		return exception.TooManyRequestsError{Message: "Too many invalid attempts, please try again later"}
+	}
+	return exception.BadRequestError{Message: "Invalid or expired OTP"}
+}
+
+func (o *otpServiceImpl) checkLockout(userId uint, purpose string) error {
+	locked, err := o.RedisService.Exists(otpLockoutPrefix + purpose + ":" + strconv.Itoa(int(userId)))
+	if err != nil {
+		return err
+	}
+	if locked {
+		return exception.TooManyRequestsError{Message: "Too many invalid attempts, please try again later"}
+	}
+	return nil
+}
+
+func (o *otpServiceImpl) rateLimit(key string, limit int) error {
+	count, err := o.RedisService.Increment(key, otpRateWindow)
+	if err != nil {
+		return err
+	}
+	if count > int64(limit) {
+		return exception.TooManyRequestsError{Message: "Too many OTP requests, please try again later"}
+	}
+	return nil
+}
+
+// hashCode binds the code to its user and purpose so a hash cannot be replayed
+// for another account or flow
+func (o *otpServiceImpl) hashCode(userId uint, purpose string, code string) string {
+	mac := hmac.New(sha256.New, []byte(o.Config.Get("OTP_SECRET_KEY")))
+	mac.Write([]byte(strconv.Itoa(int(userId)) + ":" + purpose + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// intConfig reads a positive integer setting, falling back when it is unset or invalid
+func intConfig(config configuration.Config, key string, fallback int) int {
+	value, err := strconv.Atoi(config.Get(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func generateOtpCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
@@ -0,0 +1,162 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/google/uuid"
+)
+
+type outboxServiceImpl struct {
+	repository.OutboxRepository
+	service.MessageBrokerService
+	interval       time.Duration
+	batchSize      int
+	lease          time.Duration
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	retention      time.Duration
+	stop           chan struct{}
+	stopOnce       sync.Once
+	stopped        sync.WaitGroup
+}
+
+func NewOutboxService(outboxRepository repository.OutboxRepository, messageBrokerService service.MessageBrokerService, config configuration.Config) service.OutboxService {
+	return &outboxServiceImpl{
+		OutboxRepository:     outboxRepository,
+		MessageBrokerService: messageBrokerService,
+		interval:             time.Duration(intConfig(config, "OUTBOX_RELAY_INTERVAL_MILLISECONDS", 1000)) * time.Millisecond,
+		batchSize:            intConfig(config, "OUTBOX_BATCH_SIZE", 100),
+		lease:                time.Duration(intConfig(config, "OUTBOX_LEASE_SECONDS", 60)) * time.Second,
+		retryBaseDelay:       time.Duration(intConfig(config, "OUTBOX_RETRY_BASE_SECONDS", 5)) * time.Second,
+		retryMaxDelay:        time.Duration(intConfig(config, "OUTBOX_RETRY_MAX_SECONDS", 600)) * time.Second,
+		retention:            time.Duration(intConfig(config, "OUTBOX_RETENTION_HOURS", 72)) * time.Hour,
+		stop:                 make(chan struct{}),
+	}
+}
+
+func (o *outboxServiceImpl) Enqueue(ctx context.Context, events ...model.OutboxEventModel) error {
+	rows := make([]entity.OutboxEvent, 0, len(events))
+	now := time.Now()
+	for _, event := range events {
+		body, err := json.Marshal(event.Payload)
+		if err != nil {
+			return err
+		}
+		if event.Version == 0 {
+			event.Version = 1
+		}
+		rows = append(rows, entity.OutboxEvent{
+			EventId:       uuid.NewString(),
+			AggregateType: event.AggregateType,
+			AggregateId:   event.AggregateId,
+			Topic:         event.Topic,
+			Version:       event.Version,
+			Payload:       string(body),
+			NextAttemptAt: now,
+		})
+	}
+	return o.OutboxRepository.Create(ctx, rows...)
+}
+
+func (o *outboxServiceImpl) RelayPending(ctx context.Context) (int, error) {
+	// A publish the broker only buffers would be lost with the process, keep
+	// the events in the outbox until the broker is back
+	if o.MessageBrokerService.Health().State != common.BROKER_STATE_CONNECTED {
+		return 0, nil
+	}
+
+	events, err := o.OutboxRepository.ClaimRelayable(ctx, time.Now(), o.batchSize, o.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, event := range events {
+		// Only a confirmed publish may mark the event published
+		err := o.MessageBrokerService.PublishConfirmed(event.Topic, model.OutboxMessageModel{
+			EventId:       event.EventId,
+			Topic:         event.Topic,
+			Version:       event.Version,
+			AggregateType: event.AggregateType,
+			AggregateId:   event.AggregateId,
+			OccurredAt:    event.CreatedAt,
+			Payload:       json.RawMessage(event.Payload),
+		})
+		if err != nil {
+			delay := exponentialBackoff(o.retryBaseDelay, o.retryMaxDelay, event.Attempts+1)
+			logger.Logger.Error(fmt.Sprintf("Failed to relay outbox event %s to %s, retrying in %s: %s", event.EventId, event.Topic, delay, err.Error()))
+			if err := o.OutboxRepository.MarkFailed(ctx, event.ID, truncate(err.Error(), 255), time.Now().Add(delay)); err != nil {
+				logger.Logger.Error(fmt.Sprintf("Failed to record outbox failure of %s: %s", event.EventId, err.Error()))
+			}
+			continue
+		}
+		// When this fails the event is published again after the lease,
+		// which at least once delivery allows
+		if err := o.OutboxRepository.MarkPublished(ctx, event.ID, time.Now()); err != nil {
+			logger.Logger.Error(fmt.Sprintf("Failed to mark outbox event %s published: %s", event.EventId, err.Error()))
+			continue
+		}
+		published++
+	}
+	return published, nil
+}
+
+func (o *outboxServiceImpl) StartRelay() {
+	o.stopped.Add(1)
+	go func() {
+		defer o.stopped.Done()
+		logger.Logger.Info("Starting outbox relay")
+
+		ticker := time.NewTicker(o.interval)
+		defer ticker.Stop()
+		lastCleanup := time.Time{}
+		for {
+			select {
+			case <-o.stop:
+				return
+			case <-ticker.C:
+			}
+
+			ctx := context.Background()
+			// Keep going while full batches come back so a backlog drains
+			// faster than one batch per interval
+			for {
+				published, err := o.RelayPending(ctx)
+				if err != nil {
+					logger.Logger.Error("Outbox relay failed: " + err.Error())
+				}
+				if err != nil || published < o.batchSize {
+					break
+				}
+			}
+
+			if time.Since(lastCleanup) >= time.Hour {
+				lastCleanup = time.Now()
+				deleted, err := o.OutboxRepository.DeletePublishedBefore(ctx, time.Now().Add(-o.retention))
+				if err != nil {
+					logger.Logger.Error("Outbox cleanup failed: " + err.Error())
+				} else if deleted > 0 {
+					logger.Logger.Info(fmt.Sprintf("Removed %d published outbox events", deleted))
+				}
+			}
+		}
+	}()
+}
+
+func (o *outboxServiceImpl) StopRelay() {
+	o.stopOnce.Do(func() {
+		close(o.stop)
+	})
+	o.stopped.Wait()
+}
@@ -2,20 +2,28 @@ package impl
 
 import (
 	"context"
+	"strings"
 
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
 )
 
 type PaymentConfigServiceImpl struct {
-	repository repository.PaymentConfigRepository
+	repository      repository.PaymentConfigRepository
+	auditRepository repository.PaymentConfigAuditRepository
+	cipher          *common.SecretCipher
 }
 
-func NewPaymentConfigService(repo repository.PaymentConfigRepository) service.PaymentConfigService {
+func NewPaymentConfigService(repo repository.PaymentConfigRepository, auditRepo repository.PaymentConfigAuditRepository, cipher *common.SecretCipher) service.PaymentConfigService {
 	return &PaymentConfigServiceImpl{
-		repository: repo,
+		repository:      repo,
+		auditRepository: auditRepo,
+		cipher:          cipher,
 	}
 }
 
@@ -24,7 +32,7 @@ func (s *PaymentConfigServiceImpl) GetPaymentConfig(ctx context.Context, country
 	if err != nil {
 		return nil, err
 	}
-	return s.entityToModel(config), nil
+	return s.entityToModel(config)
 }
 
 func (s *PaymentConfigServiceImpl) ListPaymentConfigs(ctx context.Context) ([]model.PaymentConfigModel, error) {
@@ -35,32 +43,164 @@ func (s *PaymentConfigServiceImpl) ListPaymentConfigs(ctx context.Context) ([]mo
 
 	var result []model.PaymentConfigModel
 	for _, config := range configs {
-		result = append(result, *s.entityToModel(&config))
+		configModel, err := s.entityToModel(&config)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *configModel)
 	}
 	return result, nil
 }
 
-func (s *PaymentConfigServiceImpl) CreatePaymentConfig(ctx context.Context, config *model.PaymentConfigModel) error {
-	entity := s.modelToEntity(config)
-	return s.repository.SavePaymentConfig(ctx, entity)
+func (s *PaymentConfigServiceImpl) CreatePaymentConfig(ctx context.Context, config *model.PaymentConfigModel, actor model.AuditActor) error {
+	if config.CountryCode == "" || config.SecretKey == "" {
+		return exception.BadRequestError{Message: "countryCode and secretKey are required"}
+	}
+
+	secret, err := s.cipher.Encrypt(config.SecretKey)
+	if err != nil {
+		return err
+	}
+	configEntity := &entity.PaymentConfiguration{
+		CountryCode:      config.CountryCode,
+		SecretKey:        secret.Ciphertext,
+		EncryptedDataKey: secret.EncryptedDataKey,
+		KeyVersion:       secret.KeyVersion,
+		PublicKey:        config.PublicKey,
+	}
+	if err := s.repository.SavePaymentConfig(ctx, configEntity); err != nil {
+		return err
+	}
+
+	config.ID = configEntity.ID
+	config.KeyVersion = configEntity.KeyVersion
+	config.SecretKey = common.MaskSecret(config.SecretKey)
+	s.writeAudit(ctx, configEntity.CountryCode, "create", []string{"secretKey", "publicKey"}, configEntity.KeyVersion, actor)
+	return nil
+}
+
+func (s *PaymentConfigServiceImpl) UpdatePaymentConfig(ctx context.Context, config *model.PaymentConfigModel, actor model.AuditActor) error {
+	existing, err := s.repository.GetPaymentConfig(ctx, config.CountryCode)
+	if err != nil {
+		return err
+	}
+
+	var changedFields []string
+	if config.PublicKey != existing.PublicKey {
+		changedFields = append(changedFields, "publicKey")
+	}
+	existing.PublicKey = config.PublicKey
+
+	// An empty or masked secret means the client is not replacing the secret
+	if config.SecretKey != "" && !strings.Contains(config.SecretKey, "****") {
+		secret, err := s.cipher.Encrypt(config.SecretKey)
+		if err != nil {
+			return err
+		}
+		existing.SecretKey = secret.Ciphertext
+		existing.EncryptedDataKey = secret.EncryptedDataKey
+		existing.KeyVersion = secret.KeyVersion
+		changedFields = append(changedFields, "secretKey")
+	}
+
+	if err := s.repository.UpdatePaymentConfig(ctx, existing); err != nil {
+		return err
+	}
+
+	updated, err := s.entityToModel(existing)
+	if err != nil {
+		return err
+	}
+	*config = *updated
+	s.writeAudit(ctx, existing.CountryCode, "update", changedFields, existing.KeyVersion, actor)
+	return nil
+}
+
+func (s *PaymentConfigServiceImpl) DeletePaymentConfig(ctx context.Context, countryCode string, actor model.AuditActor) error {
+	if err := s.repository.DeletePaymentConfig(ctx, countryCode); err != nil {
+		return err
+	}
+	s.writeAudit(ctx, countryCode, "delete", nil, "", actor)
+	return nil
+}
+
+func (s *PaymentConfigServiceImpl) GetSecretKey(ctx context.Context, countryCode string) (string, error) {
+	config, err := s.repository.GetPaymentConfig(ctx, countryCode)
+	if err != nil {
+		return "", err
+	}
+	return s.cipher.Decrypt(toEncryptedSecret(config))
 }
 
-func (s *PaymentConfigServiceImpl) UpdatePaymentConfig(ctx context.Context, config *model.PaymentConfigModel) error {
-	entity := s.modelToEntity(config)
-	return s.repository.UpdatePaymentConfig(ctx, entity)
+func (s *PaymentConfigServiceImpl) RotateSecrets(ctx context.Context, actor model.AuditActor) (int, error) {
+	configs, err := s.repository.ListPaymentConfigs(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, config := range configs {
+		// Secrets already on the active key are left alone, so that running
+		// the rotation again only touches the ones it did not reach
+		if !s.cipher.NeedsRotation(toEncryptedSecret(&config)) {
+			continue
+		}
+		plaintext, err := s.cipher.Decrypt(toEncryptedSecret(&config))
+		if err != nil {
+			return rotated, err
+		}
+		secret, err := s.cipher.Encrypt(plaintext)
+		if err != nil {
+			return rotated, err
+		}
+
+		config.SecretKey = secret.Ciphertext
+		config.EncryptedDataKey = secret.EncryptedDataKey
+		config.KeyVersion = secret.KeyVersion
+		if err := s.repository.UpdatePaymentConfig(ctx, &config); err != nil {
+			return rotated, err
+		}
+
+		s.writeAudit(ctx, config.CountryCode, "rotate", []string{"secretKey"}, config.KeyVersion, actor)
+		rotated++
+	}
+	return rotated, nil
 }
 
-func (s *PaymentConfigServiceImpl) DeletePaymentConfig(ctx context.Context, countryCode string) error {
-	return s.repository.DeletePaymentConfig(ctx, countryCode)
+func (s *PaymentConfigServiceImpl) ListAuditTrail(ctx context.Context, countryCode string) ([]model.PaymentConfigAuditModel, error) {
+	audits, err := s.auditRepository.FindByCountryCode(ctx, countryCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.PaymentConfigAuditModel
+	for _, audit := range audits {
+		result = append(result, model.PaymentConfigAuditModel{
+			ID:            audit.ID,
+			CountryCode:   audit.CountryCode,
+			Action:        audit.Action,
+			ChangedFields: audit.ChangedFields,
+			KeyVersion:    audit.KeyVersion,
+			ActorId:       audit.ActorId,
+			ActorUsername: audit.ActorUsername,
+			CreatedAt:     audit.CreatedAt,
+		})
+	}
+	return result, nil
 }
 
-func (s *PaymentConfigServiceImpl) entityToModel(entity *entity.PaymentConfiguration) *model.PaymentConfigModel {
+func (s *PaymentConfigServiceImpl) entityToModel(entity *entity.PaymentConfiguration) (*model.PaymentConfigModel, error) {
+	secretKey, err := s.cipher.Decrypt(toEncryptedSecret(entity))
+	if err != nil {
+		return nil, err
+	}
 	return &model.PaymentConfigModel{
 		ID:          entity.ID,
 		CountryCode: entity.CountryCode,
-		SecretKey:   entity.SecretKey,
+		SecretKey:   common.MaskSecret(secretKey),
 		PublicKey:   entity.PublicKey,
-	}
+		KeyVersion:  entity.KeyVersion,
+	}, nil
 }
 
 func (s *PaymentConfigServiceImpl) RefreshCache(ctx context.Context) error {
@@ -79,10 +219,26 @@ func (s *PaymentConfigServiceImpl) RefreshCache(ctx context.Context) error {
 	return nil
 }
 
-func (s *PaymentConfigServiceImpl) modelToEntity(model *model.PaymentConfigModel) *entity.PaymentConfiguration {
-	return &entity.PaymentConfiguration{
-		CountryCode: model.CountryCode,
-		SecretKey:   model.SecretKey,
-		PublicKey:   model.PublicKey,
+// writeAudit records a config change. A failed audit write is logged rather
+// than returned because the change itself has already been committed.
+func (s *PaymentConfigServiceImpl) writeAudit(ctx context.Context, countryCode string, action string, changedFields []string, keyVersion string, actor model.AuditActor) {
+	_, err := s.auditRepository.Create(ctx, entity.PaymentConfigurationAudit{
+		CountryCode:   countryCode,
+		Action:        action,
+		ChangedFields: strings.Join(changedFields, ","),
+		KeyVersion:    keyVersion,
+		ActorId:       actor.UserId,
+		ActorUsername: actor.Username,
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to write payment config audit for " + countryCode + ": " + err.Error())
+	}
+}
+
+func toEncryptedSecret(config *entity.PaymentConfiguration) common.EncryptedSecret {
+	return common.EncryptedSecret{
+		Ciphertext:       config.SecretKey,
+		EncryptedDataKey: config.EncryptedDataKey,
+		KeyVersion:       config.KeyVersion,
 	}
 }
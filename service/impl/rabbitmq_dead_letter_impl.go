@@ -0,0 +1,268 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/streadway/amqp"
+)
+
+const defaultDeadLetterLimit = 50
+const maxDeadLetterLimit = 500
+
+// The dead letter operations run on their own channel so that the messages
+// they hold unacknowledged never mix with the consumers' deliveries, closing
+// the channel returns anything still held to the dead letter queue.
+
+func (s *RabbitMQService) ListDeadLetterQueues(ctx context.Context) ([]model.DeadLetterQueueModel, error) {
+	channel, err := s.adminChannel()
+	if err != nil {
+		return nil, err
+	}
+	defer channel.Close()
+
+	queues := make([]model.DeadLetterQueueModel, 0)
+	for _, topic := range s.subscribedTopics() {
+		queueName := deadLetterQueueName(s.queueName(topic))
+		queue, err := channel.QueueInspect(queueName)
+		if err != nil {
+			return nil, err
+		}
+		queues = append(queues, model.DeadLetterQueueModel{
+			Topic:    topic,
+			Queue:    queueName,
+			Messages: queue.Messages,
+		})
+	}
+	return queues, nil
+}
+
+func (s *RabbitMQService) ListDeadLetters(ctx context.Context, topic string, limit int) ([]model.DeadLetterModel, error) {
+	queueName, err := s.deadLetterQueueOf(topic)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultDeadLetterLimit
+	}
+	if limit > maxDeadLetterLimit {
+		limit = maxDeadLetterLimit
+	}
+
+	channel, err := s.adminChannel()
+	if err != nil {
+		return nil, err
+	}
+	defer channel.Close()
+
+	deadLetters := make([]model.DeadLetterModel, 0)
+	var lastTag uint64
+	for len(deadLetters) < limit && ctx.Err() == nil {
+		delivery, ok, err := channel.Get(queueName, false)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		lastTag = delivery.DeliveryTag
+		deadLetters = append(deadLetters, deadLetterModel(topic, delivery))
+	}
+	if lastTag > 0 {
+		if err := channel.Nack(lastTag, true, true); err != nil {
+			return nil, err
+		}
+	}
+	return deadLetters, nil
+}
+
+func (s *RabbitMQService) ReplayDeadLetters(ctx context.Context, topic string, messageIds []string) (int, error) {
+	queueName, err := s.deadLetterQueueOf(topic)
+	if err != nil {
+		return 0, err
+	}
+
+	channel, err := s.adminChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer channel.Close()
+
+	// A dead letter is only removed once the broker confirmed its replay
+	if err := channel.Confirm(false); err != nil {
+		return 0, err
+	}
+	confirms := channel.NotifyPublish(make(chan amqp.Confirmation, 1))
+
+	workQueue := s.queueName(topic)
+	return s.drainDeadLetters(ctx, channel, queueName, messageIds, func(delivery amqp.Delivery) error {
+		headers := amqp.Table{}
+		for key, value := range delivery.Headers {
+			headers[key] = value
+		}
+		delete(headers, "x-attempts")
+		delete(headers, "x-last-error")
+		delete(headers, "x-failed-at")
+
+		err := channel.Publish("", workQueue, false, false, amqp.Publishing{
+			ContentType:  delivery.ContentType,
+			Body:         delivery.Body,
+			Headers:      headers,
+			MessageId:    delivery.MessageId,
+			DeliveryMode: amqp.Persistent,
+		})
+		if err != nil {
+			return err
+		}
+		select {
+		case confirm := <-confirms:
+			if !confirm.Ack {
+				return fmt.Errorf("broker rejected the replay of message %s", delivery.MessageId)
+			}
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+func (s *RabbitMQService) PurgeDeadLetters(ctx context.Context, topic string, messageIds []string) (int, error) {
+	queueName, err := s.deadLetterQueueOf(topic)
+	if err != nil {
+		return 0, err
+	}
+
+	channel, err := s.adminChannel()
+	if err != nil {
+		return 0, err
+	}
+	defer channel.Close()
+
+	if len(messageIds) == 0 {
+		return channel.QueuePurge(queueName, false)
+	}
+	return s.drainDeadLetters(ctx, channel, queueName, messageIds, func(amqp.Delivery) error {
+		return nil
+	})
+}
+
+// drainDeadLetters walks the dead letter queue once and acknowledges every
+// selected message that action handled. Unselected messages are held until
+// the walk is over so that they are not fetched twice, then requeued. The walk
+// stops after the messages that were queued when it started, a replayed
+// message that fails again is dead lettered behind them and left for later.
+func (s *RabbitMQService) drainDeadLetters(ctx context.Context, channel *amqp.Channel, queueName string, messageIds []string, action func(amqp.Delivery) error) (int, error) {
+	queue, err := channel.QueueInspect(queueName)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	handled := make(map[string]bool)
+	var heldTag uint64
+	var walkErr error
+	for fetched := 0; fetched < queue.Messages && ctx.Err() == nil; fetched++ {
+		delivery, ok, err := channel.Get(queueName, false)
+		if err != nil {
+			walkErr = err
+			break
+		}
+		if !ok {
+			break
+		}
+		selected := len(messageIds) == 0 || contains(messageIds, delivery.MessageId)
+		if !selected || (delivery.MessageId != "" && handled[delivery.MessageId]) {
+			heldTag = delivery.DeliveryTag
+			continue
+		}
+		if err := action(delivery); err != nil {
+			walkErr = err
+			_ = delivery.Nack(false, true)
+			break
+		}
+		if err := delivery.Ack(false); err != nil {
+			walkErr = err
+			break
+		}
+		handled[delivery.MessageId] = true
+		processed++
+	}
+	if heldTag > 0 {
+		if err := channel.Nack(heldTag, true, true); err != nil && walkErr == nil {
+			walkErr = err
+		}
+	}
+	if walkErr != nil {
+		logger.Logger.Error(fmt.Sprintf("Error processing dead letters of %s: %s", queueName, walkErr.Error()))
+		return processed, walkErr
+	}
+	if ctx.Err() != nil {
+		return processed, ctx.Err()
+	}
+	return processed, nil
+}
+
+func (s *RabbitMQService) adminChannel() (*amqp.Channel, error) {
+	s.mu.Lock()
+	connection := s.Connection
+	s.mu.Unlock()
+	if connection == nil || connection.IsClosed() {
+		return nil, errors.New("rabbitmq connection is closed")
+	}
+	return connection.Channel()
+}
+
+func (s *RabbitMQService) subscribedTopics() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	topics := make([]string, 0, len(s.subscriptions))
+	for topic := range s.subscriptions {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+func (s *RabbitMQService) deadLetterQueueOf(topic string) (string, error) {
+	s.mu.Lock()
+	subscription, ok := s.subscriptions[topic]
+	s.mu.Unlock()
+	if !ok {
+		return "", exception.NotFoundError{Message: "No consumer is subscribed to topic " + topic}
+	}
+	return deadLetterQueueName(subscription.queue), nil
+}
+
+func deadLetterModel(topic string, delivery amqp.Delivery) model.DeadLetterModel {
+	deadLetter := model.DeadLetterModel{
+		MessageId: delivery.MessageId,
+		Topic:     topic,
+		Attempts:  headerInt(delivery.Headers, "x-attempts"),
+	}
+	if lastError, ok := delivery.Headers["x-last-error"].(string); ok {
+		deadLetter.Error = lastError
+	}
+	if failedAt, ok := delivery.Headers["x-failed-at"].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339, failedAt); err == nil {
+			deadLetter.FailedAt = &parsed
+		}
+	}
+	deadLetter.Body = deadLetterBody(delivery.Body)
+	return deadLetter
+}
+
+// deadLetterBody shows a JSON body as is and anything else as a string
+func deadLetterBody(body []byte) json.RawMessage {
+	if json.Valid(body) {
+		return body
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
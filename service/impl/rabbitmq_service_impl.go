@@ -5,12 +5,36 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/google/uuid"
 	"github.com/streadway/amqp"
 )
 
+var errBrokerUnavailable = errors.New("rabbitmq is reconnecting, message not published")
+var errPublishBufferFull = errors.New("rabbitmq publish buffer is full, message not published")
+var errPublishNotConfirmed = errors.New("rabbitmq channel closed before the message was confirmed")
+
+// rabbitMQSubscription is a consumer that is restarted on every new channel
+type rabbitMQSubscription struct {
+	queue   string
+	handler func([]byte) error
+	// channel the consumer currently runs on, nil while it is not running
+	channel *amqp.Channel
+}
+
+type bufferedPublishing struct {
+	exchange   string
+	routingKey string
+	publishing amqp.Publishing
+}
+
 // RabbitMQService implements the MessageBrokerService interface for RabbitMQ
 type RabbitMQService struct {
 	Connection   *amqp.Connection
@@ -18,12 +42,45 @@ type RabbitMQService struct {
 	ExchangeName string
 	ExchangeType string
 	mu           sync.Mutex
-	closeChan    chan *amqp.Error
-	connClose    chan *amqp.Error
+	url          string
+	// The channel is in confirm mode, publishMu serialises publishes so that
+	// each waits for its own confirm. published counts the publishes on the
+	// current channel, which is the delivery tag the broker confirms.
+	publishMu      sync.Mutex
+	confirms       chan amqp.Confirmation
+	published      uint64
+	confirmTimeout time.Duration
+	// A failed message is retried after retryBaseDelay, doubling up to
+	// retryMaxDelay, and dead-lettered after maxAttempts deliveries
+	maxAttempts    int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	// subscriptions maps the subscribed routing keys to their consumers,
+	// subscribeMu keeps a consumer from being started twice
+	subscriptions map[string]*rabbitMQSubscription
+	subscribeMu   sync.Mutex
+
+	// The supervisor re-dials after reconnectBaseDelay, doubling up to
+	// reconnectMaxDelay, whenever recoverSignal fires
+	reconnectBaseDelay time.Duration
+	reconnectMaxDelay  time.Duration
+	recoverSignal      chan struct{}
+	done               chan struct{}
+	closed             bool
+	state              string
+	stateSince         time.Time
+	lastError          string
+	reconnects         int
+
+	// Publishes made while reconnecting are held in publishBuffer when
+	// bufferPublishes is set, and rejected otherwise
+	bufferPublishes   bool
+	publishBufferSize int
+	publishBuffer     []bufferedPublishing
 }
 
 // NewRabbitMQService creates a new RabbitMQ service
-func NewRabbitMQService(conn *amqp.Connection, exchangeName string, exchangeType string) *RabbitMQService {
+func NewRabbitMQService(conn *amqp.Connection, exchangeName string, exchangeType string, config configuration.Config) *RabbitMQService {
 	if conn == nil {
 		exception.PanicLogging(errors.New("nil rabbitmq connection"))
 	}
@@ -32,26 +89,35 @@ func NewRabbitMQService(conn *amqp.Connection, exchangeName string, exchangeType
 		Connection:   conn,
 		ExchangeName: exchangeName,
 		ExchangeType: exchangeType,
-		closeChan:    make(chan *amqp.Error),
-		connClose:    make(chan *amqp.Error),
+		url:          configuration.RabbitMQURL(config),
+
+		confirmTimeout: time.Duration(intConfig(config, "RABBITMQ_CONFIRM_TIMEOUT_SECONDS", 10)) * time.Second,
+
+		maxAttempts:    intConfig(config, "RABBITMQ_MAX_ATTEMPTS", 5),
+		retryBaseDelay: time.Duration(intConfig(config, "RABBITMQ_RETRY_BASE_SECONDS", 5)) * time.Second,
+		retryMaxDelay:  time.Duration(intConfig(config, "RABBITMQ_RETRY_MAX_SECONDS", 600)) * time.Second,
+		subscriptions:  map[string]*rabbitMQSubscription{},
+
+		reconnectBaseDelay: time.Duration(intConfig(config, "RABBITMQ_RECONNECT_BASE_SECONDS", 1)) * time.Second,
+		reconnectMaxDelay:  time.Duration(intConfig(config, "RABBITMQ_RECONNECT_MAX_SECONDS", 30)) * time.Second,
+		recoverSignal:      make(chan struct{}, 1),
+		done:               make(chan struct{}),
+		state:              common.BROKER_STATE_CONNECTED,
+		stateSince:         time.Now(),
+
+		bufferPublishes:   config.Get("RABBITMQ_PUBLISH_MODE") == common.BROKER_PUBLISH_MODE_BUFFER,
+		publishBufferSize: intConfig(config, "RABBITMQ_PUBLISH_BUFFER_SIZE", 1000),
+	}
+	if s.maxAttempts < 1 {
+		s.maxAttempts = 1
 	}
 
 	if err := s.ensureChannelAndExchange(); err != nil {
 		exception.PanicLogging(err)
 	}
 
-	// monitor connection close
-	go func() {
-		notify := s.Connection.NotifyClose(make(chan *amqp.Error))
-		for err := range notify {
-			if err != nil {
-				logger.Logger.Warn(fmt.Sprintf("rabbitmq connection closed: %s", err.Error()))
-			}
-			// signal and keep loop ended
-			close(s.connClose)
-			return
-		}
-	}()
+	go s.watchConnection(conn)
+	go s.supervise()
 
 	return s
 }
@@ -91,80 +157,358 @@ func (s *RabbitMQService) ensureChannelAndExchange() error {
 		return err
 	}
 
+	// A publish only succeeds once the broker confirmed it has the message
+	if err := ch.Confirm(false); err != nil {
+		_ = ch.Close()
+		logger.Logger.Error(fmt.Sprintf("Error enabling publisher confirms: %s", err.Error()))
+		return err
+	}
+
 	s.Channel = ch
+	s.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, 16))
+	s.published = 0
 
-	// watch channel close and clear s.Channel when closed
-	notify := s.Channel.NotifyClose(make(chan *amqp.Error))
+	// watch channel close, the consumers die with the channel so the
+	// supervisor has to start them again on a new one
+	notify := ch.NotifyClose(make(chan *amqp.Error, 1))
 	go func() {
-		if e, ok := <-notify; ok && e != nil {
+		e, ok := <-notify
+		if ok && e != nil {
 			logger.Logger.Warn(fmt.Sprintf("rabbitmq channel closed: %s", e.Error()))
 		} else {
 			logger.Logger.Warn("rabbitmq channel closed")
 		}
 		s.mu.Lock()
 		// mark channel nil so next publish re-creates it
-		s.Channel = nil
+		if s.Channel == ch {
+			s.Channel = nil
+		}
 		s.mu.Unlock()
+		s.signalRecover()
 	}()
 
 	logger.Logger.Info(fmt.Sprintf("Declared exchange and created channel: %s", s.ExchangeName))
 	return nil
 }
 
+// watchConnection asks the supervisor to reconnect once conn drops
+func (s *RabbitMQService) watchConnection(conn *amqp.Connection) {
+	e, ok := <-conn.NotifyClose(make(chan *amqp.Error, 1))
+	if !ok || e == nil {
+		// closed on purpose
+		return
+	}
+	logger.Logger.Warn(fmt.Sprintf("rabbitmq connection closed: %s", e.Error()))
+	s.mu.Lock()
+	s.setStateLocked(common.BROKER_STATE_RECONNECTING, e.Error())
+	s.mu.Unlock()
+	s.signalRecover()
+}
+
+func (s *RabbitMQService) signalRecover() {
+	select {
+	case s.recoverSignal <- struct{}{}:
+	default:
+		// a recovery is already pending
+	}
+}
+
+// supervise restores the connection, the channel and every subscription
+// after a failure, retrying with backoff until it succeeds or the service is
+// closed
+func (s *RabbitMQService) supervise() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.recoverSignal:
+		}
+
+		for attempt := 1; ; attempt++ {
+			err := s.restore()
+			if err == nil {
+				break
+			}
+			delay := exponentialBackoff(s.reconnectBaseDelay, s.reconnectMaxDelay, attempt)
+			logger.Logger.Warn(fmt.Sprintf("rabbitmq recovery attempt %d failed, retrying in %s: %s", attempt, delay, err.Error()))
+			s.mu.Lock()
+			s.lastError = err.Error()
+			s.mu.Unlock()
+			select {
+			case <-s.done:
+				return
+			case <-time.After(delay):
+			}
+		}
+	}
+}
+
+func (s *RabbitMQService) restore() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	conn := s.Connection
+	s.mu.Unlock()
+
+	if conn == nil || conn.IsClosed() {
+		newConn, err := amqp.Dial(s.url)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = newConn.Close()
+			return nil
+		}
+		s.Connection = newConn
+		s.Channel = nil
+		s.reconnects++
+		s.mu.Unlock()
+		go s.watchConnection(newConn)
+		logger.Logger.Info("rabbitmq connection re-established")
+	}
+
+	if err := s.ensureChannelAndExchange(); err != nil {
+		return err
+	}
+	if err := s.resubscribe(); err != nil {
+		return err
+	}
+	return s.flushPublishBuffer()
+}
+
+// resubscribe starts the consumers that are not running on the current channel
+func (s *RabbitMQService) resubscribe() error {
+	s.subscribeMu.Lock()
+	defer s.subscribeMu.Unlock()
+
+	s.mu.Lock()
+	channel := s.Channel
+	pending := map[string]*rabbitMQSubscription{}
+	for routingKey, subscription := range s.subscriptions {
+		if subscription.channel != channel {
+			pending[routingKey] = subscription
+		}
+	}
+	s.mu.Unlock()
+
+	for routingKey, subscription := range pending {
+		if err := s.consume(routingKey, subscription); err != nil {
+			return err
+		}
+		logger.Logger.Info(fmt.Sprintf("Resubscribed to %s with routing key %s", s.ExchangeName, routingKey))
+	}
+	return nil
+}
+
+// flushPublishBuffer publishes the buffered messages in order and marks the
+// service connected once the buffer is empty, so that new publishes keep
+// queueing behind the older ones until then
+func (s *RabbitMQService) flushPublishBuffer() error {
+	for {
+		s.mu.Lock()
+		if len(s.publishBuffer) == 0 {
+			s.setStateLocked(common.BROKER_STATE_CONNECTED, "")
+			s.mu.Unlock()
+			return nil
+		}
+		next := s.publishBuffer[0]
+		s.mu.Unlock()
+
+		if err := s.publishRaw(next.exchange, next.routingKey, next.publishing); err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.publishBuffer = s.publishBuffer[1:]
+		s.mu.Unlock()
+	}
+}
+
+// setStateLocked must be called with s.mu held
+func (s *RabbitMQService) setStateLocked(state string, lastError string) {
+	if s.closed && state != common.BROKER_STATE_CLOSED {
+		return
+	}
+	if s.state != state {
+		s.state = state
+		s.stateSince = time.Now()
+	}
+	if lastError != "" {
+		s.lastError = lastError
+	}
+}
+
+// Health reports the connection state, the service is only usable while it
+// is connected
+func (s *RabbitMQService) Health() model.BrokerHealthModel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return model.BrokerHealthModel{
+		State:            s.state,
+		Since:            s.stateSince,
+		LastError:        s.lastError,
+		Reconnects:       s.reconnects,
+		Subscriptions:    len(s.subscriptions),
+		BufferedMessages: len(s.publishBuffer),
+	}
+}
+
 // PublishMessage publishes a message to a specified topic (routing key)
 func (s *RabbitMQService) PublishMessage(routingKey string, message interface{}) error {
-	if err := s.ensureChannelAndExchange(); err != nil {
-		logger.Logger.Error(fmt.Sprintf("Cannot publish, ensureChannel error: %s", err.Error()))
+	return s.PublishWithHeaders(routingKey, message, nil)
+}
+
+// PublishConfirmed publishes a message without ever buffering it, so that a
+// nil error means RabbitMQ confirmed it has the message
+func (s *RabbitMQService) PublishConfirmed(routingKey string, message interface{}) error {
+	publishing, err := jsonPublishing(message, nil)
+	if err != nil {
 		return err
 	}
 
-	body, err := json.Marshal(message)
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errors.New("rabbitmq service is closed")
+	}
+	if s.state != common.BROKER_STATE_CONNECTED {
+		s.mu.Unlock()
+		return errBrokerUnavailable
+	}
+	s.mu.Unlock()
+
+	if err := s.publishRaw(s.ExchangeName, routingKey, publishing); err != nil {
+		s.signalRecover()
+		logger.Logger.Error(fmt.Sprintf("Error publishing message to %s: %s", routingKey, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// PublishWithHeaders publishes a message with additional headers
+func (s *RabbitMQService) PublishWithHeaders(routingKey string, message interface{}, headers amqp.Table) error {
+	publishing, err := jsonPublishing(message, headers)
 	if err != nil {
-		logger.Logger.Error(fmt.Sprintf("Error marshaling message: %s", err.Error()))
 		return err
 	}
 
-	err = s.Channel.Publish(
-		s.ExchangeName, // exchange
-		routingKey,     // routing key
-		false,          // mandatory
-		false,          // immediate
-		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			DeliveryMode: amqp.Persistent, // Make message persistent
-		})
+	err = s.publish(s.ExchangeName, routingKey, publishing)
+	if err != nil {
+		logger.Logger.Error(fmt.Sprintf("Error publishing message to %s: %s", routingKey, err.Error()))
+		return err
+	}
+
+	logger.Logger.Info(fmt.Sprintf("Published message to %s with routing key %s", s.ExchangeName, routingKey))
+	return nil
+}
 
+func jsonPublishing(message interface{}, headers amqp.Table) (amqp.Publishing, error) {
+	body, err := json.Marshal(message)
 	if err != nil {
-		logger.Logger.Error(fmt.Sprintf("Error publishing message: %s", err.Error()))
-		// if channel/connection closed, clear channel so next attempt recreates it
-		s.mu.Lock()
-		_ = s.Channel // attempt to leave it for Close handling, but mark nil
-		s.Channel = nil
+		logger.Logger.Error(fmt.Sprintf("Error marshaling message: %s", err.Error()))
+		return amqp.Publishing{}, err
+	}
+	return amqp.Publishing{
+		ContentType:  "application/json",
+		Body:         body,
+		Headers:      headers,
+		DeliveryMode: amqp.Persistent, // Make message persistent
+	}, nil
+}
+
+// publish sends the message at once while connected. During an outage it is
+// buffered or rejected depending on RABBITMQ_PUBLISH_MODE.
+func (s *RabbitMQService) publish(exchange string, routingKey string, publishing amqp.Publishing) error {
+	s.mu.Lock()
+	if s.closed {
 		s.mu.Unlock()
+		return errors.New("rabbitmq service is closed")
+	}
+	if s.state != common.BROKER_STATE_CONNECTED {
+		defer s.mu.Unlock()
+		return s.bufferLocked(exchange, routingKey, publishing)
+	}
+	s.mu.Unlock()
+
+	err := s.publishRaw(exchange, routingKey, publishing)
+	if err == nil {
+		return nil
+	}
+	// The outage may not have been noticed yet
+	s.signalRecover()
+	if !s.bufferPublishes {
 		return err
 	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.bufferLocked(exchange, routingKey, publishing)
+}
 
-	logger.Logger.Info(fmt.Sprintf("Published message to %s with routing key %s", s.ExchangeName, routingKey))
+// bufferLocked must be called with s.mu held
+func (s *RabbitMQService) bufferLocked(exchange string, routingKey string, publishing amqp.Publishing) error {
+	if !s.bufferPublishes {
+		return errBrokerUnavailable
+	}
+	if len(s.publishBuffer) >= s.publishBufferSize {
+		return errPublishBufferFull
+	}
+	s.publishBuffer = append(s.publishBuffer, bufferedPublishing{exchange: exchange, routingKey: routingKey, publishing: publishing})
+	logger.Logger.Warn(fmt.Sprintf("Buffered message for %s while rabbitmq is unavailable, %d buffered", routingKey, len(s.publishBuffer)))
 	return nil
 }
 
-// SubscribeToTopic subscribes to a topic (queue bound to exchange with routing key)
+// SubscribeToTopic subscribes to a topic (queue bound to exchange with routing
+// key). The subscription survives reconnects, when the broker is unreachable
+// the error is returned and the consumer starts once the connection is back.
 func (s *RabbitMQService) SubscribeToTopic(routingKey string, handler func([]byte) error) error {
+	s.subscribeMu.Lock()
+	defer s.subscribeMu.Unlock()
+
+	s.mu.Lock()
+	if _, ok := s.subscriptions[routingKey]; ok {
+		s.mu.Unlock()
+		return fmt.Errorf("already subscribed to %s", routingKey)
+	}
+	subscription := &rabbitMQSubscription{queue: s.queueName(routingKey), handler: handler}
+	s.subscriptions[routingKey] = subscription
+	s.mu.Unlock()
+
 	if err := s.ensureChannelAndExchange(); err != nil {
 		logger.Logger.Error(fmt.Sprintf("Cannot subscribe, ensureChannel error: %s", err.Error()))
+		s.signalRecover()
+		return err
+	}
+	if err := s.consume(routingKey, subscription); err != nil {
+		s.signalRecover()
 		return err
 	}
 
+	logger.Logger.Info(fmt.Sprintf("Subscribed to %s with routing key %s", s.ExchangeName, routingKey))
+	return nil
+}
+
+// consume declares the queues of a subscription and starts its consumer on
+// the current channel. Callers hold s.subscribeMu.
+func (s *RabbitMQService) consume(routingKey string, subscription *rabbitMQSubscription) error {
+	s.mu.Lock()
+	channel := s.Channel
+	s.mu.Unlock()
+	if channel == nil {
+		return errors.New("rabbitmq channel is closed")
+	}
+
 	// Create a queue for this subscription
-	queueName := fmt.Sprintf("%s.%s", s.ExchangeName, routingKey)
-	queue, err := s.Channel.QueueDeclare(
-		queueName, // name
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
+	queue, err := channel.QueueDeclare(
+		subscription.queue, // name
+		true,               // durable
+		false,              // delete when unused
+		false,              // exclusive
+		false,              // no-wait
+		nil,                // arguments
 	)
 	if err != nil {
 		logger.Logger.Error(fmt.Sprintf("Error declaring queue: %s", err.Error()))
@@ -172,7 +516,7 @@ func (s *RabbitMQService) SubscribeToTopic(routingKey string, handler func([]byt
 	}
 
 	// Bind the queue to the exchange with the routing key
-	err = s.Channel.QueueBind(
+	err = channel.QueueBind(
 		queue.Name,     // queue name
 		routingKey,     // routing key
 		s.ExchangeName, // exchange
@@ -184,8 +528,12 @@ func (s *RabbitMQService) SubscribeToTopic(routingKey string, handler func([]byt
 		return err
 	}
 
+	if err := s.declareRetryTopology(channel, queue.Name); err != nil {
+		return err
+	}
+
 	// Create a consumer
-	msgs, err := s.Channel.Consume(
+	msgs, err := channel.Consume(
 		queue.Name, // queue
 		"",         // consumer
 		false,      // auto-ack
@@ -199,84 +547,256 @@ func (s *RabbitMQService) SubscribeToTopic(routingKey string, handler func([]byt
 		return err
 	}
 
-	// Process messages in a goroutine
+	s.mu.Lock()
+	subscription.channel = channel
+	s.mu.Unlock()
+
+	// Process messages in a goroutine, the deliveries end with the channel
 	go func() {
 		for msg := range msgs {
-			err := handler(msg.Body)
+			err := invokeHandler(subscription.handler, msg.Body)
 			if err != nil {
 				logger.Logger.Error(fmt.Sprintf("Error handling message: %s", err.Error()))
-				// Negative acknowledgement, message will be requeued
-				msg.Nack(false, true)
+				s.handleFailure(queue.Name, routingKey, msg, err)
 			} else {
 				// Acknowledge the message
 				msg.Ack(false)
 			}
 		}
-		// When msgs channel closes, mark channel nil so it can be recreated
 		s.mu.Lock()
-		s.Channel = nil
+		if subscription.channel == channel {
+			subscription.channel = nil
+		}
 		s.mu.Unlock()
+		logger.Logger.Warn(fmt.Sprintf("Consumer of %s stopped", queue.Name))
 	}()
 
-	logger.Logger.Info(fmt.Sprintf("Subscribed to %s with routing key %s", s.ExchangeName, routingKey))
 	return nil
 }
 
-// Close closes the connection to RabbitMQ
-func (s *RabbitMQService) Close() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *RabbitMQService) queueName(routingKey string) string {
+	return fmt.Sprintf("%s.%s", s.ExchangeName, routingKey)
+}
 
-	var err error
-	if s.Channel != nil {
-		if e := s.Channel.Close(); e != nil {
-			err = e
+func (s *RabbitMQService) deadLetterExchange() string {
+	return s.ExchangeName + ".dlx"
+}
+
+// retryDelay is the wait before the given retry, retries count from 1
+func (s *RabbitMQService) retryDelay(retry int) time.Duration {
+	return exponentialBackoff(s.retryBaseDelay, s.retryMaxDelay, retry)
+}
+
+// exponentialBackoff doubles base for every step after the first, capped at max
+func exponentialBackoff(base time.Duration, max time.Duration, step int) time.Duration {
+	delay := base
+	for i := 1; i < step && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// retryQueueName carries the delay so that a changed configuration declares
+// new queues instead of clashing with the arguments of the existing ones
+func retryQueueName(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%ds", queueName, int(delay.Seconds()))
+}
+
+func deadLetterQueueName(queueName string) string {
+	return queueName + ".dead"
+}
+
+// declareRetryTopology declares a queue per retry delay whose expired
+// messages return to the work queue through the default exchange, and the
+// dead letter queue bound to the dead letter exchange. The work queue itself
+// keeps its arguments, redeclaring an existing queue with new ones fails.
+func (s *RabbitMQService) declareRetryTopology(channel *amqp.Channel, queueName string) error {
+	for retry := 1; retry < s.maxAttempts; retry++ {
+		delay := s.retryDelay(retry)
+		_, err := channel.QueueDeclare(retryQueueName(queueName, delay), true, false, false, false, amqp.Table{
+			"x-message-ttl":             int64(delay / time.Millisecond),
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		})
+		if err != nil {
+			logger.Logger.Error(fmt.Sprintf("Error declaring retry queue for %s: %s", queueName, err.Error()))
+			return err
 		}
-		s.Channel = nil
 	}
-	if s.Connection != nil && !s.Connection.IsClosed() {
-		if e := s.Connection.Close(); e != nil && err == nil {
-			err = e
+
+	if err := channel.ExchangeDeclare(s.deadLetterExchange(), "direct", true, false, false, false, nil); err != nil {
+		logger.Logger.Error(fmt.Sprintf("Error declaring dead letter exchange: %s", err.Error()))
+		return err
+	}
+	deadQueue := deadLetterQueueName(queueName)
+	if _, err := channel.QueueDeclare(deadQueue, true, false, false, false, nil); err != nil {
+		logger.Logger.Error(fmt.Sprintf("Error declaring dead letter queue %s: %s", deadQueue, err.Error()))
+		return err
+	}
+	if err := channel.QueueBind(deadQueue, queueName, s.deadLetterExchange(), false, nil); err != nil {
+		logger.Logger.Error(fmt.Sprintf("Error binding dead letter queue %s: %s", deadQueue, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// handleFailure sends a failed message to the retry queue of its next
+// attempt, or to the dead letter exchange once it has used every attempt or
+// can never succeed. The message is only acknowledged after it was moved.
+func (s *RabbitMQService) handleFailure(queueName string, routingKey string, msg amqp.Delivery, handlerErr error) {
+	headers := amqp.Table{}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+	attempts := headerInt(headers, "x-attempts") + 1
+	headers["x-attempts"] = int32(attempts)
+	headers["x-last-error"] = truncate(handlerErr.Error(), 255)
+	if _, ok := headers["x-original-routing-key"]; !ok {
+		headers["x-original-routing-key"] = routingKey
+	}
+
+	publishing := amqp.Publishing{
+		ContentType:  msg.ContentType,
+		Body:         msg.Body,
+		Headers:      headers,
+		MessageId:    msg.MessageId,
+		DeliveryMode: amqp.Persistent,
+	}
+	if publishing.MessageId == "" {
+		publishing.MessageId = uuid.NewString()
+	}
+
+	var permanent service.PermanentError
+	var err error
+	if attempts >= s.maxAttempts || errors.As(handlerErr, &permanent) {
+		headers["x-failed-at"] = time.Now().UTC().Format(time.RFC3339)
+		err = s.publishRaw(s.deadLetterExchange(), queueName, publishing)
+		if err == nil {
+			logger.Logger.Warn(fmt.Sprintf("Dead-lettered message %s from %s after %d attempts", publishing.MessageId, queueName, attempts))
+		}
+	} else {
+		delay := s.retryDelay(attempts)
+		err = s.publishRaw("", retryQueueName(queueName, delay), publishing)
+		if err == nil {
+			logger.Logger.Info(fmt.Sprintf("Retrying message %s from %s in %s, attempt %d of %d", publishing.MessageId, queueName, delay, attempts+1, s.maxAttempts))
 		}
 	}
-	return err
+
+	if err != nil {
+		// The message could not be moved, leave it on the work queue
+		logger.Logger.Error(fmt.Sprintf("Failed to move message from %s: %s", queueName, err.Error()))
+		msg.Nack(false, true)
+		return
+	}
+	msg.Ack(false)
 }
 
-// PublishWithHeaders publishes a message with additional headers
-func (s *RabbitMQService) PublishWithHeaders(routingKey string, message interface{}, headers amqp.Table) error {
+// publishRaw publishes on the current channel and waits until the broker
+// confirms the message, a nack or a missing confirm fails the publish
+func (s *RabbitMQService) publishRaw(exchange string, routingKey string, publishing amqp.Publishing) error {
 	if err := s.ensureChannelAndExchange(); err != nil {
-		logger.Logger.Error(fmt.Sprintf("Cannot publish with headers, ensureChannel error: %s", err.Error()))
 		return err
 	}
+	s.publishMu.Lock()
+	defer s.publishMu.Unlock()
 
-	body, err := json.Marshal(message)
-	if err != nil {
-		logger.Logger.Error(fmt.Sprintf("Error marshaling message: %s", err.Error()))
+	s.mu.Lock()
+	channel := s.Channel
+	confirms := s.confirms
+	s.mu.Unlock()
+	if channel == nil {
+		return errors.New("rabbitmq channel is closed")
+	}
+	if err := channel.Publish(exchange, routingKey, false, false, publishing); err != nil {
 		return err
 	}
 
-	err = s.Channel.Publish(
-		s.ExchangeName, // exchange
-		routingKey,     // routing key
-		false,          // mandatory
-		false,          // immediate
-		amqp.Publishing{
-			ContentType:  "application/json",
-			Body:         body,
-			Headers:      headers,
-			DeliveryMode: amqp.Persistent, // Make message persistent
-		})
-
-	if err != nil {
-		logger.Logger.Error(fmt.Sprintf("Error publishing message with headers: %s", err.Error()))
-		s.mu.Lock()
-		s.Channel = nil
+	s.mu.Lock()
+	if s.Channel != channel {
 		s.mu.Unlock()
-		return err
+		return errPublishNotConfirmed
 	}
+	s.published++
+	deliveryTag := s.published
+	s.mu.Unlock()
+	return s.awaitConfirm(confirms, deliveryTag)
+}
 
-	logger.Logger.Info(fmt.Sprintf("Published message with headers to %s with routing key %s", s.ExchangeName, routingKey))
-	return nil
+// awaitConfirm waits for the confirm of deliveryTag. Confirms of earlier
+// publishes that timed out may still arrive first and are skipped.
+func (s *RabbitMQService) awaitConfirm(confirms chan amqp.Confirmation, deliveryTag uint64) error {
+	timeout := time.NewTimer(s.confirmTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case confirmation, ok := <-confirms:
+			if !ok {
+				return errPublishNotConfirmed
+			}
+			if confirmation.DeliveryTag < deliveryTag {
+				continue
+			}
+			if !confirmation.Ack {
+				return errors.New("rabbitmq rejected the message")
+			}
+			return nil
+		case <-timeout.C:
+			return fmt.Errorf("rabbitmq did not confirm the message within %s", s.confirmTimeout)
+		}
+	}
+}
+
+// headerInt reads an integer header whatever integer type the broker decoded
+func headerInt(headers amqp.Table, key string) int {
+	switch value := headers[key].(type) {
+	case int:
+		return value
+	case int8:
+		return int(value)
+	case int16:
+		return int(value)
+	case int32:
+		return int(value)
+	case int64:
+		return int(value)
+	default:
+		return 0
+	}
+}
+
+// Close stops the supervisor and closes the connection to RabbitMQ, buffered
+// publishes are dropped
+func (s *RabbitMQService) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	if len(s.publishBuffer) > 0 {
+		logger.Logger.Warn(fmt.Sprintf("Dropping %d buffered rabbitmq messages on close", len(s.publishBuffer)))
+		s.publishBuffer = nil
+	}
+	s.setStateLocked(common.BROKER_STATE_CLOSED, "")
+	s.closed = true
+	close(s.done)
+
+	var err error
+	if s.Channel != nil {
+		if e := s.Channel.Close(); e != nil {
+			err = e
+		}
+		s.Channel = nil
+	}
+	if s.Connection != nil && !s.Connection.IsClosed() {
+		if e := s.Connection.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
 }
 
 // CreateQueue creates a new queue
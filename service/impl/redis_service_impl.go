@@ -134,6 +134,22 @@ func (s *RedisServiceImpl) Exists(key string) (bool, error) {
 	return result > 0, nil
 }
 
+// Increment increases a counter, the ttl is applied when the counter is created
+func (s *RedisServiceImpl) Increment(key string, ttl time.Duration) (int64, error) {
+	count, err := s.Client.Incr(s.Ctx, key).Result()
+	if err != nil {
+		logger.Logger.Error(fmt.Sprintf("Error incrementing key %s: %s", key, err.Error()))
+		return 0, err
+	}
+	if count == 1 {
+		if err := s.Client.Expire(s.Ctx, key, ttl).Err(); err != nil {
+			logger.Logger.Error(fmt.Sprintf("Error setting TTL for key %s: %s", key, err.Error()))
+			return count, err
+		}
+	}
+	return count, nil
+}
+
 // SetWithTTL stores a value in Redis with a specific TTL
 func (s *RedisServiceImpl) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
 	jsonData, err := json.Marshal(value)
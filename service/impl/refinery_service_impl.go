@@ -23,11 +23,12 @@ type RefineryServiceImpl struct {
 	service.UserService
 	service.MessageService
 	service.SettingService
+	service.InvitationService
 	configuration.Config
 }
 
-func NewRefineryServiceImpl(repository *repository.RefineryRepository, userService *service.UserService, messageService *service.MessageService, settingService *service.SettingService, configuration configuration.Config) service.RefineryService {
-	return &RefineryServiceImpl{RefineryRepository: *repository, UserService: *userService, MessageService: *messageService, SettingService: *settingService, Config: configuration}
+func NewRefineryServiceImpl(repository *repository.RefineryRepository, userService *service.UserService, messageService *service.MessageService, settingService *service.SettingService, invitationService *service.InvitationService, configuration configuration.Config) service.RefineryService {
+	return &RefineryServiceImpl{RefineryRepository: *repository, UserService: *userService, MessageService: *messageService, SettingService: *settingService, InvitationService: *invitationService, Config: configuration}
 }
 
 func (r RefineryServiceImpl) GetRefinery(context context.Context, request model.GetRefineryModel) (model.RefineryCostModel, error) {
@@ -238,11 +239,8 @@ func (r RefineryServiceImpl) CreateRefinery(ctx context.Context, refineryModel m
 		Address:                        refineryData.Address,
 		IsActive:                       refineryData.IsActive,
 	}
-	password, err := common.GeneratePassword(8)
-	exception.PanicLogging(err)
 	user := model.UserModel{
 		Username:     refineryModel.Phone,
-		Password:     password,
 		Role:         common.REFINERY_ADMIN_ROLE,
 		EmailAddress: refineryModel.Email,
 		FirstName:    refineryModel.FirstName,
@@ -253,14 +251,10 @@ func (r RefineryServiceImpl) CreateRefinery(ctx context.Context, refineryModel m
 		CountryCode:  "+233",
 	}
 
-	_ = r.UserService.Register(context.TODO(), user)
-	exception.PanicLogging(err)
-	smsModel := model.SMSMessageModel{
-		Message:     "Hello " + user.FirstName + "," + "Your Refinery has been registered successfully.Your password is " + user.Password + ":\n visit https://aqua-wizz.app\n",
-		PhoneNumber: user.PhoneNumber,
-		CountryCode: user.CountryCode,
+	// The refinery admin sets their own password through the invitation link
+	if _, err := r.InvitationService.Invite(ctx, user); err != nil {
+		return model.RefineryModel{}, err
 	}
-	r.MessageService.SendSMS(context.TODO(), smsModel)
 	return refineryModelResponse, nil
 }
 
@@ -294,7 +288,9 @@ func (r RefineryServiceImpl) ListRefineries(ctx context.Context) ([]model.Refine
 
 }
 
-func (r RefineryServiceImpl) UpdateRefinery(ctx context.Context, refineryModel model.CreateRefineryModel, id string) (model.RefineryModel, error) {
+// UpdateRefinery updates the refinery details. Prices are only taken from the
+// request when the caller may change pricing, otherwise they are kept.
+func (r RefineryServiceImpl) UpdateRefinery(ctx context.Context, refineryModel model.CreateRefineryModel, id string, includePricing bool) (model.RefineryModel, error) {
 	existingRefinery := r.RefineryRepository.FindById(ctx, id)
 	if existingRefinery.ID == 0 {
 		return model.RefineryModel{}, exception.BadRequestError{
@@ -304,8 +300,10 @@ func (r RefineryServiceImpl) UpdateRefinery(ctx context.Context, refineryModel m
 	existingRefinery.Name = refineryModel.Name
 	existingRefinery.PlaceId = refineryModel.PlaceId
 	existingRefinery.LicenceExpiry = refineryModel.LicenceExpirationDate
-	existingRefinery.DomesticCostPerThousandLitre = refineryModel.DomesticCostPerThousandLitre
-	existingRefinery.IndustrialCostPerThousandLitre = refineryModel.IndustrialCostPerThousandLitre
+	if includePricing {
+		existingRefinery.DomesticCostPerThousandLitre = refineryModel.DomesticCostPerThousandLitre
+		existingRefinery.IndustrialCostPerThousandLitre = refineryModel.IndustrialCostPerThousandLitre
+	}
 	existingRefinery.RawLocationData = refineryModel.RawLocationData
 	existingRefinery.Region = refineryModel.Region
 	existingRefinery.Phone = refineryModel.Phone
@@ -331,6 +329,31 @@ func (r RefineryServiceImpl) UpdateRefinery(ctx context.Context, refineryModel m
 	return refineryModelResponse, nil
 }
 
+func (r RefineryServiceImpl) UpdatePricing(ctx context.Context, refineryId uint, pricing model.RefineryPricingModel) (model.RefineryModel, error) {
+	if pricing.DomesticCostPerThousandLitre < 0 || pricing.IndustrialCostPerThousandLitre < 0 {
+		return model.RefineryModel{}, exception.BadRequestError{Message: "Prices cannot be negative"}
+	}
+	id := strconv.FormatUint(uint64(refineryId), 10)
+	existingRefinery := r.RefineryRepository.FindById(ctx, id)
+	if existingRefinery.ID == 0 {
+		return model.RefineryModel{}, exception.NotFoundError{Message: "Refinery not found"}
+	}
+	existingRefinery.DomesticCostPerThousandLitre = pricing.DomesticCostPerThousandLitre
+	existingRefinery.IndustrialCostPerThousandLitre = pricing.IndustrialCostPerThousandLitre
+
+	refineryData, err := r.RefineryRepository.Update(ctx, existingRefinery, id)
+	if err != nil {
+		return model.RefineryModel{}, err
+	}
+	return model.RefineryModel{
+		Id:                             refineryData.ID,
+		Name:                           refineryData.Name,
+		LicenceExpiry:                  refineryData.LicenceExpiry,
+		DomesticCostPerThousandLitre:   refineryData.DomesticCostPerThousandLitre,
+		IndustrialCostPerThousandLitre: refineryData.IndustrialCostPerThousandLitre,
+	}, nil
+}
+
 func (r RefineryServiceImpl) GetRefineryDashboardData(ctx context.Context, u uint) (map[string]interface{}, error) {
 	refineryData, err := r.RefineryRepository.GetRefineryDashboardData(ctx, u)
 	exception.PanicLogging(err)
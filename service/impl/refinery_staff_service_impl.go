@@ -0,0 +1,183 @@
+package impl
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+type refineryStaffServiceImpl struct {
+	repository.UserRepository
+	service.InvitationService
+	service.TokenService
+}
+
+func NewRefineryStaffService(userRepository repository.UserRepository, invitationService service.InvitationService, tokenService service.TokenService) service.RefineryStaffService {
+	return &refineryStaffServiceImpl{
+		UserRepository:    userRepository,
+		InvitationService: invitationService,
+		TokenService:      tokenService,
+	}
+}
+
+func (r *refineryStaffServiceImpl) ListStaff(ctx context.Context, refineryId uint) ([]model.RefineryStaffModel, error) {
+	if refineryId == 0 {
+		return nil, errNoRefinery
+	}
+	users, err := r.UserRepository.FindByRefineryId(ctx, refineryId)
+	if err != nil {
+		return nil, err
+	}
+
+	staff := make([]model.RefineryStaffModel, 0, len(users))
+	for _, user := range users {
+		staff = append(staff, refineryStaffModel(user))
+	}
+	return staff, nil
+}
+
+// InviteStaff creates the staff account in the caller's refinery and sends an
+// invitation link. The new user inherits the country settings of the inviter.
+func (r *refineryStaffServiceImpl) InviteStaff(ctx context.Context, refineryId uint, actorId uint, request model.InviteRefineryStaffModel) (model.RefineryStaffModel, error) {
+	if refineryId == 0 {
+		return model.RefineryStaffModel{}, errNoRefinery
+	}
+	if err := validateStaffRole(request.Role); err != nil {
+		return model.RefineryStaffModel{}, err
+	}
+	if request.FirstName == "" || request.LastName == "" || request.PhoneNumber == "" {
+		return model.RefineryStaffModel{}, exception.BadRequestError{Message: "firstName, lastName and phoneNumber are required"}
+	}
+
+	actor, err := r.UserRepository.FindById(ctx, int(actorId))
+	if err != nil {
+		return model.RefineryStaffModel{}, err
+	}
+
+	user, err := r.InvitationService.Invite(ctx, model.UserModel{
+		Username:     request.PhoneNumber,
+		FirstName:    request.FirstName,
+		LastName:     request.LastName,
+		PhoneNumber:  request.PhoneNumber,
+		EmailAddress: request.Email,
+		Role:         request.Role,
+		RefineryId:   refineryId,
+		CountryCode:  actor.CountryCode,
+		AreaCode:     actor.AreaCode,
+	})
+	if err != nil {
+		return model.RefineryStaffModel{}, err
+	}
+	return refineryStaffModel(user), nil
+}
+
+// UpdateStaffRole changes the role of a staff member. Their sessions are
+// revoked because the role is carried in the access token claims.
+func (r *refineryStaffServiceImpl) UpdateStaffRole(ctx context.Context, refineryId uint, actorId uint, userId uint, role string) (model.RefineryStaffModel, error) {
+	if err := validateStaffRole(role); err != nil {
+		return model.RefineryStaffModel{}, err
+	}
+	user, err := r.findStaff(ctx, refineryId, actorId, userId)
+	if err != nil {
+		return model.RefineryStaffModel{}, err
+	}
+	if user.UserRole == role {
+		return refineryStaffModel(user), nil
+	}
+	if user.UserRole == common.REFINERY_ADMIN_ROLE {
+		if err := r.ensureAnotherOwner(ctx, refineryId, userId); err != nil {
+			return model.RefineryStaffModel{}, err
+		}
+	}
+
+	user.UserRole = role
+	user, err = r.UserRepository.Update(ctx, user)
+	if err != nil {
+		return model.RefineryStaffModel{}, err
+	}
+	if err := r.TokenService.RevokeAllSessions(ctx, user.ID); err != nil {
+		return model.RefineryStaffModel{}, err
+	}
+	return refineryStaffModel(user), nil
+}
+
+// RemoveStaff deactivates the account, revokes its sessions and any pending
+// invitation. The user keeps its refinery so that past actions stay attributable.
+func (r *refineryStaffServiceImpl) RemoveStaff(ctx context.Context, refineryId uint, actorId uint, userId uint) error {
+	user, err := r.findStaff(ctx, refineryId, actorId, userId)
+	if err != nil {
+		return err
+	}
+	if user.UserRole == common.REFINERY_ADMIN_ROLE {
+		if err := r.ensureAnotherOwner(ctx, refineryId, userId); err != nil {
+			return err
+		}
+	}
+
+	if err := r.UserRepository.UpdateActiveStatus(ctx, user.ID, false); err != nil {
+		return err
+	}
+	if err := r.InvitationService.RevokeForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	return r.TokenService.RevokeAllSessions(ctx, user.ID)
+}
+
+// findStaff loads a member of the refinery other than the caller
+func (r *refineryStaffServiceImpl) findStaff(ctx context.Context, refineryId uint, actorId uint, userId uint) (entity.User, error) {
+	if refineryId == 0 {
+		return entity.User{}, errNoRefinery
+	}
+	if userId == actorId {
+		return entity.User{}, exception.BadRequestError{Message: "You cannot change your own staff membership"}
+	}
+
+	user, err := r.UserRepository.FindById(ctx, int(userId))
+	if err != nil {
+		return entity.User{}, err
+	}
+	if user.RefineryId != refineryId || !contains(common.RefineryStaffRoles, user.UserRole) {
+		return entity.User{}, exception.NotFoundError{Message: "Staff member not found"}
+	}
+	return user, nil
+}
+
+// ensureAnotherOwner keeps at least one active owner on every refinery
+func (r *refineryStaffServiceImpl) ensureAnotherOwner(ctx context.Context, refineryId uint, userId uint) error {
+	users, err := r.UserRepository.FindByRefineryId(ctx, refineryId)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.ID != userId && user.IsActive && user.UserRole == common.REFINERY_ADMIN_ROLE {
+			return nil
+		}
+	}
+	return exception.BadRequestError{Message: "A refinery must keep at least one active owner"}
+}
+
+var errNoRefinery = exception.ForbiddenError{Message: "Your account is not linked to a refinery"}
+
+func validateStaffRole(role string) error {
+	if !contains(common.RefineryStaffRoles, role) {
+		return exception.BadRequestError{Message: "Unknown refinery role " + role}
+	}
+	return nil
+}
+
+func refineryStaffModel(user entity.User) model.RefineryStaffModel {
+	return model.RefineryStaffModel{
+		Id:          user.ID,
+		FirstName:   user.FirstName,
+		LastName:    user.LastName,
+		PhoneNumber: user.PhoneNumber,
+		Email:       user.Email,
+		Role:        user.UserRole,
+		IsActive:    user.IsActive,
+	}
+}
@@ -0,0 +1,198 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+// s3FileStorage talks to any S3 compatible object store (AWS S3, MinIO,
+// DigitalOcean Spaces) using path style requests signed with SigV4
+type s3FileStorage struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+func NewS3FileStorage(config configuration.Config) (service.FileStorage, error) {
+	endpoint, err := url.Parse(config.Get("S3_ENDPOINT"))
+	if err != nil || endpoint.Host == "" {
+		return nil, errors.New("S3_ENDPOINT must be an absolute URL")
+	}
+	storage := &s3FileStorage{
+		endpoint:  endpoint,
+		region:    config.Get("S3_REGION"),
+		bucket:    config.Get("S3_BUCKET"),
+		accessKey: config.Get("S3_ACCESS_KEY_ID"),
+		secretKey: config.Get("S3_SECRET_ACCESS_KEY"),
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+	if storage.region == "" {
+		storage.region = "us-east-1"
+	}
+	if storage.bucket == "" || storage.accessKey == "" || storage.secretKey == "" {
+		return nil, errors.New("S3_BUCKET, S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for the s3 storage driver")
+	}
+	return storage, nil
+}
+
+func (s *s3FileStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	response, err := s.do(ctx, http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return s3Error(response)
+	}
+	return nil
+}
+
+func (s *s3FileStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	response, err := s.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, os.ErrNotExist
+	}
+	if response.StatusCode != http.StatusOK {
+		defer response.Body.Close()
+		return nil, s3Error(response)
+	}
+	return response.Body, nil
+}
+
+func (s *s3FileStorage) Delete(ctx context.Context, key string) error {
+	response, err := s.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusNoContent && response.StatusCode != http.StatusOK {
+		return s3Error(response)
+	}
+	return nil
+}
+
+func (s *s3FileStorage) do(ctx context.Context, method string, key string, body []byte, contentType string) (*http.Response, error) {
+	if !validFileKey(key) {
+		return nil, errInvalidFileKey
+	}
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	rawPath := strings.TrimSuffix(s.endpoint.EscapedPath(), "/") + "/" + s3Escape(s.bucket) + "/" + strings.Join(segments, "/")
+	path, _ := url.PathUnescape(rawPath)
+	target := url.URL{Scheme: s.endpoint.Scheme, Host: s.endpoint.Host, Path: path, RawPath: rawPath}
+
+	request, err := http.NewRequestWithContext(ctx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	s.sign(request, rawPath, body, time.Now().UTC())
+	return s.client.Do(request)
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to the request
+func (s *s3FileStorage) sign(request *http.Request, canonicalUri string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 request.URL.Host,
+		"x-amz-date":           amzDate,
+		"x-amz-content-sha256": payloadHash,
+	}
+	if contentType := request.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		canonicalUri,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	signingKey := hmacSha256([]byte("AWS4"+s.secretKey), date)
+	signingKey = hmacSha256(signingKey, s.region)
+	signingKey = hmacSha256(signingKey, "s3")
+	signingKey = hmacSha256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSha256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.accessKey, scope, signedHeaders, signature))
+}
+
+func s3Error(response *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	return fmt.Errorf("object storage returned %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// s3Escape percent-encodes everything except the RFC 3986 unreserved
+// characters, as SigV4 requires for path segments
+func s3Escape(value string) string {
+	var builder strings.Builder
+	for _, b := range []byte(value) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') || b == '-' || b == '.' || b == '_' || b == '~' {
+			builder.WriteByte(b)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", b)
+		}
+	}
+	return builder.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,308 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+const (
+	loginFailuresPrefix = "login_failures:"
+	loginDelayPrefix    = "login_delay:"
+	loginLockoutPrefix  = "login_lockout:"
+	lockoutTypeUsername = "username"
+	lockoutTypeIp       = "ip"
+)
+
+type loginLockoutRecord struct {
+	Failures int64     `json:"failures"`
+	LockedAt time.Time `json:"lockedAt"`
+}
+
+type securityServiceImpl struct {
+	repository.SecurityEventRepository
+	service.RedisService
+	service.NotificationDispatcher
+	configuration.Config
+}
+
+func NewSecurityService(securityEventRepository repository.SecurityEventRepository, redisService service.RedisService, notificationDispatcher service.NotificationDispatcher, config configuration.Config) service.SecurityService {
+	return &securityServiceImpl{
+		SecurityEventRepository: securityEventRepository,
+		RedisService:            redisService,
+		NotificationDispatcher:  notificationDispatcher,
+		Config:                  config,
+	}
+}
+
+// CheckLogin rejects a login attempt while the username or the client IP is
+// locked out, or while the username is waiting out its progressive delay.
+// Redis being unavailable does not block logins, the failure is only logged.
+func (s *securityServiceImpl) CheckLogin(ctx context.Context, username string, client model.SessionClientModel) error {
+	username = normaliseUsername(username)
+
+	for _, key := range []string{lockoutKey(lockoutTypeUsername, username), lockoutKey(lockoutTypeIp, client.IpAddress)} {
+		ttl, err := s.RedisService.GetTTL(key)
+		if err != nil {
+			logger.Logger.Error("Failed to check login lockout: " + err.Error())
+			continue
+		}
+		if ttl > 0 {
+			s.logLoginEvent(ctx, common.SECURITY_EVENT_LOGIN_BLOCKED, 0, username, "locked out", client)
+			return exception.TooManyRequestsError{Message: fmt.Sprintf("Too many failed login attempts, try again in %d minutes", int(math.Ceil(ttl.Minutes())))}
+		}
+	}
+
+	ttl, err := s.RedisService.GetTTL(loginDelayPrefix + username)
+	if err != nil {
+		logger.Logger.Error("Failed to check login delay: " + err.Error())
+		return nil
+	}
+	if ttl > 0 {
+		s.logLoginEvent(ctx, common.SECURITY_EVENT_LOGIN_BLOCKED, 0, username, "delayed", client)
+		return exception.TooManyRequestsError{Message: fmt.Sprintf("Too many failed login attempts, try again in %d seconds", int(math.Ceil(ttl.Seconds())))}
+	}
+	return nil
+}
+
+// RecordLoginFailure counts a failed login against the username and the IP.
+// Past a threshold every further failure doubles the wait before the next
+// attempt, and at the limit the username or IP is locked out. The account
+// owner is told when their account gets locked.
+func (s *securityServiceImpl) RecordLoginFailure(ctx context.Context, username string, user *entity.User, reason string, client model.SessionClientModel) {
+	username = normaliseUsername(username)
+	var userId uint
+	if user != nil {
+		userId = user.ID
+	}
+	s.logLoginEvent(ctx, common.SECURITY_EVENT_LOGIN_FAILED, userId, username, reason, client)
+
+	window := time.Duration(intConfig(s.Config, "LOGIN_FAILURE_WINDOW_MINUTES", 15)) * time.Minute
+	lockout := time.Duration(intConfig(s.Config, "LOGIN_LOCKOUT_MINUTES", 30)) * time.Minute
+
+	if username != "" {
+		failures, err := s.RedisService.Increment(loginFailuresPrefix+lockoutTypeUsername+":"+username, window)
+		if err != nil {
+			logger.Logger.Error("Failed to count login failure: " + err.Error())
+		} else if failures >= int64(intConfig(s.Config, "LOGIN_MAX_FAILURES", 10)) {
+			if s.lock(lockoutTypeUsername, username, failures, lockout) {
+				s.logLoginEvent(ctx, common.SECURITY_EVENT_ACCOUNT_LOCKED, userId, username, strconv.FormatInt(failures, 10)+" failed attempts", client)
+				s.notifyLockout(ctx, user, lockout)
+			}
+		} else if delay := s.loginDelay(failures); delay > 0 {
+			if err := s.RedisService.Set(loginDelayPrefix+username, true, delay); err != nil {
+				logger.Logger.Error("Failed to set login delay: " + err.Error())
+			}
+		}
+	}
+
+	if client.IpAddress != "" {
+		failures, err := s.RedisService.Increment(loginFailuresPrefix+lockoutTypeIp+":"+client.IpAddress, window)
+		if err != nil {
+			logger.Logger.Error("Failed to count login failure: " + err.Error())
+		} else if failures >= int64(intConfig(s.Config, "LOGIN_MAX_IP_FAILURES", 50)) {
+			if s.lock(lockoutTypeIp, client.IpAddress, failures, lockout) {
+				s.logLoginEvent(ctx, common.SECURITY_EVENT_IP_LOCKED, 0, username, strconv.FormatInt(failures, 10)+" failed attempts", client)
+			}
+		}
+	}
+}
+
+// RecordLoginSuccess logs the login and forgets earlier failures of the username
+func (s *securityServiceImpl) RecordLoginSuccess(ctx context.Context, user entity.User, client model.SessionClientModel) {
+	username := normaliseUsername(user.Username)
+	s.logLoginEvent(ctx, common.SECURITY_EVENT_LOGIN_SUCCEEDED, user.ID, username, "", client)
+	s.clearFailures(lockoutTypeUsername, username)
+}
+
+func (s *securityServiceImpl) ListLockouts(ctx context.Context) ([]model.LoginLockoutModel, error) {
+	keys, err := s.RedisService.GetKeys(loginLockoutPrefix + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	var lockouts []model.LoginLockoutModel
+	for _, key := range keys {
+		parts := strings.SplitN(strings.TrimPrefix(key, loginLockoutPrefix), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		lockout := model.LoginLockoutModel{Type: parts[0], Value: parts[1]}
+
+		if value, err := s.RedisService.Get(key); err == nil {
+			var record loginLockoutRecord
+			if err := json.Unmarshal([]byte(value), &record); err == nil {
+				lockout.Failures = record.Failures
+				lockout.LockedAt = record.LockedAt
+			}
+		}
+		ttl, err := s.RedisService.GetTTL(key)
+		if err != nil || ttl <= 0 {
+			continue
+		}
+		lockout.ExpiresAt = time.Now().Add(ttl)
+		lockouts = append(lockouts, lockout)
+	}
+	return lockouts, nil
+}
+
+func (s *securityServiceImpl) ClearLockout(ctx context.Context, lockoutType string, value string, actor model.AuditActor) error {
+	if lockoutType != lockoutTypeUsername && lockoutType != lockoutTypeIp {
+		return exception.BadRequestError{Message: "type must be username or ip"}
+	}
+	if lockoutType == lockoutTypeUsername {
+		value = normaliseUsername(value)
+	}
+	if value == "" {
+		return exception.BadRequestError{Message: "value is required"}
+	}
+
+	exists, err := s.RedisService.Exists(lockoutKey(lockoutType, value))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return exception.NotFoundError{Message: "Lockout not found"}
+	}
+
+	if err := s.RedisService.Delete(lockoutKey(lockoutType, value)); err != nil {
+		return err
+	}
+	s.clearFailures(lockoutType, value)
+
+	s.LogEvent(ctx, model.SecurityEventModel{
+		UserId:    actor.UserId,
+		Username:  actor.Username,
+		EventType: common.SECURITY_EVENT_LOCKOUT_CLEARED,
+		Reason:    "cleared " + lockoutType + " " + value,
+	})
+	return nil
+}
+
+// LogEvent writes to the security event log. A failed write is logged rather
+// than returned so that it never blocks the action being recorded.
+func (s *securityServiceImpl) LogEvent(ctx context.Context, event model.SecurityEventModel) {
+	_, err := s.SecurityEventRepository.Create(ctx, entity.SecurityEvent{
+		UserId:    event.UserId,
+		Username:  truncate(event.Username, 100),
+		EventType: event.EventType,
+		Reason:    truncate(event.Reason, 255),
+		IpAddress: truncate(event.IpAddress, 64),
+		UserAgent: truncate(event.UserAgent, 255),
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to write security event " + event.EventType + ": " + err.Error())
+	}
+}
+
+func (s *securityServiceImpl) ListEvents(ctx context.Context, filter model.SecurityEventFilterModel, page, limit int) ([]model.SecurityEventModel, int64, error) {
+	events, totalCount, err := s.SecurityEventRepository.FindPaginated(ctx, filter, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var result []model.SecurityEventModel
+	for _, event := range events {
+		result = append(result, model.SecurityEventModel{
+			Id:        event.ID,
+			UserId:    event.UserId,
+			Username:  event.Username,
+			EventType: event.EventType,
+			Reason:    event.Reason,
+			IpAddress: event.IpAddress,
+			UserAgent: event.UserAgent,
+			CreatedAt: event.CreatedAt,
+		})
+	}
+	return result, totalCount, nil
+}
+
+func (s *securityServiceImpl) logLoginEvent(ctx context.Context, eventType string, userId uint, username string, reason string, client model.SessionClientModel) {
+	s.LogEvent(ctx, model.SecurityEventModel{
+		UserId:    userId,
+		Username:  username,
+		EventType: eventType,
+		Reason:    reason,
+		IpAddress: client.IpAddress,
+		UserAgent: client.UserAgent,
+	})
+}
+
+// lock reports whether a new lockout was created, repeated failures while
+// already locked must not notify the owner again
+func (s *securityServiceImpl) lock(lockoutType string, value string, failures int64, lockout time.Duration) bool {
+	key := lockoutKey(lockoutType, value)
+	if exists, err := s.RedisService.Exists(key); err == nil && exists {
+		return false
+	}
+	if err := s.RedisService.Set(key, loginLockoutRecord{Failures: failures, LockedAt: time.Now()}, lockout); err != nil {
+		logger.Logger.Error("Failed to lock out " + lockoutType + " " + value + ": " + err.Error())
+		return false
+	}
+	s.clearFailures(lockoutType, value)
+	return true
+}
+
+func (s *securityServiceImpl) clearFailures(lockoutType string, value string) {
+	if err := s.RedisService.Delete(loginFailuresPrefix + lockoutType + ":" + value); err != nil {
+		logger.Logger.Error("Failed to clear login failures: " + err.Error())
+	}
+	if lockoutType == lockoutTypeUsername {
+		if err := s.RedisService.Delete(loginDelayPrefix + value); err != nil {
+			logger.Logger.Error("Failed to clear login delay: " + err.Error())
+		}
+	}
+}
+
+// loginDelay doubles from one second for every failure past the threshold
+func (s *securityServiceImpl) loginDelay(failures int64) time.Duration {
+	threshold := int64(intConfig(s.Config, "LOGIN_DELAY_AFTER_FAILURES", 3))
+	if failures < threshold {
+		return 0
+	}
+	maxDelay := time.Duration(intConfig(s.Config, "LOGIN_MAX_DELAY_SECONDS", 60)) * time.Second
+	exponent := failures - threshold
+	if exponent > 16 {
+		return maxDelay
+	}
+	delay := time.Second << exponent
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+func (s *securityServiceImpl) notifyLockout(ctx context.Context, user *entity.User, lockout time.Duration) {
+	if user == nil {
+		return
+	}
+	message := fmt.Sprintf("Hello %s, your account has been locked for %d minutes after several failed login attempts. If this was not you, please reset your password.", user.FirstName, int(lockout.Minutes()))
+
+	s.NotificationDispatcher.Dispatch(ctx, *user, model.DispatchMessageModel{
+		Category: common.NOTIFICATION_CATEGORY_SECURITY,
+		Title:    "Your account has been locked",
+		Body:     message,
+		Channels: []string{common.NOTIFICATION_CHANNEL_SMS, common.NOTIFICATION_CHANNEL_EMAIL, common.NOTIFICATION_CHANNEL_IN_APP},
+	})
+}
+
+func lockoutKey(lockoutType string, value string) string {
+	return loginLockoutPrefix + lockoutType + ":" + value
+}
+
+func normaliseUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
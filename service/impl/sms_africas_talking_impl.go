@@ -0,0 +1,95 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+type africasTalkingSMSProvider struct {
+	baseUrl  string
+	username string
+	apiKey   string
+	client   *http.Client
+}
+
+func NewAfricasTalkingSMSProvider(config configuration.Config) (service.SMSProvider, error) {
+	provider := &africasTalkingSMSProvider{
+		baseUrl:  strings.TrimSuffix(config.Get("AFRICAS_TALKING_BASE_URL"), "/"),
+		username: config.Get("AFRICAS_TALKING_USERNAME"),
+		apiKey:   config.Get("AFRICAS_TALKING_API_KEY"),
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+	if provider.baseUrl == "" {
+		provider.baseUrl = "https://api.africastalking.com"
+	}
+	if provider.username == "" || provider.apiKey == "" {
+		return nil, errors.New("AFRICAS_TALKING_USERNAME and AFRICAS_TALKING_API_KEY are required for the africastalking SMS provider")
+	}
+	return provider, nil
+}
+
+func (a *africasTalkingSMSProvider) Name() string {
+	return common.SMS_PROVIDER_AFRICAS_TALKING
+}
+
+func (a *africasTalkingSMSProvider) Send(ctx context.Context, message model.SMSProviderMessage) (model.SMSProviderResult, error) {
+	form := url.Values{}
+	form.Set("username", a.username)
+	form.Set("to", message.To)
+	form.Set("message", message.Message)
+	form.Set("enqueue", "1")
+	if message.From != "" {
+		form.Set("from", message.From)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseUrl+"/version1/messaging", strings.NewReader(form.Encode()))
+	if err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	request.Header.Set("apiKey", a.apiKey)
+
+	response, err := a.client.Do(request)
+	if err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusCreated {
+		return model.SMSProviderResult{}, smsProviderError(a.Name(), response)
+	}
+
+	var body struct {
+		SMSMessageData struct {
+			Message    string `json:"Message"`
+			Recipients []struct {
+				StatusCode int    `json:"statusCode"`
+				Status     string `json:"status"`
+				MessageId  string `json:"messageId"`
+			} `json:"Recipients"`
+		} `json:"SMSMessageData"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	if len(body.SMSMessageData.Recipients) == 0 {
+		return model.SMSProviderResult{}, fmt.Errorf("africastalking rejected the message: %s", body.SMSMessageData.Message)
+	}
+	// 100 processed, 101 sent and 102 queued are the accepted statuses
+	recipient := body.SMSMessageData.Recipients[0]
+	if recipient.StatusCode < 100 || recipient.StatusCode > 102 {
+		return model.SMSProviderResult{}, fmt.Errorf("africastalking rejected the message: %s", recipient.Status)
+	}
+	return model.SMSProviderResult{Provider: a.Name(), MessageId: recipient.MessageId}, nil
+}
@@ -0,0 +1,80 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+type hubtelSMSProvider struct {
+	baseUrl      string
+	clientId     string
+	clientSecret string
+	client       *http.Client
+}
+
+func NewHubtelSMSProvider(config configuration.Config) (service.SMSProvider, error) {
+	provider := &hubtelSMSProvider{
+		baseUrl:      strings.TrimSuffix(config.Get("HUBTEL_BASE_URL"), "/"),
+		clientId:     config.Get("HUBTEL_CLIENT_ID"),
+		clientSecret: config.Get("HUBTEL_CLIENT_SECRET"),
+		client:       &http.Client{Timeout: 30 * time.Second},
+	}
+	if provider.baseUrl == "" {
+		provider.baseUrl = "https://smsc.hubtel.com"
+	}
+	if provider.clientId == "" || provider.clientSecret == "" {
+		return nil, errors.New("HUBTEL_CLIENT_ID and HUBTEL_CLIENT_SECRET are required for the hubtel SMS provider")
+	}
+	return provider, nil
+}
+
+func (h *hubtelSMSProvider) Name() string {
+	return common.SMS_PROVIDER_HUBTEL
+}
+
+func (h *hubtelSMSProvider) Send(ctx context.Context, message model.SMSProviderMessage) (model.SMSProviderResult, error) {
+	// Hubtel expects the number without the leading +
+	payload, err := json.Marshal(map[string]string{
+		"From":    message.From,
+		"To":      strings.TrimPrefix(message.To, "+"),
+		"Content": message.Message,
+	})
+	if err != nil {
+		return model.SMSProviderResult{}, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, h.baseUrl+"/v1/messages/send", bytes.NewReader(payload))
+	if err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	request.SetBasicAuth(h.clientId, h.clientSecret)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return model.SMSProviderResult{}, smsProviderError(h.Name(), response)
+	}
+
+	var body struct {
+		MessageId string `json:"messageId"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	return model.SMSProviderResult{Provider: h.Name(), MessageId: body.MessageId}, nil
+}
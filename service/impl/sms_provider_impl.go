@@ -0,0 +1,110 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/google/uuid"
+)
+
+// NewSMSProvider builds the named provider from its configuration
+func NewSMSProvider(name string, config configuration.Config) (service.SMSProvider, error) {
+	switch name {
+	case common.SMS_PROVIDER_AFRICAS_TALKING:
+		return NewAfricasTalkingSMSProvider(config)
+	case common.SMS_PROVIDER_TWILIO:
+		return NewTwilioSMSProvider(config)
+	case common.SMS_PROVIDER_HUBTEL:
+		return NewHubtelSMSProvider(config)
+	case common.SMS_PROVIDER_FAKE:
+		path := config.Get("SMS_FAKE_FILE")
+		if path == "" {
+			path = "./sms-outbox.jsonl"
+		}
+		return NewFakeSMSProvider(path), nil
+	default:
+		return nil, fmt.Errorf("unknown SMS provider %q", name)
+	}
+}
+
+// fakeSMSProvider appends every message to a JSON lines file instead of
+// sending it, for development and tests
+type fakeSMSProvider struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewFakeSMSProvider(path string) service.SMSProvider {
+	return &fakeSMSProvider{path: path}
+}
+
+func (f *fakeSMSProvider) Name() string {
+	return common.SMS_PROVIDER_FAKE
+}
+
+func (f *fakeSMSProvider) Send(ctx context.Context, message model.SMSProviderMessage) (model.SMSProviderResult, error) {
+	messageId := uuid.NewString()
+	line, err := json.Marshal(map[string]interface{}{
+		"messageId": messageId,
+		"to":        message.To,
+		"from":      message.From,
+		"message":   message.Message,
+		"sentAt":    time.Now(),
+	})
+	if err != nil {
+		return model.SMSProviderResult{}, err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o750); err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o640)
+	if err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	return model.SMSProviderResult{Provider: common.SMS_PROVIDER_FAKE, MessageId: messageId}, nil
+}
+
+// internationalPhoneNumber turns a local number such as 0543798411 into
+// +233543798411 using the country dialling code
+func internationalPhoneNumber(countryCode string, phoneNumber string) (string, error) {
+	phoneNumber = strings.TrimSpace(phoneNumber)
+	if phoneNumber == "" {
+		return "", errors.New("invalid phone number")
+	}
+	if phoneNumber[0] == '+' {
+		return phoneNumber, nil
+	}
+	if countryCode == "" {
+		return "", errors.New("a country code is required for " + phoneNumber)
+	}
+	if !strings.HasPrefix(countryCode, "+") {
+		countryCode = "+" + countryCode
+	}
+	return countryCode + strings.TrimPrefix(phoneNumber, "0"), nil
+}
+
+// smsProviderError reads the body of a failed provider response into the error
+func smsProviderError(provider string, response *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	return fmt.Errorf("%s returned %s: %s", provider, response.Status, strings.TrimSpace(string(body)))
+}
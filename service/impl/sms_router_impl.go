@@ -0,0 +1,132 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+const defaultSMSSenderId = "AquaWizz"
+
+type smsRouterImpl struct {
+	providers       map[string]service.SMSProvider
+	defaultRoute    []string
+	routes          map[string][]string
+	defaultSenderId string
+	senderIds       map[string]string
+}
+
+// NewSMSRouter builds every provider named in the configuration:
+//
+//	SMS_PROVIDERS=africastalking,twilio           default order of providers
+//	SMS_ROUTES=233:hubtel,africastalking;234:twilio  order per dialling code
+//	SMS_SENDER_ID=AquaWizz                         default sender ID
+//	SMS_SENDER_IDS=225:AQUAWIZZ                    sender ID per dialling code
+func NewSMSRouter(config configuration.Config) (service.SMSRouter, error) {
+	router := &smsRouterImpl{
+		providers:       map[string]service.SMSProvider{},
+		defaultRoute:    splitList(config.Get("SMS_PROVIDERS"), ","),
+		routes:          map[string][]string{},
+		defaultSenderId: config.Get("SMS_SENDER_ID"),
+		senderIds:       map[string]string{},
+	}
+	if len(router.defaultRoute) == 0 {
+		router.defaultRoute = []string{common.SMS_PROVIDER_AFRICAS_TALKING}
+	}
+	if router.defaultSenderId == "" {
+		router.defaultSenderId = defaultSMSSenderId
+	}
+
+	routes, err := parseCountryValues(config.Get("SMS_ROUTES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMS_ROUTES: %w", err)
+	}
+	for country, value := range routes {
+		router.routes[country] = splitList(value, ",")
+	}
+	if router.senderIds, err = parseCountryValues(config.Get("SMS_SENDER_IDS")); err != nil {
+		return nil, fmt.Errorf("invalid SMS_SENDER_IDS: %w", err)
+	}
+
+	names := append([]string{}, router.defaultRoute...)
+	for _, route := range router.routes {
+		names = append(names, route...)
+	}
+	for _, name := range names {
+		if _, ok := router.providers[name]; ok {
+			continue
+		}
+		provider, err := NewSMSProvider(name, config)
+		if err != nil {
+			return nil, err
+		}
+		router.providers[name] = provider
+	}
+	return router, nil
+}
+
+func (r *smsRouterImpl) Send(ctx context.Context, data model.SMSMessageModel) (model.SMSProviderResult, error) {
+	to, err := internationalPhoneNumber(data.CountryCode, data.PhoneNumber)
+	if err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	country := dialingCode(data.CountryCode)
+
+	route, ok := r.routes[country]
+	if !ok {
+		route = r.defaultRoute
+	}
+	senderId, ok := r.senderIds[country]
+	if !ok {
+		senderId = r.defaultSenderId
+	}
+	message := model.SMSProviderMessage{To: to, From: senderId, Message: data.Message}
+
+	var failures []string
+	for _, name := range route {
+		result, err := r.providers[name].Send(ctx, message)
+		if err == nil {
+			logger.Logger.Info(fmt.Sprintf("SMS to %s sent through %s", to, name))
+			return result, nil
+		}
+		logger.Logger.Error(fmt.Sprintf("SMS provider %s failed to send to %s: %s", name, to, err.Error()))
+		failures = append(failures, name+": "+err.Error())
+	}
+	return model.SMSProviderResult{}, errors.New("every SMS provider failed, " + strings.Join(failures, "; "))
+}
+
+// dialingCode returns the digits of a country dialling code, "+233" becomes "233"
+func dialingCode(countryCode string) string {
+	return strings.TrimPrefix(strings.TrimSpace(countryCode), "+")
+}
+
+// parseCountryValues reads "233:a,b;234:c" into a map keyed by dialling code
+func parseCountryValues(value string) (map[string]string, error) {
+	result := map[string]string{}
+	for _, entry := range splitList(value, ";") {
+		country, values, ok := strings.Cut(entry, ":")
+		country = dialingCode(country)
+		if !ok || country == "" || strings.TrimSpace(values) == "" {
+			return nil, fmt.Errorf("%q is not country:value", entry)
+		}
+		result[country] = strings.TrimSpace(values)
+	}
+	return result, nil
+}
+
+func splitList(value string, separator string) []string {
+	var result []string
+	for _, item := range strings.Split(value, separator) {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
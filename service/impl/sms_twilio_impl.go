@@ -0,0 +1,84 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+)
+
+type twilioSMSProvider struct {
+	baseUrl    string
+	accountSid string
+	authToken  string
+	// fromNumber replaces the sender ID in countries where Twilio does not
+	// support alphanumeric senders
+	fromNumber string
+	client     *http.Client
+}
+
+func NewTwilioSMSProvider(config configuration.Config) (service.SMSProvider, error) {
+	provider := &twilioSMSProvider{
+		baseUrl:    strings.TrimSuffix(config.Get("TWILIO_BASE_URL"), "/"),
+		accountSid: config.Get("TWILIO_ACCOUNT_SID"),
+		authToken:  config.Get("TWILIO_AUTH_TOKEN"),
+		fromNumber: config.Get("TWILIO_FROM_NUMBER"),
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}
+	if provider.baseUrl == "" {
+		provider.baseUrl = "https://api.twilio.com"
+	}
+	if provider.accountSid == "" || provider.authToken == "" {
+		return nil, errors.New("TWILIO_ACCOUNT_SID and TWILIO_AUTH_TOKEN are required for the twilio SMS provider")
+	}
+	return provider, nil
+}
+
+func (t *twilioSMSProvider) Name() string {
+	return common.SMS_PROVIDER_TWILIO
+}
+
+func (t *twilioSMSProvider) Send(ctx context.Context, message model.SMSProviderMessage) (model.SMSProviderResult, error) {
+	from := message.From
+	if t.fromNumber != "" {
+		from = t.fromNumber
+	}
+	form := url.Values{}
+	form.Set("To", message.To)
+	form.Set("From", from)
+	form.Set("Body", message.Message)
+
+	endpoint := t.baseUrl + "/2010-04-01/Accounts/" + url.PathEscape(t.accountSid) + "/Messages.json"
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	request.SetBasicAuth(t.accountSid, t.authToken)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := t.client.Do(request)
+	if err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusOK {
+		return model.SMSProviderResult{}, smsProviderError(t.Name(), response)
+	}
+
+	var body struct {
+		Sid string `json:"sid"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&body); err != nil {
+		return model.SMSProviderResult{}, err
+	}
+	return model.SMSProviderResult{Provider: t.Name(), MessageId: body.Sid}, nil
+}
@@ -0,0 +1,279 @@
+package impl
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const revokedTokenPrefix = "revoked_token:"
+
+type tokenServiceImpl struct {
+	repository.RefreshTokenRepository
+	repository.UserRepository
+	service.RedisService
+	configuration.Config
+}
+
+func NewTokenService(refreshTokenRepository repository.RefreshTokenRepository, userRepository repository.UserRepository, redisService service.RedisService, config configuration.Config) service.TokenService {
+	return &tokenServiceImpl{
+		RefreshTokenRepository: refreshTokenRepository,
+		UserRepository:         userRepository,
+		RedisService:           redisService,
+		Config:                 config,
+	}
+}
+
+// IssueTokens starts a new session for the user and returns its first token pair
+func (t *tokenServiceImpl) IssueTokens(ctx context.Context, user entity.User, client model.SessionClientModel) (model.TokenPairModel, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return model.TokenPairModel{}, err
+	}
+
+	tokenId := uuid.NewString()
+	accessToken, accessExpiresAt := common.GenerateToken(user.Username, nil, user, tokenId, t.Config)
+	now := time.Now()
+	session := entity.RefreshToken{
+		UserId:               user.ID,
+		TokenHash:            hashRefreshToken(refreshToken),
+		AccessTokenId:        tokenId,
+		AccessTokenExpiresAt: accessExpiresAt,
+		ExpiresAt:            now.Add(t.refreshTokenTTL()),
+		LastUsedAt:           now,
+		UserAgent:            truncate(client.UserAgent, 255),
+		IpAddress:            truncate(client.IpAddress, 64),
+	}
+	session, err = t.RefreshTokenRepository.Create(ctx, session)
+	if err != nil {
+		return model.TokenPairModel{}, err
+	}
+
+	return model.TokenPairModel{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// RefreshTokens rotates the refresh token of a session. Presenting a refresh
+// token that has already been rotated means it was stolen or replayed, so the
+// whole session is revoked.
+func (t *tokenServiceImpl) RefreshTokens(ctx context.Context, refreshToken string, client model.SessionClientModel) (model.TokenPairModel, error) {
+	tokenHash := hashRefreshToken(refreshToken)
+	session, err := t.RefreshTokenRepository.FindByTokenHash(ctx, tokenHash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		reused, err := t.RefreshTokenRepository.FindByPreviousTokenHash(ctx, tokenHash)
+		if err == nil {
+			logger.Logger.Warn("Refresh token reuse detected for session " + strconv.Itoa(int(reused.ID)))
+			t.revoke(ctx, reused)
+		}
+		return model.TokenPairModel{}, exception.UnauthorizedError{Message: "Invalid refresh token"}
+	}
+	if err != nil {
+		return model.TokenPairModel{}, err
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return model.TokenPairModel{}, exception.UnauthorizedError{Message: "Refresh token has expired"}
+	}
+
+	user, err := t.UserRepository.FindById(ctx, int(session.UserId))
+	if err != nil || !user.IsActive {
+		t.revoke(ctx, session)
+		return model.TokenPairModel{}, exception.UnauthorizedError{Message: "User is not active"}
+	}
+
+	newRefreshToken, err := generateRefreshToken()
+	if err != nil {
+		return model.TokenPairModel{}, err
+	}
+
+	// The access token issued with the previous refresh token is superseded
+	t.denyAccessToken(session.AccessTokenId, session.AccessTokenExpiresAt)
+
+	tokenId := uuid.NewString()
+	accessToken, accessExpiresAt := common.GenerateToken(user.Username, nil, user, tokenId, t.Config)
+	session.PreviousTokenHash = tokenHash
+	session.TokenHash = hashRefreshToken(newRefreshToken)
+	session.AccessTokenId = tokenId
+	session.AccessTokenExpiresAt = accessExpiresAt
+	session.LastUsedAt = time.Now()
+	session.UserAgent = truncate(client.UserAgent, 255)
+	session.IpAddress = truncate(client.IpAddress, 64)
+	session, err = t.RefreshTokenRepository.Update(ctx, session)
+	if err != nil {
+		return model.TokenPairModel{}, err
+	}
+
+	return model.TokenPairModel{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          newRefreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// Logout revokes the session the access token in claims belongs to
+func (t *tokenServiceImpl) Logout(ctx context.Context, claims map[string]interface{}) error {
+	tokenId, _ := claims["jti"].(string)
+	if tokenId == "" {
+		return exception.UnauthorizedError{Message: "Invalid token"}
+	}
+
+	session, err := t.RefreshTokenRepository.FindByAccessTokenId(ctx, tokenId)
+	if err == nil {
+		t.revoke(ctx, session)
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// No session for this token, deny the access token on its own
+	if exp, ok := claims["exp"].(float64); ok {
+		t.denyAccessToken(tokenId, time.Unix(int64(exp), 0))
+	}
+	return nil
+}
+
+func (t *tokenServiceImpl) ListSessions(ctx context.Context, userId uint, currentTokenId string) ([]model.SessionModel, error) {
+	sessions, err := t.RefreshTokenRepository.FindActiveByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.SessionModel
+	for _, session := range sessions {
+		result = append(result, model.SessionModel{
+			Id:         session.ID,
+			UserAgent:  session.UserAgent,
+			IpAddress:  session.IpAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.AccessTokenId == currentTokenId,
+		})
+	}
+	return result, nil
+}
+
+func (t *tokenServiceImpl) RevokeSession(ctx context.Context, userId uint, sessionId uint) error {
+	sessions, err := t.RefreshTokenRepository.FindActiveByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session.ID == sessionId {
+			return t.revoke(ctx, session)
+		}
+	}
+	return exception.NotFoundError{Message: "Session not found"}
+}
+
+// RevokeAllSessions signs the user out everywhere, used after a password
+// change or when the account is deactivated
+func (t *tokenServiceImpl) RevokeAllSessions(ctx context.Context, userId uint) error {
+	sessions, err := t.RefreshTokenRepository.FindActiveByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := t.revoke(ctx, session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsTokenRevoked reports whether an access token has been denied. Tokens
+// without an id cannot be revoked and are therefore rejected.
+//
+// While the denylist cannot be read the session the token was issued with
+// decides instead, so that a Redis outage does not log every user out. A
+// token whose session is revoked or no longer issues it, as it was refreshed
+// since, is treated as revoked, which is what the denylist would say. Only
+// when the database cannot be read either is the token rejected.
+func (t *tokenServiceImpl) IsTokenRevoked(ctx context.Context, tokenId string) bool {
+	if tokenId == "" {
+		return true
+	}
+	revoked, err := t.RedisService.Exists(revokedTokenPrefix + tokenId)
+	if err == nil {
+		return revoked
+	}
+	logger.Logger.Warn("Failed to check token denylist, checking the session instead: " + err.Error())
+
+	session, err := t.RefreshTokenRepository.FindByAccessTokenId(ctx, tokenId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true
+	}
+	if err != nil {
+		logger.Logger.Error("Failed to check the session of token " + tokenId + ", rejecting it: " + err.Error())
+		return true
+	}
+	return session.RevokedAt != nil || time.Now().After(session.ExpiresAt)
+}
+
+func (t *tokenServiceImpl) revoke(ctx context.Context, session entity.RefreshToken) error {
+	now := time.Now()
+	session.RevokedAt = &now
+	if _, err := t.RefreshTokenRepository.Update(ctx, session); err != nil {
+		return err
+	}
+	t.denyAccessToken(session.AccessTokenId, session.AccessTokenExpiresAt)
+	return nil
+}
+
+// denyAccessToken adds the token id to the denylist until the token expires
+func (t *tokenServiceImpl) denyAccessToken(tokenId string, expiresAt time.Time) {
+	ttl := time.Until(expiresAt)
+	if tokenId == "" || ttl <= 0 {
+		return
+	}
+	if err := t.RedisService.Set(revokedTokenPrefix+tokenId, true, ttl); err != nil {
+		logger.Logger.Error("Failed to add token " + tokenId + " to denylist: " + err.Error())
+	}
+}
+
+func (t *tokenServiceImpl) refreshTokenTTL() time.Duration {
+	days, err := strconv.Atoi(t.Config.Get("JWT_REFRESH_EXPIRE_DAYS_COUNT"))
+	exception.PanicLogging(err)
+	return time.Hour * 24 * time.Duration(days)
+}
+
+func generateRefreshToken() (string, error) {
+	buffer := make([]byte, 32)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buffer), nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
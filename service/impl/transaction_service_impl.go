@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
@@ -21,21 +22,24 @@ type transactionServiceImpl struct {
 	repository.TransactionRepository
 	repository.OrderRepository
 	repository.PaymentMethodRepository
-	repository.PaymentConfigRepository
+	service.PaymentConfigService
 	service.HttpService
 	configuration.Config
-	service.NotificationService // Add this line
+	service.NotificationDispatcher
+	repository.Transactor
+	service.DomainEventService
 }
 
-// Update your constructor to accept NotificationService
 func NewTransactionServiceImpl(
 	transactionRepository *repository.TransactionRepository,
 	orderRepo *repository.OrderRepository,
 	paymentRepo *repository.PaymentMethodRepository,
 	client *service.HttpService,
 	config configuration.Config,
-	notificationService *service.NotificationService, // Add this param
-	paymentConfigRepository *repository.PaymentConfigRepository,
+	notificationDispatcher service.NotificationDispatcher,
+	paymentConfigService *service.PaymentConfigService,
+	transactor repository.Transactor,
+	domainEventService service.DomainEventService,
 ) service.TransactionService {
 	return &transactionServiceImpl{
 		TransactionRepository:   *transactionRepository,
@@ -43,8 +47,10 @@ func NewTransactionServiceImpl(
 		Config:                  config,
 		OrderRepository:         *orderRepo,
 		PaymentMethodRepository: *paymentRepo,
-		NotificationService:     *notificationService,
-		PaymentConfigRepository: *paymentConfigRepository,
+		NotificationDispatcher:  notificationDispatcher,
+		PaymentConfigService:    *paymentConfigService,
+		Transactor:              transactor,
+		DomainEventService:      domainEventService,
 	}
 }
 
@@ -54,9 +60,9 @@ func (t *transactionServiceImpl) ProcessRecurringPayment(ctx context.Context, re
 	paymentMethod, err := t.PaymentMethodRepository.GetByID(ctx, strconv.FormatUint(uint64(request.PaymentMethodId), 10))
 	exception.PanicLogging(err)
 
-	paymentConfig, err := t.PaymentConfigRepository.GetPaymentConfig(ctx, request.CountryCode)
+	secretKey, err := t.PaymentConfigService.GetSecretKey(ctx, request.CountryCode)
 	exception.PanicLogging(err)
-	if paymentConfig == nil {
+	if secretKey == "" {
 		exception.PanicLogging(errors.New("payment configuration not found for the given country code"))
 	}
 
@@ -73,7 +79,7 @@ func (t *transactionServiceImpl) ProcessRecurringPayment(ctx context.Context, re
 	data["email"] = email
 	data["currency"] = request.Currency
 	paystackUrl := t.Config.Get("PAYSTACK_BASE_URL") + "/transaction/charge_authorization"
-	response, err := t.SendToPayStack(ctx, paystackUrl, data, paymentConfig.SecretKey)
+	response, err := t.SendToPayStack(ctx, paystackUrl, data, secretKey)
 	exception.PanicLogging(err)
 	transaction.Reference = response["data"].(map[string]interface{})["reference"].(string)
 	err = t.TransactionRepository.Update(ctx, transaction)
@@ -93,8 +99,7 @@ func (t *transactionServiceImpl) GetCustomerOrders(ctx context.Context, u uint)
 	}
 	var orderModels []model.OrderModel
 	for _, order := range orders {
-		longitude, _ := strconv.ParseFloat(order.Transaction.Address.Longitude, 64)
-		latitude, _ := strconv.ParseFloat(order.Transaction.Address.Latitude, 64)
+		address := deliveryAddress(order)
 		orderModels = append(orderModels, model.OrderModel{
 			Id:          order.ID,
 			Amount:      order.Amount,
@@ -120,17 +125,17 @@ func (t *transactionServiceImpl) GetCustomerOrders(ctx context.Context, u uint)
 				DeliveryFee: order.Transaction.DeliveryFee,
 				WaterCost:   order.Transaction.WaterCost,
 				DeliveryAddress: model.AddressModel{
-					Longitude:   longitude,
-					Latitude:    latitude,
-					Description: order.Transaction.Address.Description,
-					PlaceId:     order.Transaction.Address.PlaceId,
+					Longitude:   address.Longitude,
+					Latitude:    address.Latitude,
+					Description: address.Description,
+					PlaceId:     address.PlaceId,
 				},
 			},
 			Status:          order.Status,
 			TransactionId:   order.TransactionId,
 			TruckId:         order.TruckId,
 			CreatedAt:       order.CreatedAt,
-			DeliveryAddress: order.Transaction.Address.Description,
+			DeliveryAddress: address.Description,
 			OrderStatus:     order.Status,
 			Rating:          order.Rating,
 			Review:          order.Review,
@@ -212,7 +217,7 @@ func (t *transactionServiceImpl) GetDriverPendingOrder(ctx context.Context, user
 				DeliveryFee: order.Transaction.DeliveryFee,
 				WaterCost:   order.Transaction.WaterCost,
 			},
-			DeliveryAddress: order.Transaction.Address.Description,
+			DeliveryAddress: deliveryAddress(order).Description,
 			DeliveryPlaceId: order.DeliveryPlaceId,
 			Status:          order.Status,
 			TransactionId:   order.TransactionId,
@@ -272,7 +277,7 @@ func (t *transactionServiceImpl) GetCustomerPendingOrder(ctx context.Context, us
 				DeliveryFee: order.Transaction.DeliveryFee,
 				WaterCost:   order.Transaction.WaterCost,
 			},
-			DeliveryAddress: order.Transaction.Address.Description,
+			DeliveryAddress: deliveryAddress(order).Description,
 			DeliveryPlaceId: order.DeliveryPlaceId,
 			Status:          order.Status,
 			TransactionId:   order.TransactionId,
@@ -334,7 +339,7 @@ func (t *transactionServiceImpl) GetDriverCompletedOrder(ctx context.Context, us
 				DeliveryFee: order.Transaction.DeliveryFee,
 				WaterCost:   order.Transaction.WaterCost,
 			},
-			DeliveryAddress: order.Transaction.Address.Description,
+			DeliveryAddress: deliveryAddress(order).Description,
 			DeliveryPlaceId: order.DeliveryPlaceId,
 			Status:          order.Status,
 			TransactionId:   order.TransactionId,
@@ -395,7 +400,21 @@ func (t *transactionServiceImpl) ApproveOrRejectOrder(ctx context.Context, order
 		order.Status -= 1
 	}
 
-	err = t.OrderRepository.Update(ctx, order)
+	err = t.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := t.OrderRepository.Update(ctx, order); err != nil {
+			return err
+		}
+		if orderModel.Action != "approve" {
+			return t.DomainEventService.Record(ctx, common.EVENT_ORDER_REJECTED, order.ID, orderEvent(order, order.Transaction.UserId))
+		}
+		if err := t.DomainEventService.Record(ctx, common.EVENT_ORDER_APPROVED, order.ID, orderEvent(order, order.Transaction.UserId)); err != nil {
+			return err
+		}
+		if orderModel.TruckId != 0 {
+			return t.DomainEventService.Record(ctx, common.EVENT_ORDER_ASSIGNED, order.ID, orderEvent(order, order.Transaction.UserId))
+		}
+		return nil
+	})
 	exception.PanicLogging(err)
 
 	return order, nil
@@ -465,7 +484,7 @@ func (t *transactionServiceImpl) GetRefineryOrders(ctx context.Context, u uint,
 					PhoneNumber:  order.Truck.User.PhoneNumber,
 				},
 			},
-			DeliveryAddress: order.Transaction.Address.Description,
+			DeliveryAddress: deliveryAddress(order).Description,
 			RefineryAddress: order.RefineryAddress,
 			Status:          order.Status,
 			TransactionId:   order.TransactionId,
@@ -488,19 +507,20 @@ func (t *transactionServiceImpl) PaymentStatus(ctx context.Context, id string) m
 		exception.PanicLogging(errors.New("transaction does not exist"))
 	}
 
-	paymentConfig, err := t.PaymentConfigRepository.GetPaymentConfig(ctx, transaction.CountryCode)
+	secretKey, err := t.PaymentConfigService.GetSecretKey(ctx, transaction.CountryCode)
 	exception.PanicLogging(err)
-	if paymentConfig == nil {
+	if secretKey == "" {
 		exception.PanicLogging(errors.New("payment configuration not found for the given country code"))
 	}
 	header := make(map[string]interface{})
-	header["Authorization"] = "Bearer " + paymentConfig.SecretKey
+	header["Authorization"] = "Bearer " + secretKey
 	paystackUrl := t.Config.Get("PAYSTACK_BASE_URL")
 	response, err := t.HttpService.PostMethod(ctx, paystackUrl+"/transaction/verify/"+transaction.Reference, "GET", &map[string]interface{}{}, &header, false)
 	jsn, err := json.Marshal(response)
 	exception.PanicLogging(err)
 
 	transactionStatus := GetTransactionStatus(string(jsn))
+	previousStatus := transaction.Status
 	transaction.Status = transactionStatus.Data.Status
 	transaction.RawResponse = string(jsn)
 	transaction.UpdatedAt = time.Now()
@@ -509,35 +529,33 @@ func (t *transactionServiceImpl) PaymentStatus(ctx context.Context, id string) m
 	transaction.PaymentID = pan
 	transaction.PaymentType = transactionStatus.Data.Channel
 	transaction.Scheme = transactionStatus.Data.Authorization.Brand
-	err = t.TransactionRepository.Update(ctx, transaction)
-	exception.PanicLogging(err)
 	var order entity.Order = entity.Order{}
 	var request model.MobileMoneyRequestModel
 	err = json.Unmarshal([]byte(transaction.RawRequest), &request)
 	exception.PanicLogging(err)
-	if transactionStatus.Data.Status == "success" {
-		order, _ = t.OrderRepository.FindByTransactionId(ctx, transaction.ID)
-
-		if order.ID == 0 {
-			order = entity.Order{
-				TransactionId: transaction.ID,
-				//UserId:          transaction.UserId,
-				Amount:          transaction.Amount,
-				Currency:        transaction.Currency,
-				WaterCost:       transaction.WaterCost,
-				DeliveryFee:     transaction.DeliveryFee,
-				DeliveryAddress: request.CustomerAddress,
-				DeliveryPlaceId: request.CustomerPlaceId,
-				RefineryAddress: request.RefineryAddress,
-				RefineryPlaceId: request.RefineryPlaceId,
-				RefineryId:      request.RefineryId,
-				Capacity:        transaction.Capacity,
-				Type:            transaction.Type,
-				WaterType:       request.Type,
-				AddressId:       transaction.AddressId,
+	// The order and the events are written with the status so that a
+	// verification that fails half way leaves nothing to publish
+	err = t.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := t.TransactionRepository.Update(ctx, transaction); err != nil {
+			return err
+		}
+		if transactionStatus.Data.Status == "success" {
+			order, _ = t.OrderRepository.FindByTransactionId(ctx, transaction.ID)
+			if order.ID == 0 {
+				order = t.OrderRepository.Insert(ctx, newOrder(transaction, request))
+				if err := t.DomainEventService.Record(ctx, common.EVENT_ORDER_CREATED, order.ID, orderEvent(order, transaction.UserId)); err != nil {
+					return err
+				}
 			}
-			order = t.OrderRepository.Insert(ctx, order)
 		}
+		// Verifying a payment again must not announce it twice
+		if transaction.Status == previousStatus {
+			return nil
+		}
+		return t.recordPaymentEvent(ctx, transaction, order.ID)
+	})
+	exception.PanicLogging(err)
+	if transactionStatus.Data.Status == "success" {
 		var uniqueId string
 		if transactionStatus.Data.Channel == "mobile_money" {
 			uniqueId = transaction.PhoneNumber
@@ -590,9 +608,9 @@ func (t *transactionServiceImpl) PaymentStatus(ctx context.Context, id string) m
 
 func (t *transactionServiceImpl) InitiateMobileMoneyTransaction(ctx context.Context, request model.MobileMoneyRequestModel) interface{} {
 	// Load payment configuration based on country code
-	paymentConfig, err := t.PaymentConfigRepository.GetPaymentConfig(ctx, request.CountryCode)
+	secretKey, err := t.PaymentConfigService.GetSecretKey(ctx, request.CountryCode)
 	exception.PanicLogging(err)
-	if paymentConfig == nil {
+	if secretKey == "" {
 		exception.PanicLogging(errors.New("payment configuration not found for the given country code"))
 	}
 
@@ -623,7 +641,7 @@ func (t *transactionServiceImpl) InitiateMobileMoneyTransaction(ctx context.Cont
 		data["callback_url"] = "https://www.aquawizz.com/redirect-url"
 	}
 
-	response, err := t.SendToPayStack(ctx, paystackUrl, data, paymentConfig.SecretKey)
+	response, err := t.SendToPayStack(ctx, paystackUrl, data, secretKey)
 	if request.Provider == "card" {
 		transaction.Reference = response["data"].(map[string]interface{})["reference"].(string)
 		err = t.TransactionRepository.Update(ctx, transaction)
@@ -672,6 +690,80 @@ func (t *transactionServiceImpl) insertTransaction(ctx context.Context, request
 	return transaction
 }
 
+// recordPaymentEvent announces a transaction that reached a final status
+func (t *transactionServiceImpl) recordPaymentEvent(ctx context.Context, transaction entity.Transaction, orderId uint) error {
+	var eventType string
+	switch transaction.Status {
+	case "success":
+		eventType = common.EVENT_PAYMENT_SUCCEEDED
+	case "failed", "abandoned":
+		eventType = common.EVENT_PAYMENT_FAILED
+	case "reversed":
+		eventType = common.EVENT_REFUND_COMPLETED
+	default:
+		return nil
+	}
+	return t.DomainEventService.Record(ctx, eventType, transaction.ID, model.PaymentEventModel{
+		TransactionId: transaction.ID,
+		CustomerId:    transaction.UserId,
+		OrderId:       orderId,
+		Reference:     transaction.Reference,
+		Amount:        transaction.Amount,
+		Currency:      transaction.Currency,
+		Provider:      transaction.Provider,
+		Channel:       transaction.PaymentType,
+		Status:        transaction.Status,
+		CountryCode:   transaction.CountryCode,
+	})
+}
+
+func newOrder(transaction entity.Transaction, request model.MobileMoneyRequestModel) entity.Order {
+	return entity.Order{
+		TransactionId: transaction.ID,
+		//UserId:          transaction.UserId,
+		Amount:          transaction.Amount,
+		Currency:        transaction.Currency,
+		WaterCost:       transaction.WaterCost,
+		DeliveryFee:     transaction.DeliveryFee,
+		DeliveryAddress: request.CustomerAddress,
+		DeliveryPlaceId: request.CustomerPlaceId,
+		RefineryAddress: request.RefineryAddress,
+		RefineryPlaceId: request.RefineryPlaceId,
+		RefineryId:      request.RefineryId,
+		Capacity:        transaction.Capacity,
+		Type:            transaction.Type,
+		WaterType:       request.Type,
+		AddressId:       transaction.AddressId,
+		AddressSnapshot: entity.NewAddressSnapshot(transaction.Address),
+	}
+}
+
+func orderEvent(order entity.Order, customerId uint) model.OrderEventModel {
+	return model.OrderEventModel{
+		OrderId:       order.ID,
+		TransactionId: order.TransactionId,
+		CustomerId:    customerId,
+		RefineryId:    order.RefineryId,
+		TruckId:       order.TruckId,
+		Status:        order.Status,
+		Amount:        order.Amount,
+		Currency:      order.Currency,
+		WaterCost:     order.WaterCost,
+		DeliveryFee:   order.DeliveryFee,
+		Capacity:      order.Capacity,
+		WaterType:     order.WaterType,
+	}
+}
+
+// deliveryAddress prefers the snapshot taken when the order was placed and
+// falls back to the live address for orders created before snapshots existed
+func deliveryAddress(order entity.Order) entity.AddressSnapshot {
+	if !order.AddressSnapshot.IsEmpty() {
+		return order.AddressSnapshot
+	}
+	return entity.NewAddressSnapshot(order.Transaction.Address)
+}
+
 func GetTransactionStatus(data string) model.TransactionStatusModel {
 	var transactionStatus model.TransactionStatusModel
 	err := json.Unmarshal([]byte(data), &transactionStatus)
@@ -693,8 +785,7 @@ func (t *transactionServiceImpl) GetRefineryDashboardData(ctx context.Context, u
 func (t *transactionServiceImpl) FindById(ctx context.Context, id uint) (model.OrderModel, error) {
 	order, err := t.OrderRepository.FindById(ctx, id)
 	exception.PanicLogging(err)
-	longitude, _ := strconv.ParseFloat(order.Transaction.Address.Longitude, 64)
-	latitude, _ := strconv.ParseFloat(order.Transaction.Address.Latitude, 64)
+	address := deliveryAddress(order)
 	orderModel := model.OrderModel{
 		Id:            order.ID,
 		TransactionId: order.TransactionId,
@@ -714,17 +805,17 @@ func (t *transactionServiceImpl) FindById(ctx context.Context, id uint) (model.O
 
 			CreatedAt: order.Transaction.CreatedAt,
 			DeliveryAddress: model.AddressModel{
-				Description: order.Transaction.Address.Description,
-				Latitude:    latitude,
-				Longitude:   longitude,
-				PlaceId:     order.Transaction.Address.PlaceId,
+				Description: address.Description,
+				Latitude:    address.Latitude,
+				Longitude:   address.Longitude,
+				PlaceId:     address.PlaceId,
 			},
 		},
 		Amount:          order.Amount,
 		Currency:        order.Currency,
 		WaterCost:       order.WaterCost,
 		DeliveryFee:     order.DeliveryFee,
-		DeliveryAddress: order.Transaction.Address.Description,
+		DeliveryAddress: address.Description,
 		DeliveryPlaceId: order.DeliveryPlaceId,
 		RefineryAddress: order.RefineryAddress,
 		RefineryPlaceId: order.RefineryPlaceId,
@@ -780,50 +871,50 @@ func (t *transactionServiceImpl) ProcessPendingTransactions(ctx context.Context,
 		order.UpdatedAt = time.Now()
 		//order.TruckId = &truck.Id
 
-		err := t.OrderRepository.Update(ctx, order)
-
-		user := order.Transaction.User
-		if user.FcmToken != "" {
-			//return errors.New("user FCM token not found")
-
-			err = t.NotificationService.SendToDevice(context.Background(), model.NotificationModel{
-				Token:       user.FcmToken,
+		err := t.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := t.OrderRepository.Update(ctx, order); err != nil {
+				return err
+			}
+			return t.NotificationDispatcher.Enqueue(ctx, order.Transaction.UserId, model.DispatchMessageModel{
+				Category:    common.NOTIFICATION_CATEGORY_ORDER_UPDATES,
 				Title:       "Order Ready",
 				Body:        "Your order has been confirm and is processing!",
 				Data:        map[string]string{"orderId": strconv.Itoa(int(order.ID)), "status": "ready_for_delivery"},
 				ClickAction: "OPEN_ORDER_DETAILS",
 			})
-		}
+		})
 
 		if err != nil {
 			logger.Logger.Error("Failed to update transaction " + ": " + err.Error())
 			// Continue processing other transactions even if one fails
 			continue
 		}
-
-		// Additional processing like sending notifications could be done here
 	}
 
 	return nil
 }
 
 func (t *transactionServiceImpl) MarkOrderReadyForDelivery(id string) error {
-	order, err := t.OrderRepository.MarkOrderReadyForDelivery(id)
-	if err != nil {
-		return err
-	}
-	// Assume you can get the user and their FCM token from the order
-	user := order.Transaction.User
-	if user.FcmToken != "" {
-		//return errors.New("user FCM token not found")
-
-		err = t.NotificationService.SendToDevice(context.Background(), model.NotificationModel{
-			Token:       user.FcmToken,
+	var order entity.Order
+	err := t.Transactor.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		var err error
+		order, err = t.OrderRepository.MarkOrderReadyForDelivery(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := t.DomainEventService.Record(ctx, common.EVENT_ORDER_READY_FOR_DELIVERY, order.ID, orderEvent(order, order.Transaction.UserId)); err != nil {
+			return err
+		}
+		return t.NotificationDispatcher.Enqueue(ctx, order.Transaction.UserId, model.DispatchMessageModel{
+			Category:    common.NOTIFICATION_CATEGORY_ORDER_UPDATES,
 			Title:       "Order Ready",
 			Body:        "Your order is ready for delivery!",
 			Data:        map[string]string{"orderId": strconv.Itoa(int(order.ID)), "status": "ready_for_delivery"},
 			ClickAction: "OPEN_ORDER_DETAILS",
 		})
+	})
+	if err != nil {
+		return err
 	}
 	//driver := order.Truck.User
 	//if driver.FcmToken != "" {
@@ -845,30 +936,27 @@ func (t *transactionServiceImpl) MarkOrderReadyForDelivery(id string) error {
 }
 
 func (t *transactionServiceImpl) CloseOrder(id string) error {
-	order, err := t.OrderRepository.CloseOrder(id)
+	var order entity.Order
+	err := t.Transactor.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		var err error
+		order, err = t.OrderRepository.CloseOrder(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := t.DomainEventService.Record(ctx, common.EVENT_ORDER_DELIVERED, order.ID, orderEvent(order, order.Transaction.UserId)); err != nil {
+			return err
+		}
+		return t.NotificationDispatcher.Enqueue(ctx, order.Transaction.UserId, model.DispatchMessageModel{
+			Category:    common.NOTIFICATION_CATEGORY_ORDER_UPDATES,
+			Title:       "Order Completed",
+			Body:        "Your order has been completed successfully!",
+			Data:        map[string]string{"orderId": strconv.Itoa(int(order.ID)), "status": "order_completed"},
+			ClickAction: "FLUTTER_NOTIFICATION_CLICK",
+		})
+	})
 	if err != nil {
 		return err
 	}
-	// Assume you can get the user and their FCM token from the order
-	user := order.Transaction.User
-	if user.FcmToken == "" {
-		//return errors.New("user FCM token not found")
-		return errors.New("user FCM token not found")
-	}
-
-	notification := model.NotificationModel{
-		Token:       user.FcmToken,
-		Title:       "Order Completed",
-		Body:        "Your order has been completed successfully!",
-		Data:        map[string]string{"orderId": strconv.Itoa(int(order.ID)), "status": "order_completed"},
-		ClickAction: "FLUTTER_NOTIFICATION_CLICK",
-	}
-
-	ctx := context.Background()
-	err = t.NotificationService.SendToDevice(ctx, notification)
-	//if err != nil {
-	//	return err
-	//}
 
 	return nil
 }
@@ -925,9 +1013,9 @@ func (t *transactionServiceImpl) GetOrderRating(ctx context.Context, orderId uin
 	return rating, nil
 }
 
-// GetTransactionsByCountryCode returns transactions filtered by country code or all if code is empty
-func (t *transactionServiceImpl) GetTransactionsByCountryCode(ctx context.Context, countryCode string, page, limit int) ([]model.TransactionModel, int64) {
-	transactions, totalCount := t.TransactionRepository.FindByCountryCode(ctx, countryCode, page, limit)
+// GetTransactionsByScope returns the page of transactions visible within scope
+func (t *transactionServiceImpl) GetTransactionsByScope(ctx context.Context, scope model.TransactionScopeModel, page, limit int) ([]model.TransactionModel, int64) {
+	transactions, totalCount := t.TransactionRepository.FindByScope(ctx, scope, page, limit)
 
 	var transactionModels []model.TransactionModel
 	for _, transaction := range transactions {
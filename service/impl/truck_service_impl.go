@@ -16,29 +16,29 @@ type TruckServiceImpl struct {
 	repository.TruckRepository
 	service.UserService
 	service.MessageService
+	service.InvitationService
 }
 
-func NewTruckServiceImpl(r *repository.TruckRepository, u *service.UserService, m *service.MessageService) service.TruckService {
-	return &TruckServiceImpl{TruckRepository: *r, UserService: *u, MessageService: *m}
+func NewTruckServiceImpl(r *repository.TruckRepository, u *service.UserService, m *service.MessageService, i *service.InvitationService) service.TruckService {
+	return &TruckServiceImpl{TruckRepository: *r, UserService: *u, MessageService: *m, InvitationService: *i}
 }
 
-func (t TruckServiceImpl) ListAllTrucks(c context.Context) ([]model.TruckModel, error) {
-	truck, err := t.TruckRepository.ListTrucks(c)
+func (t TruckServiceImpl) ListAllTrucks(c context.Context, refineryId uint) ([]model.TruckModel, error) {
+	truck, err := t.TruckRepository.ListTrucks(c, refineryId)
 	exception.PanicLogging(err)
 	return truck, nil
 }
 
-func (t TruckServiceImpl) CreateTruck(truckModel model.TruckModel) (model.TruckModel, error) {
+// CreateTruck registers the truck together with its driver's account. Both are
+// created in one transaction and the driver is only invited once they exist.
+func (t TruckServiceImpl) CreateTruck(ctx context.Context, truckModel model.TruckModel) (model.TruckModel, error) {
 	yearOfmanufacture, err := strconv.Atoi(truckModel.YearOfManufacture)
 	exception.PanicLogging(err)
 	capacity, err := strconv.Atoi(truckModel.Capacity)
 	exception.PanicLogging(err)
 
-	password, err := common.GeneratePassword(8)
-	exception.PanicLogging(err)
 	user := model.UserModel{
 		Username:     truckModel.Phone,
-		Password:     password,
 		Role:         common.TRUCK_DRIVER_ROLE,
 		EmailAddress: truckModel.Email,
 		FirstName:    truckModel.FirstName,
@@ -49,29 +49,29 @@ func (t TruckServiceImpl) CreateTruck(truckModel model.TruckModel) (model.TruckM
 		AreaCode:     truckModel.AreaCode,
 	}
 
-	userResult := t.UserService.Register(context.TODO(), user)
-
-	truckEntity := entity.Truck{
-		ManufacturerModel:     truckModel.ManufacturerModel,
-		YearOfManufacture:     yearOfmanufacture,
-		PlateNumber:           truckModel.PlateNumber,
-		Capacity:              capacity,
-		EngineNumber:          truckModel.EngineNumber,
-		IsActive:              true,
-		UserId:                userResult.ID,
-		LicenceExpirationDate: truckModel.LicenceExpirationDate,
-	}
-	truck, err := t.TruckRepository.Create(truckEntity)
-	exception.PanicLogging(err)
-	truckModel.Id = truck.ID
-
-	smsModel := model.SMSMessageModel{
-		Message:     "Hello " + truckModel.FirstName + "," + "Your Truck has been registered successfully.Your password is " + user.Password + ":\n Download  the app to complete your registration\n",
-		PhoneNumber: user.PhoneNumber,
-		CountryCode: userResult.AreaCode,
+	// The driver sets their own password through the invitation link
+	_, err = t.InvitationService.InviteWith(ctx, user, func(ctx context.Context, driver entity.User) error {
+		truckEntity := entity.Truck{
+			ManufacturerModel:     truckModel.ManufacturerModel,
+			YearOfManufacture:     yearOfmanufacture,
+			PlateNumber:           truckModel.PlateNumber,
+			Capacity:              capacity,
+			EngineNumber:          truckModel.EngineNumber,
+			IsActive:              true,
+			UserId:                driver.ID,
+			RefineryId:            truckModel.RefineryId,
+			LicenceExpirationDate: truckModel.LicenceExpirationDate,
+		}
+		truck, err := t.TruckRepository.Create(ctx, truckEntity)
+		if err != nil {
+			return err
+		}
+		truckModel.Id = truck.ID
+		return nil
+	})
+	if err != nil {
+		return model.TruckModel{}, err
 	}
-	_ = t.MessageService.SendSMS(context.TODO(), smsModel)
-	//exception.PanicLogging(sendErr)
 
 	return truckModel, nil
 }
@@ -98,6 +98,6 @@ func (t TruckServiceImpl) GetActiveTruck(ctx context.Context) model.TruckModel {
 }
 
 // ListTrucksByCountryCode returns active trucks whose owner's area_code matches the provided countryCode.
-func (t TruckServiceImpl) ListTrucksByCountryCode(ctx context.Context, countryCode string) ([]model.TruckModel, error) {
-	return t.TruckRepository.ListTrucksByCountryCode(ctx, countryCode)
+func (t TruckServiceImpl) ListTrucksByCountryCode(ctx context.Context, countryCode string, refineryId uint) ([]model.TruckModel, error) {
+	return t.TruckRepository.ListTrucksByCountryCode(ctx, countryCode, refineryId)
 }
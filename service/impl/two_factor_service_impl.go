@@ -0,0 +1,422 @@
+package impl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/common"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
+	"gorm.io/gorm"
+)
+
+const (
+	twoFactorChallengePrefix         = "two_factor_challenge:"
+	twoFactorChallengeAttemptsPrefix = "two_factor_challenge_attempts:"
+	twoFactorChallengeTTL            = 5 * time.Minute
+	twoFactorChallengeMaxAttempts    = 5
+	recoveryCodeCount                = 10
+	recoveryCodeAlphabet             = "abcdefghjkmnpqrstuvwxyz023456789"
+)
+
+type twoFactorChallenge struct {
+	UserId uint `json:"userId"`
+}
+
+type twoFactorServiceImpl struct {
+	repository.TwoFactorRepository
+	repository.UserRepository
+	service.RedisService
+	service.SecurityService
+	cipher *common.SecretCipher
+	configuration.Config
+}
+
+func NewTwoFactorService(twoFactorRepository repository.TwoFactorRepository, userRepository repository.UserRepository, redisService service.RedisService, securityService service.SecurityService, cipher *common.SecretCipher, config configuration.Config) service.TwoFactorService {
+	return &twoFactorServiceImpl{
+		TwoFactorRepository: twoFactorRepository,
+		UserRepository:      userRepository,
+		RedisService:        redisService,
+		SecurityService:     securityService,
+		cipher:              cipher,
+		Config:              config,
+	}
+}
+
+func (t *twoFactorServiceImpl) Status(ctx context.Context, userId uint) (model.TwoFactorStatusModel, error) {
+	user, err := t.UserRepository.FindById(ctx, int(userId))
+	if err != nil {
+		return model.TwoFactorStatusModel{}, err
+	}
+	twoFactor, err := t.findTwoFactor(ctx, userId)
+	if err != nil {
+		return model.TwoFactorStatusModel{}, err
+	}
+	return model.TwoFactorStatusModel{
+		Enabled:  twoFactor != nil && twoFactor.EnabledAt != nil,
+		Required: isTwoFactorRequired(user.UserRole),
+	}, nil
+}
+
+func (t *twoFactorServiceImpl) StartEnrolment(ctx context.Context, userId uint) (model.TwoFactorEnrolmentModel, error) {
+	user, err := t.UserRepository.FindById(ctx, int(userId))
+	if err != nil {
+		return model.TwoFactorEnrolmentModel{}, err
+	}
+	return t.startEnrolment(ctx, user)
+}
+
+func (t *twoFactorServiceImpl) ConfirmEnrolment(ctx context.Context, userId uint, code string) ([]string, error) {
+	user, err := t.UserRepository.FindById(ctx, int(userId))
+	if err != nil {
+		return nil, err
+	}
+	return t.confirmEnrolment(ctx, user, code)
+}
+
+func (t *twoFactorServiceImpl) Disable(ctx context.Context, userId uint, code string) error {
+	user, err := t.UserRepository.FindById(ctx, int(userId))
+	if err != nil {
+		return err
+	}
+	if isTwoFactorRequired(user.UserRole) {
+		return exception.ForbiddenError{Message: "Two-factor authentication is mandatory for this role"}
+	}
+	if err := t.verifyEnabled(ctx, user, code); err != nil {
+		return err
+	}
+	if err := t.TwoFactorRepository.DeleteByUserId(ctx, user.ID); err != nil {
+		return err
+	}
+	t.logEvent(ctx, user, common.SECURITY_EVENT_TWO_FACTOR_DISABLED, "", model.SessionClientModel{})
+	return nil
+}
+
+func (t *twoFactorServiceImpl) RegenerateRecoveryCodes(ctx context.Context, userId uint, code string) ([]string, error) {
+	user, err := t.UserRepository.FindById(ctx, int(userId))
+	if err != nil {
+		return nil, err
+	}
+	if err := t.verifyEnabled(ctx, user, code); err != nil {
+		return nil, err
+	}
+	return t.replaceRecoveryCodes(ctx, user.ID)
+}
+
+// startEnrolment generates a new secret for the user. It only takes effect
+// once confirmEnrolment has seen a valid code from the authenticator app.
+func (t *twoFactorServiceImpl) startEnrolment(ctx context.Context, user entity.User) (model.TwoFactorEnrolmentModel, error) {
+	twoFactor, err := t.findTwoFactor(ctx, user.ID)
+	if err != nil {
+		return model.TwoFactorEnrolmentModel{}, err
+	}
+	if twoFactor != nil && twoFactor.EnabledAt != nil {
+		return model.TwoFactorEnrolmentModel{}, exception.BadRequestError{Message: "Two-factor authentication is already enabled"}
+	}
+	if twoFactor == nil {
+		twoFactor = &entity.UserTwoFactor{UserId: user.ID}
+	}
+
+	secret, err := common.GenerateTotpSecret()
+	if err != nil {
+		return model.TwoFactorEnrolmentModel{}, err
+	}
+	encrypted, err := t.cipher.Encrypt(secret)
+	if err != nil {
+		return model.TwoFactorEnrolmentModel{}, err
+	}
+	twoFactor.Secret = encrypted.Ciphertext
+	twoFactor.EncryptedDataKey = encrypted.EncryptedDataKey
+	twoFactor.KeyVersion = encrypted.KeyVersion
+	twoFactor.LastUsedStep = 0
+	if _, err := t.TwoFactorRepository.Save(ctx, *twoFactor); err != nil {
+		return model.TwoFactorEnrolmentModel{}, err
+	}
+
+	return model.TwoFactorEnrolmentModel{
+		Secret:          secret,
+		ProvisioningUri: common.TotpProvisioningUri(t.issuer(), user.Username, secret),
+	}, nil
+}
+
+// confirmEnrolment enables two-factor authentication and returns the recovery
+// codes, which are shown to the user this one time only
+func (t *twoFactorServiceImpl) confirmEnrolment(ctx context.Context, user entity.User, code string) ([]string, error) {
+	twoFactor, err := t.findTwoFactor(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor == nil {
+		return nil, exception.BadRequestError{Message: "Two-factor enrolment has not been started"}
+	}
+	if twoFactor.EnabledAt != nil {
+		return nil, exception.BadRequestError{Message: "Two-factor authentication is already enabled"}
+	}
+	if err := t.verifyTotp(ctx, twoFactor, code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	twoFactor.EnabledAt = &now
+	if _, err := t.TwoFactorRepository.Save(ctx, *twoFactor); err != nil {
+		return nil, err
+	}
+	recoveryCodes, err := t.replaceRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	t.logEvent(ctx, user, common.SECURITY_EVENT_TWO_FACTOR_ENABLED, "", model.SessionClientModel{})
+	return recoveryCodes, nil
+}
+
+// BeginLogin returns a challenge when the user has to pass a second factor
+// after their password, or nil when the password alone is enough. The login
+// only counts as successful, clearing earlier failures, once no factor is left.
+func (t *twoFactorServiceImpl) BeginLogin(ctx context.Context, user entity.User, client model.SessionClientModel) (*model.TwoFactorChallengeModel, error) {
+	twoFactor, err := t.findTwoFactor(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := twoFactor != nil && twoFactor.EnabledAt != nil
+	if !enabled && !isTwoFactorRequired(user.UserRole) {
+		t.SecurityService.RecordLoginSuccess(ctx, user, client)
+		return nil, nil
+	}
+
+	challengeToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := t.RedisService.Set(twoFactorChallengePrefix+hashRefreshToken(challengeToken), twoFactorChallenge{UserId: user.ID}, twoFactorChallengeTTL); err != nil {
+		return nil, err
+	}
+
+	return &model.TwoFactorChallengeModel{
+		ChallengeToken:    challengeToken,
+		ExpiresAt:         time.Now().Add(twoFactorChallengeTTL),
+		EnrolmentRequired: !enabled,
+	}, nil
+}
+
+// StartLoginEnrolment lets an account that must use two-factor authentication
+// enrol in the middle of its first login
+func (t *twoFactorServiceImpl) StartLoginEnrolment(ctx context.Context, challengeToken string) (model.TwoFactorEnrolmentModel, error) {
+	user, err := t.challengeUser(ctx, challengeToken)
+	if err != nil {
+		return model.TwoFactorEnrolmentModel{}, err
+	}
+	// Whoever knows a public default password must not bind the account to
+	// their authenticator
+	if user.PasswordSeeded {
+		return model.TwoFactorEnrolmentModel{}, seededPasswordError()
+	}
+	return t.startEnrolment(ctx, user)
+}
+
+func seededPasswordError() error {
+	return exception.ForbiddenError{Message: "This account still has its default password, reset the password before enrolling two-factor authentication"}
+}
+
+// challengeUser resolves the user a login challenge was issued to
+func (t *twoFactorServiceImpl) challengeUser(ctx context.Context, challengeToken string) (entity.User, error) {
+	value, err := t.RedisService.Get(twoFactorChallengePrefix + hashRefreshToken(challengeToken))
+	if err != nil {
+		return entity.User{}, exception.UnauthorizedError{Message: "Login challenge has expired, please sign in again"}
+	}
+	var challenge twoFactorChallenge
+	if err := json.Unmarshal([]byte(value), &challenge); err != nil {
+		return entity.User{}, err
+	}
+	return t.UserRepository.FindById(ctx, int(challenge.UserId))
+}
+
+// CompleteLogin checks the second factor of a login challenge. Users who still
+// have to enrol confirm their new authenticator here and get their recovery
+// codes back, everyone else may use a TOTP or a recovery code.
+func (t *twoFactorServiceImpl) CompleteLogin(ctx context.Context, request model.TwoFactorLoginModel, client model.SessionClientModel) (entity.User, []string, error) {
+	user, err := t.challengeUser(ctx, request.ChallengeToken)
+	if err != nil {
+		return entity.User{}, nil, err
+	}
+	// Wrong codes count towards the login lockout, which also stops guessing
+	// on challenges that were handed out before the account got locked
+	if err := t.SecurityService.CheckLogin(ctx, user.Username, client); err != nil {
+		return entity.User{}, nil, err
+	}
+
+	challengeKey := twoFactorChallengePrefix + hashRefreshToken(request.ChallengeToken)
+	attemptsKey := twoFactorChallengeAttemptsPrefix + hashRefreshToken(request.ChallengeToken)
+	attempts, err := t.RedisService.Increment(attemptsKey, twoFactorChallengeTTL)
+	if err != nil {
+		return entity.User{}, nil, err
+	}
+	if attempts > twoFactorChallengeMaxAttempts {
+		t.deleteChallenge(challengeKey, attemptsKey)
+		return entity.User{}, nil, exception.UnauthorizedError{Message: "Too many invalid codes, please sign in again"}
+	}
+
+	twoFactor, err := t.findTwoFactor(ctx, user.ID)
+	if err != nil {
+		return entity.User{}, nil, err
+	}
+
+	var recoveryCodes []string
+	if (twoFactor == nil || twoFactor.EnabledAt == nil) && user.PasswordSeeded {
+		return entity.User{}, nil, seededPasswordError()
+	}
+	if twoFactor == nil || twoFactor.EnabledAt == nil {
+		recoveryCodes, err = t.confirmEnrolment(ctx, user, request.Code)
+	} else {
+		err = t.verifyCode(ctx, user, twoFactor, request.Code, client)
+	}
+	if err != nil {
+		t.SecurityService.RecordLoginFailure(ctx, user.Username, &user, "incorrect two-factor code", client)
+		return entity.User{}, nil, err
+	}
+
+	t.deleteChallenge(challengeKey, attemptsKey)
+	t.SecurityService.RecordLoginSuccess(ctx, user, client)
+	return user, recoveryCodes, nil
+}
+
+func (t *twoFactorServiceImpl) verifyEnabled(ctx context.Context, user entity.User, code string) error {
+	twoFactor, err := t.findTwoFactor(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if twoFactor == nil || twoFactor.EnabledAt == nil {
+		return exception.BadRequestError{Message: "Two-factor authentication is not enabled"}
+	}
+	return t.verifyCode(ctx, user, twoFactor, code, model.SessionClientModel{})
+}
+
+// verifyCode accepts a TOTP from the authenticator app or an unused recovery code
+func (t *twoFactorServiceImpl) verifyCode(ctx context.Context, user entity.User, twoFactor *entity.UserTwoFactor, code string, client model.SessionClientModel) error {
+	code = strings.TrimSpace(code)
+	if len(code) == 6 {
+		err := t.verifyTotp(ctx, twoFactor, code)
+		if err != nil {
+			t.logEvent(ctx, user, common.SECURITY_EVENT_TWO_FACTOR_FAILED, "invalid code", client)
+		}
+		return err
+	}
+
+	used, err := t.TwoFactorRepository.UseRecoveryCode(ctx, user.ID, hashRefreshToken(normaliseRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		t.logEvent(ctx, user, common.SECURITY_EVENT_TWO_FACTOR_FAILED, "invalid recovery code", client)
+		return exception.UnauthorizedError{Message: "Invalid two-factor code"}
+	}
+	t.logEvent(ctx, user, common.SECURITY_EVENT_RECOVERY_CODE_USED, "", client)
+	return nil
+}
+
+// verifyTotp refuses a code from a time step that has already been used so a
+// code seen by someone else cannot be replayed
+func (t *twoFactorServiceImpl) verifyTotp(ctx context.Context, twoFactor *entity.UserTwoFactor, code string) error {
+	secret, err := t.cipher.Decrypt(common.EncryptedSecret{
+		Ciphertext:       twoFactor.Secret,
+		EncryptedDataKey: twoFactor.EncryptedDataKey,
+		KeyVersion:       twoFactor.KeyVersion,
+	})
+	if err != nil {
+		return err
+	}
+
+	step, ok := common.ValidateTotp(secret, code, time.Now(), 1)
+	if !ok || step <= twoFactor.LastUsedStep {
+		return exception.UnauthorizedError{Message: "Invalid two-factor code"}
+	}
+	twoFactor.LastUsedStep = step
+	_, err = t.TwoFactorRepository.Save(ctx, *twoFactor)
+	return err
+}
+
+func (t *twoFactorServiceImpl) replaceRecoveryCodes(ctx context.Context, userId uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashRefreshToken(normaliseRecoveryCode(code)))
+	}
+	if err := t.TwoFactorRepository.ReplaceRecoveryCodes(ctx, userId, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (t *twoFactorServiceImpl) findTwoFactor(ctx context.Context, userId uint) (*entity.UserTwoFactor, error) {
+	twoFactor, err := t.TwoFactorRepository.FindByUserId(ctx, userId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+func (t *twoFactorServiceImpl) deleteChallenge(keys ...string) {
+	for _, key := range keys {
+		if err := t.RedisService.Delete(key); err != nil {
+			logger.Logger.Error("Failed to delete login challenge: " + err.Error())
+		}
+	}
+}
+
+func (t *twoFactorServiceImpl) logEvent(ctx context.Context, user entity.User, eventType string, reason string, client model.SessionClientModel) {
+	t.SecurityService.LogEvent(ctx, loginEvent(user, eventType, reason, client))
+}
+
+func (t *twoFactorServiceImpl) issuer() string {
+	if issuer := t.Config.Get("TOTP_ISSUER"); issuer != "" {
+		return issuer
+	}
+	return "AquaWizz"
+}
+
+func isTwoFactorRequired(role string) bool {
+	for _, required := range common.TwoFactorRequiredRoles {
+		if required == role {
+			return true
+		}
+	}
+	return false
+}
+
+// generateRecoveryCode returns a code like "k7m2p-x9q4r" that avoids easily
+// confused characters
+func generateRecoveryCode() (string, error) {
+	buffer := make([]byte, 10)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+	code := make([]byte, 0, 11)
+	for i, b := range buffer {
+		if i == 5 {
+			code = append(code, '-')
+		}
+		code = append(code, recoveryCodeAlphabet[b&0x1f])
+	}
+	return string(code), nil
+}
+
+func normaliseRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/configuration"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/exception"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/logger"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/repository"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/service"
@@ -17,11 +18,16 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-func NewUserServiceImpl(userRepository *repository.UserRepository, messageService *service.MessageService, localGovernmentService *service.LocalGovernmentService, config configuration.Config) service.UserService {
+func NewUserServiceImpl(userRepository *repository.UserRepository, messageService *service.MessageService, localGovernmentService *service.LocalGovernmentService, tokenService *service.TokenService, otpService *service.OtpService, securityService *service.SecurityService, messageTemplateService *service.MessageTemplateService, invitationService *service.InvitationService, config configuration.Config) service.UserService {
 	return &userServiceImpl{
 		UserRepository:         *userRepository,
 		MessageService:         *messageService,
 		LocalGovernmentService: *localGovernmentService,
+		tokenService:           *tokenService,
+		otpService:             *otpService,
+		securityService:        *securityService,
+		messageTemplateService: *messageTemplateService,
+		invitationService:      *invitationService,
 		Config:                 config,
 	}
 }
@@ -30,11 +36,16 @@ type userServiceImpl struct {
 	repository.UserRepository
 	service.MessageService
 	service.LocalGovernmentService
-	Config configuration.Config
+	tokenService           service.TokenService
+	otpService             service.OtpService
+	securityService        service.SecurityService
+	messageTemplateService service.MessageTemplateService
+	invitationService      service.InvitationService
+	Config                 configuration.Config
 }
 
 func (u *userServiceImpl) VerifyEmail(ctx context.Context, request model.OtpModel) error {
-	_, err := u.UserRepository.ValidateOtp(ctx, request)
+	err := u.otpService.Verify(ctx, uint(request.UserId), common.OTP_PURPOSE_EMAIL_VERIFY, request.Code)
 	if err != nil {
 		return err
 	}
@@ -75,50 +86,6 @@ func (u *userServiceImpl) FindByEmailOrPhone(ctx context.Context, userModel mode
 	return userResult
 }
 
-func (u *userServiceImpl) GetAddresses(ctx context.Context, id uint) (interface{}, error) {
-	addresses, err := u.UserRepository.FindAllAddress(ctx, id)
-	exception.PanicLogging(err)
-	return addresses, nil
-}
-
-func (u *userServiceImpl) SaveAddress(ctx context.Context, request map[string]interface{}) (interface{}, error) {
-	longitude := request["longitude"].(float64)
-	latitude := request["latitude"].(float64)
-	placeId := request["place_id"].(string)
-	description := request["description"].(string)
-	countryCode := "GH"
-	if request["country_code"] != nil {
-		countryCode = request["country_code"].(string)
-	}
-	userId := request["userId"].(uint)
-	if longitude == 0 || latitude == 0 {
-		locationGeometryResult := u.LocalGovernmentService.GetPlaceDetail(ctx, placeId)
-		longitude = locationGeometryResult.Result.Geometry.Location.Lng
-		latitude = locationGeometryResult.Result.Geometry.Location.Lat
-	}
-	var addressMode = map[string]interface{}{
-		"longitude":    longitude,
-		"latitude":     latitude,
-		"place_id":     placeId,
-		"description":  description,
-		"country_code": countryCode,
-		"userId":       userId,
-	}
-	address, err := u.UserRepository.SaveAddress(ctx, addressMode)
-	if err != nil {
-		return nil, err
-	}
-	addressModel := model.AddressResponseModel{
-		Id:          address.ID,
-		Longitude:   common.ToFloat64(address.Longitude),
-		Latitude:    common.ToFloat64(address.Latitude),
-		PlaceId:     address.PlaceId,
-		Description: address.Description,
-		CountryCode: address.CountryCode,
-	}
-	return addressModel, nil
-}
-
 func (u *userServiceImpl) UpdateProfile(ctx context.Context, request model.UserModel, username string) (model.UserModel, error) {
 	request.Username = username
 	userResult, err := u.UserRepository.UpdateProfile(ctx, request)
@@ -143,16 +110,10 @@ func (u *userServiceImpl) UpdateUserPassword(ctx context.Context, request model.
 		})
 	}
 
-	otpModel := model.OtpModel{
-		Code:      request.OTP,
-		UserId:    request.UserId,
-		Operation: "reset-password",
-	}
-
-	_, err = u.UserRepository.ValidateOtp(ctx, otpModel)
+	err = u.otpService.Verify(ctx, userResult.ID, common.OTP_PURPOSE_RESET_PASSWORD, request.OTP)
 	exception.PanicLogging(err)
 
-	passwordResetResult, err := u.UserRepository.SetPassword(request.UserId, request.Password)
+	passwordResetResult, err := u.UserRepository.SetPassword(ctx, request.UserId, request.Password)
 	exception.PanicLogging(err)
 	if passwordResetResult.Id == 0 {
 		panic(exception.BadRequestError{
@@ -160,10 +121,13 @@ func (u *userServiceImpl) UpdateUserPassword(ctx context.Context, request model.
 		})
 	}
 
+	err = u.tokenService.RevokeAllSessions(ctx, passwordResetResult.Id)
+	exception.PanicLogging(err)
+
 	return passwordResetResult
 }
 
-func (u *userServiceImpl) ResetPassword(ctx context.Context, request model.UserModel) model.UserModel {
+func (u *userServiceImpl) ResetPassword(ctx context.Context, request model.UserModel, ipAddress string) model.UserModel {
 	userResult, err := u.UserRepository.ResetPassword(ctx, request)
 	if err != nil {
 		panic(exception.BadRequestError{
@@ -175,29 +139,78 @@ func (u *userServiceImpl) ResetPassword(ctx context.Context, request model.UserM
 			Message: "User not found",
 		})
 	}
-	otp, err := u.MessageService.GenerateOneTimePassword(ctx, userResult.Id)
+	code, err := u.otpService.Issue(ctx, userResult.Id, request.PhoneNumber, common.OTP_PURPOSE_RESET_PASSWORD, ipAddress)
 	exception.PanicLogging(err)
-	message := "Hello " + userResult.FirstName + "  your password reset otp is " + otp.Code + ", Do not share your this otp with a third-party."
-	// Send SMS
-	emailMessageModel := model.SMSMessageModel{
-		PhoneNumber: request.PhoneNumber,
-		CountryCode: userResult.AreaCode,
+	u.sendOtp(ctx, userResult.FirstName, request.PhoneNumber, userResult.AreaCode, common.OTP_PURPOSE_RESET_PASSWORD, code)
+	return userResult
+}
+
+// ResendOtp issues a fresh code for a pending registration, email verification
+// or password reset, subject to the OTP cooldown and rate limits
+func (u *userServiceImpl) ResendOtp(ctx context.Context, request model.ResendOtpModel, ipAddress string) error {
+	if request.Purpose != common.OTP_PURPOSE_REGISTRATION && request.Purpose != common.OTP_PURPOSE_RESET_PASSWORD && request.Purpose != common.OTP_PURPOSE_EMAIL_VERIFY {
+		return exception.BadRequestError{Message: "OTP purpose cannot be resent"}
+	}
+
+	user, err := u.UserRepository.FindById(ctx, int(request.UserId))
+	if err != nil {
+		return exception.BadRequestError{Message: "User not found"}
+	}
+	if request.Purpose == common.OTP_PURPOSE_REGISTRATION && user.IsActive {
+		return exception.BadRequestError{Message: "User is already verified"}
+	}
+	if request.Purpose == common.OTP_PURPOSE_EMAIL_VERIFY {
+		if user.Email == "" || user.EmailValidated {
+			return exception.BadRequestError{Message: "Email is already verified"}
+		}
+		return u.issueEmailVerification(ctx, user, ipAddress)
+	}
+
+	code, err := u.otpService.Issue(ctx, user.ID, user.PhoneNumber, request.Purpose, ipAddress)
+	if err != nil {
+		return err
+	}
+	u.sendOtp(ctx, user.FirstName, user.PhoneNumber, user.AreaCode, request.Purpose, code)
+	return nil
+}
+
+func (u *userServiceImpl) sendOtp(ctx context.Context, firstName string, phoneNumber string, areaCode string, purpose string, code string) {
+	message := "Hello " + firstName + "   your OTP is " + code + ", Do not share your this otp with a third-party."
+	if purpose == common.OTP_PURPOSE_RESET_PASSWORD {
+		message = "Hello " + firstName + "  your password reset otp is " + code + ", Do not share your this otp with a third-party."
+	}
+	err := u.MessageService.SendSMS(ctx, model.SMSMessageModel{
+		PhoneNumber: phoneNumber,
+		CountryCode: areaCode,
 		Message:     message,
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to queue OTP SMS: " + err.Error())
 	}
-	u.MessageService.SendSMS(ctx, emailMessageModel)
-	return userResult
 }
 
-func (u *userServiceImpl) ValidatePhoneNumber(ctx context.Context, request model.OtpModel) entity.OneTimePassword {
-	otp, err := u.UserRepository.ValidateOtp(ctx, request)
+// issueEmailVerification emails the user a code for VerifyEmail. The phone
+// rate limit does not apply as the code is not sent by SMS.
+func (u *userServiceImpl) issueEmailVerification(ctx context.Context, user entity.User, ipAddress string) error {
+	code, err := u.otpService.Issue(ctx, user.ID, "", common.OTP_PURPOSE_EMAIL_VERIFY, ipAddress)
+	if err != nil {
+		return err
+	}
+	u.MessageService.SendEmail(ctx, model.EmailMessageModel{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Message: "Hello " + user.FirstName + "  your email verification code is " + code + ", Do not share your this code with a third-party.",
+	})
+	return nil
+}
 
+func (u *userServiceImpl) ValidatePhoneNumber(ctx context.Context, request model.OtpModel) error {
+	err := u.otpService.Verify(ctx, uint(request.UserId), common.OTP_PURPOSE_REGISTRATION, request.Code)
 	if err != nil {
-		panic(exception.BadRequestError{
-			Message: "Invalid OTP",
-		})
+		return err
 	}
 	var user entity.User
-	user, err = u.UserRepository.FindById(ctx, int(otp.UserId))
+	user, err = u.UserRepository.FindById(ctx, request.UserId)
 	if err != nil {
 		panic(exception.BadRequestError{
 			Message: "Invalid OTP",
@@ -211,7 +224,7 @@ func (u *userServiceImpl) ValidatePhoneNumber(ctx context.Context, request model
 			Message: "Invalid OTP",
 		})
 	}
-	return otp
+	return nil
 }
 
 func (u *userServiceImpl) FindByID(ctx context.Context, id int) (model.UserModel, error) {
@@ -227,6 +240,9 @@ func (u *userServiceImpl) FindByID(ctx context.Context, id int) (model.UserModel
 		Role:         user.UserRole,
 		IsActive:     user.IsActive,
 		FileName:     user.FileName,
+		CountryCode:  user.CountryCode,
+		AreaCode:     user.AreaCode,
+		Language:     common.ResolveLanguage(user.Language, user.CountryCode),
 	}
 	return userModel, nil
 }
@@ -261,86 +277,132 @@ func (u *userServiceImpl) ChangePassword(ctx context.Context, token string, mode
 	exception.PanicLogging(err)
 	userResult, err := u.UserRepository.ChangePassword(ctx, claims, model)
 	exception.PanicLogging(err)
+	err = u.tokenService.RevokeAllSessions(ctx, userResult.ID)
+	exception.PanicLogging(err)
 	return userResult
 }
 
+// IsTokenRevoked checks the jti of already validated claims against the denylist
+func (u *userServiceImpl) IsTokenRevoked(ctx context.Context, claims map[string]interface{}) bool {
+	tokenId, _ := claims["jti"].(string)
+	return u.tokenService.IsTokenRevoked(ctx, tokenId)
+}
+
+func (u *userServiceImpl) UpdateUserStatus(ctx context.Context, request model.ToggleUserStatusModel) error {
+	user, err := u.UserRepository.FindById(ctx, int(request.Id))
+	if err != nil || user.ID == 0 {
+		return exception.NotFoundError{Message: "User not found"}
+	}
+
+	if err := u.UserRepository.UpdateActiveStatus(ctx, user.ID, request.Status); err != nil {
+		return err
+	}
+	if !request.Status {
+		// A pending invitation would let the account activate itself again
+		if err := u.invitationService.RevokeForUser(ctx, user.ID); err != nil {
+			return err
+		}
+		return u.tokenService.RevokeAllSessions(ctx, user.ID)
+	}
+	return nil
+}
+
+// SeedUser invites the seed accounts that do not exist yet. They have no
+// known password, whoever holds the activation link chooses it.
 func (u *userServiceImpl) SeedUser(ctx context.Context) {
-	u.UserRepository.SeedUser(ctx)
+	seeds, err := u.UserRepository.SeedUser(ctx)
+	if err != nil {
+		logger.Logger.Error("Failed to seed users: " + err.Error())
+		return
+	}
+	for _, seed := range seeds {
+		link, err := u.invitationService.Seed(ctx, seed)
+		if err != nil {
+			logger.Logger.Error(fmt.Sprintf("Failed to seed %s user %s: %s", seed.Role, seed.Username, err.Error()))
+			continue
+		}
+		logger.Logger.Warn(fmt.Sprintf("Seeded %s user %s, activate it at %s", seed.Role, seed.Username, link))
+	}
 }
 
-func (u *userServiceImpl) Authentication(ctx context.Context, model model.LoginModel) entity.User {
+func (u *userServiceImpl) Authentication(ctx context.Context, model model.LoginModel, client model.SessionClientModel) entity.User {
+	if err := u.securityService.CheckLogin(ctx, model.Username, client); err != nil {
+		panic(err)
+	}
+
 	userResult, err := u.UserRepository.Authentication(ctx, model.Username)
 	if err != nil {
+		u.securityService.RecordLoginFailure(ctx, model.Username, nil, "unknown username", client)
 		panic(exception.UnauthorizedError{
 			Message: err.Error(),
 		})
 	}
 	err = bcrypt.CompareHashAndPassword([]byte(userResult.Password), []byte(model.Password))
 	if err != nil {
+		u.securityService.RecordLoginFailure(ctx, model.Username, &userResult, "incorrect password", client)
 		panic(exception.UnauthorizedError{
 			Message: "incorrect username and password",
 		})
 	}
+
+	// The password was right, so these do not count towards a lockout
 	if userResult.IsActive == false {
+		u.securityService.LogEvent(ctx, loginEvent(userResult, common.SECURITY_EVENT_LOGIN_FAILED, "user is not active", client))
 		panic(exception.UnauthorizedError{
 			Message: "User is not active",
 		})
 	}
 	if userResult.EmailValidated != true {
+		u.securityService.LogEvent(ctx, loginEvent(userResult, common.SECURITY_EVENT_LOGIN_FAILED, "user has not been verified", client))
 		panic(exception.UnauthorizedError{
 			Message: "User has not been verified",
 		})
 	}
+
+	// The login is recorded as successful by the two-factor step, once the
+	// user has passed every factor
 	return userResult
 }
 
-func (u *userServiceImpl) Register(ctx context.Context, userModel model.UserModel) entity.User {
-	userModel.IsActive = true
-	if userModel.Password == "" {
-		// Generate a random password if not provided
-		userModel.Password, _ = common.GeneratePassword(8)
+func loginEvent(user entity.User, eventType string, reason string, client model.SessionClientModel) model.SecurityEventModel {
+	return model.SecurityEventModel{
+		UserId:    user.ID,
+		Username:  user.Username,
+		EventType: eventType,
+		Reason:    reason,
+		IpAddress: client.IpAddress,
+		UserAgent: client.UserAgent,
 	}
-	user, err := u.UserRepository.Create(userModel)
+}
+
+// Register invites the user, the password is chosen through the activation
+// link and never sent in a message
+func (u *userServiceImpl) Register(ctx context.Context, userModel model.UserModel) entity.User {
+	user, err := u.invitationService.Invite(ctx, userModel)
 	exception.PanicLogging(err)
-	template := u.MessageService.FindMessageTemplateByName(ctx, userModel.Role+"_template")
-
-	data := map[string]string{
-		"FirstName": user.FirstName,
-		"LastName":  user.LastName,
-		"Username":  user.Username,
-		"Password":  userModel.Password,
-		"Email":     user.Email,
-		"Url":       "http://localhost:9999",
-	}
-	message := ReplacePlaceholders(template.Message, data)
-	// Send email
-	emailMessageModel := model.EmailMessageModel{
-		To:      user.Email,
-		Subject: template.Subject,
-		Message: message,
-	}
-	u.MessageService.SendEmail(ctx, emailMessageModel)
+	user.Password = ""
 	return user
 }
 
-func (u *userServiceImpl) RegisterCustomer(ctx context.Context, userModel model.UserModel) interface{} {
+func (u *userServiceImpl) RegisterCustomer(ctx context.Context, userModel model.UserModel, ipAddress string) interface{} {
 	userModel.IsActive = false
 	userModel.Role = "customer"
-	user, err := u.UserRepository.Create(userModel)
+	user, err := u.UserRepository.Create(ctx, userModel)
 	exception.PanicLogging(err)
 
-	// Send email
-	otp, err := u.MessageService.GenerateOneTimePassword(ctx, user.ID)
-	exception.PanicLogging(err)
-	message := "Hello " + user.FirstName + "   your OTP is " + otp.Code + ", Do not share your this otp with a third-party."
-	// Send SMS
-	emailMessageModel := model.SMSMessageModel{
-		PhoneNumber: user.PhoneNumber,
-		Message:     message,
-		CountryCode: user.AreaCode,
+	// The account is created either way, a rate limited OTP can be resent later
+	code, err := u.otpService.Issue(ctx, user.ID, user.PhoneNumber, common.OTP_PURPOSE_REGISTRATION, ipAddress)
+	if err != nil {
+		logger.Logger.Error("Failed to issue registration OTP: " + err.Error())
+	} else {
+		u.sendOtp(ctx, user.FirstName, user.PhoneNumber, user.AreaCode, common.OTP_PURPOSE_REGISTRATION, code)
+	}
+	if user.Email != "" {
+		if err := u.issueEmailVerification(ctx, user, ipAddress); err != nil {
+			logger.Logger.Error("Failed to issue email verification OTP: " + err.Error())
+		}
 	}
 	user.Password = ""
-	err = u.MessageService.SendSMS(ctx, emailMessageModel)
 	userModel = model.UserModel{
 		Id:           user.ID,
 		FirstName:    user.FirstName,
@@ -350,14 +412,13 @@ func (u *userServiceImpl) RegisterCustomer(ctx context.Context, userModel model.
 		PhoneNumber:  user.PhoneNumber,
 		Role:         user.UserRole,
 		IsActive:     user.IsActive,
-		OtpCode:      otp.Code,
 	}
 	return userModel
 }
 
 func (u *userServiceImpl) Create(ctx context.Context, model model.UserModel, file *multipart.FileHeader) entity.User {
 	model.IsActive = false
-	user, err := u.UserRepository.Create(model)
+	user, err := u.UserRepository.Create(ctx, model)
 	exception.PanicLogging(err)
 	return user
 }
@@ -365,14 +426,6 @@ func (u *userServiceImpl) Create(ctx context.Context, model model.UserModel, fil
 func (u *userServiceImpl) List(ctx context.Context) ([]entity.User, error) {
 	return u.UserRepository.List(ctx)
 }
-func ReplacePlaceholders(template string, placeholders map[string]string) string {
-	for key, value := range placeholders {
-		placeholder := fmt.Sprintf("{{%s}}", key)
-		template = strings.Replace(template, placeholder, value, -1)
-	}
-	return template
-}
-
 func (u *userServiceImpl) UpdateFcmToken(ctx context.Context, request model.UpdateFcmToken) error {
 	err := u.UserRepository.UpdateFcmToken(ctx, request)
 	return err
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+type InvitationService interface {
+	Invite(ctx context.Context, user model.UserModel) (entity.User, error)
+	// InviteWith also runs setup, such as creating the invitee's truck, in the
+	// transaction that creates the account
+	InviteWith(ctx context.Context, user model.UserModel, setup func(ctx context.Context, user entity.User) error) (entity.User, error)
+	// Seed invites a seed account and returns its activation link, which is
+	// logged once as nobody may be able to read the seed account's messages
+	Seed(ctx context.Context, user model.UserModel) (string, error)
+	Activate(ctx context.Context, request model.ActivateInvitationModel) error
+	ListPending(ctx context.Context, page, limit int) ([]model.InvitationModel, int64, error)
+	Resend(ctx context.Context, id uint) error
+	Revoke(ctx context.Context, id uint) error
+	RevokeForUser(ctx context.Context, userId uint) error
+}
@@ -1,13 +1,36 @@
 package service
 
+import "github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+
 // MessageBrokerService interface for messaging services
 type MessageBrokerService interface {
 	// PublishMessage publishes a message to a specified topic
 	PublishMessage(topic string, message interface{}) error
 
+	// PublishConfirmed publishes a message and only returns nil once the
+	// broker has taken it, it is never held in a publish buffer
+	PublishConfirmed(topic string, message interface{}) error
+
 	// SubscribeToTopic subscribes to a topic and processes messages with the handler
 	SubscribeToTopic(topic string, handler func([]byte) error) error
 
 	// Close closes the connection to the message broker
 	Close() error
+
+	// Health reports the connection state for health checks
+	Health() model.BrokerHealthModel
+}
+
+// PermanentError marks a message that can never be handled, such as one that
+// does not parse. The broker dead-letters it at once instead of retrying.
+type PermanentError struct {
+	Err error
+}
+
+func (e PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e PermanentError) Unwrap() error {
+	return e.Err
 }
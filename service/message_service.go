@@ -2,8 +2,8 @@ package service
 
 import (
 	"context"
+	"time"
 
-	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 )
 
@@ -12,9 +12,10 @@ type MessageService interface {
 	SendSMS(ctx context.Context, model model.SMSMessageModel) error
 	FindMessageTemplateById(ctx context.Context, id int) model.MessageTemplateModel
 	FindAllMessageTemplate(ctx context.Context) []model.MessageTemplateModel
-	CreateMessageTemplate(ctx context.Context, model model.MessageTemplateModel)
-	UpdateMessageTemplate(ctx context.Context, model model.MessageTemplateModel)
-	GenerateOneTimePassword(ctx context.Context, uid uint) (entity.OneTimePassword, error)
 	FindMessageTemplateByName(ctx context.Context, name string) model.MessageTemplateModel
 	SendSMSDirect(data model.SMSMessageModel) error
+	HandleSMSDeliveryReport(ctx context.Context, provider string, report model.SMSDeliveryReportModel) error
+	// DeliveryStats counts the notifications created in [from, to) per
+	// type, country and provider, all types when messageType is empty
+	DeliveryStats(ctx context.Context, messageType string, from time.Time, to time.Time) ([]model.DeliveryStatModel, error)
 }
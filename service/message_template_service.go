@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// MessageTemplateService manages message templates and their declared
+// variables, renders the published, localised version of a template and runs
+// the draft, publish and rollback cycle of versions. Every change is recorded
+// in the template audit trail.
+type MessageTemplateService interface {
+	// Render falls back to English when the template has no published
+	// version in the requested language
+	Render(ctx context.Context, name string, language string, data map[string]interface{}) (model.RenderedMessageModel, error)
+	ListTemplates(ctx context.Context) ([]model.MessageTemplateModel, error)
+	GetTemplate(ctx context.Context, templateId uint) (model.MessageTemplateModel, error)
+	CreateTemplate(ctx context.Context, request model.MessageTemplateModel, actor model.AuditActor) (model.MessageTemplateModel, error)
+	// UpdateTemplate rejects variable or channel changes that would break a
+	// draft or the published versions. The name is fixed once created.
+	UpdateTemplate(ctx context.Context, templateId uint, request model.MessageTemplateModel, actor model.AuditActor) (model.MessageTemplateModel, error)
+	DeleteTemplate(ctx context.Context, templateId uint, actor model.AuditActor) error
+	// SendTest renders a version with the sample values overridden by the
+	// request data and sends it to the given recipient only
+	SendTest(ctx context.Context, templateId uint, request model.MessageTemplateTestModel, actor model.AuditActor) (model.RenderedMessageModel, error)
+	ListAudits(ctx context.Context, templateId uint) ([]model.MessageTemplateAuditModel, error)
+	ListVersions(ctx context.Context, templateId uint, language string) ([]model.MessageTemplateVersionModel, error)
+	CreateDraft(ctx context.Context, templateId uint, request model.MessageTemplateDraftModel, actor model.AuditActor) (model.MessageTemplateVersionModel, error)
+	UpdateDraft(ctx context.Context, templateId uint, versionId uint, request model.MessageTemplateDraftModel, actor model.AuditActor) (model.MessageTemplateVersionModel, error)
+	Publish(ctx context.Context, templateId uint, versionId uint, actor model.AuditActor) (model.MessageTemplateVersionModel, error)
+	// Rollback republishes the archived version before the published one
+	Rollback(ctx context.Context, templateId uint, language string, actor model.AuditActor) (model.MessageTemplateVersionModel, error)
+	// Preview renders any version, drafts included, with the declared sample
+	// values overridden by data
+	Preview(ctx context.Context, templateId uint, versionId uint, data map[string]interface{}) (model.RenderedMessageModel, error)
+	// SeedVersions publishes the original text of templates that have no
+	// versions yet as their first English version
+	SeedVersions(ctx context.Context)
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// NotificationInboxService is the user's view of the email, SMS and push
+// messages sent to them
+type NotificationInboxService interface {
+	List(ctx context.Context, userId uint, page, limit int) ([]model.InboxNotificationModel, int64, error)
+	UnreadCount(ctx context.Context, userId uint) (model.UnreadNotificationCountModel, error)
+	MarkRead(ctx context.Context, userId uint, id uint) error
+	MarkAllRead(ctx context.Context, userId uint) (model.UnreadNotificationCountModel, error)
+}
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+type NotificationPreferenceService interface {
+	Get(ctx context.Context, userId uint) (model.NotificationPreferencesModel, error)
+	Update(ctx context.Context, userId uint, request model.UpdateNotificationPreferencesModel) (model.NotificationPreferencesModel, error)
+}
+
+// NotificationDispatcher sends a message to a user on the channels their
+// preferences allow for its category. Security messages go out on every
+// channel they are meant for. During quiet hours SMS and push are held back,
+// the message still reaches email and the inbox.
+type NotificationDispatcher interface {
+	Dispatch(ctx context.Context, user entity.User, message model.DispatchMessageModel) model.DispatchResultModel
+	// Enqueue records the message in the outbox, it has to be called with the
+	// context of the transaction that made the change the message is about
+	Enqueue(ctx context.Context, userId uint, message model.DispatchMessageModel) error
+	// HandleQueued dispatches a message relayed on
+	// common.NOTIFICATION_DISPATCH_TOPIC
+	HandleQueued(message []byte) error
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
 	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
 )
 
@@ -10,8 +11,16 @@ type NotificationService interface {
 	// SendToDevice sends a notification to a single device
 	SendToDevice(ctx context.Context, notification model.NotificationModel) error
 
-	// SendToMultipleDevices sends a notification to multiple devices
-	SendToMultipleDevices(ctx context.Context, notification model.NotificationModel) error
+	// SendToUser sends a notification to every device of the user. Devices
+	// not seen for too long are removed instead, a user without devices gets
+	// nothing and an empty result.
+	SendToUser(ctx context.Context, user entity.User, notification model.NotificationModel) (model.PushResultModel, error)
+
+	// SendToMultipleDevices sends a notification to the devices of Tokens in
+	// batches of the FCM limit. Tokens FCM reports as unregistered or invalid
+	// are removed from the device registry. It only fails when no token got
+	// the notification for another reason than the token being removed.
+	SendToMultipleDevices(ctx context.Context, notification model.NotificationModel) (model.PushResultModel, error)
 
 	// SendToTopic sends a notification to a topic
 	SendToTopic(ctx context.Context, topic string, notification model.NotificationModel) error
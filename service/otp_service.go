@@ -0,0 +1,8 @@
+package service
+
+import "context"
+
+type OtpService interface {
+	Issue(ctx context.Context, userId uint, phoneNumber string, purpose string, ipAddress string) (string, error)
+	Verify(ctx context.Context, userId uint, purpose string, code string) error
+}
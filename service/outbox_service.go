@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// OutboxService records events in the transaction of the state change that
+// caused them and relays them to the message broker once it committed, so
+// that an event is neither lost when the process dies after the write nor
+// published for a change that was rolled back
+type OutboxService interface {
+	// Enqueue must be called with the context a Transactor passes to its
+	// function. Payloads are marshalled to JSON, events of one aggregate are
+	// published in the order they were enqueued.
+	Enqueue(ctx context.Context, events ...model.OutboxEventModel) error
+	// RelayPending publishes one batch of due events and returns how many
+	// were published
+	RelayPending(ctx context.Context) (int, error)
+	// StartRelay polls for due events and removes old published ones until
+	// StopRelay is called
+	StartRelay()
+	StopRelay()
+}
@@ -9,8 +9,14 @@ import (
 type PaymentConfigService interface {
 	GetPaymentConfig(ctx context.Context, countryCode string) (*model.PaymentConfigModel, error)
 	ListPaymentConfigs(ctx context.Context) ([]model.PaymentConfigModel, error)
-	CreatePaymentConfig(ctx context.Context, config *model.PaymentConfigModel) error
-	UpdatePaymentConfig(ctx context.Context, config *model.PaymentConfigModel) error
-	DeletePaymentConfig(ctx context.Context, countryCode string) error
+	CreatePaymentConfig(ctx context.Context, config *model.PaymentConfigModel, actor model.AuditActor) error
+	UpdatePaymentConfig(ctx context.Context, config *model.PaymentConfigModel, actor model.AuditActor) error
+	DeletePaymentConfig(ctx context.Context, countryCode string, actor model.AuditActor) error
 	RefreshCache(ctx context.Context) error
+	// GetSecretKey returns the decrypted gateway secret for outbound payment calls
+	GetSecretKey(ctx context.Context, countryCode string) (string, error)
+	// RotateSecrets re-encrypts the stored secrets that are not sealed with the
+	// active master key and returns how many it rotated
+	RotateSecrets(ctx context.Context, actor model.AuditActor) (int, error)
+	ListAuditTrail(ctx context.Context, countryCode string) ([]model.PaymentConfigAuditModel, error)
 }
@@ -8,4 +8,8 @@ type RedisService interface {
 	Get(key string) (string, error)
 	Set(key string, value interface{}, ttl time.Duration) error
 	Delete(key string) error
+	Exists(key string) (bool, error)
+	Increment(key string, ttl time.Duration) (int64, error)
+	GetTTL(key string) (time.Duration, error)
+	GetKeys(pattern string) ([]string, error)
 }
@@ -9,7 +9,8 @@ type RefineryService interface {
 	GetRefinery(context context.Context, model model.GetRefineryModel) (model.RefineryCostModel, error)
 	ListRefineries(ctx context.Context) ([]model.RefineryModel, error)
 	CreateRefinery(ctx context.Context, refineryModel model.CreateRefineryModel) (model.RefineryModel, error)
-	UpdateRefinery(ctx context.Context, refineryModel model.CreateRefineryModel, id string) (model.RefineryModel, error)
+	UpdateRefinery(ctx context.Context, refineryModel model.CreateRefineryModel, id string, includePricing bool) (model.RefineryModel, error)
+	UpdatePricing(ctx context.Context, refineryId uint, pricing model.RefineryPricingModel) (model.RefineryModel, error)
 	GetRefineryDashboardData(ctx context.Context, u uint) (map[string]interface{}, error)
 	ToggleRefineryStatus(ctx context.Context, statusModel model.ToggleRefineryStatusModel) (bool, error)
 }
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// RefineryStaffService manages the users of a single refinery. The refinery
+// is always taken from the caller's claims by the controller.
+type RefineryStaffService interface {
+	ListStaff(ctx context.Context, refineryId uint) ([]model.RefineryStaffModel, error)
+	InviteStaff(ctx context.Context, refineryId uint, actorId uint, request model.InviteRefineryStaffModel) (model.RefineryStaffModel, error)
+	UpdateStaffRole(ctx context.Context, refineryId uint, actorId uint, userId uint, role string) (model.RefineryStaffModel, error)
+	RemoveStaff(ctx context.Context, refineryId uint, actorId uint, userId uint) error
+}
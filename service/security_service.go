@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// SecurityService guards authentication against brute force and keeps the
+// security event log
+type SecurityService interface {
+	CheckLogin(ctx context.Context, username string, client model.SessionClientModel) error
+	RecordLoginFailure(ctx context.Context, username string, user *entity.User, reason string, client model.SessionClientModel)
+	RecordLoginSuccess(ctx context.Context, user entity.User, client model.SessionClientModel)
+	ListLockouts(ctx context.Context) ([]model.LoginLockoutModel, error)
+	ClearLockout(ctx context.Context, lockoutType string, value string, actor model.AuditActor) error
+	LogEvent(ctx context.Context, event model.SecurityEventModel)
+	ListEvents(ctx context.Context, filter model.SecurityEventFilterModel, page, limit int) ([]model.SecurityEventModel, int64, error)
+}
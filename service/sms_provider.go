@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+// SMSProvider sends SMS through a single gateway
+type SMSProvider interface {
+	Name() string
+	Send(ctx context.Context, message model.SMSProviderMessage) (model.SMSProviderResult, error)
+}
+
+// SMSRouter picks the providers for the country of a message from the
+// SMS_ROUTES configuration and fails over to the next one in order when a
+// provider cannot send
+type SMSRouter interface {
+	Send(ctx context.Context, message model.SMSMessageModel) (model.SMSProviderResult, error)
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+type TokenService interface {
+	IssueTokens(ctx context.Context, user entity.User, client model.SessionClientModel) (model.TokenPairModel, error)
+	RefreshTokens(ctx context.Context, refreshToken string, client model.SessionClientModel) (model.TokenPairModel, error)
+	Logout(ctx context.Context, claims map[string]interface{}) error
+	ListSessions(ctx context.Context, userId uint, currentTokenId string) ([]model.SessionModel, error)
+	RevokeSession(ctx context.Context, userId uint, sessionId uint) error
+	RevokeAllSessions(ctx context.Context, userId uint) error
+	// IsTokenRevoked falls back to the session in the database when the
+	// denylist cannot be read
+	IsTokenRevoked(ctx context.Context, tokenId string) bool
+}
@@ -7,9 +7,10 @@ import (
 )
 
 type TruckService interface {
-	ListAllTrucks(ctx context.Context) ([]model.TruckModel, error)
-	CreateTruck(truck model.TruckModel) (model.TruckModel, error)
+	// ListAllTrucks lists the active trucks, of one refinery unless refineryId is 0
+	ListAllTrucks(ctx context.Context, refineryId uint) ([]model.TruckModel, error)
+	CreateTruck(ctx context.Context, truck model.TruckModel) (model.TruckModel, error)
 	UpdateTruck(truck model.TruckModel) (model.TruckModel, error)
 	GetActiveTruck(ctx context.Context) model.TruckModel
-	ListTrucksByCountryCode(ctx context.Context, countryCode string) ([]model.TruckModel, error)
+	ListTrucksByCountryCode(ctx context.Context, countryCode string, refineryId uint) ([]model.TruckModel, error)
 }
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/entity"
+	"github.com/RizkiMufrizal/gofiber-clean-architecture/model"
+)
+
+type TwoFactorService interface {
+	Status(ctx context.Context, userId uint) (model.TwoFactorStatusModel, error)
+	StartEnrolment(ctx context.Context, userId uint) (model.TwoFactorEnrolmentModel, error)
+	ConfirmEnrolment(ctx context.Context, userId uint, code string) ([]string, error)
+	Disable(ctx context.Context, userId uint, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userId uint, code string) ([]string, error)
+	BeginLogin(ctx context.Context, user entity.User, client model.SessionClientModel) (*model.TwoFactorChallengeModel, error)
+	StartLoginEnrolment(ctx context.Context, challengeToken string) (model.TwoFactorEnrolmentModel, error)
+	CompleteLogin(ctx context.Context, request model.TwoFactorLoginModel, client model.SessionClientModel) (entity.User, []string, error)
+}
@@ -8,22 +8,23 @@ import (
 )
 
 type UserService interface {
-	Authentication(ctx context.Context, model model.LoginModel) entity.User
+	Authentication(ctx context.Context, model model.LoginModel, client model.SessionClientModel) entity.User
 	ChangePassword(ctx context.Context, token string, model model.ChangePasswordModel) entity.User
 	GetClaimsFromToken(ctx context.Context, tokenString string) (map[string]interface{}, error)
 	Register(ctx context.Context, model model.UserModel) entity.User
 	SeedUser(ctx context.Context)
 	List(ctx context.Context) ([]entity.User, error)
 	FindByID(ctx context.Context, id int) (model.UserModel, error)
-	RegisterCustomer(ctx context.Context, request model.UserModel) interface{}
-	ValidatePhoneNumber(ctx context.Context, request model.OtpModel) entity.OneTimePassword
-	ResetPassword(ctx context.Context, request model.UserModel) model.UserModel
+	RegisterCustomer(ctx context.Context, request model.UserModel, ipAddress string) interface{}
+	ValidatePhoneNumber(ctx context.Context, request model.OtpModel) error
+	ResetPassword(ctx context.Context, request model.UserModel, ipAddress string) model.UserModel
+	ResendOtp(ctx context.Context, request model.ResendOtpModel, ipAddress string) error
 	UpdateUserPassword(ctx context.Context, request model.ResetPasswordViewModel) model.UserModel
 	UpdateProfile(ctx context.Context, request model.UserModel, token string) (model.UserModel, error)
-	SaveAddress(ctx context.Context, request map[string]interface{}) (interface{}, error)
-	GetAddresses(ctx context.Context, id uint) (interface{}, error)
 	FindByEmailOrPhone(ctx context.Context, userModel model.UserModel) entity.User
 	UpdateFcmToken(ctx context.Context, request model.UpdateFcmToken) error
 	FineAddressById(ctx context.Context, addressId uint) (model.AddressResponseModel, error)
 	VerifyEmail(ctx context.Context, request model.OtpModel) error
+	IsTokenRevoked(ctx context.Context, claims map[string]interface{}) bool
+	UpdateUserStatus(ctx context.Context, request model.ToggleUserStatusModel) error
 }